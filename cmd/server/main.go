@@ -2,28 +2,70 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"live-oil-prices-go/internal/config"
 	"live-oil-prices-go/internal/handlers"
+	"live-oil-prices-go/internal/metrics"
 	"live-oil-prices-go/internal/middleware"
 	"live-oil-prices-go/internal/services"
+	"live-oil-prices-go/internal/store"
+	"live-oil-prices-go/internal/upstream"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 )
 
-var commoditySymbols = []string{
-	"WTI", "BRENT", "NATGAS", "HEATING", "RBOB",
-	"OPEC", "DUBAI", "MURBAN", "WCS", "GASOIL",
-}
-
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	record := flag.String("record", "", "capture every upstream response to fixture files in `dir`")
+	replay := flag.String("replay", "", "serve upstream responses from the fixtures in `dir` instead of the network")
+	flag.Parse()
+
+	// Defaults, then CONFIG_FILE, then the environment. Everything is
+	// validated here so a bad symbol table or interval fails the deploy
+	// instead of surfacing as an empty chart later.
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"), os.Getenv)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	policy, err := services.ParseHealthPolicy(cfg.Health.MaxAge, cfg.Health.MaxFailures, cfg.Health.CriticalFeeds)
+	if err != nil {
+		log.Fatalf("Invalid health thresholds: %v", err)
+	}
+
+	// -record captures every upstream exchange; -replay serves a captured
+	// session back in place of the network, with its original timing.
+	// Either has to be in place before the feeds build their clients.
+	switch {
+	case *record != "" && *replay != "":
+		log.Fatal("-record and -replay are mutually exclusive")
+	case *record != "":
+		rec, err := upstream.NewRecorder(*record, nil)
+		if err != nil {
+			log.Fatalf("Failed to start recording: %v", err)
+		}
+		upstream.SetTransport(rec)
+		log.Printf("Recording upstream responses to %s", *record)
+	case *replay != "":
+		rp, err := upstream.NewReplayer(*replay)
+		if err != nil {
+			log.Fatalf("Failed to load replay: %v", err)
+		}
+		upstream.SetTransport(rp)
+		// A replay runs in memory so its output depends on the fixtures
+		// alone, and fixtures match any EIA key, so it needs no real one.
+		cfg.DataDir = "off"
+		if os.Getenv("EIA_API_KEY") == "" {
+			os.Setenv("EIA_API_KEY", "replay")
+		}
+		log.Printf("Replaying upstream responses from %s", *replay)
 	}
+	services.Configure(cfg)
 
 	if err := handlers.InitCommodityTemplate("web/templates/commodity.html"); err != nil {
 		log.Fatalf("Failed to parse commodity template: %v", err)
@@ -33,46 +75,137 @@ func main() {
 		log.Fatalf("Failed to parse page templates: %v", err)
 	}
 
-	marketService := services.NewMarketDataService()
-	newsService := services.NewNewsFeedService()
-	handler := newServerHandler(marketService, newsService)
+	// Persistent market-data store. DATA_DIR=off runs purely in memory,
+	// which is what you want for throwaway local runs.
+	var st store.Store
+	var alertsPath, ledgerPath string
+	if dataDir := cfg.DataDir; dataDir != "off" {
+		alertsPath = filepath.Join(dataDir, "alerts.json")
+		ledgerPath = filepath.Join(dataDir, "forecasts.jsonl")
+		fs, err := store.OpenFileStore(dataDir)
+		if err != nil {
+			log.Printf("store: %v — continuing without persistence", err)
+		} else {
+			st = fs
+			defer st.Close()
+		}
+	}
 
+	// Constructors only load state; the feeds start polling below, once
+	// everything is wired, and stop in the reverse order on shutdown.
+	runCtx, stopRun := context.WithCancel(context.Background())
+	defer stopRun()
+
+	marketService := services.NewMarketDataService(st)
+	newsService := services.NewNewsFeedService(marketService.Bus())
+	marketService.SetNews(newsService)
+
+	// Custom spread formulas on top of the built-in Brent–WTI, 3-2-1 crack,
+	// WCS–WTI and oil/gas ratio.
+	if path := cfg.SpreadsFile; path != "" {
+		defs, err := services.LoadSpreadDefinitions(path)
+		if err == nil {
+			err = marketService.AddSpreads(defs...)
+		}
+		if err != nil {
+			log.Fatalf("Failed to load spreads: %v", err)
+		}
+	}
+
+	alertEngine, err := services.NewAlertEngine(marketService, alertsPath)
+	if err != nil {
+		log.Fatalf("Failed to load alerts: %v", err)
+	}
+
+	ledger, err := services.NewForecastLedger(marketService, ledgerPath)
+	if err != nil {
+		log.Fatalf("Failed to load forecast ledger: %v", err)
+	}
+
+	if cfg.AlertsToken == "" {
+		log.Println("ALERTS_API_TOKEN not set — /api/alerts is disabled; stored alerts still fire")
+	}
+
+	health := services.NewHealthMonitor(policy)
+
+	handler := newServerHandler(cfg, marketService, newsService, alertEngine, ledger, health)
+
+	// Every request context derives from reqCtx, which Shutdown cancels
+	// straight away: /api/stream and /api/ws never go idle on their own,
+	// so without it Shutdown would wait out its timeout on them.
+	reqCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 	srv := &http.Server{
-		Addr:         ":" + port,
+		Addr:         ":" + cfg.Port,
 		Handler:      handler,
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
+		BaseContext:  func(net.Listener) context.Context { return reqCtx },
 	}
+	srv.RegisterOnShutdown(cancelRequests)
 
-	go func() {
-		sigint := make(chan os.Signal, 1)
-		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
-		<-sigint
+	marketService.Start(runCtx)
+	newsService.Start(runCtx)
+	alertEngine.Start(runCtx)
+	ledger.Start(runCtx)
 
-		log.Println("Shutting down server...")
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := srv.Shutdown(ctx); err != nil {
-			log.Fatalf("Server shutdown error: %v", err)
-		}
-	}()
+	sigCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.ListenAndServe() }()
 
 	fmt.Printf("\n  Live Oil Prices Server\n")
 	fmt.Printf("  ──────────────────────\n")
-	fmt.Printf("  → http://localhost:%s\n\n", port)
+	fmt.Printf("  → http://localhost:%s\n\n", cfg.Port)
 
-	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Server error: %v", err)
+	case <-sigCtx.Done():
+	}
+
+	// Drain in dependency order: stop taking requests, then the consumers
+	// of market data (alerts, ledger), then the feeds themselves. The
+	// store closes last, via the defer above.
+	log.Println("Shutting down server...")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown error: %v", err)
 	}
+	alertEngine.Stop()
+	ledger.Stop()
+	newsService.Stop()
+	marketService.Stop()
+	log.Println("Shutdown complete")
 }
 
-func newServerHandler(market handlers.MarketDataClient, news handlers.NewsClient) http.Handler {
+// newServerHandler builds the full route table. alerts and ledger may be
+// nil, which leaves the /api/alerts and /api/predictions/track-record
+// routes unregistered (as does an unset cfg.AlertsToken for the former);
+// a nil health keeps /api/health/ready at 200.
+func newServerHandler(cfg config.Config, market handlers.MarketDataClient, news handlers.NewsClient, alerts handlers.AlertClient, ledger handlers.TrackRecordClient, health handlers.HealthClient) http.Handler {
 	api := handlers.NewAPI(market, news)
+	api.SetSiteURL(cfg.SiteURL)
+	api.SetInstruments(cfg.Instruments)
+	if alerts != nil {
+		api.SetAlerts(alerts, cfg.AlertsToken)
+	}
+	if ledger != nil {
+		api.SetTrackRecord(ledger)
+	}
+	if health != nil {
+		api.SetHealth(health)
+	}
 
 	mux := http.NewServeMux()
 	api.RegisterRoutes(mux)
 
+	// Prometheus scrape endpoint: HTTP traffic, upstream fetches, cache
+	// ages, Pyth tick rate, prediction compute time, synthetic fallbacks.
+	mux.Handle("GET /metrics", metrics.Handler())
+
 	// Server-rendered HTML pages. Each route uses Go 1.22 exact-match
 	// patterns so they take precedence over the static FileServer below.
 	mux.HandleFunc("GET /{$}", api.ServeHome)
@@ -84,14 +217,15 @@ func newServerHandler(market handlers.MarketDataClient, news handlers.NewsClient
 	mux.HandleFunc("GET /sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
 		now := time.Now().Format("2006-01-02")
+		host := cfg.SiteURL
 		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>`)
 		fmt.Fprint(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
-		fmt.Fprintf(w, `<url><loc>https://liveoilprices.com/</loc><lastmod>%s</lastmod><changefreq>always</changefreq><priority>1.0</priority></url>`, now)
-		fmt.Fprintf(w, `<url><loc>https://liveoilprices.com/charts</loc><lastmod>%s</lastmod><changefreq>hourly</changefreq><priority>0.9</priority></url>`, now)
-		fmt.Fprintf(w, `<url><loc>https://liveoilprices.com/forecast</loc><lastmod>%s</lastmod><changefreq>hourly</changefreq><priority>0.9</priority></url>`, now)
-		fmt.Fprintf(w, `<url><loc>https://liveoilprices.com/news</loc><lastmod>%s</lastmod><changefreq>hourly</changefreq><priority>0.9</priority></url>`, now)
-		for _, sym := range commoditySymbols {
-			fmt.Fprintf(w, `<url><loc>https://liveoilprices.com/commodity/%s</loc><lastmod>%s</lastmod><changefreq>always</changefreq><priority>0.8</priority></url>`, sym, now)
+		fmt.Fprintf(w, `<url><loc>%s/</loc><lastmod>%s</lastmod><changefreq>always</changefreq><priority>1.0</priority></url>`, host, now)
+		fmt.Fprintf(w, `<url><loc>%s/charts</loc><lastmod>%s</lastmod><changefreq>hourly</changefreq><priority>0.9</priority></url>`, host, now)
+		fmt.Fprintf(w, `<url><loc>%s/forecast</loc><lastmod>%s</lastmod><changefreq>hourly</changefreq><priority>0.9</priority></url>`, host, now)
+		fmt.Fprintf(w, `<url><loc>%s/news</loc><lastmod>%s</lastmod><changefreq>hourly</changefreq><priority>0.9</priority></url>`, host, now)
+		for _, in := range cfg.Instruments {
+			fmt.Fprintf(w, `<url><loc>%s/commodity/%s</loc><lastmod>%s</lastmod><changefreq>always</changefreq><priority>0.8</priority></url>`, host, in.Symbol, now)
 		}
 		fmt.Fprint(w, `</urlset>`)
 	})
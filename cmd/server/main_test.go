@@ -1,20 +1,29 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"live-oil-prices-go/internal/config"
 	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/websocket"
 )
 
 type fakeMarketDataService struct {
-	getPricesFunc     func() []models.Price
-	getChartDataFunc  func(symbol string, days int, interval string) models.ChartData
-	getPredictionsFunc func() []models.Prediction
-	getAnalysisFunc    func() models.MarketAnalysis
+	getPricesFunc        func() []models.Price
+	getChartDataFunc     func(symbol string, days int, interval string) models.ChartData
+	getChartRangeFunc    func(symbol, interval string, from, to time.Time) models.ChartData
+	fetchFullHistoryFunc func(symbol string) error
+	getPredictionsFunc   func() []models.Prediction
+	getForecastSetFunc   func(symbol string, horizons []int) (models.ForecastSet, bool)
+	getRiskFunc          func(symbol string) (models.RiskReport, bool)
+	getAnalysisFunc      func() models.MarketAnalysis
+	subscribeFunc        func(symbols []string, lastEventID uint64) ([]models.StreamEvent, <-chan models.StreamEvent, func())
 }
 
 func (f *fakeMarketDataService) GetPrices() []models.Price {
@@ -25,6 +34,44 @@ func (f *fakeMarketDataService) GetChartData(symbol string, days int, interval s
 	return f.getChartDataFunc(symbol, days, interval)
 }
 
+func (f *fakeMarketDataService) GetChartRange(symbol, interval string, from, to time.Time) models.ChartData {
+	if f.getChartRangeFunc == nil {
+		return models.ChartData{Symbol: symbol, Interval: interval}
+	}
+	return f.getChartRangeFunc(symbol, interval, from, to)
+}
+
+func (f *fakeMarketDataService) ChartWindow(symbol, interval string) (time.Time, bool) {
+	switch interval {
+	case "", "1d":
+		return time.Now().AddDate(-2, 0, 0), true
+	case "1m", "5m", "15m", "1h", "2h", "4h":
+		return time.Now().AddDate(0, 0, -60), true
+	}
+	return time.Time{}, false
+}
+
+func (f *fakeMarketDataService) FetchFullHistory(ctx context.Context, symbol string) error {
+	if f.fetchFullHistoryFunc == nil {
+		return nil
+	}
+	return f.fetchFullHistoryFunc(symbol)
+}
+
+func (f *fakeMarketDataService) GetForecastSet(symbol string, horizons []int) (models.ForecastSet, bool) {
+	if f.getForecastSetFunc == nil {
+		return models.ForecastSet{}, false
+	}
+	return f.getForecastSetFunc(symbol, horizons)
+}
+
+func (f *fakeMarketDataService) GetRisk(symbol string) (models.RiskReport, bool) {
+	if f.getRiskFunc == nil {
+		return models.RiskReport{}, false
+	}
+	return f.getRiskFunc(symbol)
+}
+
 func (f *fakeMarketDataService) GetPredictions() []models.Prediction {
 	if f.getPredictionsFunc == nil {
 		return nil
@@ -51,6 +98,34 @@ func (f *fakeMarketDataService) GetConsensusForecast(symbol string) (models.Cons
 	return models.ConsensusForecast{}, false
 }
 
+func (f *fakeMarketDataService) GetSpreads() []models.Spread {
+	return nil
+}
+
+func (f *fakeMarketDataService) GetSpreadChart(id string, days int) (models.SpreadChart, bool) {
+	if id != "brent-wti" {
+		return models.SpreadChart{}, false
+	}
+	return models.SpreadChart{ID: id, Data: []models.SpreadPoint{}}, true
+}
+
+func (f *fakeMarketDataService) GetForwardCurve(symbol string) (models.ForwardCurve, bool) {
+	return models.ForwardCurve{}, false
+}
+
+func (f *fakeMarketDataService) GetCurveHistory(symbol string, days int) (models.CurveHistory, bool) {
+	return models.CurveHistory{}, false
+}
+
+func (f *fakeMarketDataService) Subscribe(symbols []string, lastEventID uint64) ([]models.StreamEvent, <-chan models.StreamEvent, func()) {
+	if f.subscribeFunc == nil {
+		ch := make(chan models.StreamEvent)
+		close(ch)
+		return nil, ch, func() {}
+	}
+	return f.subscribeFunc(symbols, lastEventID)
+}
+
 type fakeNewsFeedService struct {
 	getNewsFunc     func() []models.NewsArticle
 	getNewsByIDFunc func(id string) *models.NewsArticle
@@ -72,6 +147,7 @@ func (f *fakeNewsFeedService) GetNewsByID(id string) *models.NewsArticle {
 
 func TestNewServerHandlerWiresRoutesAndMiddleware(t *testing.T) {
 	server := newServerHandler(
+		config.Default(),
 		&fakeMarketDataService{
 			getPricesFunc: func() []models.Price {
 				return []models.Price{
@@ -113,6 +189,9 @@ func TestNewServerHandlerWiresRoutesAndMiddleware(t *testing.T) {
 				return nil
 			},
 		},
+		nil,
+		nil,
+		nil,
 	)
 
 	tests := []struct {
@@ -122,6 +201,7 @@ func TestNewServerHandlerWiresRoutesAndMiddleware(t *testing.T) {
 		wantStatus int
 	}{
 		{"health", http.MethodGet, "/api/health", http.StatusOK},
+		{"ready", http.MethodGet, "/api/health/ready", http.StatusOK},
 		{"prices", http.MethodGet, "/api/prices", http.StatusOK},
 		{"analysis", http.MethodGet, "/api/analysis", http.StatusOK},
 		{"predictions", http.MethodGet, "/api/predictions", http.StatusOK},
@@ -163,4 +243,31 @@ func TestNewServerHandlerWiresRoutesAndMiddleware(t *testing.T) {
 	if len(prices) != 1 || prices[0].Symbol != "WTI" {
 		t.Fatalf("unexpected prices payload: %#v", prices)
 	}
+
+	metricsRes := httptest.NewRecorder()
+	server.ServeHTTP(metricsRes, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if metricsRes.Code != http.StatusOK || !strings.Contains(metricsRes.Body.String(), `oilprices_http_requests_total{route="/api/prices",method="GET",status="200"}`) {
+		t.Fatalf("expected request metrics on /metrics, got %d %s", metricsRes.Code, metricsRes.Body.String())
+	}
+}
+
+func TestSocketUpgradesThroughMiddleware(t *testing.T) {
+	events := make(chan models.StreamEvent)
+	defer close(events)
+	srv := httptest.NewServer(newServerHandler(config.Default(), &fakeMarketDataService{
+		subscribeFunc: func([]string, uint64) ([]models.StreamEvent, <-chan models.StreamEvent, func()) {
+			return nil, events, func() {}
+		},
+	}, &fakeNewsFeedService{}, nil, nil, nil))
+	defer srv.Close()
+
+	conn, err := websocket.Dial("ws" + strings.TrimPrefix(srv.URL, "http") + "/api/ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetIdleTimeout(5 * time.Second)
+	if _, data, err := conn.ReadMessage(); err != nil || !strings.Contains(string(data), `"type":"welcome"`) {
+		t.Fatalf("expected a welcome message, got %s %v", data, err)
+	}
 }
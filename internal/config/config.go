@@ -0,0 +1,370 @@
+// Package config is the server's single typed configuration: poll
+// intervals, the instrument catalogue behind every feed and page, news
+// queries and the public site URL. Defaults reproduce the built-in
+// behaviour; a JSON file (CONFIG_FILE) overrides any subset of them and
+// environment variables override the file.
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration that reads and writes as a Go duration
+// string ("30s", "6h") in JSON.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Config is the full server configuration.
+type Config struct {
+	Port        string `json:"port"`
+	DataDir     string `json:"dataDir"`     // "off" disables persistence
+	SpreadsFile string `json:"spreadsFile"` // optional custom spreads
+	// SiteURL is the public origin used in the sitemap, canonical links
+	// and structured data, without a trailing slash.
+	SiteURL string `json:"siteUrl"`
+	// CatalogFile, when set, replaces Instruments with the JSON array in
+	// that file.
+	CatalogFile string `json:"catalogFile"`
+
+	Intervals Intervals  `json:"intervals"`
+	Upstreams Upstreams  `json:"upstreams"`
+	Health    Health     `json:"health"`
+	News      []NewsFeed `json:"news"`
+	// Instruments is the symbol catalogue, in /api/prices order.
+	Instruments []models.Instrument `json:"instruments"`
+
+	// AlertsToken is the bearer token the /api/alerts routes require;
+	// unset leaves them unregistered. Environment only (ALERTS_API_TOKEN),
+	// so it stays out of config files.
+	AlertsToken string `json:"-"`
+}
+
+// Intervals are the upstream poll cadences.
+type Intervals struct {
+	Pyth          Duration `json:"pyth"`
+	YahooQuotes   Duration `json:"yahooQuotes"`
+	YahooHistory  Duration `json:"yahooHistory"`
+	YahooIntraday Duration `json:"yahooIntraday"`
+	Curve         Duration `json:"curve"`
+	News          Duration `json:"news"`
+	EIA           Duration `json:"eia"`
+}
+
+// Upstreams are the base URLs the feeds fetch from. The defaults are the
+// public APIs; point them at a mirror or a stub server to run offline.
+type Upstreams struct {
+	Yahoo      string `json:"yahoo"`      // chart API; "<symbol>?<query>" is appended
+	Pyth       string `json:"pyth"`       // Hermes latest-price endpoint
+	EIA        string `json:"eia"`        // STEO data endpoint
+	GoogleNews string `json:"googleNews"` // RSS search; the escaped query is appended
+}
+
+// Health carries the readiness thresholds in the same form as the
+// HEALTH_* variables; see services.ParseHealthPolicy.
+type Health struct {
+	MaxAge        string `json:"maxAge"`
+	MaxFailures   string `json:"maxFailures"`
+	CriticalFeeds string `json:"criticalFeeds"`
+}
+
+// NewsFeed is one news category. Query is a Google News search; URL, when
+// set, polls any RSS feed instead.
+type NewsFeed struct {
+	Category string `json:"category"`
+	Query    string `json:"query,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// Default returns the built-in configuration.
+func Default() Config {
+	return Config{
+		Port:    "8080",
+		DataDir: "data",
+		SiteURL: "https://liveoilprices.com",
+		Intervals: Intervals{
+			Pyth:          Duration(2 * time.Second),
+			YahooQuotes:   Duration(30 * time.Second),
+			YahooHistory:  Duration(6 * time.Hour),
+			YahooIntraday: Duration(5 * time.Minute),
+			Curve:         Duration(10 * time.Minute),
+			News:          Duration(10 * time.Minute),
+			EIA:           Duration(24 * time.Hour),
+		},
+		Upstreams: Upstreams{
+			Yahoo:      "https://query1.finance.yahoo.com/v8/finance/chart/",
+			Pyth:       "https://hermes.pyth.network/v2/updates/price/latest",
+			EIA:        "https://api.eia.gov/v2/steo/data/",
+			GoogleNews: "https://news.google.com/rss/search?hl=en-US&gl=US&ceid=US:en&q=",
+		},
+		News: []NewsFeed{
+			{Category: "Oil Markets", Query: "crude oil price WTI Brent"},
+			{Category: "OPEC", Query: "OPEC oil production output"},
+			{Category: "Natural Gas", Query: "natural gas LNG Henry Hub"},
+			{Category: "Refining", Query: "oil refining gasoline diesel fuel"},
+			{Category: "Extraction", Query: "oil drilling extraction upstream shale"},
+			{Category: "Technology", Query: "oil gas engineering technology energy innovation"},
+			{Category: "International", Query: "international energy policy geopolitics oil sanctions"},
+			{Category: "Inventory", Query: "oil gas inventory EIA stockpile storage"},
+		},
+		Instruments: defaultInstruments(),
+	}
+}
+
+// instrumentsJSON is the built-in catalogue. Adding an instrument is an
+// entry here (or in CATALOG_FILE), not a code change.
+//
+//go:embed instruments.json
+var instrumentsJSON []byte
+
+func defaultInstruments() []models.Instrument {
+	var out []models.Instrument
+	if err := json.Unmarshal(instrumentsJSON, &out); err != nil {
+		panic("config: built-in instruments.json: " + err.Error())
+	}
+	return out
+}
+
+// LoadCatalog reads a JSON array of instruments.
+func LoadCatalog(path string) ([]models.Instrument, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("catalog: %w", err)
+	}
+	var out []models.Instrument
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&out); err != nil {
+		return nil, fmt.Errorf("catalog %s: %w", path, err)
+	}
+	return out, nil
+}
+
+// Load reads path over the defaults and applies env overrides. An empty
+// path uses the defaults alone. The result is validated.
+func Load(path string, getenv func(string) string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: %w", err)
+		}
+		// encoding/json decodes an array into the existing elements of a
+		// slice, which would merge a file's list into the defaults'
+		// entries. Lists replace the defaults wholesale instead.
+		news, instruments := cfg.News, cfg.Instruments
+		cfg.News, cfg.Instruments = nil, nil
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return Config{}, fmt.Errorf("config %s: %w", path, err)
+		}
+		if cfg.News == nil {
+			cfg.News = news
+		}
+		if cfg.Instruments == nil {
+			cfg.Instruments = instruments
+		}
+	}
+	if err := cfg.applyEnv(getenv); err != nil {
+		return Config{}, err
+	}
+	if cfg.CatalogFile != "" {
+		instruments, err := LoadCatalog(cfg.CatalogFile)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg.Instruments = instruments
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overlays the environment variables documented in the README.
+func (c *Config) applyEnv(getenv func(string) string) error {
+	for env, dst := range map[string]*string{
+		"PORT":                  &c.Port,
+		"DATA_DIR":              &c.DataDir,
+		"SPREADS_FILE":          &c.SpreadsFile,
+		"SITE_URL":              &c.SiteURL,
+		"CATALOG_FILE":          &c.CatalogFile,
+		"ALERTS_API_TOKEN":      &c.AlertsToken,
+		"YAHOO_URL":             &c.Upstreams.Yahoo,
+		"PYTH_URL":              &c.Upstreams.Pyth,
+		"EIA_URL":               &c.Upstreams.EIA,
+		"GNEWS_URL":             &c.Upstreams.GoogleNews,
+		"HEALTH_MAX_AGE":        &c.Health.MaxAge,
+		"HEALTH_MAX_FAILURES":   &c.Health.MaxFailures,
+		"HEALTH_CRITICAL_FEEDS": &c.Health.CriticalFeeds,
+	} {
+		if v := getenv(env); v != "" {
+			*dst = v
+		}
+	}
+	for env, dst := range map[string]*Duration{
+		"PYTH_POLL_INTERVAL":      &c.Intervals.Pyth,
+		"YAHOO_QUOTE_INTERVAL":    &c.Intervals.YahooQuotes,
+		"YAHOO_HISTORY_INTERVAL":  &c.Intervals.YahooHistory,
+		"YAHOO_INTRADAY_INTERVAL": &c.Intervals.YahooIntraday,
+		"CURVE_INTERVAL":          &c.Intervals.Curve,
+		"NEWS_INTERVAL":           &c.Intervals.News,
+		"EIA_INTERVAL":            &c.Intervals.EIA,
+	} {
+		v := getenv(env)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", env, err)
+		}
+		*dst = Duration(d)
+	}
+	return nil
+}
+
+// Validate reports every problem at once so a bad deploy fails with the
+// full list rather than one error per restart.
+func (c Config) Validate() error {
+	var errs []error
+	bad := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if n, err := strconv.Atoi(c.Port); err != nil || n <= 0 || n > 65535 {
+		bad("port %q is not a TCP port", c.Port)
+	}
+	if u, err := url.Parse(c.SiteURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		bad("siteUrl %q must be an absolute http(s) URL", c.SiteURL)
+	} else if strings.HasSuffix(c.SiteURL, "/") {
+		bad("siteUrl %q must not end in a slash", c.SiteURL)
+	}
+
+	for _, up := range []struct{ name, url string }{
+		{"yahoo", c.Upstreams.Yahoo},
+		{"pyth", c.Upstreams.Pyth},
+		{"eia", c.Upstreams.EIA},
+		{"googleNews", c.Upstreams.GoogleNews},
+	} {
+		if u, err := url.Parse(up.url); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			bad("upstreams.%s %q must be an absolute http(s) URL", up.name, up.url)
+		}
+	}
+
+	for _, iv := range []struct {
+		name string
+		d    Duration
+	}{
+		{"pyth", c.Intervals.Pyth},
+		{"yahooQuotes", c.Intervals.YahooQuotes},
+		{"yahooHistory", c.Intervals.YahooHistory},
+		{"yahooIntraday", c.Intervals.YahooIntraday},
+		{"curve", c.Intervals.Curve},
+		{"news", c.Intervals.News},
+		{"eia", c.Intervals.EIA},
+	} {
+		// Hermes is the fastest upstream we poll and publishes every
+		// ~400ms; anything quicker just hammers a free API.
+		if time.Duration(iv.d) < 500*time.Millisecond {
+			bad("intervals.%s %s is below the 500ms minimum", iv.name, time.Duration(iv.d))
+		}
+	}
+
+	if len(c.Instruments) == 0 {
+		bad("instruments is empty")
+	}
+	seen := make(map[string]bool, len(c.Instruments))
+	for i, in := range c.Instruments {
+		switch {
+		case in.Symbol == "" || strings.ToUpper(in.Symbol) != in.Symbol:
+			bad("instruments[%d]: symbol %q must be non-empty upper case", i, in.Symbol)
+		case seen[in.Symbol]:
+			bad("instruments[%d]: duplicate symbol %s", i, in.Symbol)
+		}
+		seen[in.Symbol] = true
+		if in.Name == "" {
+			bad("instruments[%d]: %s has no name", i, in.Symbol)
+		}
+		if !priceUnits[in.Unit] {
+			bad("instruments[%d]: %s unit %q is not one of USD/barrel, USD/gallon, USD/MMBtu, USD/tonne", i, in.Symbol, in.Unit)
+		}
+		if in.BasePrice <= 0 {
+			bad("instruments[%d]: %s basePrice must be positive", i, in.Symbol)
+		}
+		if in.PythFeedID != "" && !isHex(in.PythFeedID, 64) {
+			bad("instruments[%d]: %s pythFeedId must be 64 hex characters without 0x", i, in.Symbol)
+		}
+		if in.EIA != nil && in.EIA.Series == "" {
+			bad("instruments[%d]: %s eia needs a series", i, in.Symbol)
+		}
+		if in.ForecastHorizon < 0 {
+			bad("instruments[%d]: %s forecastHorizon must not be negative", i, in.Symbol)
+		}
+	}
+
+	categories := map[string]bool{}
+	for i, f := range c.News {
+		if f.Category == "" {
+			bad("news[%d]: missing category", i)
+		} else if categories[f.Category] {
+			bad("news[%d]: duplicate category %q", i, f.Category)
+		}
+		categories[f.Category] = true
+		if (f.Query == "") == (f.URL == "") {
+			bad("news[%d]: set exactly one of query or url", i)
+		} else if f.URL != "" {
+			if u, err := url.Parse(f.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				bad("news[%d]: url %q must be an absolute http(s) URL", i, f.URL)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// priceUnits are the units the spread and curve code can convert between.
+var priceUnits = map[string]bool{
+	"USD/barrel": true, "USD/gallon": true, "USD/MMBtu": true, "USD/tonne": true,
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
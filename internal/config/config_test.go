@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("defaults should validate: %v", err)
+	}
+}
+
+func TestLoadFileThenEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	body := `{
+		"siteUrl": "https://staging.example.com",
+		"intervals": {"pyth": "5s", "news": "30m"},
+		"upstreams": {"pyth": "http://localhost:9000/hermes"},
+		"news": [{"category": "Desk", "url": "https://example.com/rss"}]
+	}`
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"PORT": "9090", "NEWS_INTERVAL": "1h", "EIA_URL": "http://localhost:9000/eia/"}
+	cfg, err := Load(path, func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SiteURL != "https://staging.example.com" || cfg.Port != "9090" {
+		t.Fatalf("file/env not applied: %+v", cfg)
+	}
+	if time.Duration(cfg.Intervals.Pyth) != 5*time.Second || time.Duration(cfg.Intervals.News) != time.Hour {
+		t.Fatalf("intervals: %+v", cfg.Intervals)
+	}
+	if time.Duration(cfg.Intervals.YahooQuotes) != 30*time.Second {
+		t.Fatalf("unset intervals should keep defaults, got %v", time.Duration(cfg.Intervals.YahooQuotes))
+	}
+	if up := cfg.Upstreams; up.Pyth != "http://localhost:9000/hermes" || up.EIA != "http://localhost:9000/eia/" || up.Yahoo != Default().Upstreams.Yahoo {
+		t.Fatalf("upstreams: %+v", up)
+	}
+	if len(cfg.News) != 1 || cfg.News[0].Category != "Desk" {
+		t.Fatalf("news should be replaced: %+v", cfg.News)
+	}
+	if len(cfg.Instruments) != len(Default().Instruments) {
+		t.Fatalf("instruments should keep defaults")
+	}
+}
+
+func TestCatalogFileReplacesInstruments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	body := `[{"symbol": "GOLD", "name": "Gold", "unit": "USD/tonne", "basePrice": 77000000, "yahoo": "GC=F", "forecastHorizon": 5}]`
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load("", func(k string) string {
+		return map[string]string{"CATALOG_FILE": path}[k]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Instruments) != 1 || cfg.Instruments[0].Yahoo != "GC=F" || cfg.Instruments[0].ForecastHorizon != 5 {
+		t.Fatalf("catalog not loaded: %+v", cfg.Instruments)
+	}
+}
+
+func TestLoadRejectsBadConfig(t *testing.T) {
+	cases := map[string]string{
+		"unknown field":  `{"prot": "80"}`,
+		"bad duration":   `{"intervals": {"pyth": 2}}`,
+		"too fast":       `{"intervals": {"pyth": "100ms"}}`,
+		"bad unit":       `{"instruments": [{"symbol": "GOLD", "name": "Gold", "unit": "USD/oz", "basePrice": 2400}]}`,
+		"bad feed id":    `{"instruments": [{"symbol": "WTI", "name": "WTI", "unit": "USD/barrel", "basePrice": 70, "pythFeedId": "0x12"}]}`,
+		"duplicate":      `{"instruments": [{"symbol": "WTI", "name": "WTI", "unit": "USD/barrel", "basePrice": 70}, {"symbol": "WTI", "name": "WTI", "unit": "USD/barrel", "basePrice": 70}]}`,
+		"trailing slash": `{"siteUrl": "https://example.com/"}`,
+		"relative url":   `{"upstreams": {"yahoo": "/v8/finance/chart/"}}`,
+		"news both":      `{"news": [{"category": "X", "query": "oil", "url": "https://example.com/rss"}]}`,
+	}
+	for name, body := range cases {
+		path := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path, func(string) string { return "" }); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	_, err := Load("", func(k string) string {
+		return map[string]string{"PORT": "http", "YAHOO_QUOTE_INTERVAL": "1ms"}[k]
+	})
+	if err == nil || !strings.Contains(err.Error(), "port") || !strings.Contains(err.Error(), "yahooQuotes") {
+		t.Fatalf("expected every problem reported, got %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+// Package export streams tabular market data as CSV, NDJSON or Parquet.
+// Every writer takes one row at a time and holds at most one Parquet row
+// group in memory, so multi-year exports don't buffer whole files.
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Kind is a column's value type.
+type Kind int
+
+const (
+	Float Kind = iota // float64, e.g. a price
+	Int               // int64, e.g. volume
+)
+
+// Column describes one value column. Every table also has a leading,
+// non-null "time" column.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Cell is one value; Valid false means null (no bar for that symbol at
+// that time in a wide export).
+type Cell struct {
+	Float float64
+	Int   int64
+	Valid bool
+}
+
+// Row is one line of the table. Cells line up with the writer's columns.
+type Row struct {
+	Time  time.Time
+	Cells []Cell
+}
+
+// Writer streams rows in one format. Close writes any trailer (the Parquet
+// footer) but does not close the underlying io.Writer.
+type Writer interface {
+	WriteRow(Row) error
+	Close() error
+}
+
+// Formats lists the supported format names with their content types.
+var Formats = map[string]string{
+	"csv":     "text/csv; charset=utf-8",
+	"ndjson":  "application/x-ndjson",
+	"parquet": "application/vnd.apache.parquet",
+}
+
+// NewWriter returns a writer for format ("csv", "ndjson" or "parquet").
+func NewWriter(format string, w io.Writer, cols []Column) (Writer, error) {
+	switch format {
+	case "csv":
+		return newCSVWriter(w, cols)
+	case "ndjson":
+		return newNDJSONWriter(w, cols), nil
+	case "parquet":
+		return newParquetWriter(w, cols)
+	}
+	return nil, fmt.Errorf("export: unknown format %q", format)
+}
@@ -0,0 +1,196 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testCols = []Column{{Name: "close", Kind: Float}, {Name: "volume", Kind: Int}}
+
+func testRows() []Row {
+	t0 := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	return []Row{
+		{Time: t0, Cells: []Cell{{Float: 71.25, Valid: true}, {Int: 1200, Valid: true}}},
+		{Time: t0.Add(24 * time.Hour), Cells: []Cell{{Float: 72.5, Valid: true}, {}}},
+		{Time: t0.Add(48 * time.Hour), Cells: []Cell{{}, {Int: 900, Valid: true}}},
+	}
+}
+
+func writeAll(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf, testCols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range testRows() {
+		if err := w.WriteRow(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSV(t *testing.T) {
+	got := string(writeAll(t, "csv"))
+	want := "time,close,volume\n" +
+		"2026-03-02T00:00:00Z,71.25,1200\n" +
+		"2026-03-03T00:00:00Z,72.5,\n" +
+		"2026-03-04T00:00:00Z,,900\n"
+	if got != want {
+		t.Fatalf("csv:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(string(writeAll(t, "ndjson"))), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines", len(lines))
+	}
+	if lines[1] != `{"time":"2026-03-03T00:00:00Z","close":72.5,"volume":null}` {
+		t.Fatalf("line 2 = %s", lines[1])
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := NewWriter("xlsx", &bytes.Buffer{}, testCols); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestParquetLayout(t *testing.T) {
+	b := writeAll(t, "parquet")
+	if string(b[:4]) != "PAR1" || string(b[len(b)-4:]) != "PAR1" {
+		t.Fatal("missing magic")
+	}
+	n := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	r := &thriftReader{b: b[len(b)-8-n : len(b)-8]}
+	meta := r.readStruct()
+
+	if meta[3] != int64(3) {
+		t.Fatalf("num_rows = %v", meta[3])
+	}
+	schema := meta[2].([]any)
+	var names []string
+	for _, e := range schema {
+		names = append(names, e.(map[int16]any)[4].(string))
+	}
+	if strings.Join(names, ",") != "schema,time,close,volume" {
+		t.Fatalf("schema = %v", names)
+	}
+	if schema[1].(map[int16]any)[6] != int64(9) {
+		t.Fatal("time column is not TIMESTAMP_MILLIS")
+	}
+
+	groups := meta[4].([]any)
+	if len(groups) != 1 {
+		t.Fatalf("%d row groups", len(groups))
+	}
+	chunks := groups[0].(map[int16]any)[1].([]any)
+
+	// close: defined, defined, null → levels 0b011, two doubles.
+	cm := chunks[1].(map[int16]any)[3].(map[int16]any)
+	off := cm[9].(int64)
+	pr := &thriftReader{b: b[off:]}
+	ph := pr.readStruct()
+	body := b[int(off)+pr.pos:][:ph[3].(int64)]
+	levelLen := binary.LittleEndian.Uint32(body)
+	levels := body[4 : 4+levelLen]
+	if !bytes.Equal(levels, []byte{0x03, 0x03}) {
+		t.Fatalf("definition levels = %x", levels)
+	}
+	vals := body[4+levelLen:]
+	if len(vals) != 16 || math.Float64frombits(binary.LittleEndian.Uint64(vals[8:])) != 72.5 {
+		t.Fatalf("close values = %x", vals)
+	}
+}
+
+func TestParquetSplitsRowGroups(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter("parquet", &buf, testCols)
+	row := testRows()[0]
+	for i := 0; i < parquetRowGroupRows+10; i++ {
+		w.WriteRow(row)
+	}
+	w.Close()
+	b := buf.Bytes()
+	n := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	meta := (&thriftReader{b: b[len(b)-8-n : len(b)-8]}).readStruct()
+	if groups := meta[4].([]any); len(groups) != 2 {
+		t.Fatalf("%d row groups, want 2", len(groups))
+	}
+	if meta[3] != int64(parquetRowGroupRows+10) {
+		t.Fatalf("num_rows = %v", meta[3])
+	}
+}
+
+// thriftReader decodes the compact-protocol subset parquetWriter emits:
+// structs become field-id maps, lists slices, integers int64.
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		s := string(r.b[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		h := r.b[r.pos]
+		r.pos++
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		out := make([]any, n)
+		for i := range out {
+			out[i] = r.value(h & 0x0f)
+		}
+		return out
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("unexpected thrift type")
+}
+
+func (r *thriftReader) readStruct() map[int16]any {
+	out := map[int16]any{}
+	var last int16
+	for {
+		h := r.b[r.pos]
+		r.pos++
+		if h == 0 {
+			return out
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			id = int16(r.varint())
+		}
+		last = id
+		out[id] = r.value(h & 0x0f)
+	}
+}
@@ -0,0 +1,317 @@
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// Minimal Parquet writer: one flat schema, PLAIN-encoded uncompressed data
+// pages (format v1), one page per column chunk. The time column is a
+// required INT64 TIMESTAMP_MILLIS; value columns are optional DOUBLE or
+// INT64 with their definition levels bit-packed. That is the subset every
+// reader (pyarrow, DuckDB, Spark, parquet-tools) handles, and it keeps the
+// module free of third-party dependencies.
+
+// parquetRowGroupRows bounds the rows buffered before a row group is
+// flushed: 64Ki rows × a few dozen 8-byte columns stays in the low MBs.
+const parquetRowGroupRows = 1 << 16
+
+const parquetMagic = "PAR1"
+
+// Parquet thrift enums used below.
+const (
+	pqTypeInt64  = 2
+	pqTypeDouble = 5
+
+	pqRequired = 0
+	pqOptional = 1
+
+	pqConvertedTimestampMillis = 9
+
+	pqEncodingPlain = 0
+	pqEncodingRLE   = 3
+
+	pqCodecUncompressed = 0
+	pqPageData          = 0
+)
+
+type parquetColumn struct {
+	name     string
+	physical int32
+	optional bool
+
+	// Buffered row-group values. defined is only kept for optional
+	// columns; values holds only non-null entries as raw 8-byte words.
+	defined []bool
+	values  []uint64
+}
+
+type columnChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+type rowGroup struct {
+	rows    int64
+	columns []columnChunk
+}
+
+type parquetWriter struct {
+	w       io.Writer
+	offset  int64
+	columns []*parquetColumn
+	rows    int // rows buffered in the current group
+	groups  []rowGroup
+}
+
+func newParquetWriter(w io.Writer, cols []Column) (*parquetWriter, error) {
+	pw := &parquetWriter{w: w}
+	pw.columns = append(pw.columns, &parquetColumn{name: "time", physical: pqTypeInt64})
+	for _, c := range cols {
+		physical := int32(pqTypeDouble)
+		if c.Kind == Int {
+			physical = pqTypeInt64
+		}
+		pw.columns = append(pw.columns, &parquetColumn{name: c.Name, physical: physical, optional: true})
+	}
+	if err := pw.write([]byte(parquetMagic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (pw *parquetWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+func (pw *parquetWriter) WriteRow(r Row) error {
+	t := pw.columns[0]
+	t.values = append(t.values, uint64(r.Time.UnixMilli()))
+	for i, c := range r.Cells {
+		col := pw.columns[i+1]
+		col.defined = append(col.defined, c.Valid)
+		if !c.Valid {
+			continue
+		}
+		if col.physical == pqTypeDouble {
+			col.values = append(col.values, math.Float64bits(c.Float))
+		} else {
+			col.values = append(col.values, uint64(c.Int))
+		}
+	}
+	pw.rows++
+	if pw.rows >= parquetRowGroupRows {
+		return pw.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered rows as one row group.
+func (pw *parquetWriter) flush() error {
+	if pw.rows == 0 {
+		return nil
+	}
+	rg := rowGroup{rows: int64(pw.rows)}
+	for _, col := range pw.columns {
+		var body []byte
+		if col.optional {
+			levels := encodeDefinitionLevels(col.defined)
+			body = binary.LittleEndian.AppendUint32(body, uint32(len(levels)))
+			body = append(body, levels...)
+		}
+		for _, v := range col.values {
+			body = binary.LittleEndian.AppendUint64(body, v)
+		}
+
+		var h thriftWriter
+		h.i32(1, pqPageData)
+		h.i32(2, int32(len(body)))
+		h.i32(3, int32(len(body)))
+		h.structBegin(5) // DataPageHeader
+		h.i32(1, int32(pw.rows))
+		h.i32(2, pqEncodingPlain)
+		h.i32(3, pqEncodingRLE)
+		h.i32(4, pqEncodingRLE)
+		h.structEnd()
+		h.stop()
+
+		chunk := columnChunk{offset: pw.offset, size: int64(len(h.buf) + len(body)), numValues: int64(pw.rows)}
+		if err := pw.write(h.buf); err != nil {
+			return err
+		}
+		if err := pw.write(body); err != nil {
+			return err
+		}
+		rg.columns = append(rg.columns, chunk)
+		col.defined = col.defined[:0]
+		col.values = col.values[:0]
+	}
+	pw.groups = append(pw.groups, rg)
+	pw.rows = 0
+	return nil
+}
+
+// encodeDefinitionLevels encodes 0/1 levels as a single bit-packed run of
+// the RLE/bit-packing hybrid (bit width 1), padded to a multiple of 8.
+func encodeDefinitionLevels(defined []bool) []byte {
+	groups := (len(defined) + 7) / 8
+	out := binary.AppendUvarint(nil, uint64(groups)<<1|1)
+	packed := make([]byte, groups)
+	for i, d := range defined {
+		if d {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return append(out, packed...)
+}
+
+func (pw *parquetWriter) Close() error {
+	if err := pw.flush(); err != nil {
+		return err
+	}
+	var numRows int64
+	for _, g := range pw.groups {
+		numRows += g.rows
+	}
+
+	var m thriftWriter // FileMetaData
+	m.i32(1, 1)
+	m.listBegin(2, thriftStruct, len(pw.columns)+1)
+	m.elemBegin() // root
+	m.binary(4, "schema")
+	m.i32(5, int32(len(pw.columns)))
+	m.elemEnd()
+	for _, col := range pw.columns {
+		m.elemBegin()
+		m.i32(1, col.physical)
+		if col.optional {
+			m.i32(3, pqOptional)
+		} else {
+			m.i32(3, pqRequired)
+		}
+		m.binary(4, col.name)
+		if !col.optional {
+			m.i32(6, pqConvertedTimestampMillis)
+		}
+		m.elemEnd()
+	}
+	m.i64(3, numRows)
+	m.listBegin(4, thriftStruct, len(pw.groups))
+	for _, g := range pw.groups {
+		var total int64
+		m.elemBegin()
+		m.listBegin(1, thriftStruct, len(g.columns))
+		for i, c := range g.columns {
+			col := pw.columns[i]
+			total += c.size
+			m.elemBegin() // ColumnChunk
+			m.i64(2, c.offset)
+			m.structBegin(3) // ColumnMetaData
+			m.i32(1, col.physical)
+			m.listBegin(2, thriftI32, 2)
+			m.listI32(pqEncodingPlain)
+			m.listI32(pqEncodingRLE)
+			m.listBegin(3, thriftBinary, 1)
+			m.listBinary(col.name)
+			m.i32(4, pqCodecUncompressed)
+			m.i64(5, c.numValues)
+			m.i64(6, c.size)
+			m.i64(7, c.size)
+			m.i64(9, c.offset)
+			m.structEnd()
+			m.elemEnd()
+		}
+		m.i64(2, total)
+		m.i64(3, g.rows)
+		m.elemEnd()
+	}
+	m.binary(6, "live-oil-prices-go")
+	m.stop()
+
+	footer := binary.LittleEndian.AppendUint32(m.buf, uint32(len(m.buf)))
+	footer = append(footer, parquetMagic...)
+	return pw.write(footer)
+}
+
+// Thrift compact protocol, just enough for Parquet metadata.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+type thriftWriter struct {
+	buf   []byte
+	last  int16   // last field id in the current struct
+	stack []int16 // enclosing structs' last field ids
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if d := id - t.last; d > 0 && d <= 15 {
+		t.buf = append(t.buf, byte(d)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.buf = binary.AppendVarint(t.buf, int64(id))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.buf = binary.AppendVarint(t.buf, int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.buf = binary.AppendVarint(t.buf, v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.listBinary(s)
+}
+
+func (t *thriftWriter) listBegin(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|elem)
+	t.buf = binary.AppendUvarint(t.buf, uint64(n))
+}
+
+func (t *thriftWriter) listI32(v int32) { t.buf = binary.AppendVarint(t.buf, int64(v)) }
+
+func (t *thriftWriter) listBinary(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+// structBegin opens a struct-typed field; elemBegin opens a struct that is
+// a list element (no field header).
+func (t *thriftWriter) structBegin(id int16) {
+	t.field(id, thriftStruct)
+	t.elemBegin()
+}
+
+func (t *thriftWriter) elemBegin() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) structEnd() { t.elemEnd() }
+
+func (t *thriftWriter) elemEnd() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *thriftWriter) stop() { t.buf = append(t.buf, 0) }
@@ -0,0 +1,94 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvWriter writes an RFC 4180 table with a header line. Nulls are empty
+// fields; times are RFC3339 UTC.
+type csvWriter struct {
+	w      *csv.Writer
+	kind   []Kind
+	record []string
+}
+
+func newCSVWriter(w io.Writer, cols []Column) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(cols)+1)}
+	cw.record[0] = "time"
+	for i, c := range cols {
+		cw.record[i+1] = c.Name
+		cw.kind = append(cw.kind, c.Kind)
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) WriteRow(r Row) error {
+	cw.record[0] = r.Time.UTC().Format(time.RFC3339)
+	for i, c := range r.Cells {
+		switch {
+		case !c.Valid:
+			cw.record[i+1] = ""
+		case cw.kind[i] == Int:
+			cw.record[i+1] = strconv.FormatInt(c.Int, 10)
+		default:
+			cw.record[i+1] = strconv.FormatFloat(c.Float, 'f', -1, 64)
+		}
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// ndjsonWriter writes one JSON object per line keyed by column name, with
+// nulls for missing values.
+type ndjsonWriter struct {
+	w    *bufio.Writer
+	keys [][]byte // pre-encoded `"name":` per column
+	kind []Kind
+	buf  []byte
+}
+
+func newNDJSONWriter(w io.Writer, cols []Column) *ndjsonWriter {
+	nw := &ndjsonWriter{w: bufio.NewWriter(w)}
+	for _, c := range cols {
+		k, _ := json.Marshal(c.Name)
+		nw.keys = append(nw.keys, append(k, ':'))
+		nw.kind = append(nw.kind, c.Kind)
+	}
+	return nw
+}
+
+func (nw *ndjsonWriter) WriteRow(r Row) error {
+	b := append(nw.buf[:0], `{"time":"`...)
+	b = r.Time.UTC().AppendFormat(b, time.RFC3339)
+	b = append(b, '"')
+	for i, c := range r.Cells {
+		b = append(b, ',')
+		b = append(b, nw.keys[i]...)
+		switch {
+		case !c.Valid:
+			b = append(b, "null"...)
+		case nw.kind[i] == Int:
+			b = strconv.AppendInt(b, c.Int, 10)
+		default:
+			b = strconv.AppendFloat(b, c.Float, 'f', -1, 64)
+		}
+	}
+	b = append(b, '}', '\n')
+	nw.buf = b
+	_, err := nw.w.Write(b)
+	return err
+}
+
+func (nw *ndjsonWriter) Close() error { return nw.w.Flush() }
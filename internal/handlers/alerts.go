@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"live-oil-prices-go/internal/middleware"
+	"live-oil-prices-go/internal/models"
+	"net/http"
+	"strings"
+)
+
+// AlertClient is the alert engine as the API sees it.
+type AlertClient interface {
+	CreateAlert(a models.Alert) (models.Alert, error)
+	ListAlerts() []models.Alert
+	GetAlert(id string) (models.Alert, bool)
+	DeleteAlert(id string) bool
+	Deliveries(alertID string) []models.AlertDelivery
+}
+
+// maxAlertBody bounds a POST /api/alerts body.
+const maxAlertBody = 64 << 10
+
+// SetAlerts attaches the alert engine and the bearer token every
+// /api/alerts route requires. Call before RegisterRoutes; without an
+// engine and a token the routes aren't registered.
+func (a *API) SetAlerts(alerts AlertClient, token string) {
+	a.alerts, a.alertsToken = alerts, token
+}
+
+func (a *API) registerAlertRoutes(mux *http.ServeMux) {
+	if a.alerts == nil || a.alertsToken == "" {
+		return
+	}
+	auth := a.requireAlertsToken
+	mux.HandleFunc("POST /api/alerts", middleware.JSON(auth(a.CreateAlert)))
+	mux.HandleFunc("GET /api/alerts", middleware.JSON(auth(a.ListAlerts)))
+	mux.HandleFunc("GET /api/alerts/deliveries", middleware.JSON(auth(a.ListDeliveries)))
+	mux.HandleFunc("GET /api/alerts/{id}", middleware.JSON(auth(a.GetAlert)))
+	mux.HandleFunc("DELETE /api/alerts/{id}", auth(a.DeleteAlert))
+	mux.HandleFunc("GET /api/alerts/{id}/deliveries", middleware.JSON(auth(a.ListDeliveries)))
+}
+
+// requireAlertsToken answers 401 unless the request carries
+// "Authorization: Bearer <token>".
+func (a *API) requireAlertsToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(a.alertsToken)) != 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="alerts"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "missing or invalid API token"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// CreateAlert registers an alert from a JSON body. Unknown fields are
+// rejected so a typo doesn't silently create a rule that never fires.
+func (a *API) CreateAlert(w http.ResponseWriter, r *http.Request) {
+	var in models.Alert
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAlertBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	created, err := a.alerts.CreateAlert(in)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, models.ErrInvalidAlert):
+			status = http.StatusBadRequest
+		case errors.Is(err, models.ErrAlertLimit):
+			status = http.StatusConflict
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Location", "/api/alerts/"+created.ID)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (a *API) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	out := a.alerts.ListAlerts()
+	if out == nil {
+		out = []models.Alert{}
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func (a *API) GetAlert(w http.ResponseWriter, r *http.Request) {
+	alert, ok := a.alerts.GetAlert(r.PathValue("id"))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "alert not found"})
+		return
+	}
+	json.NewEncoder(w).Encode(alert)
+}
+
+func (a *API) DeleteAlert(w http.ResponseWriter, r *http.Request) {
+	if !a.alerts.DeleteAlert(r.PathValue("id")) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "alert not found"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries returns the webhook delivery log, newest first — for one
+// alert under /api/alerts/{id}/deliveries, or every alert otherwise.
+func (a *API) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id != "" {
+		if _, ok := a.alerts.GetAlert(id); !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "alert not found"})
+			return
+		}
+	}
+	out := a.alerts.Deliveries(id)
+	if out == nil {
+		out = []models.AlertDelivery{}
+	}
+	json.NewEncoder(w).Encode(out)
+}
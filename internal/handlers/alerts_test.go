@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeAlertClient struct {
+	alerts map[string]models.Alert
+}
+
+func (f *fakeAlertClient) CreateAlert(a models.Alert) (models.Alert, error) {
+	if a.Type != "threshold" {
+		return models.Alert{}, fmt.Errorf("%w: unknown type", models.ErrInvalidAlert)
+	}
+	a.ID = "a1"
+	f.alerts[a.ID] = a
+	return a, nil
+}
+
+func (f *fakeAlertClient) ListAlerts() []models.Alert { return nil }
+
+func (f *fakeAlertClient) GetAlert(id string) (models.Alert, bool) {
+	a, ok := f.alerts[id]
+	return a, ok
+}
+
+func (f *fakeAlertClient) DeleteAlert(id string) bool {
+	_, ok := f.alerts[id]
+	delete(f.alerts, id)
+	return ok
+}
+
+func (f *fakeAlertClient) Deliveries(alertID string) []models.AlertDelivery { return nil }
+
+func TestAlertEndpoints(t *testing.T) {
+	api := NewAPI(&fakeMarketDataService{}, &fakeNewsFeedService{})
+	api.SetAlerts(&fakeAlertClient{alerts: map[string]models.Alert{}}, "t0ken")
+	mux := setupMux(api)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer t0ken")
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, req)
+		return res
+	}
+
+	for _, auth := range []string{"", "Bearer wrong", "t0ken"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/alerts", nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, req)
+		if res.Code != http.StatusUnauthorized || !strings.Contains(res.Body.String(), "error") {
+			t.Fatalf("Authorization %q: expected a JSON 401, got %d", auth, res.Code)
+		}
+	}
+
+	res := do(http.MethodPost, "/api/alerts", `{"type":"threshold","symbol":"WTI","level":80,"webhookUrl":"http://x"}`)
+	if res.Code != http.StatusCreated || res.Header().Get("Location") != "/api/alerts/a1" {
+		t.Fatalf("expected 201 with Location, got %d %q", res.Code, res.Header().Get("Location"))
+	}
+	if res = do(http.MethodPost, "/api/alerts", `{"type":"bogus"}`); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid alert, got %d", res.Code)
+	}
+	if res = do(http.MethodPost, "/api/alerts", `{"type":"threshold","levle":80}`); res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown field, got %d", res.Code)
+	}
+	if res = do(http.MethodGet, "/api/alerts", ""); strings.TrimSpace(res.Body.String()) != "[]" {
+		t.Fatalf("expected an empty JSON array, got %q", res.Body.String())
+	}
+	if res = do(http.MethodGet, "/api/alerts/a1/deliveries", ""); res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if res = do(http.MethodDelete, "/api/alerts/a1", ""); res.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", res.Code)
+	}
+	if res = do(http.MethodDelete, "/api/alerts/a1", ""); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", res.Code)
+	}
+	if res = do(http.MethodGet, "/api/alerts/a1", ""); res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
+// Without a token the alert API isn't served at all.
+func TestAlertEndpointsNeedAToken(t *testing.T) {
+	api := NewAPI(&fakeMarketDataService{}, &fakeNewsFeedService{})
+	api.SetAlerts(&fakeAlertClient{alerts: map[string]models.Alert{}}, "")
+	mux := setupMux(api)
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/api/alerts", strings.NewReader(`{}`)))
+	if res.Code == http.StatusCreated || res.Code == http.StatusBadRequest {
+		t.Fatalf("expected the route to be unregistered, got %d", res.Code)
+	}
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"live-oil-prices-go/internal/export"
+	"live-oil-prices-go/internal/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxIntradayExport bounds intraday ranges. The caches only reach back a
+// few weeks, so anything longer would be mostly synthetic fill.
+const maxIntradayExport = 60 * 24 * time.Hour
+
+// Export streams OHLCV history as a downloadable file.
+//
+// Path: /api/export/{symbol} — one symbol or a comma-separated list.
+//
+// Query params:
+//   - format: csv (default), ndjson or parquet.
+//   - interval: 1d (default) or an intraday interval /api/charts accepts.
+//   - from, to: YYYY-MM-DD, RFC3339 or unix seconds, inclusive. Default: the last year
+//     for daily bars, the last 7 days for intraday.
+//   - synthetic: "true" includes generated stand-in series. By default a
+//     symbol with no real bars in range is left out and named in the
+//     X-Export-Excluded header.
+//
+// Several symbols produce one wide table: a row per timestamp, columns
+// SYMBOL_open … SYMBOL_volume, empty where a symbol has no bar.
+func (a *API) Export(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	fail := func(status int, msg string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	}
+
+	symbols := splitUpperList(r.PathValue("symbol"))
+	for _, sym := range symbols {
+		if _, ok := a.instrument(sym); !ok {
+			fail(http.StatusNotFound, "unknown symbol "+sym)
+			return
+		}
+	}
+	if len(symbols) == 0 {
+		fail(http.StatusBadRequest, "no symbol")
+		return
+	}
+
+	format := strings.ToLower(q.Get("format"))
+	if format == "" {
+		format = "csv"
+	}
+	contentType, ok := export.Formats[format]
+	if !ok {
+		fail(http.StatusBadRequest, "format must be csv, ndjson or parquet")
+		return
+	}
+
+	interval := q.Get("interval")
+	if interval == "" {
+		interval = "1d"
+	}
+	to := time.Now()
+	if v := q.Get("to"); v != "" {
+		t, err := parseTimeParam(v, true)
+		if err != nil {
+			fail(http.StatusBadRequest, "to: "+err.Error())
+			return
+		}
+		to = t
+	}
+	from := to.AddDate(-1, 0, 0)
+	if interval != "1d" {
+		from = to.AddDate(0, 0, -7)
+	}
+	if v := q.Get("from"); v != "" {
+		t, err := parseTimeParam(v, false)
+		if err != nil {
+			fail(http.StatusBadRequest, "from: "+err.Error())
+			return
+		}
+		from = t
+	}
+	switch {
+	case !from.Before(to):
+		fail(http.StatusBadRequest, "from must be before to")
+		return
+	case interval != "1d" && to.Sub(from) > maxIntradayExport:
+		fail(http.StatusBadRequest, "intraday exports are limited to 60 days")
+		return
+	}
+	includeSynthetic := q.Get("synthetic") == "true"
+
+	var series [][]models.OHLCV
+	var kept, excluded []string
+	for _, sym := range symbols {
+		data := a.market.GetChartRange(sym, interval, from, to)
+		if data.Interval != interval {
+			fail(http.StatusBadRequest, fmt.Sprintf("unsupported interval %q", interval))
+			return
+		}
+		if data.Provenance != nil && data.Provenance.Synthetic {
+			if !includeSynthetic {
+				excluded = append(excluded, sym)
+				continue
+			}
+			syntheticServed.Inc("export")
+		}
+		series = append(series, data.Data)
+		kept = append(kept, sym)
+	}
+	if len(kept) == 0 {
+		fail(http.StatusNotFound, "no market data in range (synthetic=true includes generated series)")
+		return
+	}
+
+	// Multi-year exports can outlast the server's WriteTimeout.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s_%s_%s_%s.%s"`,
+		strings.Join(kept, "-"), interval, from.UTC().Format("20060102"), to.UTC().Format("20060102"), format))
+	if len(excluded) > 0 {
+		h.Set("X-Export-Excluded", strings.Join(excluded, ","))
+	}
+
+	ew, err := export.NewWriter(format, w, exportColumns(kept))
+	if err != nil {
+		// Nothing has been written yet unless the header write failed,
+		// in which case the client is gone.
+		return
+	}
+	align := int64(1)
+	if interval == "1d" {
+		align = 86400
+	}
+	for row := range mergeBars(series, align) {
+		if err := ew.WriteRow(row); err != nil {
+			return
+		}
+	}
+	ew.Close()
+}
+
+var barFields = []struct {
+	name string
+	kind export.Kind
+}{
+	{"open", export.Float},
+	{"high", export.Float},
+	{"low", export.Float},
+	{"close", export.Float},
+	{"volume", export.Int},
+}
+
+// exportColumns names the value columns: plain field names for a single
+// symbol, SYMBOL_field for a wide table.
+func exportColumns(symbols []string) []export.Column {
+	var cols []export.Column
+	for _, sym := range symbols {
+		for _, f := range barFields {
+			name := f.name
+			if len(symbols) > 1 {
+				name = sym + "_" + f.name
+			}
+			cols = append(cols, export.Column{Name: name, Kind: f.kind})
+		}
+	}
+	return cols
+}
+
+// mergeBars yields one row per distinct timestamp across the oldest-first
+// series, in time order, without materialising the joined table. Times are
+// floored to align seconds first: daily bars carry each exchange's session
+// timestamp, so a wide daily table joins on the UTC date.
+func mergeBars(series [][]models.OHLCV, align int64) func(yield func(export.Row) bool) {
+	key := func(b models.OHLCV) int64 { return b.Time - b.Time%align }
+	return func(yield func(export.Row) bool) {
+		pos := make([]int, len(series))
+		cells := make([]export.Cell, len(series)*len(barFields))
+		for {
+			next := int64(0)
+			found := false
+			for i, s := range series {
+				if pos[i] < len(s) && (!found || key(s[pos[i]]) < next) {
+					next, found = key(s[pos[i]]), true
+				}
+			}
+			if !found {
+				return
+			}
+			for i, s := range series {
+				c := cells[i*len(barFields) : (i+1)*len(barFields)]
+				if pos[i] >= len(s) || key(s[pos[i]]) != next {
+					for j := range c {
+						c[j] = export.Cell{}
+					}
+					continue
+				}
+				b := s[pos[i]]
+				pos[i]++
+				c[0] = export.Cell{Float: b.Open, Valid: true}
+				c[1] = export.Cell{Float: b.High, Valid: true}
+				c[2] = export.Cell{Float: b.Low, Valid: true}
+				c[3] = export.Cell{Float: b.Close, Valid: true}
+				c[4] = export.Cell{Int: b.Volume, Valid: true}
+			}
+			if !yield(export.Row{Time: time.Unix(next, 0), Cells: cells}) {
+				return
+			}
+		}
+	}
+}
@@ -1,22 +1,40 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"live-oil-prices-go/internal/config"
+	"live-oil-prices-go/internal/metrics"
 	"live-oil-prices-go/internal/middleware"
 	"live-oil-prices-go/internal/models"
+	"log"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type MarketDataClient interface {
 	GetPrices() []models.Price
 	GetChartData(symbol string, days int, interval string) models.ChartData
+	GetChartRange(symbol, interval string, from, to time.Time) models.ChartData
+	ChartWindow(symbol, interval string) (earliest time.Time, ok bool)
+	FetchFullHistory(ctx context.Context, symbol string) error
 	GetPredictions() []models.Prediction
+	GetForecastSet(symbol string, horizons []int) (models.ForecastSet, bool)
+	GetRisk(symbol string) (models.RiskReport, bool)
 	GetAnalysis() models.MarketAnalysis
 	GetHeroChart(symbol string, maxLiveBars int) models.HeroChart
 	GetConsensusForecasts() []models.ConsensusForecast
 	GetConsensusForecast(symbol string) (models.ConsensusForecast, bool)
+	GetSpreads() []models.Spread
+	GetForwardCurve(symbol string) (models.ForwardCurve, bool)
+	GetCurveHistory(symbol string, days int) (models.CurveHistory, bool)
+	GetSpreadChart(id string, days int) (models.SpreadChart, bool)
+	Subscribe(symbols []string, lastEventID uint64) (backlog []models.StreamEvent, events <-chan models.StreamEvent, cancel func())
 }
 
 type NewsClient interface {
@@ -27,46 +45,238 @@ type NewsClient interface {
 type API struct {
 	market MarketDataClient
 	news   NewsClient
+	alerts AlertClient       // optional, see SetAlerts
+	health HealthClient      // optional, see SetHealth
+	ledger TrackRecordClient // optional, see SetTrackRecord
+
+	// alertsToken is the bearer token the /api/alerts routes require.
+	alertsToken string
+
+	// siteURL is the public origin for canonical links and structured
+	// data, without a trailing slash.
+	siteURL string
+
+	// instruments is the symbol catalogue behind /api/symbols, the
+	// commodity pages and the headline price cards.
+	instruments []models.Instrument
+	bySymbol    map[string]models.Instrument
 }
 
+// defaultSiteURL is the production origin.
+const defaultSiteURL = "https://liveoilprices.com"
+
+// NewAPI serves the built-in instrument catalogue until SetInstruments
+// replaces it.
 func NewAPI(market MarketDataClient, news NewsClient) *API {
-	return &API{market: market, news: news}
+	a := &API{market: market, news: news, siteURL: defaultSiteURL}
+	a.SetInstruments(config.Default().Instruments)
+	return a
+}
+
+// SetInstruments replaces the symbol catalogue. Call before serving.
+func (a *API) SetInstruments(instruments []models.Instrument) {
+	a.instruments = instruments
+	a.bySymbol = make(map[string]models.Instrument, len(instruments))
+	for _, in := range instruments {
+		a.bySymbol[in.Symbol] = in
+	}
+}
+
+func (a *API) instrument(symbol string) (models.Instrument, bool) {
+	in, ok := a.bySymbol[symbol]
+	return in, ok
+}
+
+// SetSiteURL points canonical links and structured data at another host,
+// e.g. a staging origin.
+func (a *API) SetSiteURL(u string) {
+	a.siteURL = strings.TrimSuffix(u, "/")
 }
 
 func (a *API) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/prices", middleware.JSON(a.GetPrices))
+	mux.HandleFunc("GET /api/symbols", middleware.JSON(a.GetSymbols))
+	mux.HandleFunc("GET /api/symbols/{symbol}", middleware.JSON(a.GetSymbol))
 	mux.HandleFunc("GET /api/charts/{symbol}", middleware.JSON(a.GetChartData))
+	mux.HandleFunc("GET /api/indicators/{symbol}", middleware.JSON(a.GetIndicators))
+	mux.HandleFunc("GET /api/export/{symbol}", a.Export)
 	mux.HandleFunc("GET /api/hero/{symbol}", middleware.JSON(a.GetHeroChart))
+	mux.HandleFunc("GET /api/stream", a.Stream)
+	mux.HandleFunc("GET /api/ws", a.Socket)
 	mux.HandleFunc("GET /api/news", middleware.JSON(a.GetNews))
 	mux.HandleFunc("GET /api/news/{id}", middleware.JSON(a.GetNewsArticle))
 	mux.HandleFunc("GET /api/predictions", middleware.JSON(a.GetPredictions))
+	mux.HandleFunc("GET /api/predictions/{symbol}", middleware.JSON(a.GetSymbolForecast))
+	mux.HandleFunc("GET /api/risk/{symbol}", middleware.JSON(a.GetRisk))
 	mux.HandleFunc("GET /api/analysis", middleware.JSON(a.GetAnalysis))
 	mux.HandleFunc("GET /api/consensus", middleware.JSON(a.GetConsensusForecasts))
 	mux.HandleFunc("GET /api/consensus/{symbol}", middleware.JSON(a.GetConsensusForecast))
-	mux.HandleFunc("GET /api/health", middleware.JSON(a.HealthCheck))
+	mux.HandleFunc("GET /api/spreads", middleware.JSON(a.GetSpreads))
+	mux.HandleFunc("GET /api/spreads/{id}/chart", middleware.JSON(a.GetSpreadChart))
+	mux.HandleFunc("GET /api/curve/{symbol}", middleware.JSON(a.GetForwardCurve))
+	mux.HandleFunc("GET /api/curve/{symbol}/history", middleware.JSON(a.GetCurveHistory))
+	a.registerHealthRoutes(mux)
+	a.registerAlertRoutes(mux)
+	a.registerTrackRecordRoutes(mux)
 }
 
+// syntheticServed counts generated stand-ins (provenance.synthetic) handed
+// to clients in place of market data.
+var syntheticServed = metrics.NewCounterVec("oilprices_synthetic_served_total",
+	"Synthetic prices and charts served in place of market data, by endpoint.",
+	"endpoint")
+
 func (a *API) GetPrices(w http.ResponseWriter, r *http.Request) {
-	json.NewEncoder(w).Encode(a.market.GetPrices())
+	prices := a.market.GetPrices()
+	for _, p := range prices {
+		if p.Provenance != nil && p.Provenance.Synthetic {
+			syntheticServed.Inc("prices")
+		}
+	}
+	json.NewEncoder(w).Encode(prices)
 }
 
+// GetSymbols returns the instrument catalogue in /api/prices order.
+func (a *API) GetSymbols(w http.ResponseWriter, r *http.Request) {
+	out := a.instruments
+	if out == nil {
+		out = []models.Instrument{}
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+// GetSymbol returns one catalogue entry.
+func (a *API) GetSymbol(w http.ResponseWriter, r *http.Request) {
+	in, ok := a.instrument(strings.ToUpper(r.PathValue("symbol")))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "unknown symbol"})
+		return
+	}
+	json.NewEncoder(w).Encode(in)
+}
+
+// GetChartData serves OHLCV bars for one symbol.
+//
+// Query params:
+//   - days: trailing window, 1 up to as far back as history is stored
+//     (default 90). Mutually exclusive with from/to.
+//   - from, to: YYYY-MM-DD, RFC3339 or unix seconds, inclusive. to
+//     defaults to now and from to 90 days before it, or to the start of
+//     the stored history when that is later (60 days for intraday).
+//   - interval: 1m, 5m, 15m, 1h, 2h, 4h or 1d (default picked from the
+//     span).
+//   - full: "true" backfills the symbol's entire daily history from
+//     upstream first, extending how far back days/from may reach.
+//
+// Invalid input is a 400 with a JSON error rather than a silent default.
 func (a *API) GetChartData(w http.ResponseWriter, r *http.Request) {
-	symbol := r.PathValue("symbol")
-	if symbol == "" {
-		symbol = "WTI"
+	data, ok := a.chartFor(w, r, strings.ToUpper(r.PathValue("symbol")))
+	if !ok {
+		return
+	}
+	if data.Provenance != nil && data.Provenance.Synthetic {
+		syntheticServed.Inc("charts")
 	}
+	json.NewEncoder(w).Encode(data)
+}
 
-	days := 90
-	if d := r.URL.Query().Get("days"); d != "" {
-		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 365 {
-			days = parsed
+// chartFor loads the bars a chart request's days, from/to, interval and
+// full parameters ask for. On a bad request, including a symbol outside
+// the catalogue, it writes the JSON error and returns false.
+func (a *API) chartFor(w http.ResponseWriter, r *http.Request, symbol string) (models.ChartData, bool) {
+	q := r.URL.Query()
+	fail := func(status int, msg string) (models.ChartData, bool) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": msg})
+		return models.ChartData{}, false
+	}
+	if _, ok := a.instrument(symbol); !ok {
+		return fail(http.StatusNotFound, "unknown symbol")
+	}
+
+	if q.Get("full") == "true" {
+		// A multi-decade backfill can outlast the server's WriteTimeout.
+		rc := http.NewResponseController(w)
+		_ = rc.SetWriteDeadline(time.Time{})
+		if err := a.market.FetchFullHistory(r.Context(), symbol); err != nil {
+			// The error carries Yahoo's response body: log it, don't echo it.
+			log.Printf("charts: full history for %s: %v", symbol, err)
+			return fail(http.StatusBadGateway, "full history is unavailable right now; try again later")
 		}
 	}
 
-	interval := r.URL.Query().Get("interval")
+	interval := q.Get("interval")
+	earliest, ok := a.market.ChartWindow(symbol, interval)
+	if !ok {
+		return fail(http.StatusBadRequest, fmt.Sprintf("unsupported interval %q: use 1m, 5m, 15m, 1h, 2h, 4h or 1d", interval))
+	}
+	now := time.Now()
+	maxDays := int(math.Ceil(now.Sub(earliest).Hours() / 24))
 
-	data := a.market.GetChartData(symbol, days, interval)
-	json.NewEncoder(w).Encode(data)
+	var data models.ChartData
+	if !q.Has("from") && !q.Has("to") {
+		days := 90
+		if d := q.Get("days"); d != "" {
+			parsed, err := strconv.Atoi(d)
+			if err != nil || parsed < 1 || parsed > maxDays {
+				return fail(http.StatusBadRequest, fmt.Sprintf("days must be an integer from 1 to %d for %s", maxDays, symbol))
+			}
+			days = parsed
+		}
+		data = a.market.GetChartData(symbol, days, interval)
+	} else {
+		if q.Has("days") {
+			return fail(http.StatusBadRequest, "use either days or from/to, not both")
+		}
+		to := now
+		if v := q.Get("to"); v != "" {
+			t, err := parseTimeParam(v, true)
+			if err != nil {
+				return fail(http.StatusBadRequest, "to: "+err.Error())
+			}
+			to = t
+		}
+		from := to.AddDate(0, 0, -90)
+		if from.Before(earliest) {
+			from = earliest
+		}
+		if v := q.Get("from"); v != "" {
+			t, err := parseTimeParam(v, false)
+			if err != nil {
+				return fail(http.StatusBadRequest, "from: "+err.Error())
+			}
+			from = t
+		}
+		switch {
+		case !from.Before(to):
+			return fail(http.StatusBadRequest, "from must be before to")
+		case from.Before(earliest.Truncate(24 * time.Hour)):
+			return fail(http.StatusBadRequest, fmt.Sprintf("from is before the stored history for %s, which starts %s (full=true fetches more)",
+				symbol, earliest.UTC().Format("2006-01-02")))
+		}
+		data = a.market.GetChartRange(symbol, interval, from, to)
+	}
+	return data, true
+}
+
+// parseTimeParam accepts a date, an RFC3339 timestamp or unix seconds. A
+// bare date as the end of a range means the whole day.
+func parseTimeParam(v string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if sec, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(sec, 0), nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not YYYY-MM-DD, RFC3339 or unix seconds", v)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t, nil
 }
 
 func (a *API) GetNews(w http.ResponseWriter, r *http.Request) {
@@ -112,6 +322,70 @@ func (a *API) GetPredictions(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(a.market.GetPredictions())
 }
 
+// maxForecastHorizon is the longest horizon /api/predictions/{symbol}
+// accepts: a trading year, which still leaves the two-year history room
+// for a rolling backtest. maxForecastHorizons caps how many one request
+// may ask for, since each fits and backtests every model on the request
+// path.
+const (
+	maxForecastHorizon  = 250
+	maxForecastHorizons = 8
+)
+
+// GetSymbolForecast returns the multi-model ensemble forecast for one
+// symbol.
+//
+// Query params:
+//   - horizon: trading days ahead, one value or a comma-separated list
+//     of up to maxForecastHorizons, each 1 to maxForecastHorizon
+//     (default: 1, 5, 20 and 60).
+func (a *API) GetSymbolForecast(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	var horizons []int
+	for _, v := range splitList(r.URL.Query().Get("horizon")) {
+		h, err := strconv.Atoi(v)
+		if err != nil || h < 1 || h > maxForecastHorizon {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": fmt.Sprintf("horizon must be an integer from 1 to %d trading days", maxForecastHorizon),
+			})
+			return
+		}
+		if !slices.Contains(horizons, h) {
+			horizons = append(horizons, h)
+		}
+	}
+	if len(horizons) > maxForecastHorizons {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": fmt.Sprintf("at most %d horizons per request", maxForecastHorizons),
+		})
+		return
+	}
+	set, ok := a.market.GetForecastSet(symbol, horizons)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "unknown symbol"})
+		return
+	}
+	json.NewEncoder(w).Encode(set)
+}
+
+// GetRisk returns realised volatility, drawdowns, historical VaR/CVaR and
+// return correlations for symbol, from its daily history.
+func (a *API) GetRisk(w http.ResponseWriter, r *http.Request) {
+	report, ok := a.market.GetRisk(strings.ToUpper(r.PathValue("symbol")))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "unknown symbol"})
+		return
+	}
+	if report.Provenance != nil && report.Provenance.Synthetic {
+		syntheticServed.Inc("risk")
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
 func (a *API) GetAnalysis(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(a.market.GetAnalysis())
 }
@@ -140,6 +414,63 @@ func (a *API) GetConsensusForecast(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(c)
 }
 
-func (a *API) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+// GetSpreads returns every named spread (built-in and SPREADS_FILE) computed
+// from the current prices.
+func (a *API) GetSpreads(w http.ResponseWriter, r *http.Request) {
+	out := a.market.GetSpreads()
+	if out == nil {
+		out = []models.Spread{}
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+// GetSpreadChart returns a spread's daily history. `days` caps the number
+// of points (default 90, max 730).
+func (a *API) GetSpreadChart(w http.ResponseWriter, r *http.Request) {
+	days := 90
+	if d := r.URL.Query().Get("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 730 {
+			days = parsed
+		}
+	}
+	chart, ok := a.market.GetSpreadChart(r.PathValue("id"), days)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "unknown spread"})
+		return
+	}
+	json.NewEncoder(w).Encode(chart)
+}
+
+// GetForwardCurve returns the futures strip for a symbol with contango /
+// backwardation metrics. 404 until the first curve refresh lands, and for
+// symbols without listed futures.
+func (a *API) GetForwardCurve(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	c, ok := a.market.GetForwardCurve(symbol)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "forward curve not available"})
+		return
+	}
+	json.NewEncoder(w).Encode(c)
+}
+
+// GetCurveHistory returns one curve snapshot per trading day so the UI can
+// chart how the curve's shape moved. `days` defaults to 90, max 730.
+func (a *API) GetCurveHistory(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	days := 90
+	if d := r.URL.Query().Get("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 && parsed <= 730 {
+			days = parsed
+		}
+	}
+	h, ok := a.market.GetCurveHistory(symbol, days)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "forward curve not available"})
+		return
+	}
+	json.NewEncoder(w).Encode(h)
 }
@@ -1,18 +1,30 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/websocket"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 type fakeMarketDataService struct {
-	getPricesFunc     func() []models.Price
-	getChartDataFunc  func(symbol string, days int, interval string) models.ChartData
-	getPredictionsFunc func() []models.Prediction
-	getAnalysisFunc    func() models.MarketAnalysis
+	getPricesFunc        func() []models.Price
+	getChartDataFunc     func(symbol string, days int, interval string) models.ChartData
+	getChartRangeFunc    func(symbol, interval string, from, to time.Time) models.ChartData
+	fetchFullHistoryFunc func(symbol string) error
+	getPredictionsFunc   func() []models.Prediction
+	getForecastSetFunc   func(symbol string, horizons []int) (models.ForecastSet, bool)
+	getRiskFunc          func(symbol string) (models.RiskReport, bool)
+	getAnalysisFunc      func() models.MarketAnalysis
+	subscribeFunc        func(symbols []string, lastEventID uint64) ([]models.StreamEvent, <-chan models.StreamEvent, func())
 }
 
 func (f *fakeMarketDataService) GetPrices() []models.Price {
@@ -29,6 +41,44 @@ func (f *fakeMarketDataService) GetChartData(symbol string, days int, interval s
 	return f.getChartDataFunc(symbol, days, interval)
 }
 
+func (f *fakeMarketDataService) GetChartRange(symbol, interval string, from, to time.Time) models.ChartData {
+	if f.getChartRangeFunc == nil {
+		return models.ChartData{Symbol: symbol, Interval: interval}
+	}
+	return f.getChartRangeFunc(symbol, interval, from, to)
+}
+
+func (f *fakeMarketDataService) ChartWindow(symbol, interval string) (time.Time, bool) {
+	switch interval {
+	case "", "1d":
+		return time.Now().AddDate(-2, 0, 0), true
+	case "1m", "5m", "15m", "1h", "2h", "4h":
+		return time.Now().AddDate(0, 0, -60), true
+	}
+	return time.Time{}, false
+}
+
+func (f *fakeMarketDataService) FetchFullHistory(ctx context.Context, symbol string) error {
+	if f.fetchFullHistoryFunc == nil {
+		return nil
+	}
+	return f.fetchFullHistoryFunc(symbol)
+}
+
+func (f *fakeMarketDataService) GetForecastSet(symbol string, horizons []int) (models.ForecastSet, bool) {
+	if f.getForecastSetFunc == nil {
+		return models.ForecastSet{}, false
+	}
+	return f.getForecastSetFunc(symbol, horizons)
+}
+
+func (f *fakeMarketDataService) GetRisk(symbol string) (models.RiskReport, bool) {
+	if f.getRiskFunc == nil {
+		return models.RiskReport{}, false
+	}
+	return f.getRiskFunc(symbol)
+}
+
 func (f *fakeMarketDataService) GetPredictions() []models.Prediction {
 	if f.getPredictionsFunc == nil {
 		return nil
@@ -55,6 +105,34 @@ func (f *fakeMarketDataService) GetConsensusForecast(symbol string) (models.Cons
 	return models.ConsensusForecast{}, false
 }
 
+func (f *fakeMarketDataService) GetSpreads() []models.Spread {
+	return nil
+}
+
+func (f *fakeMarketDataService) GetSpreadChart(id string, days int) (models.SpreadChart, bool) {
+	if id != "brent-wti" {
+		return models.SpreadChart{}, false
+	}
+	return models.SpreadChart{ID: id, Data: []models.SpreadPoint{}}, true
+}
+
+func (f *fakeMarketDataService) GetForwardCurve(symbol string) (models.ForwardCurve, bool) {
+	return models.ForwardCurve{}, false
+}
+
+func (f *fakeMarketDataService) GetCurveHistory(symbol string, days int) (models.CurveHistory, bool) {
+	return models.CurveHistory{}, false
+}
+
+func (f *fakeMarketDataService) Subscribe(symbols []string, lastEventID uint64) ([]models.StreamEvent, <-chan models.StreamEvent, func()) {
+	if f.subscribeFunc == nil {
+		ch := make(chan models.StreamEvent)
+		close(ch)
+		return nil, ch, func() {}
+	}
+	return f.subscribeFunc(symbols, lastEventID)
+}
+
 type fakeNewsFeedService struct {
 	getNewsFunc     func() []models.NewsArticle
 	getNewsByIDFunc func(id string) *models.NewsArticle
@@ -183,8 +261,30 @@ func TestChartEndpointRespectsInputAndDefaults(t *testing.T) {
 	)
 	mux := setupMux(api)
 
-	req := httptest.NewRequest(http.MethodGet, "/api/charts/WTI?days=9999", nil)
+	for _, bad := range []string{"days=9999", "days=0", "days=ten", "interval=3d", "from=2026-02-01&days=5", "from=soon", "from=2026-03-01&to=2026-02-01"} {
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/charts/WTI?"+bad, nil))
+		var body map[string]string
+		if res.Code != http.StatusBadRequest || json.Unmarshal(res.Body.Bytes(), &body) != nil || body["error"] == "" {
+			t.Fatalf("%s: expected 400 with a JSON error, got %d %s", bad, res.Code, res.Body.String())
+		}
+	}
+
 	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/charts/NOPE", nil))
+	if res.Code != http.StatusNotFound || !strings.Contains(res.Body.String(), "unknown symbol") {
+		t.Fatalf("expected a JSON 404 for an unknown symbol, got %d %s", res.Code, res.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/charts/WTI?days=600", nil)
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+	if res.Code != http.StatusOK || gotDays != 600 {
+		t.Fatalf("expected days within stored history to pass, got %d days=%d", res.Code, gotDays)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/charts/WTI", nil)
+	res = httptest.NewRecorder()
 	mux.ServeHTTP(res, req)
 	if res.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d", res.Code)
@@ -193,7 +293,7 @@ func TestChartEndpointRespectsInputAndDefaults(t *testing.T) {
 		t.Fatalf("expected symbol WTI, got %q", gotSymbol)
 	}
 	if gotDays != 90 {
-		t.Fatalf("expected invalid days to default to 90, got %d", gotDays)
+		t.Fatalf("expected days to default to 90, got %d", gotDays)
 	}
 	if gotInterval != "" {
 		t.Fatalf("expected interval to pass through when omitted: %q", gotInterval)
@@ -225,6 +325,61 @@ func TestChartEndpointAcceptsExplicitInterval(t *testing.T) {
 	}
 }
 
+func TestChartEndpointDateRange(t *testing.T) {
+	var gotFrom, gotTo time.Time
+	var fetched []string
+	market := &fakeMarketDataService{
+		getChartRangeFunc: func(symbol, interval string, from, to time.Time) models.ChartData {
+			gotFrom, gotTo = from, to
+			return models.ChartData{Symbol: symbol, Interval: "1d"}
+		},
+		fetchFullHistoryFunc: func(symbol string) error {
+			fetched = append(fetched, symbol)
+			return nil
+		},
+	}
+	mux := setupMux(NewAPI(market, &fakeNewsFeedService{}))
+
+	from := time.Now().AddDate(-1, 0, 0).Truncate(time.Second)
+	url := fmt.Sprintf("/api/charts/WTI?from=%d&to=%s", from.Unix(), time.Now().AddDate(0, -1, 0).Format("2006-01-02"))
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, url, nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", res.Code, res.Body.String())
+	}
+	if !gotFrom.Equal(from) || gotTo.UTC().Hour() != 23 {
+		t.Fatalf("range = %s .. %s", gotFrom, gotTo)
+	}
+
+	// Intraday history is shorter than the 90-day default: from starts
+	// where it does.
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/charts/WTI?interval=5m&to="+time.Now().Format("2006-01-02"), nil))
+	if res.Code != http.StatusOK || gotFrom.Before(time.Now().AddDate(0, 0, -61)) {
+		t.Fatalf("expected intraday to without from to start at the stored history, got %d %s (from %s)", res.Code, res.Body.String(), gotFrom)
+	}
+
+	// Older than the stored history: rejected, with a hint at full=true.
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/charts/WTI?from=2001-01-01", nil))
+	if res.Code != http.StatusBadRequest || !strings.Contains(res.Body.String(), "full=true") {
+		t.Fatalf("expected 400 pointing at full=true, got %d %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/charts/BRENT?full=true&days=30", nil))
+	if res.Code != http.StatusOK || len(fetched) != 1 || fetched[0] != "BRENT" {
+		t.Fatalf("expected a full-history fetch for BRENT, got %d %v", res.Code, fetched)
+	}
+
+	market.fetchFullHistoryFunc = func(string) error { return errors.New("upstream down") }
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/charts/BRENT?full=true", nil))
+	if res.Code != http.StatusBadGateway || strings.Contains(res.Body.String(), "upstream down") {
+		t.Fatalf("expected a 502 without the upstream error when the backfill fails, got %d %s", res.Code, res.Body.String())
+	}
+}
+
 func TestNewsArticleNotFound(t *testing.T) {
 	api := NewAPI(
 		&fakeMarketDataService{},
@@ -243,6 +398,31 @@ func TestNewsArticleNotFound(t *testing.T) {
 	}
 }
 
+func TestSpreadEndpoints(t *testing.T) {
+	mux := setupMux(NewAPI(&fakeMarketDataService{}, &fakeNewsFeedService{}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/spreads", nil)
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+	if res.Code != http.StatusOK || strings.TrimSpace(res.Body.String()) != "[]" {
+		t.Fatalf("expected an empty JSON array, got %d %q", res.Code, res.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/spreads/brent-wti/chart", nil)
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/spreads/unknown/chart", nil)
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
 func TestNewsArticleFound(t *testing.T) {
 	api := NewAPI(
 		&fakeMarketDataService{},
@@ -250,8 +430,8 @@ func TestNewsArticleFound(t *testing.T) {
 			getNewsByIDFunc: func(id string) *models.NewsArticle {
 				if id == "a" {
 					return &models.NewsArticle{
-						ID:    "a",
-						Title: "Title A",
+						ID:     "a",
+						Title:  "Title A",
 						Source: "Reuters",
 					}
 				}
@@ -276,3 +456,502 @@ func TestNewsArticleFound(t *testing.T) {
 		t.Fatalf("unexpected article: %v", article)
 	}
 }
+
+func TestStreamWritesBacklogAndEvents(t *testing.T) {
+	var gotSymbols []string
+	var gotLastID uint64
+	events := make(chan models.StreamEvent, 1)
+	api := NewAPI(
+		&fakeMarketDataService{
+			subscribeFunc: func(symbols []string, lastEventID uint64) ([]models.StreamEvent, <-chan models.StreamEvent, func()) {
+				gotSymbols = symbols
+				gotLastID = lastEventID
+				backlog := []models.StreamEvent{{ID: 8, Type: "price", Symbol: "WTI", Data: models.Price{Symbol: "WTI", Price: 80}}}
+				events <- models.StreamEvent{ID: 9, Type: "bar", Symbol: "WTI", Data: models.PythCandle{Time: 1, Close: 80.1}}
+				close(events)
+				return backlog, events, func() {}
+			},
+		},
+		&fakeNewsFeedService{},
+	)
+	mux := setupMux(api)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stream?symbols=wti", nil)
+	req.Header.Set("Last-Event-ID", "7")
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+	if got := res.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", got)
+	}
+	if len(gotSymbols) != 1 || gotSymbols[0] != "WTI" {
+		t.Fatalf("expected symbols to be upper-cased, got %v", gotSymbols)
+	}
+	if gotLastID != 7 {
+		t.Fatalf("expected Last-Event-ID 7, got %d", gotLastID)
+	}
+	body := res.Body.String()
+	for _, want := range []string{"retry: 3000", "id: 8\nevent: price\n", "id: 9\nevent: bar\n"} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in stream body:\n%s", want, body)
+		}
+	}
+}
+
+func TestStreamFiltersByType(t *testing.T) {
+	events := make(chan models.StreamEvent, 2)
+	events <- models.StreamEvent{ID: 1, Type: "price", Symbol: "WTI"}
+	events <- models.StreamEvent{ID: 2, Type: "bar", Symbol: "WTI"}
+	close(events)
+	api := NewAPI(
+		&fakeMarketDataService{
+			subscribeFunc: func([]string, uint64) ([]models.StreamEvent, <-chan models.StreamEvent, func()) {
+				return nil, events, func() {}
+			},
+		},
+		&fakeNewsFeedService{},
+	)
+	mux := setupMux(api)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/stream?types=bar", nil)
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, req)
+
+	body := res.Body.String()
+	if strings.Contains(body, "event: price") {
+		t.Fatalf("price events should be filtered out:\n%s", body)
+	}
+	if !strings.Contains(body, "event: bar") {
+		t.Fatalf("expected bar event:\n%s", body)
+	}
+}
+
+func TestSocketProtocol(t *testing.T) {
+	events := make(chan models.StreamEvent, 4)
+	var gotLastID uint64
+	api := NewAPI(
+		&fakeMarketDataService{
+			getPricesFunc: func() []models.Price {
+				return []models.Price{{Symbol: "WTI", Price: 80}, {Symbol: "BRENT", Price: 84}}
+			},
+			subscribeFunc: func(symbols []string, lastEventID uint64) ([]models.StreamEvent, <-chan models.StreamEvent, func()) {
+				gotLastID = lastEventID
+				return nil, events, func() {}
+			},
+		},
+		&fakeNewsFeedService{},
+	)
+	srv := httptest.NewServer(setupMux(api))
+	defer srv.Close()
+
+	conn, err := websocket.Dial("ws" + strings.TrimPrefix(srv.URL, "http") + "/api/ws?channels=news&lastEventId=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetIdleTimeout(5 * time.Second)
+
+	var seq uint64
+	read := func(wantType string) models.SocketMessage {
+		t.Helper()
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("waiting for %s: %v", wantType, err)
+		}
+		var msg models.SocketMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatal(err)
+		}
+		seq++
+		if msg.Seq != seq || msg.Type != wantType {
+			t.Fatalf("expected %s #%d, got %s", wantType, seq, data)
+		}
+		return msg
+	}
+	request := func(req string) {
+		t.Helper()
+		if err := conn.WriteMessage(websocket.OpText, []byte(req)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if welcome := read("welcome"); len(welcome.Channels) != len(socketChannels) || welcome.Subscriptions["news"][0] != "*" || gotLastID != 3 {
+		t.Fatalf("welcome %+v, resumed from %d", welcome, gotLastID)
+	}
+
+	request(`{"op":"subscribe","ref":"a","channels":["ticks"],"symbols":["wti"]}`)
+	if ack := read("ack"); ack.Ref != "a" || fmt.Sprint(ack.Subscriptions["ticks"]) != "[WTI]" {
+		t.Fatalf("subscribe ack %+v", ack)
+	}
+
+	events <- models.StreamEvent{ID: 4, Type: "tick", Symbol: "BRENT", Data: models.Tick{Symbol: "BRENT"}}
+	events <- models.StreamEvent{ID: 5, Type: "candle", Symbol: "WTI"}
+	events <- models.StreamEvent{ID: 6, Type: "tick", Symbol: "WTI", Data: models.Tick{Symbol: "WTI", Price: 80.1}}
+	events <- models.StreamEvent{ID: 7, Type: "news", Data: models.NewsArticle{ID: "n1"}}
+	if evt := read("event"); evt.ID != 6 || evt.Channel != "ticks" || evt.Symbol != "WTI" {
+		t.Fatalf("tick %+v", evt)
+	}
+	if evt := read("event"); evt.ID != 7 || evt.Channel != "news" {
+		t.Fatalf("news %+v", evt)
+	}
+
+	request(`{"op":"snapshot","ref":"b","channels":["prices"],"symbols":["BRENT"]}`)
+	if snap := read("snapshot"); snap.Ref != "b" || !strings.Contains(fmt.Sprint(snap.Data), "BRENT") || strings.Contains(fmt.Sprint(snap.Data), "WTI") {
+		t.Fatalf("snapshot %+v", snap)
+	}
+	read("ack")
+
+	request(`{"op":"snapshot","ref":"d","channels":["hero"],"symbols":["NOPE"]}`)
+	if msg := read("error"); msg.Ref != "d" || !strings.Contains(msg.Error, "unknown symbol") {
+		t.Fatalf("unknown-symbol snapshot %+v", msg)
+	}
+	many := strings.Repeat(`"WTI",`, socketMaxSymbols) + `"BRENT"`
+	request(`{"op":"snapshot","channels":["hero"],"symbols":[` + many + `]}`)
+	read("error")
+	request(`{"op":"subscribe","channels":["ticks"],"symbols":["NOPE"]}`)
+	read("error")
+
+	request(`{"op":"unsubscribe","channels":["ticks"],"symbols":["BRENT","WTI"]}`)
+	if ack := read("ack"); ack.Subscriptions["ticks"] != nil {
+		t.Fatalf("unsubscribe ack %+v", ack)
+	}
+	request(`{"op":"subscribe","channels":["weather"]}`)
+	read("error")
+	request(`not json`)
+	read("error")
+	request(`{"op":"ping","ref":"c"}`)
+	read("pong")
+
+	// The hub dropping the subscriber means it fell behind.
+	close(events)
+	if msg := read("error"); msg.LastID != 7 {
+		t.Fatalf("slow-consumer error %+v", msg)
+	}
+	var ce *websocket.CloseError
+	if _, _, err := conn.ReadMessage(); !errors.As(err, &ce) || ce.Code != websocket.CloseTryAgainLater {
+		t.Fatalf("expected close 1013, got %v", err)
+	}
+}
+
+func TestSocketRejectsBadQuery(t *testing.T) {
+	mux := setupMux(NewAPI(&fakeMarketDataService{}, &fakeNewsFeedService{}))
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/ws?channels=weather", nil))
+	if res.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", res.Code)
+	}
+}
+
+type fakeHealth struct{ report models.HealthReport }
+
+func (f fakeHealth) Report() models.HealthReport { return f.report }
+
+func TestReadinessFollowsCriticalFeeds(t *testing.T) {
+	api := NewAPI(&fakeMarketDataService{}, &fakeNewsFeedService{})
+	api.SetHealth(fakeHealth{models.HealthReport{
+		Status: "unavailable",
+		Ready:  false,
+		Feeds:  []models.FeedHealth{{Name: "pyth", Status: "down", Critical: true, ConsecutiveFailures: 12}},
+	}})
+	mux := setupMux(api)
+
+	for target, want := range map[string]int{
+		"/api/health/live":  http.StatusOK,
+		"/api/health/ready": http.StatusServiceUnavailable,
+		"/api/health/feeds": http.StatusServiceUnavailable,
+	} {
+		res := httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, target, nil))
+		if res.Code != want {
+			t.Fatalf("%s: expected %d, got %d", target, want, res.Code)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/health/feeds", nil))
+	var rep models.HealthReport
+	if err := json.Unmarshal(res.Body.Bytes(), &rep); err != nil {
+		t.Fatalf("invalid json response: %v", err)
+	}
+	if len(rep.Feeds) != 1 || rep.Feeds[0].ConsecutiveFailures != 12 {
+		t.Fatalf("unexpected feeds payload: %s", res.Body.String())
+	}
+}
+
+func TestSymbolsCatalogue(t *testing.T) {
+	api := NewAPI(&fakeMarketDataService{}, &fakeNewsFeedService{})
+	api.SetInstruments([]models.Instrument{
+		{Symbol: "WTI", Name: "WTI Crude Oil", Unit: "USD/barrel", Yahoo: "CL=F", Card: true},
+		{Symbol: "GOLD", Name: "Gold", Unit: "USD/tonne"},
+	})
+	mux := setupMux(api)
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/symbols", nil))
+	var list []models.Instrument
+	if err := json.Unmarshal(res.Body.Bytes(), &list); err != nil {
+		t.Fatalf("invalid json response: %v", err)
+	}
+	if len(list) != 2 || list[0].Yahoo != "CL=F" || list[1].Symbol != "GOLD" {
+		t.Fatalf("unexpected catalogue: %s", res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/symbols/gold", nil))
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"name":"Gold"`) {
+		t.Fatalf("expected gold entry, got %d %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/symbols/XAU", nil))
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
+func TestCommodityPageRendersCatalogueCopy(t *testing.T) {
+	if err := InitCommodityTemplate("../../web/templates/commodity.html"); err != nil {
+		t.Fatal(err)
+	}
+	api := NewAPI(&fakeMarketDataService{}, &fakeNewsFeedService{})
+	api.SetSiteURL("https://staging.example.com/")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /commodity/{symbol}", api.ServeCommodityPage)
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/commodity/brent", nil))
+	body := res.Body.String()
+	if res.Code != http.StatusOK || !strings.Contains(body, "Intercontinental Exchange") {
+		t.Fatalf("expected Brent page copy, got %d", res.Code)
+	}
+	if !strings.Contains(body, `href="https://staging.example.com/commodity/BRENT"`) || strings.Contains(body, "liveoilprices.com/") {
+		t.Fatalf("expected canonical links on the configured site URL")
+	}
+}
+
+func TestExportJoinsSymbolsAndSkipsSynthetic(t *testing.T) {
+	day := int64(86400)
+	t0 := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC).Unix()
+	var gotFrom, gotTo time.Time
+	market := &fakeMarketDataService{
+		getChartRangeFunc: func(symbol, interval string, from, to time.Time) models.ChartData {
+			gotFrom, gotTo = from, to
+			out := models.ChartData{Symbol: symbol, Interval: interval, Provenance: &models.Provenance{}}
+			switch symbol {
+			case "WTI": // NY session stamps, 04:00 UTC
+				out.Data = []models.OHLCV{
+					{Time: t0 + 4*3600, Open: 70, High: 71, Low: 69, Close: 70.5, Volume: 10},
+					{Time: t0 + day + 4*3600, Open: 70.5, High: 72, Low: 70, Close: 71.5, Volume: 12},
+				}
+			case "BRENT":
+				out.Data = []models.OHLCV{
+					{Time: t0 + day, Open: 74, High: 75, Low: 73, Close: 74.5, Volume: 7},
+					{Time: t0 + 2*day, Open: 74.5, High: 76, Low: 74, Close: 75.5, Volume: 8},
+				}
+			default:
+				out.Provenance.Synthetic = true
+				out.Data = []models.OHLCV{{Time: t0, Close: 1}}
+			}
+			return out
+		},
+	}
+	mux := setupMux(NewAPI(market, &fakeNewsFeedService{}))
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/export/wti,brent,opec?from=2026-03-01&to=2026-03-05", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %s", res.Code, res.Body.String())
+	}
+	if got := res.Header().Get("X-Export-Excluded"); got != "OPEC" {
+		t.Fatalf("X-Export-Excluded = %q", got)
+	}
+	if !strings.HasPrefix(res.Header().Get("Content-Disposition"), `attachment; filename="WTI-BRENT_1d_20260301_20260305.csv"`) {
+		t.Fatalf("Content-Disposition = %q", res.Header().Get("Content-Disposition"))
+	}
+	if gotFrom != time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC) || gotTo != time.Date(2026, 3, 5, 23, 59, 59, 0, time.UTC) {
+		t.Fatalf("range = %s .. %s", gotFrom, gotTo)
+	}
+	want := "time,WTI_open,WTI_high,WTI_low,WTI_close,WTI_volume,BRENT_open,BRENT_high,BRENT_low,BRENT_close,BRENT_volume\n" +
+		"2026-03-02T00:00:00Z,70,71,69,70.5,10,,,,,\n" +
+		"2026-03-03T00:00:00Z,70.5,72,70,71.5,12,74,75,73,74.5,7\n" +
+		"2026-03-04T00:00:00Z,,,,,,74.5,76,74,75.5,8\n"
+	if res.Body.String() != want {
+		t.Fatalf("csv:\n%s\nwant:\n%s", res.Body.String(), want)
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/export/OPEC", nil))
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("synthetic-only export: expected 404, got %d", res.Code)
+	}
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/export/OPEC?synthetic=true&format=ndjson", nil))
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"close":1`) {
+		t.Fatalf("synthetic=true: got %d %s", res.Code, res.Body.String())
+	}
+
+	for _, bad := range []string{"/api/export/WTI?format=xlsx", "/api/export/WTI?from=yesterday", "/api/export/WTI?interval=5m&from=2026-01-01&to=2026-06-01"} {
+		res = httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, bad, nil))
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", bad, res.Code)
+		}
+	}
+}
+
+func TestSymbolForecastHorizons(t *testing.T) {
+	var gotSymbol string
+	var gotHorizons []int
+	market := &fakeMarketDataService{
+		getForecastSetFunc: func(symbol string, horizons []int) (models.ForecastSet, bool) {
+			gotSymbol, gotHorizons = symbol, horizons
+			return models.ForecastSet{Symbol: symbol, Horizons: []models.HorizonForecast{}}, symbol == "WTI"
+		},
+	}
+	mux := setupMux(NewAPI(market, &fakeNewsFeedService{}))
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/predictions/wti?horizon=5,20,5", nil))
+	if res.Code != http.StatusOK || gotSymbol != "WTI" || fmt.Sprint(gotHorizons) != "[5 20]" {
+		t.Fatalf("got %d symbol=%q horizons=%v", res.Code, gotSymbol, gotHorizons)
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/predictions/WTI", nil))
+	if res.Code != http.StatusOK || gotHorizons != nil {
+		t.Fatalf("expected default horizons, got %d %v", res.Code, gotHorizons)
+	}
+
+	for _, bad := range []string{"0", "251", "week", "1,2,3,4,5,6,7,8,9"} {
+		res = httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/predictions/WTI?horizon="+bad, nil))
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("horizon=%s: expected 400, got %d", bad, res.Code)
+		}
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/predictions/XAU", nil))
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
+func TestRiskRoute(t *testing.T) {
+	market := &fakeMarketDataService{
+		getRiskFunc: func(symbol string) (models.RiskReport, bool) {
+			return models.RiskReport{
+				Symbol: symbol,
+				Correlations: []models.CorrelationMatrix{{
+					Days:    20,
+					Symbols: []string{"WTI", "XAU"},
+					Matrix:  []models.Series{{1, math.NaN()}, {math.NaN(), 1}},
+				}},
+			}, symbol == "WTI"
+		},
+	}
+	mux := setupMux(NewAPI(market, &fakeNewsFeedService{}))
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/risk/wti", nil))
+	if res.Code != http.StatusOK || !strings.Contains(res.Body.String(), `"matrix":[[1,null],[null,1]]`) {
+		t.Fatalf("got %d %s", res.Code, res.Body.String())
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/risk/XAU", nil))
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
+type fakeLedger struct{ symbol string }
+
+func (f *fakeLedger) TrackRecord(symbol string) models.TrackRecord {
+	f.symbol = symbol
+	return models.TrackRecord{Rows: []models.TrackRecordRow{{Symbol: "WTI", Model: "ensemble", Horizon: 5}}}
+}
+
+func TestTrackRecordRoute(t *testing.T) {
+	market := &fakeMarketDataService{
+		getForecastSetFunc: func(symbol string, horizons []int) (models.ForecastSet, bool) {
+			return models.ForecastSet{}, false
+		},
+	}
+	api := NewAPI(market, &fakeNewsFeedService{})
+	ledger := &fakeLedger{}
+	api.SetTrackRecord(ledger)
+	mux := setupMux(api)
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/predictions/track-record?symbol=wti", nil))
+	var rec models.TrackRecord
+	if err := json.Unmarshal(res.Body.Bytes(), &rec); err != nil || res.Code != http.StatusOK || ledger.symbol != "WTI" || len(rec.Rows) != 1 {
+		t.Fatalf("got %d symbol=%q body=%s", res.Code, ledger.symbol, res.Body)
+	}
+
+	res = httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/predictions/track-record?symbol=XAU", nil))
+	if res.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", res.Code)
+	}
+}
+
+func TestIndicatorsAlignWithChartBars(t *testing.T) {
+	market := &fakeMarketDataService{
+		getChartDataFunc: func(symbol string, days int, interval string) models.ChartData {
+			if interval == "" {
+				interval = "1d"
+			}
+			return models.ChartData{Symbol: symbol, Interval: interval, Data: []models.OHLCV{
+				{Time: 1, High: 11, Low: 9, Close: 10, Volume: 5},
+				{Time: 2, High: 12, Low: 10, Close: 11, Volume: 5},
+				{Time: 3, High: 13, Low: 11, Close: 12, Volume: 5},
+			}}
+		},
+	}
+	mux := setupMux(NewAPI(market, &fakeNewsFeedService{}))
+
+	res := httptest.NewRecorder()
+	mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/indicators/wti?ind=sma:2,macd&days=30", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", res.Code, res.Body)
+	}
+	var raw struct {
+		Times      []int64 `json:"times"`
+		Indicators []struct {
+			ID      string `json:"id"`
+			Overlay bool   `json:"overlay"`
+			Lines   []struct {
+				Name   string     `json:"name"`
+				Values []*float64 `json:"values"`
+			} `json:"lines"`
+		} `json:"indicators"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.Times) != 3 || len(raw.Indicators) != 2 || raw.Indicators[1].ID != "macd:12:26:9" || len(raw.Indicators[1].Lines) != 3 {
+		t.Fatalf("unexpected payload: %s", res.Body)
+	}
+	sma := raw.Indicators[0].Lines[0].Values
+	if !raw.Indicators[0].Overlay || len(sma) != 3 || sma[0] != nil || *sma[2] != 11.5 {
+		t.Fatalf("sma:2 = %s", res.Body)
+	}
+
+	for _, target := range []string{
+		"/api/indicators/WTI",
+		"/api/indicators/WTI?ind=rsi:0",
+		"/api/indicators/WTI?ind=vwap&interval=1d",
+		"/api/indicators/WTI?ind=rsi&interval=3m",
+	} {
+		res = httptest.NewRecorder()
+		mux.ServeHTTP(res, httptest.NewRequest(http.MethodGet, target, nil))
+		if res.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", target, res.Code)
+		}
+	}
+}
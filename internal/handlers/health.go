@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"live-oil-prices-go/internal/middleware"
+	"live-oil-prices-go/internal/models"
+	"net/http"
+	"time"
+)
+
+// HealthClient reports per-feed status for the readiness endpoints.
+type HealthClient interface {
+	Report() models.HealthReport
+}
+
+// SetHealth attaches the feed health monitor. Without it readiness only
+// reflects that the process is serving.
+func (a *API) SetHealth(health HealthClient) {
+	a.health = health
+}
+
+func (a *API) registerHealthRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/health", middleware.JSON(a.HealthCheck))
+	mux.HandleFunc("GET /api/health/live", middleware.JSON(a.HealthCheck))
+	mux.HandleFunc("GET /api/health/ready", middleware.JSON(a.Ready))
+	mux.HandleFunc("GET /api/health/feeds", middleware.JSON(a.FeedHealth))
+}
+
+func (a *API) report() models.HealthReport {
+	if a.health == nil {
+		return models.HealthReport{
+			Status:    "ok",
+			Ready:     true,
+			Feeds:     []models.FeedHealth{},
+			CheckedAt: time.Now().UTC().Format(time.RFC3339),
+		}
+	}
+	return a.health.Report()
+}
+
+// HealthCheck is the liveness probe: 200 whenever the process can answer,
+// regardless of upstream state. Restarting won't fix a Yahoo outage.
+func (a *API) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Ready is the load balancer's readiness probe. It answers 503 while any
+// critical feed is down so traffic routes around a degraded instance.
+func (a *API) Ready(w http.ResponseWriter, r *http.Request) {
+	rep := a.report()
+	if !rep.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]any{"status": rep.Status, "ready": rep.Ready})
+}
+
+// FeedHealth reports every feed's last success, last error, consecutive
+// failures and cache age. Same status code as Ready.
+func (a *API) FeedHealth(w http.ResponseWriter, r *http.Request) {
+	rep := a.report()
+	if !rep.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(rep)
+}
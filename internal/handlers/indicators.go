@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"live-oil-prices-go/internal/indicators"
+	"live-oil-prices-go/internal/models"
+	"net/http"
+	"strings"
+)
+
+// maxIndicators bounds the ind list of one request.
+const maxIndicators = 10
+
+// GetIndicators computes technical indicators over the bars
+// /api/charts/{symbol} serves for the same days, from/to, interval and
+// full parameters, so each series lines up with the candles index by
+// index. ind lists them, e.g. ind=rsi:14,bb:20:2; left-out parameters take
+// their defaults. Series are null during their warm-up at the start of the
+// window — widen the range to fill it.
+func (a *API) GetIndicators(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.PathValue("symbol"))
+	fail := func(status int, msg string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": msg})
+	}
+
+	list := r.URL.Query().Get("ind")
+	if list == "" {
+		fail(http.StatusBadRequest, "ind is required, e.g. ind=rsi:14,bb:20:2 (have "+strings.Join(indicators.Names(), ", ")+")")
+		return
+	}
+	specs, err := indicators.ParseSpecs(list)
+	if err != nil {
+		fail(http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(specs) > maxIndicators {
+		fail(http.StatusBadRequest, fmt.Sprintf("at most %d indicators per request", maxIndicators))
+		return
+	}
+
+	data, ok := a.chartFor(w, r, symbol)
+	if !ok {
+		return
+	}
+	for _, s := range specs {
+		if s.Intraday() && data.Interval == "1d" {
+			fail(http.StatusBadRequest, s.Name+" needs an intraday interval")
+			return
+		}
+	}
+	if data.Provenance != nil && data.Provenance.Synthetic {
+		syntheticServed.Inc("indicators")
+	}
+
+	bars := indicators.NewBars(data.Data)
+	out := models.IndicatorSet{
+		Symbol:     symbol,
+		Interval:   data.Interval,
+		Times:      bars.Time,
+		Indicators: make([]models.Indicator, 0, len(specs)),
+		Provenance: data.Provenance,
+	}
+	for _, s := range specs {
+		ind := models.Indicator{ID: s.String(), Overlay: s.Overlay()}
+		for _, line := range indicators.Compute(bars, s) {
+			ind.Lines = append(ind.Lines, models.IndicatorLine{Name: line.Name, Values: line.Values})
+		}
+		out.Indicators = append(out.Indicators, ind)
+	}
+	json.NewEncoder(w).Encode(out)
+}
@@ -95,6 +95,16 @@ type forecastView struct {
 	SkillPct         float64 // signed; positive = beats naive
 	BacktestVerdict  string  // "Beats the naive baseline by 18%"
 	BacktestSentence string  // longer sentence for screen readers / SEO
+
+	// Calibrated ranges at the horizon, narrowest first.
+	Intervals []intervalView
+}
+
+type intervalView struct {
+	LevelPct    int // 80
+	Low, High   float64
+	CoveragePct float64 // backtest coverage, 78.5
+	Checked     bool    // false when history was too short to check
 }
 
 type signalChip struct {
@@ -119,10 +129,6 @@ type consensusMonthView struct {
 	Value    string  // "$72.45"
 }
 
-// Symbols rendered as the headline "price cards" in the hero/markets section.
-// Mirrors the CARD_SYMBOLS list in web/src/app.ts so SSR markup matches CSR.
-var cardSymbols = []string{"WTI", "BRENT", "NATGAS", "HEATING", "RBOB", "OPEC"}
-
 // Page templates, parsed once at startup. Each entry is a fully composed
 // (layout + partials + page) template, ready to be executed against PageData.
 var pageTemplates = map[string]*template.Template{}
@@ -195,14 +201,16 @@ func (a *API) populateMarketData(data *PageData) {
 	}
 	data.Prices = views
 
-	// Card subset, ordered the same way the JS does it.
-	cards := make([]priceView, 0, len(cardSymbols))
+	// Card subset: catalogue entries flagged "card", in catalogue order.
+	// web/src/app.ts keeps a CARD_SYMBOLS list that must match so SSR
+	// markup matches CSR.
 	byID := map[string]priceView{}
 	for _, v := range views {
 		byID[v.Symbol] = v
 	}
-	for _, sym := range cardSymbols {
-		if v, ok := byID[sym]; ok {
+	cards := make([]priceView, 0, len(views))
+	for _, in := range a.instruments {
+		if v, ok := byID[in.Symbol]; ok && in.Card {
 			cards = append(cards, v)
 		}
 	}
@@ -222,19 +230,19 @@ func (a *API) populateMarketData(data *PageData) {
 	consensus := a.market.GetConsensusForecasts()
 	cviews := make([]consensusView, 0, len(consensus))
 	for _, c := range consensus {
-		cviews = append(cviews, toConsensusView(c))
+		in, _ := a.instrument(c.Symbol)
+		cviews = append(cviews, toConsensusView(c, in))
 	}
 	data.Consensus = cviews
 }
 
-var consensusNames = map[string]string{
-	"WTI":    "WTI Crude Oil",
-	"BRENT":  "Brent Crude Oil",
-	"NATGAS": "Henry Hub Natural Gas",
-}
-
-func toConsensusView(c models.ConsensusForecast) consensusView {
-	name := consensusNames[c.Symbol]
+// toConsensusView labels the outlook with the instrument's EIA name,
+// falling back to its display name and then the bare symbol.
+func toConsensusView(c models.ConsensusForecast, in models.Instrument) consensusView {
+	name := in.Name
+	if in.EIA != nil && in.EIA.Name != "" {
+		name = in.EIA.Name
+	}
 	if name == "" {
 		name = c.Symbol
 	}
@@ -361,6 +369,17 @@ func toForecastView(p models.Prediction) forecastView {
 		)
 	}
 
+	intervals := make([]intervalView, 0, len(p.Intervals))
+	for _, iv := range p.Intervals {
+		intervals = append(intervals, intervalView{
+			LevelPct:    int(iv.Level*100 + 0.5),
+			Low:         iv.Low,
+			High:        iv.High,
+			CoveragePct: iv.Coverage * 100,
+			Checked:     iv.CoverageSamples > 0,
+		})
+	}
+
 	return forecastView{
 		Symbol:           p.Symbol,
 		Name:             p.Name,
@@ -387,6 +406,7 @@ func toForecastView(p models.Prediction) forecastView {
 		SkillPct:         skillPct,
 		BacktestVerdict:  verdict,
 		BacktestSentence: sentence,
+		Intervals:        intervals,
 	}
 }
 
@@ -482,7 +502,7 @@ func (a *API) ServeHome(w http.ResponseWriter, r *http.Request) {
 		Title:         "Live Oil Prices — Real-Time Crude Oil, WTI, Brent & Energy Market Data",
 		Description:   "Live oil prices updated every 15 seconds. Track WTI crude, Brent crude, natural gas, heating oil, RBOB gasoline, and OPEC basket prices with interactive charts and breaking energy market news.",
 		Keywords:      "oil prices, crude oil price, WTI price, Brent crude price, live oil prices, oil price today, natural gas price, heating oil, RBOB gasoline, OPEC, energy market, oil chart, oil news",
-		Canonical:     "https://liveoilprices.com/",
+		Canonical:     a.siteURL + "/",
 		OGTitle:       "Live Oil Prices — Real-Time Crude Oil & Energy Market Data",
 		OGDescription: "Track WTI, Brent, natural gas, and 10+ energy commodities with live prices, interactive charts, and breaking market news.",
 		SchemaType:    "WebSite",
@@ -491,18 +511,18 @@ func (a *API) ServeHome(w http.ResponseWriter, r *http.Request) {
 				"@context":    "https://schema.org",
 				"@type":       "Organization",
 				"name":        "Live Oil Prices",
-				"url":         "https://liveoilprices.com",
+				"url":         a.siteURL,
 				"description": "Real-time energy market data, interactive oil price charts, statistical price forecasts, and breaking energy news.",
 			},
 			map[string]any{
 				"@context":    "https://schema.org",
 				"@type":       "WebSite",
 				"name":        "Live Oil Prices",
-				"url":         "https://liveoilprices.com",
+				"url":         a.siteURL,
 				"description": "Live crude oil prices, energy market charts, statistical price forecasts and breaking oil and gas news.",
 				"potentialAction": map[string]any{
 					"@type":       "SearchAction",
-					"target":      "https://liveoilprices.com/commodity/{search_term_string}",
+					"target":      a.siteURL + "/commodity/{search_term_string}",
 					"query-input": "required name=search_term_string",
 				},
 			},
@@ -517,11 +537,11 @@ func (a *API) ServeCharts(w http.ResponseWriter, r *http.Request) {
 		Title:         "Live Oil Price Charts — WTI, Brent, Natural Gas & RBOB Candlestick Charts",
 		Description:   "Interactive candlestick oil price charts for WTI crude, Brent crude, natural gas, heating oil, RBOB gasoline and OPEC basket. Switch between 1-week, 1-month, 3-month, 6-month and 1-year timeframes with volume analysis.",
 		Keywords:      "oil price chart, crude oil chart, WTI chart, Brent crude chart, natural gas chart, heating oil chart, RBOB chart, candlestick chart, oil price history",
-		Canonical:     "https://liveoilprices.com/charts",
+		Canonical:     a.siteURL + "/charts",
 		OGTitle:       "Live Oil Price Charts — Interactive WTI, Brent & Energy Charts",
 		OGDescription: "Interactive candlestick charts for WTI, Brent, natural gas and more, with multi-timeframe lookbacks and volume analysis.",
 		StructuredData: []any{
-			breadcrumbJSONLD([][2]string{{"Home", "https://liveoilprices.com/"}, {"Oil Charts", "https://liveoilprices.com/charts"}}),
+			breadcrumbJSONLD([][2]string{{"Home", a.siteURL + "/"}, {"Oil Charts", a.siteURL + "/charts"}}),
 			faqJSONLD([][2]string{
 				{"How often are these oil price charts updated?",
 					"Spot prices refresh every 15 seconds. Daily candlestick charts use Yahoo Finance end-of-day data and refresh hourly. The streaming WTI hero chart on the homepage uses real-time exchange ticks aggregated into 1-minute candles."},
@@ -543,11 +563,11 @@ func (a *API) ServeForecast(w http.ResponseWriter, r *http.Request) {
 		Title:         "Oil Price Outlook & Technical Signals — WTI, Brent, Natural Gas",
 		Description:   "Multi-signal oil price outlook for WTI, Brent, natural gas and heating oil. Trend, RSI, MACD and 50/200-day moving-average regime stacked alongside a damped-Holt 7-day model forecast and an institutional EIA Short-Term Energy Outlook reference.",
 		Keywords:      "oil price outlook, WTI outlook, Brent outlook, oil technical analysis, RSI MACD oil, 50 day moving average oil, oil price signals, EIA STEO forecast, natural gas outlook",
-		Canonical:     "https://liveoilprices.com/forecast",
+		Canonical:     a.siteURL + "/forecast",
 		OGTitle:       "Oil Price Outlook & Technical Signals — WTI, Brent & Energy",
 		OGDescription: "Stacked technical signals (Trend, RSI, MACD, 50/200 DMA) plus a damped-Holt forecast and EIA STEO reference for every major oil benchmark.",
 		StructuredData: []any{
-			breadcrumbJSONLD([][2]string{{"Home", "https://liveoilprices.com/"}, {"Outlook", "https://liveoilprices.com/forecast"}}),
+			breadcrumbJSONLD([][2]string{{"Home", a.siteURL + "/"}, {"Outlook", a.siteURL + "/forecast"}}),
 			faqJSONLD([][2]string{
 				{"How is this different from a traditional price forecast?",
 					"Instead of leading with a single point prediction, each card stacks four independent technical signals — long-term trend, RSI momentum, MACD cross, and the 50/200-day moving-average regime — alongside a damped-Holt statistical forecast. You get to see whether the signals agree before you read the dollar number."},
@@ -571,11 +591,11 @@ func (a *API) ServeNews(w http.ResponseWriter, r *http.Request) {
 		Title:         "Energy Market News — Live Oil, Gas, OPEC & Refining Headlines",
 		Description:   "Breaking energy market news covering crude oil, natural gas, OPEC+ decisions, refining and global energy markets. Aggregated from Reuters, Bloomberg, the EIA and 50+ sources, updated continuously.",
 		Keywords:      "oil news, energy news, crude oil news, OPEC news, natural gas news, oil market news, energy market news today",
-		Canonical:     "https://liveoilprices.com/news",
+		Canonical:     a.siteURL + "/news",
 		OGTitle:       "Energy Market News — Oil, Gas & OPEC Headlines",
 		OGDescription: "Breaking oil, gas and energy news from Reuters, Bloomberg, the EIA and 50+ sources, updated continuously.",
 		StructuredData: []any{
-			breadcrumbJSONLD([][2]string{{"Home", "https://liveoilprices.com/"}, {"News", "https://liveoilprices.com/news"}}),
+			breadcrumbJSONLD([][2]string{{"Home", a.siteURL + "/"}, {"News", a.siteURL + "/news"}}),
 			faqJSONLD([][2]string{
 				{"Where do these articles come from?",
 					"Articles are aggregated from major financial wire services (Reuters, Bloomberg, AP), specialist energy publications, the U.S. Energy Information Administration (EIA), OPEC press releases and a curated list of global energy outlets."},
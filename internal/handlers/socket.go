@@ -0,0 +1,522 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"live-oil-prices-go/internal/metrics"
+	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/websocket"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// socketPingEvery is how often the server pings an idle socket; a
+	// client that answers nothing for socketIdleTimeout is disconnected.
+	socketPingEvery   = 20 * time.Second
+	socketIdleTimeout = 3 * socketPingEvery
+
+	// socketWriteTimeout bounds one message write. A client that stops
+	// reading fails the write rather than stalling its connection; one
+	// that reads too slowly is dropped by the stream hub (see
+	// services.StreamHub.Publish), never the other way round.
+	socketWriteTimeout = 10 * time.Second
+
+	// socketReadLimit bounds a client request, socketMaxSymbols the
+	// symbols in one subscription.
+	socketReadLimit  = 16 << 10
+	socketMaxSymbols = 64
+
+	// socketNewsSnapshot is how many articles a news snapshot carries.
+	socketNewsSnapshot = 20
+)
+
+// socketChannels are the /api/ws channels, each with the stream event
+// types it carries. "snapshot" events are the price snapshots the hub
+// sends when a resume can't be served from its backlog.
+var socketChannels = []struct {
+	name  string
+	types []string
+}{
+	{"prices", []string{"price", "snapshot"}},
+	{"ticks", []string{"tick"}},
+	{"candles", []string{"candle"}},
+	{"hero", []string{"bar"}},
+	{"news", []string{"news"}},
+}
+
+func socketChannelNames() []string {
+	out := make([]string, len(socketChannels))
+	for i, c := range socketChannels {
+		out[i] = c.name
+	}
+	return out
+}
+
+func socketChannelOf(eventType string) string {
+	for _, c := range socketChannels {
+		if slices.Contains(c.types, eventType) {
+			return c.name
+		}
+	}
+	return ""
+}
+
+var (
+	socketsOpen       atomic.Int64
+	socketDisconnects = metrics.NewCounterVec("oilprices_ws_disconnects_total",
+		"WebSocket connections closed, by reason: client (close frame), slow (dropped for falling behind) or error.",
+		"reason")
+)
+
+func init() {
+	metrics.NewGaugeFunc("oilprices_ws_connections", "Open WebSocket connections.", nil,
+		func(emit func(float64, ...string)) { emit(float64(socketsOpen.Load())) })
+}
+
+// socketRequest is a client-to-server message on /api/ws.
+type socketRequest struct {
+	Op       string   `json:"op"` // subscribe | unsubscribe | snapshot | resume | ping
+	Ref      string   `json:"ref"`
+	Channels []string `json:"channels"`
+	Symbols  []string `json:"symbols"`
+	Since    uint64   `json:"since"` // resume: last event id received
+}
+
+// Socket serves the bidirectional market feed over a WebSocket.
+//
+// The client subscribes and unsubscribes to channels — prices, ticks,
+// candles (live 1-minute bars), hero (5-minute hero bars) and news — per
+// symbol, requests snapshots and resumes after a reconnect by sending
+// JSON requests:
+//
+//	{"op":"subscribe","ref":"1","channels":["ticks","candles"],"symbols":["WTI"]}
+//	{"op":"unsubscribe","channels":["candles"]}
+//	{"op":"snapshot","channels":["hero"],"symbols":["WTI"]}
+//	{"op":"resume","since":1234}
+//	{"op":"ping"}
+//
+// Leaving symbols out means every symbol; news ignores symbols. Every
+// server message is a models.SocketMessage whose seq counts up from 1
+// without gaps, so a client can tell it missed something. Events carry
+// the stream id to resume from. channels, symbols and lastEventId query
+// parameters subscribe (and resume) before the first request.
+//
+// A client that reads too slowly to keep up is sent an error naming the
+// last event id it was sent and closed with 1013; it reconnects and
+// resumes from there.
+func (a *API) Socket(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var since uint64
+	if v := q.Get("lastEventId"); v != "" {
+		var err error
+		if since, err = strconv.ParseUint(v, 10, 64); err != nil {
+			writeSocketHTTPError(w, "lastEventId must be an event id")
+			return
+		}
+	}
+	sess := &socketSession{api: a, subs: map[string]map[string]bool{}}
+	if channels := splitList(q.Get("channels")); len(channels) > 0 {
+		if err := sess.subscribe(channels, splitUpperList(q.Get("symbols"))); err != nil {
+			writeSocketHTTPError(w, err.Error())
+			return
+		}
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(socketReadLimit)
+	conn.SetIdleTimeout(socketIdleTimeout)
+	conn.SetWriteTimeout(socketWriteTimeout)
+	sess.conn = conn
+	socketsOpen.Add(1)
+	defer socketsOpen.Add(-1)
+
+	backlog, events, cancel := a.market.Subscribe(nil, since)
+	sess.events = events
+	defer func() { sess.cancel() }()
+	sess.cancel = cancel
+
+	if err := sess.send(models.SocketMessage{Type: "welcome", Channels: socketChannelNames(), Subscriptions: sess.subscriptions()}); err != nil {
+		socketDisconnects.Inc("error")
+		return
+	}
+	for _, evt := range backlog {
+		if err := sess.deliver(evt); err != nil {
+			socketDisconnects.Inc("error")
+			return
+		}
+	}
+
+	requests := make(chan socketRequest)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			op, data, err := conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			var req socketRequest
+			if op != websocket.OpText {
+				req.Op = "binary"
+			} else if err := json.Unmarshal(data, &req); err != nil {
+				req.Op = "invalid"
+			}
+			select {
+			case requests <- req:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(socketPingEvery)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case err = <-readErr:
+			var ce *websocket.CloseError
+			if errors.As(err, &ce) {
+				socketDisconnects.Inc("client")
+			} else {
+				socketDisconnects.Inc("error")
+			}
+			return
+		case <-r.Context().Done():
+			// The server is shutting down (see BaseContext in main).
+			conn.WriteClose(websocket.CloseGoingAway, "server shutting down")
+			socketDisconnects.Inc("shutdown")
+			return
+		case req := <-requests:
+			err = sess.handle(req)
+		case evt, ok := <-sess.events:
+			if !ok {
+				// The hub dropped us: we fell a full buffer behind.
+				sess.send(models.SocketMessage{Type: "error", LastID: sess.lastID,
+					Error: "client too slow; reconnect and resume from lastId"})
+				conn.WriteClose(websocket.CloseTryAgainLater, "too slow")
+				socketDisconnects.Inc("slow")
+				return
+			}
+			err = sess.deliver(evt)
+		case <-ping.C:
+			err = conn.Ping()
+		}
+		if err != nil {
+			socketDisconnects.Inc("error")
+			return
+		}
+	}
+}
+
+func writeSocketHTTPError(w http.ResponseWriter, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// socketSession is one /api/ws connection. Only the handler goroutine
+// touches it.
+type socketSession struct {
+	api  *API
+	conn *websocket.Conn
+	seq  uint64
+
+	// subs maps channel → symbols; a nil set means every symbol.
+	subs map[string]map[string]bool
+
+	events <-chan models.StreamEvent
+	cancel func()
+	lastID uint64 // newest stream event seen, delivered or filtered
+}
+
+// send stamps msg with the next sequence number and writes it. A message
+// that can't be encoded is logged and skipped without using a number.
+func (s *socketSession) send(msg models.SocketMessage) error {
+	msg.Seq = s.seq + 1
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("ws: encode %s message: %v", msg.Type, err)
+		return nil
+	}
+	s.seq++
+	return s.conn.WriteMessage(websocket.OpText, data)
+}
+
+func (s *socketSession) sendError(ref, msg string) error {
+	return s.send(models.SocketMessage{Type: "error", Ref: ref, Error: msg})
+}
+
+// deliver forwards a stream event if a subscription wants it.
+func (s *socketSession) deliver(evt models.StreamEvent) error {
+	if evt.ID > s.lastID {
+		s.lastID = evt.ID
+	}
+	channel := socketChannelOf(evt.Type)
+	if !s.wants(channel, evt.Symbol) {
+		return nil
+	}
+	typ := "event"
+	if evt.Type == "snapshot" {
+		typ = "snapshot"
+	}
+	return s.send(models.SocketMessage{Type: typ, Channel: channel, ID: evt.ID, Symbol: evt.Symbol, Data: evt.Data})
+}
+
+func (s *socketSession) wants(channel, symbol string) bool {
+	set, ok := s.subs[channel]
+	return ok && (set == nil || symbol == "" || set[symbol])
+}
+
+func (s *socketSession) handle(req socketRequest) error {
+	symbols := make([]string, len(req.Symbols))
+	for i, sym := range req.Symbols {
+		symbols[i] = strings.ToUpper(strings.TrimSpace(sym))
+	}
+	switch req.Op {
+	case "subscribe":
+		if len(req.Channels) == 0 {
+			return s.sendError(req.Ref, "subscribe needs channels")
+		}
+		if err := s.subscribe(req.Channels, symbols); err != nil {
+			return s.sendError(req.Ref, err.Error())
+		}
+	case "unsubscribe":
+		if err := s.unsubscribe(req.Channels, symbols); err != nil {
+			return s.sendError(req.Ref, err.Error())
+		}
+	case "snapshot":
+		return s.snapshot(req.Ref, req.Channels, symbols)
+	case "resume":
+		s.cancel()
+		backlog, events, cancel := s.api.market.Subscribe(nil, req.Since)
+		s.events, s.cancel = events, cancel
+		// The hub answers a resume it can't serve with price snapshots.
+		resumed := true
+		for _, evt := range backlog {
+			if evt.Type == "snapshot" {
+				resumed = false
+			}
+			if err := s.deliver(evt); err != nil {
+				return err
+			}
+		}
+		return s.send(models.SocketMessage{Type: "ack", Ref: req.Ref, Op: req.Op, Resumed: &resumed, LastID: s.lastID})
+	case "ping":
+		return s.send(models.SocketMessage{Type: "pong", Ref: req.Ref})
+	case "binary":
+		return s.sendError(req.Ref, "requests are JSON text messages")
+	case "invalid":
+		return s.sendError(req.Ref, "request is not valid JSON")
+	default:
+		return s.sendError(req.Ref, fmt.Sprintf("unknown op %q (have subscribe, unsubscribe, snapshot, resume, ping)", req.Op))
+	}
+	return s.send(models.SocketMessage{Type: "ack", Ref: req.Ref, Op: req.Op, Subscriptions: s.subscriptions()})
+}
+
+func checkSocketChannels(channels []string) error {
+	names := socketChannelNames()
+	for _, c := range channels {
+		if !slices.Contains(names, c) {
+			return fmt.Errorf("unknown channel %q (have %s)", c, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// checkSymbols refuses symbols outside the catalogue, and more than
+// socketMaxSymbols of them.
+func (s *socketSession) checkSymbols(symbols []string) error {
+	if len(symbols) > socketMaxSymbols {
+		return fmt.Errorf("at most %d symbols per request", socketMaxSymbols)
+	}
+	for _, sym := range symbols {
+		if _, ok := s.api.instrument(sym); !ok {
+			return fmt.Errorf("unknown symbol %q", sym)
+		}
+	}
+	return nil
+}
+
+// subscribe adds symbols (all of them when empty) to each channel.
+func (s *socketSession) subscribe(channels, symbols []string) error {
+	if err := checkSocketChannels(channels); err != nil {
+		return err
+	}
+	if err := s.checkSymbols(symbols); err != nil {
+		return err
+	}
+	for _, c := range channels {
+		set, ok := s.subs[c]
+		switch {
+		case len(symbols) == 0:
+			s.subs[c] = nil
+			continue
+		case ok && set == nil:
+			continue // already every symbol
+		case !ok:
+			set = map[string]bool{}
+		}
+		for _, sym := range symbols {
+			set[sym] = true
+		}
+		if len(set) > socketMaxSymbols {
+			return fmt.Errorf("at most %d symbols per channel", socketMaxSymbols)
+		}
+		s.subs[c] = set
+	}
+	return nil
+}
+
+// unsubscribe removes symbols from each channel, or whole channels when
+// symbols is empty; no channels means all of them.
+func (s *socketSession) unsubscribe(channels, symbols []string) error {
+	if len(channels) == 0 {
+		channels = socketChannelNames()
+	}
+	if err := checkSocketChannels(channels); err != nil {
+		return err
+	}
+	for _, c := range channels {
+		set, ok := s.subs[c]
+		if !ok {
+			continue
+		}
+		if len(symbols) == 0 {
+			delete(s.subs, c)
+			continue
+		}
+		if set == nil {
+			return fmt.Errorf("%s is subscribed for every symbol; unsubscribe the channel instead", c)
+		}
+		for _, sym := range symbols {
+			delete(set, sym)
+		}
+		if len(set) == 0 {
+			delete(s.subs, c)
+		}
+	}
+	return nil
+}
+
+// subscriptions renders subs for an ack, ["*"] standing for every symbol.
+func (s *socketSession) subscriptions() map[string][]string {
+	out := make(map[string][]string, len(s.subs))
+	for c, set := range s.subs {
+		if set == nil {
+			out[c] = []string{"*"}
+			continue
+		}
+		syms := make([]string, 0, len(set))
+		for sym := range set {
+			syms = append(syms, sym)
+		}
+		sort.Strings(syms)
+		out[c] = syms
+	}
+	return out
+}
+
+// snapshot sends the current state of each channel (the subscribed ones
+// when none are named) for symbols (the subscription's, else all), then
+// acks. Ticks have no snapshot; the prices channel carries the latest.
+func (s *socketSession) snapshot(ref string, channels, symbols []string) error {
+	if len(channels) == 0 {
+		for _, c := range socketChannelNames() {
+			if _, ok := s.subs[c]; ok {
+				channels = append(channels, c)
+			}
+		}
+	}
+	if len(channels) == 0 {
+		return s.sendError(ref, "snapshot needs channels")
+	}
+	if err := checkSocketChannels(channels); err != nil {
+		return s.sendError(ref, err.Error())
+	}
+	if err := s.checkSymbols(symbols); err != nil {
+		return s.sendError(ref, err.Error())
+	}
+
+	prices := s.api.market.GetPrices()
+	symbolsFor := func(channel string) []string {
+		if len(symbols) > 0 {
+			return symbols
+		}
+		if set := s.subs[channel]; set != nil {
+			out := make([]string, 0, len(set))
+			for sym := range set {
+				out = append(out, sym)
+			}
+			sort.Strings(out)
+			return out
+		}
+		out := make([]string, len(prices))
+		for i, p := range prices {
+			out[i] = p.Symbol
+		}
+		return out
+	}
+
+	for _, c := range channels {
+		var msgs []models.SocketMessage
+		switch c {
+		case "prices":
+			want := symbolsFor(c)
+			out := []models.Price{}
+			for _, p := range prices {
+				if slices.Contains(want, p.Symbol) {
+					out = append(out, p)
+				}
+			}
+			msgs = append(msgs, models.SocketMessage{Data: out})
+		case "candles":
+			for _, sym := range symbolsFor(c) {
+				data := s.api.market.GetChartData(sym, 1, "1m")
+				if data.Provenance != nil && data.Provenance.Synthetic {
+					syntheticServed.Inc("charts")
+				}
+				msgs = append(msgs, models.SocketMessage{Symbol: sym, Data: data})
+			}
+		case "hero":
+			for _, sym := range symbolsFor(c) {
+				msgs = append(msgs, models.SocketMessage{Symbol: sym, Data: s.api.market.GetHeroChart(sym, 360)})
+			}
+		case "news":
+			news := s.api.news.GetNews()
+			if len(news) > socketNewsSnapshot {
+				news = news[:socketNewsSnapshot]
+			}
+			if news == nil {
+				news = []models.NewsArticle{}
+			}
+			msgs = append(msgs, models.SocketMessage{Data: news})
+		default:
+			if err := s.sendError(ref, "no snapshot for "+c+"; the prices channel carries the latest"); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, m := range msgs {
+			m.Type, m.Ref, m.Channel = "snapshot", ref, c
+			if err := s.send(m); err != nil {
+				return err
+			}
+		}
+	}
+	return s.send(models.SocketMessage{Type: "ack", Ref: ref, Op: "snapshot", Subscriptions: s.subscriptions()})
+}
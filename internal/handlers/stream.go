@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// streamHeartbeat is how often an SSE comment is written on an otherwise
+// idle stream. Proxies (nginx, cloud load balancers) commonly cut idle
+// upstream connections after 30–60s; 15s keeps us comfortably inside that.
+const streamHeartbeat = 15 * time.Second
+
+// streamRetryMs is the reconnect delay we ask EventSource clients to use.
+const streamRetryMs = 3000
+
+// defaultStreamTypes are the event types an SSE client gets without a
+// types parameter: the ones the feed carried before ticks, candles, alerts
+// and news were added.
+var defaultStreamTypes = []string{"price", "bar", "snapshot"}
+
+// Stream serves live price and hero-bar updates as Server-Sent Events.
+//
+// Query params:
+//   - symbols: comma-separated list to subscribe to (default: all).
+//   - types: comma-separated event types to receive — "price", "bar",
+//     "snapshot", "tick", "candle", "news" (default: price, bar
+//     and snapshot).
+//   - lastEventId: resume point for clients that can't set headers on the
+//     first connect. The standard Last-Event-ID header takes precedence.
+//
+// Each message carries `id:` so the browser's EventSource resumes
+// automatically after a dropped connection, and a comment line is sent
+// every streamHeartbeat so intermediaries don't time the stream out.
+func (a *API) Stream(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	symbols := splitUpperList(q.Get("symbols"))
+	types := map[string]bool{}
+	for _, t := range splitList(q.Get("types")) {
+		types[strings.ToLower(t)] = true
+	}
+	if len(types) == 0 {
+		for _, t := range defaultStreamTypes {
+			types[t] = true
+		}
+	}
+
+	var lastEventID uint64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		lastEventID, _ = strconv.ParseUint(v, 10, 64)
+	} else if v := q.Get("lastEventId"); v != "" {
+		lastEventID, _ = strconv.ParseUint(v, 10, 64)
+	}
+
+	// The server's WriteTimeout would otherwise kill the stream after a
+	// few seconds; clearing the deadline is scoped to this response only.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	backlog, events, cancel := a.market.Subscribe(symbols, lastEventID)
+	defer cancel()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // disable nginx response buffering
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetryMs)
+	for _, evt := range backlog {
+		if types[evt.Type] {
+			writeStreamEvent(w, evt)
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case evt, ok := <-events:
+			if !ok {
+				// Publisher dropped us (we fell too far behind, or the
+				// service is shutting down). The client reconnects and
+				// resumes from its last id.
+				return
+			}
+			if !types[evt.Type] {
+				continue
+			}
+			writeStreamEvent(w, evt)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, evt models.StreamEvent) {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", evt.ID, evt.Type, data)
+}
+
+func splitList(v string) []string {
+	if v == "" {
+		return nil
+	}
+	parts := strings.Split(v, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func splitUpperList(v string) []string {
+	out := splitList(v)
+	for i := range out {
+		out[i] = strings.ToUpper(out[i])
+	}
+	return out
+}
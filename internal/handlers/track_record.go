@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"live-oil-prices-go/internal/middleware"
+	"live-oil-prices-go/internal/models"
+	"net/http"
+	"strings"
+)
+
+// TrackRecordClient is the forecast ledger as the API sees it.
+type TrackRecordClient interface {
+	TrackRecord(symbol string) models.TrackRecord
+}
+
+// SetTrackRecord attaches the forecast ledger. Call before RegisterRoutes;
+// without it /api/predictions/track-record isn't registered.
+func (a *API) SetTrackRecord(ledger TrackRecordClient) {
+	a.ledger = ledger
+}
+
+func (a *API) registerTrackRecordRoutes(mux *http.ServeMux) {
+	if a.ledger == nil {
+		return
+	}
+	// More specific than /api/predictions/{symbol}, so it wins.
+	mux.HandleFunc("GET /api/predictions/track-record", middleware.JSON(a.GetTrackRecord))
+}
+
+// GetTrackRecord reports the live accuracy of published forecasts, for
+// every symbol or just ?symbol=.
+func (a *API) GetTrackRecord(w http.ResponseWriter, r *http.Request) {
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+	if symbol != "" {
+		if _, ok := a.instrument(symbol); !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "unknown symbol"})
+			return
+		}
+	}
+	json.NewEncoder(w).Encode(a.ledger.TrackRecord(symbol))
+}
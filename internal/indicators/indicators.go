@@ -0,0 +1,304 @@
+// Package indicators computes technical indicator series. Every function
+// returns a series aligned index by index with its input, NaN until enough
+// values have been seen (the warm-up). Smoothing starts at the first
+// non-NaN input, so indicators can be chained.
+package indicators
+
+import (
+	"math"
+	"time"
+)
+
+func nanSeries(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
+
+// firstValid returns the index of the first non-NaN value, or len(values).
+func firstValid(values []float64) int {
+	for i, v := range values {
+		if !math.IsNaN(v) {
+			return i
+		}
+	}
+	return len(values)
+}
+
+// SMA is the simple moving average over period values.
+func SMA(values []float64, period int) []float64 {
+	out := nanSeries(len(values))
+	start := firstValid(values)
+	if period <= 0 || len(values)-start < period {
+		return out
+	}
+	sum := 0.0
+	for i := start; i < len(values); i++ {
+		sum += values[i]
+		if i-start >= period {
+			sum -= values[i-period]
+		}
+		if i-start >= period-1 {
+			out[i] = sum / float64(period)
+		}
+	}
+	return out
+}
+
+// EMA is the exponential moving average with alpha = 2/(period+1), seeded
+// with the SMA of the first period values.
+func EMA(values []float64, period int) []float64 {
+	if period <= 0 {
+		return nanSeries(len(values))
+	}
+	return smooth(values, period, 2/float64(period+1))
+}
+
+// wilder is Wilder's smoothing (an EMA with alpha = 1/period) as RSI, ATR
+// and ADX use it.
+func wilder(values []float64, period int) []float64 {
+	if period <= 0 {
+		return nanSeries(len(values))
+	}
+	return smooth(values, period, 1/float64(period))
+}
+
+func smooth(values []float64, period int, alpha float64) []float64 {
+	out := nanSeries(len(values))
+	start := firstValid(values)
+	seedAt := start + period - 1
+	if seedAt >= len(values) {
+		return out
+	}
+	seed := 0.0
+	for i := start; i <= seedAt; i++ {
+		seed += values[i]
+	}
+	out[seedAt] = seed / float64(period)
+	for i := seedAt + 1; i < len(values); i++ {
+		out[i] = alpha*values[i] + (1-alpha)*out[i-1]
+	}
+	return out
+}
+
+// WMA is the linearly weighted moving average: the newest value weighs
+// period, the oldest 1.
+func WMA(values []float64, period int) []float64 {
+	out := nanSeries(len(values))
+	start := firstValid(values)
+	if period <= 0 || len(values)-start < period {
+		return out
+	}
+	denom := float64(period*(period+1)) / 2
+	for i := start + period - 1; i < len(values); i++ {
+		sum := 0.0
+		for k := 0; k < period; k++ {
+			sum += float64(period-k) * values[i-k]
+		}
+		out[i] = sum / denom
+	}
+	return out
+}
+
+// RSI is Wilder's Relative Strength Index, first defined at index period.
+// A window with no moves at all reads 50.
+func RSI(closes []float64, period int) []float64 {
+	n := len(closes)
+	out := nanSeries(n)
+	if period <= 0 || n <= period {
+		return out
+	}
+	gains, losses := nanSeries(n), nanSeries(n)
+	for i := 1; i < n; i++ {
+		ch := closes[i] - closes[i-1]
+		gains[i], losses[i] = math.Max(ch, 0), math.Max(-ch, 0)
+	}
+	avgGain, avgLoss := wilder(gains, period), wilder(losses, period)
+	for i := period; i < n; i++ {
+		switch g, l := avgGain[i], avgLoss[i]; {
+		case l == 0 && g == 0:
+			out[i] = 50
+		case l == 0:
+			out[i] = 100
+		default:
+			out[i] = 100 - 100/(1+g/l)
+		}
+	}
+	return out
+}
+
+// MACDSeries is the MACD line (fast EMA - slow EMA), its signal EMA and
+// the histogram between them.
+type MACDSeries struct {
+	MACD, Signal, Histogram []float64
+}
+
+// MACD computes the classic moving-average convergence/divergence.
+func MACD(closes []float64, fast, slow, signal int) MACDSeries {
+	emaFast, emaSlow := EMA(closes, fast), EMA(closes, slow)
+	line := make([]float64, len(closes))
+	for i := range line {
+		line[i] = emaFast[i] - emaSlow[i] // NaN until both are defined
+	}
+	sig := EMA(line, signal)
+	hist := make([]float64, len(closes))
+	for i := range hist {
+		hist[i] = line[i] - sig[i]
+	}
+	return MACDSeries{MACD: line, Signal: sig, Histogram: hist}
+}
+
+// BandSeries is an envelope around a middle line.
+type BandSeries struct {
+	Upper, Middle, Lower []float64
+}
+
+// Bollinger bands sit k population standard deviations either side of the
+// period SMA.
+func Bollinger(closes []float64, period int, k float64) BandSeries {
+	mid := SMA(closes, period)
+	upper, lower := nanSeries(len(closes)), nanSeries(len(closes))
+	for i, m := range mid {
+		if math.IsNaN(m) {
+			continue
+		}
+		ss := 0.0
+		for _, v := range closes[i-period+1 : i+1] {
+			ss += (v - m) * (v - m)
+		}
+		sd := math.Sqrt(ss / float64(period))
+		upper[i], lower[i] = m+k*sd, m-k*sd
+	}
+	return BandSeries{Upper: upper, Middle: mid, Lower: lower}
+}
+
+// trueRange is NaN at index 0, which has no previous close.
+func trueRange(high, low, closes []float64) []float64 {
+	tr := nanSeries(len(closes))
+	for i := 1; i < len(closes); i++ {
+		prev := closes[i-1]
+		tr[i] = math.Max(high[i]-low[i], math.Max(math.Abs(high[i]-prev), math.Abs(low[i]-prev)))
+	}
+	return tr
+}
+
+// ATR is Wilder's average true range, first defined at index period.
+func ATR(high, low, closes []float64, period int) []float64 {
+	return wilder(trueRange(high, low, closes), period)
+}
+
+// StochasticSeries is %K and its %D moving average.
+type StochasticSeries struct {
+	K, D []float64
+}
+
+// Stochastic is the slow stochastic oscillator: where the close sits in
+// the kPeriod high-low range, smoothed by an smoothK SMA (1 gives the fast
+// %K), with %D the dPeriod SMA of %K. A flat range reads 50.
+func Stochastic(high, low, closes []float64, kPeriod, smoothK, dPeriod int) StochasticSeries {
+	raw := nanSeries(len(closes))
+	for i := kPeriod - 1; kPeriod > 0 && i < len(closes); i++ {
+		hh, ll := math.Inf(-1), math.Inf(1)
+		for j := i - kPeriod + 1; j <= i; j++ {
+			hh, ll = math.Max(hh, high[j]), math.Min(ll, low[j])
+		}
+		raw[i] = 50
+		if hh > ll {
+			raw[i] = 100 * (closes[i] - ll) / (hh - ll)
+		}
+	}
+	k := SMA(raw, smoothK)
+	return StochasticSeries{K: k, D: SMA(k, dPeriod)}
+}
+
+// ADXSeries is Wilder's directional movement system.
+type ADXSeries struct {
+	ADX, PlusDI, MinusDI []float64
+}
+
+// ADX computes +DI and -DI from Wilder-smoothed directional movement
+// (first defined at index period) and the ADX as the Wilder average of
+// their normalised spread (first defined at 2*period - 1).
+func ADX(high, low, closes []float64, period int) ADXSeries {
+	n := len(closes)
+	plusDM, minusDM := nanSeries(n), nanSeries(n)
+	for i := 1; i < n; i++ {
+		up, down := high[i]-high[i-1], low[i-1]-low[i]
+		plusDM[i], minusDM[i] = 0, 0
+		if up > down && up > 0 {
+			plusDM[i] = up
+		}
+		if down > up && down > 0 {
+			minusDM[i] = down
+		}
+	}
+	tr := wilder(trueRange(high, low, closes), period)
+	sp, sm := wilder(plusDM, period), wilder(minusDM, period)
+	out := ADXSeries{PlusDI: nanSeries(n), MinusDI: nanSeries(n)}
+	dx := nanSeries(n)
+	for i := range tr {
+		if math.IsNaN(tr[i]) {
+			continue
+		}
+		if tr[i] == 0 {
+			out.PlusDI[i], out.MinusDI[i], dx[i] = 0, 0, 0
+			continue
+		}
+		p, m := 100*sp[i]/tr[i], 100*sm[i]/tr[i]
+		out.PlusDI[i], out.MinusDI[i], dx[i] = p, m, 0
+		if p+m > 0 {
+			dx[i] = 100 * math.Abs(p-m) / (p + m)
+		}
+	}
+	out.ADX = wilder(dx, period)
+	return out
+}
+
+// OBV is on-balance volume: a running total that adds the bar's volume on
+// an up close and subtracts it on a down close, starting from 0.
+func OBV(closes, volume []float64) []float64 {
+	out := make([]float64, len(closes))
+	for i := 1; i < len(closes); i++ {
+		out[i] = out[i-1]
+		switch {
+		case closes[i] > closes[i-1]:
+			out[i] += volume[i]
+		case closes[i] < closes[i-1]:
+			out[i] -= volume[i]
+		}
+	}
+	return out
+}
+
+// nyTZ is the NYMEX timezone; see VWAP.
+var nyTZ = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*3600)
+	}
+	return loc
+}()
+
+// VWAP is the volume-weighted average typical price, (high+low+close)/3,
+// restarting each CME Globex session at 18:00 New York. times are unix
+// seconds. It is NaN until the session has traded volume.
+func VWAP(high, low, closes, volume []float64, times []int64) []float64 {
+	out := nanSeries(len(closes))
+	var session string
+	var pv, vol float64
+	for i := range closes {
+		// Shifting by six hours puts the 18:00 open at the next midnight.
+		s := time.Unix(times[i], 0).In(nyTZ).Add(6 * time.Hour).Format("2006-01-02")
+		if s != session {
+			session, pv, vol = s, 0, 0
+		}
+		pv += (high[i] + low[i] + closes[i]) / 3 * volume[i]
+		vol += volume[i]
+		if vol > 0 {
+			out[i] = pv / vol
+		}
+	}
+	return out
+}
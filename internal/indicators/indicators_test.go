@@ -0,0 +1,134 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func expect(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: %d values, want %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if math.IsNaN(want[i]) != math.IsNaN(got[i]) || (!math.IsNaN(want[i]) && !near(got[i], want[i])) {
+			t.Fatalf("%s[%d] = %v, want %v (%v)", name, i, got[i], want[i], got)
+		}
+	}
+}
+
+var nan = math.NaN()
+
+func TestMovingAverages(t *testing.T) {
+	v := []float64{1, 2, 3, 4, 5, 6}
+	expect(t, "sma", SMA(v, 3), []float64{nan, nan, 2, 3, 4, 5})
+	expect(t, "wma", WMA(v, 3), []float64{nan, nan, 14.0 / 6, 20.0 / 6, 26.0 / 6, 32.0 / 6})
+	// Seeded with the SMA, then alpha = 0.5.
+	expect(t, "ema", EMA(v, 3), []float64{nan, nan, 2, 3, 4, 5})
+	expect(t, "ema", EMA([]float64{2, 4, 10, 0}, 3), []float64{nan, nan, 16.0 / 3, 8.0 / 3})
+	// Chained: smoothing starts at the first defined input.
+	expect(t, "sma of sma", SMA(SMA(v, 3), 2), []float64{nan, nan, nan, 2.5, 3.5, 4.5})
+}
+
+func TestRSI(t *testing.T) {
+	up := []float64{1, 2, 3, 4, 5}
+	expect(t, "rsi up", RSI(up, 3), []float64{nan, nan, nan, 100, 100})
+	expect(t, "rsi flat", RSI([]float64{5, 5, 5, 5}, 2), []float64{nan, nan, 50, 50})
+	// Gains 1, losses 1 → 50; then a gain of 2 with Wilder's smoothing:
+	// avgGain (0.5 + 2)/2, avgLoss 0.5/2.
+	got := RSI([]float64{10, 11, 10, 12}, 2)
+	expect(t, "rsi mixed", got, []float64{nan, nan, 50, 100 - 100/(1+1.25/0.25)})
+}
+
+func TestMACDAlignsSignal(t *testing.T) {
+	closes := make([]float64, 60)
+	for i := range closes {
+		closes[i] = 50 + math.Sin(float64(i)/5)*3
+	}
+	m := MACD(closes, 12, 26, 9)
+	if !math.IsNaN(m.MACD[24]) || math.IsNaN(m.MACD[25]) {
+		t.Fatal("MACD line should start at slow-1")
+	}
+	if !math.IsNaN(m.Signal[32]) || math.IsNaN(m.Signal[33]) {
+		t.Fatal("signal should start at slow+signal-2")
+	}
+	if !near(m.Histogram[59], m.MACD[59]-m.Signal[59]) {
+		t.Fatal("histogram is macd - signal")
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	b := Bollinger([]float64{1, 3, 1, 3}, 2, 2)
+	// Window sd is 1, so the bands are the mean ± 2.
+	expect(t, "upper", b.Upper, []float64{nan, 4, 4, 4})
+	expect(t, "lower", b.Lower, []float64{nan, 0, 0, 0})
+}
+
+func TestATRAndStochastic(t *testing.T) {
+	high := []float64{11, 12, 13, 14, 15}
+	low := []float64{9, 10, 11, 12, 13}
+	closes := []float64{10, 11, 12, 13, 15}
+	// Each true range is max(2, |high-prev close| = 2) = 2.
+	expect(t, "atr", ATR(high, low, closes, 2), []float64{nan, nan, 2, 2, 2})
+	s := Stochastic(high, low, closes, 3, 1, 2)
+	// Last bar closes at the 3-bar high.
+	expect(t, "%k", s.K, []float64{nan, nan, 75, 75, 100})
+	expect(t, "%d", s.D, []float64{nan, nan, nan, 75, 87.5})
+}
+
+func TestADXTrend(t *testing.T) {
+	n := 60
+	high, low, closes := make([]float64, n), make([]float64, n), make([]float64, n)
+	for i := range closes {
+		closes[i] = 100 + float64(i)
+		high[i], low[i] = closes[i]+0.5, closes[i]-0.5
+	}
+	a := ADX(high, low, closes, 14)
+	if !math.IsNaN(a.ADX[26]) || math.IsNaN(a.ADX[27]) {
+		t.Fatal("ADX should start at 2*period-1")
+	}
+	if a.PlusDI[59] <= a.MinusDI[59] || a.ADX[59] < 90 {
+		t.Fatalf("steady uptrend: +DI %.1f -DI %.1f ADX %.1f", a.PlusDI[59], a.MinusDI[59], a.ADX[59])
+	}
+}
+
+func TestOBV(t *testing.T) {
+	expect(t, "obv", OBV([]float64{10, 11, 11, 9}, []float64{5, 7, 3, 2}), []float64{0, 7, 7, 5})
+}
+
+func TestVWAPResetsAtSessionOpen(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	at := func(h, m int) int64 { return time.Date(2026, 3, 10, h, m, 0, 0, ny).Unix() }
+	times := []int64{at(16, 0), at(16, 55), at(18, 0), at(18, 5)}
+	high := []float64{11, 21, 31, 41}
+	low := []float64{9, 19, 29, 39}
+	closes := []float64{10, 20, 30, 40}
+	vol := []float64{1, 3, 0, 1}
+	// 18:00 opens a new session; its first bar has no volume yet.
+	expect(t, "vwap", VWAP(high, low, closes, vol, times), []float64{10, 17.5, nan, 40})
+}
+
+func TestParseSpecs(t *testing.T) {
+	specs, err := ParseSpecs("RSI:14, bb:20:2.5,macd,rsi:14,obv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, s := range specs {
+		ids = append(ids, s.String())
+	}
+	if got := ids; len(got) != 4 || got[0] != "rsi:14" || got[1] != "bb:20:2.5" || got[2] != "macd:12:26:9" || got[3] != "obv" {
+		t.Fatalf("specs = %v", got)
+	}
+	if !specs[1].Overlay() || specs[0].Overlay() {
+		t.Fatal("bb overlays the price; rsi doesn't")
+	}
+	for _, bad := range []string{"", "foo", "rsi:0", "rsi:2.5", "rsi:14:3", "bb:20:0", "sma:501"} {
+		if _, err := ParseSpecs(bad); err == nil {
+			t.Fatalf("%q: expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package indicators
+
+import (
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxPeriod bounds every period parameter.
+const MaxPeriod = 500
+
+// Spec is one requested indicator, e.g. "bb:20:2": Bollinger bands over 20
+// bars at 2 standard deviations.
+type Spec struct {
+	Name   string
+	Params []float64
+}
+
+// String is the canonical form, with defaults filled in.
+func (s Spec) String() string {
+	parts := []string{s.Name}
+	for _, p := range s.Params {
+		parts = append(parts, strconv.FormatFloat(p, 'f', -1, 64))
+	}
+	return strings.Join(parts, ":")
+}
+
+// Overlay reports whether the indicator is in price units and belongs on
+// the price axis rather than its own pane.
+func (s Spec) Overlay() bool { return catalog[s.Name].overlay }
+
+// Intraday reports whether the indicator only makes sense on intraday bars.
+func (s Spec) Intraday() bool { return catalog[s.Name].intraday }
+
+// param describes one positional parameter.
+type param struct {
+	name     string
+	def      float64
+	integer  bool // a period: an integer from 1 to MaxPeriod
+	min, max float64
+}
+
+func period(name string, def float64) param {
+	return param{name: name, def: def, integer: true, min: 1, max: MaxPeriod}
+}
+
+type definition struct {
+	params   []param
+	overlay  bool
+	intraday bool
+	compute  func(b Bars, p []int, f []float64) []Line
+}
+
+// catalog lists the supported indicators by name.
+var catalog = map[string]definition{
+	"sma": {params: []param{period("period", 20)}, overlay: true, compute: func(b Bars, p []int, _ []float64) []Line {
+		return []Line{{"value", SMA(b.Close, p[0])}}
+	}},
+	"ema": {params: []param{period("period", 20)}, overlay: true, compute: func(b Bars, p []int, _ []float64) []Line {
+		return []Line{{"value", EMA(b.Close, p[0])}}
+	}},
+	"wma": {params: []param{period("period", 20)}, overlay: true, compute: func(b Bars, p []int, _ []float64) []Line {
+		return []Line{{"value", WMA(b.Close, p[0])}}
+	}},
+	"rsi": {params: []param{period("period", 14)}, compute: func(b Bars, p []int, _ []float64) []Line {
+		return []Line{{"value", RSI(b.Close, p[0])}}
+	}},
+	"macd": {params: []param{period("fast", 12), period("slow", 26), period("signal", 9)}, compute: func(b Bars, p []int, _ []float64) []Line {
+		m := MACD(b.Close, p[0], p[1], p[2])
+		return []Line{{"macd", m.MACD}, {"signal", m.Signal}, {"histogram", m.Histogram}}
+	}},
+	"bb": {params: []param{period("period", 20), {name: "k", def: 2, min: 0.1, max: 10}}, overlay: true, compute: func(b Bars, p []int, f []float64) []Line {
+		bb := Bollinger(b.Close, p[0], f[1])
+		return []Line{{"upper", bb.Upper}, {"middle", bb.Middle}, {"lower", bb.Lower}}
+	}},
+	"atr": {params: []param{period("period", 14)}, compute: func(b Bars, p []int, _ []float64) []Line {
+		return []Line{{"value", ATR(b.High, b.Low, b.Close, p[0])}}
+	}},
+	"stoch": {params: []param{period("k", 14), period("smooth", 3), period("d", 3)}, compute: func(b Bars, p []int, _ []float64) []Line {
+		s := Stochastic(b.High, b.Low, b.Close, p[0], p[1], p[2])
+		return []Line{{"k", s.K}, {"d", s.D}}
+	}},
+	"adx": {params: []param{period("period", 14)}, compute: func(b Bars, p []int, _ []float64) []Line {
+		a := ADX(b.High, b.Low, b.Close, p[0])
+		return []Line{{"adx", a.ADX}, {"plusDI", a.PlusDI}, {"minusDI", a.MinusDI}}
+	}},
+	"obv": {compute: func(b Bars, _ []int, _ []float64) []Line {
+		return []Line{{"value", OBV(b.Close, b.Volume)}}
+	}},
+	"vwap": {overlay: true, intraday: true, compute: func(b Bars, _ []int, _ []float64) []Line {
+		return []Line{{"value", VWAP(b.High, b.Low, b.Close, b.Volume, b.Time)}}
+	}},
+}
+
+// Names lists the supported indicators.
+func Names() []string {
+	out := make([]string, 0, len(catalog))
+	for name := range catalog {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ParseSpecs parses a comma-separated list such as "rsi:14,bb:20:2,obv".
+// Trailing parameters may be left out to take their defaults; a repeated
+// indicator is kept once.
+func ParseSpecs(list string) ([]Spec, error) {
+	var out []Spec
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		fields := strings.Split(strings.ToLower(item), ":")
+		def, ok := catalog[fields[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown indicator %q (have %s)", fields[0], strings.Join(Names(), ", "))
+		}
+		if len(fields)-1 > len(def.params) {
+			return nil, fmt.Errorf("%s takes at most %d parameters", fields[0], len(def.params))
+		}
+		spec := Spec{Name: fields[0], Params: make([]float64, len(def.params))}
+		for i, p := range def.params {
+			spec.Params[i] = p.def
+			if i+1 >= len(fields) {
+				continue
+			}
+			v, err := strconv.ParseFloat(fields[i+1], 64)
+			if err != nil || v < p.min || v > p.max || (p.integer && v != float64(int(v))) {
+				kind := "a number"
+				if p.integer {
+					kind = "an integer"
+				}
+				return nil, fmt.Errorf("%s %s must be %s from %g to %g", fields[0], p.name, kind, p.min, p.max)
+			}
+			spec.Params[i] = v
+		}
+		if key := spec.String(); !seen[key] {
+			seen[key] = true
+			out = append(out, spec)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no indicators requested")
+	}
+	return out, nil
+}
+
+// Bars is OHLCV data column by column, oldest first.
+type Bars struct {
+	Time                           []int64
+	Open, High, Low, Close, Volume []float64
+}
+
+// NewBars splits bars into columns.
+func NewBars(bars []models.OHLCV) Bars {
+	b := Bars{
+		Time:   make([]int64, len(bars)),
+		Open:   make([]float64, len(bars)),
+		High:   make([]float64, len(bars)),
+		Low:    make([]float64, len(bars)),
+		Close:  make([]float64, len(bars)),
+		Volume: make([]float64, len(bars)),
+	}
+	for i, bar := range bars {
+		b.Time[i] = bar.Time
+		b.Open[i], b.High[i], b.Low[i], b.Close[i] = bar.Open, bar.High, bar.Low, bar.Close
+		b.Volume[i] = float64(bar.Volume)
+	}
+	return b
+}
+
+// Line is one named output series of an indicator.
+type Line struct {
+	Name   string
+	Values []float64
+}
+
+// Compute evaluates spec over b.
+func Compute(b Bars, spec Spec) []Line {
+	ints := make([]int, len(spec.Params))
+	for i, p := range spec.Params {
+		ints[i] = int(p)
+	}
+	return catalog[spec.Name].compute(b, ints, spec.Params)
+}
@@ -0,0 +1,343 @@
+// Package metrics is a minimal Prometheus registry: counters, gauges and
+// histograms with labels, written in the text exposition format (0.0.4).
+// It covers what /metrics needs without pulling in client_golang.
+//
+// Metrics are registered on Default by the package-level constructors and
+// are safe for concurrent use.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets are latency buckets in seconds, from 5ms to 10s.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds a set of metrics and writes them sorted by name.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// Default is the registry served by Handler.
+var Default = NewRegistry()
+
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.metrics[name]; dup {
+		panic("metrics: duplicate registration of " + name)
+	}
+	r.metrics[name] = m
+}
+
+// WriteTo writes every metric in the text exposition format.
+func (r *Registry) WriteTo(out io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ms := make([]metric, len(names))
+	for i, name := range names {
+		ms[i] = r.metrics[name]
+	}
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: out}
+	w := bufio.NewWriter(cw)
+	for _, m := range ms {
+		m.write(w)
+	}
+	err := w.Flush()
+	return cw.n, err
+}
+
+// Handler serves the registry for a Prometheus scrape.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+// Handler serves Default.
+func Handler() http.Handler { return Default.Handler() }
+
+// vec is the label bookkeeping shared by every metric type.
+type vec[T any] struct {
+	name, help, kind string
+	labels           []string
+	mu               sync.Mutex
+	series           map[string]*T
+	values           map[string][]string
+	newT             func() *T
+}
+
+func (v *vec[T]) with(lvs []string) *T {
+	if len(lvs) != len(v.labels) {
+		panic("metrics: " + v.name + " wants " + strconv.Itoa(len(v.labels)) + " label values")
+	}
+	key := strings.Join(lvs, "\xff")
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.series[key]
+	if !ok {
+		s = v.newT()
+		v.series[key] = s
+		v.values[key] = append([]string(nil), lvs...)
+	}
+	return s
+}
+
+// each calls fn for every series sorted by label values, under the lock.
+func (v *vec[T]) each(fn func(lvs []string, s *T)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fn(v.values[k], v.series[k])
+	}
+}
+
+func (v *vec[T]) header(w *bufio.Writer) {
+	w.WriteString("# HELP " + v.name + " " + escapeHelp(v.help) + "\n")
+	w.WriteString("# TYPE " + v.name + " " + v.kind + "\n")
+}
+
+func newVec[T any](kind, name, help string, labels []string, newT func() *T) *vec[T] {
+	return &vec[T]{
+		name: name, help: help, kind: kind, labels: labels,
+		series: make(map[string]*T),
+		values: make(map[string][]string),
+		newT:   newT,
+	}
+}
+
+// CounterVec is a monotonically increasing value per label set.
+type CounterVec struct{ v *vec[float64] }
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec("counter", name, help, labels, func() *float64 { return new(float64) })}
+	r.register(name, c)
+	return c
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// Add adds delta (which must be >= 0) to the series for lvs.
+func (c *CounterVec) Add(delta float64, lvs ...string) {
+	p := c.v.with(lvs)
+	c.v.mu.Lock()
+	*p += delta
+	c.v.mu.Unlock()
+}
+
+func (c *CounterVec) Inc(lvs ...string) { c.Add(1, lvs...) }
+
+// Value returns the current count for lvs.
+func (c *CounterVec) Value(lvs ...string) float64 {
+	p := c.v.with(lvs)
+	c.v.mu.Lock()
+	defer c.v.mu.Unlock()
+	return *p
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.v.header(w)
+	c.v.each(func(lvs []string, p *float64) {
+		writeSample(w, c.v.name, c.v.labels, lvs, "", "", *p)
+	})
+}
+
+// GaugeVec is a value per label set that can go up and down.
+type GaugeVec struct{ v *vec[float64] }
+
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{newVec("gauge", name, help, labels, func() *float64 { return new(float64) })}
+	r.register(name, g)
+	return g
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+func (g *GaugeVec) Set(val float64, lvs ...string) {
+	p := g.v.with(lvs)
+	g.v.mu.Lock()
+	*p = val
+	g.v.mu.Unlock()
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.v.header(w)
+	g.v.each(func(lvs []string, p *float64) {
+		writeSample(w, g.v.name, g.v.labels, lvs, "", "", *p)
+	})
+}
+
+// GaugeFunc is a gauge computed at scrape time. fn reports one value per
+// label set through emit.
+type GaugeFunc struct {
+	name, help string
+	labels     []string
+	fn         func(emit func(val float64, lvs ...string))
+}
+
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, fn func(emit func(val float64, lvs ...string))) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, labels: labels, fn: fn}
+	r.register(name, g)
+	return g
+}
+
+func NewGaugeFunc(name, help string, labels []string, fn func(emit func(val float64, lvs ...string))) *GaugeFunc {
+	return Default.NewGaugeFunc(name, help, labels, fn)
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	w.WriteString("# HELP " + g.name + " " + escapeHelp(g.help) + "\n")
+	w.WriteString("# TYPE " + g.name + " gauge\n")
+	g.fn(func(val float64, lvs ...string) {
+		writeSample(w, g.name, g.labels, lvs, "", "", val)
+	})
+}
+
+// HistogramVec counts observations into cumulative buckets per label set.
+type HistogramVec struct {
+	v       *vec[histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, non-cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{buckets: buckets}
+	h.v = newVec("histogram", name, help, labels, func() *histogram {
+		return &histogram{counts: make([]uint64, len(buckets)+1)}
+	})
+	r.register(name, h)
+	return h
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+func (h *HistogramVec) Observe(val float64, lvs ...string) {
+	s := h.v.with(lvs)
+	i := sort.SearchFloat64s(h.buckets, val) // first bucket with le >= val
+	h.v.mu.Lock()
+	s.counts[i]++
+	s.sum += val
+	s.count++
+	h.v.mu.Unlock()
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.v.header(w)
+	h.v.each(func(lvs []string, s *histogram) {
+		var cum uint64
+		for i, le := range h.buckets {
+			cum += s.counts[i]
+			writeSample(w, h.v.name+"_bucket", h.v.labels, lvs, "le", formatFloat(le), float64(cum))
+		}
+		writeSample(w, h.v.name+"_bucket", h.v.labels, lvs, "le", "+Inf", float64(s.count))
+		writeSample(w, h.v.name+"_sum", h.v.labels, lvs, "", "", s.sum)
+		writeSample(w, h.v.name+"_count", h.v.labels, lvs, "", "", float64(s.count))
+	})
+}
+
+// writeSample writes `name{labels...,extra="extraVal"} value`.
+func writeSample(w *bufio.Writer, name string, labels, lvs []string, extra, extraVal string, val float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extra != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(l + `="` + escapeLabel(lvs[i]) + `"`)
+		}
+		if extra != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extra + `="` + extraVal + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(val))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+var startTime = float64(time.Now().UnixNano()) / 1e9
+
+func init() {
+	NewGaugeFunc("process_start_time_seconds", "Start time of the process since the Unix epoch in seconds.", nil,
+		func(emit func(float64, ...string)) { emit(startTime) })
+	NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", nil,
+		func(emit func(float64, ...string)) { emit(float64(runtime.NumGoroutine())) })
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritesExpositionFormat(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_requests_total", "Requests.", "route", "status")
+	c.Inc("/a", "200")
+	c.Add(2, "/a", "200")
+	c.Inc(`/b"\`, "500")
+	h := r.NewHistogramVec("test_latency_seconds", "Latency.", []float64{1, 0.1}, "route")
+	h.Observe(0.05, "/a")
+	h.Observe(0.1, "/a")
+	h.Observe(3, "/a")
+	r.NewGaugeFunc("test_age_seconds", "Age.", nil, func(emit func(float64, ...string)) { emit(1.5) })
+
+	var sb strings.Builder
+	if _, err := r.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_age_seconds Age.
+# TYPE test_age_seconds gauge
+test_age_seconds 1.5
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{route="/a",le="0.1"} 2
+test_latency_seconds_bucket{route="/a",le="1"} 2
+test_latency_seconds_bucket{route="/a",le="+Inf"} 3
+test_latency_seconds_sum{route="/a"} 3.15
+test_latency_seconds_count{route="/a"} 3
+# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{route="/a",status="200"} 3
+test_requests_total{route="/b\"\\",status="500"} 1
+`
+	if sb.String() != want {
+		t.Fatalf("unexpected exposition:\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
+
+func TestRegistryRejectsDuplicatesAndBadLabels(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("dup_total", "x", "a")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected a panic on duplicate registration")
+			}
+		}()
+		r.NewGaugeVec("dup_total", "x")
+	}()
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic on a label count mismatch")
+		}
+	}()
+	c.Inc("a", "b")
+}
+
+func TestHandlerSetsContentType(t *testing.T) {
+	res := httptest.NewRecorder()
+	Handler().ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := res.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	if !strings.Contains(res.Body.String(), "go_goroutines ") {
+		t.Fatalf("expected process metrics, got %s", res.Body.String())
+	}
+}
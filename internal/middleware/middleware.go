@@ -2,8 +2,11 @@ package middleware
 
 import (
 	"fmt"
+	"live-oil-prices-go/internal/metrics"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,9 +16,12 @@ var (
 )
 
 func Chain(h http.Handler) http.Handler {
-	return Logging(CORS(Recovery(h)))
+	return Logging(Metrics(CORS(Recovery(h))))
 }
 
+// CORS opens the read-only API to any origin. Writes (the token-guarded
+// /api/alerts routes) are for server-side callers, so browsers on other
+// origins are only ever allowed GET.
 func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -54,11 +60,58 @@ func Logging(next http.Handler) http.Handler {
 	})
 }
 
+var (
+	httpRequests = metrics.NewCounterVec("oilprices_http_requests_total",
+		"HTTP requests by route pattern, method and status code.",
+		"route", "method", "status")
+	httpLatency = metrics.NewHistogramVec("oilprices_http_request_duration_seconds",
+		"HTTP request latency by route pattern and method. Streaming routes stay open and land in +Inf.",
+		metrics.DefBuckets, "route", "method")
+)
+
+// Metrics records request counts and latencies. Requests are labelled by
+// the ServeMux pattern that matched (e.g. /api/charts/{symbol}), never the
+// raw path, so scrapers can't blow up the series count; anything the mux
+// didn't route is "unmatched". Methods get the same treatment: anything
+// outside the standard set is "other".
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		wr := &responseWriter{ResponseWriter: w}
+		next.ServeHTTP(wr, r)
+
+		route := "unmatched"
+		if r.Pattern != "" {
+			// Patterns may lead with a method ("GET /api/prices").
+			route = r.Pattern[strings.IndexByte(r.Pattern, '/'):]
+		}
+		status := wr.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		method := metricMethod(r.Method)
+		httpRequests.Inc(route, method, strconv.Itoa(status))
+		httpLatency.Observe(time.Since(start).Seconds(), route, method)
+	})
+}
+
+// metricMethod is the method label for m: one of the RFC 9110 methods or
+// PATCH, else "other".
+func metricMethod(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return m
+	}
+	return "other"
+}
+
 // responseWriter wraps http.ResponseWriter to capture headers
-// needed for logging after next.ServeHTTP call.
+// needed for logging after next.ServeHTTP call, and the status code.
 type responseWriter struct {
 	http.ResponseWriter
 	headers http.Header
+	status  int
 }
 
 func (rw *responseWriter) Header() http.Header {
@@ -67,13 +120,26 @@ func (rw *responseWriter) Header() http.Header {
 }
 
 func (rw *responseWriter) WriteHeader(statusCode int) {
+	if rw.status == 0 {
+		rw.status = statusCode
+	}
 	rw.ResponseWriter.WriteHeader(statusCode)
 }
 
 func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
 	return rw.ResponseWriter.Write(b)
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController so
+// streaming handlers (SSE) can flush and adjust write deadlines through
+// the logging wrapper.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 
 func Recovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
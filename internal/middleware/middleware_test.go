@@ -59,3 +59,33 @@ func TestLogging_WithoutRetryCount(t *testing.T) {
 		t.Error("Expected log output, got empty string")
 	}
 }
+
+func TestMetricsLabelsByRoutePattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/charts/{symbol}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	h := Metrics(mux)
+
+	before := httpRequests.Value("/api/charts/{symbol}", "GET", "418")
+	for _, path := range []string{"/api/charts/WTI", "/api/charts/BRENT"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	if got := httpRequests.Value("/api/charts/{symbol}", "GET", "418") - before; got != 2 {
+		t.Fatalf("expected 2 requests under the route pattern, got %v", got)
+	}
+
+	before = httpRequests.Value("unmatched", "GET", "404")
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nope", nil))
+	if got := httpRequests.Value("unmatched", "GET", "404") - before; got != 1 {
+		t.Fatalf("expected unrouted requests under \"unmatched\", got %v", got)
+	}
+
+	before = httpRequests.Value("unmatched", "other", "404")
+	for _, m := range []string{"BREW", "PROPFIND"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(m, "/nope", nil))
+	}
+	if got := httpRequests.Value("unmatched", "other", "404") - before; got != 2 {
+		t.Fatalf("expected non-standard methods under \"other\", got %v", got)
+	}
+}
@@ -1,5 +1,11 @@
 package models
 
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
 type Price struct {
 	Symbol    string  `json:"symbol"`
 	Name      string  `json:"name"`
@@ -12,6 +18,72 @@ type Price struct {
 	UpdatedAt string  `json:"updatedAt"`
 	Contract  string  `json:"contract,omitempty"`
 	Source    string  `json:"source,omitempty"`
+	// Provenance is nil only on zero-value Prices; every Price the API
+	// serves carries one.
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// Provenance records where a payload's numbers came from and how far to
+// trust them. Consumers that must only see market data (risk reports)
+// should reject anything with Synthetic set.
+type Provenance struct {
+	// Sources lists the contributing providers, highest priority first,
+	// e.g. ["pyth", "yahoo"] for a Pyth tick overlaid on a Yahoo quote.
+	Sources []string `json:"sources"`
+	// FetchedAt is when we last pulled the data from its upstream (RFC3339).
+	// Empty when the provider doesn't track it.
+	FetchedAt string `json:"fetchedAt,omitempty"`
+	// AsOf is the market timestamp of the newest data point (RFC3339).
+	AsOf string `json:"asOf,omitempty"`
+	// AgeSeconds is how old AsOf was when the payload was built.
+	AgeSeconds int64 `json:"ageSeconds"`
+	// Synthetic marks generated stand-in values, not market data.
+	Synthetic bool `json:"synthetic"`
+	// ChangeSuppressed is set when an extreme-move guard zeroed the daily
+	// change because its baseline looked like a contract-roll artefact.
+	ChangeSuppressed bool `json:"changeSuppressed,omitempty"`
+	// BaselineFallback is set when a live tick's change was derived from
+	// the slower source's own change instead of the prior close, for the
+	// same reason.
+	BaselineFallback bool `json:"baselineFallback,omitempty"`
+}
+
+// Instrument is one entry in the symbol catalogue: display metadata,
+// the upstream identifiers each feed polls it by, and the copy for its
+// /commodity page. GET /api/symbols serves the whole catalogue.
+type Instrument struct {
+	Symbol       string `json:"symbol"` // internal id, e.g. "WTI"
+	Name         string `json:"name"`
+	ShortName    string `json:"shortName"`
+	Unit         string `json:"unit"` // "USD/barrel", "USD/gallon", "USD/MMBtu", "USD/tonne"
+	Exchange     string `json:"exchange"`
+	TradingHours string `json:"tradingHours"`
+	// Card puts the symbol in the home page's headline price cards.
+	Card bool `json:"card,omitempty"`
+	// BasePrice seeds the synthetic estimate served when no feed quotes
+	// the symbol.
+	BasePrice float64 `json:"basePrice"`
+
+	// Provider identifiers; empty means the provider doesn't cover it.
+	Yahoo      string         `json:"yahoo,omitempty"`      // continuous futures ticker, "CL=F"
+	PythFeedID string         `json:"pythFeedId,omitempty"` // Hermes feed id, hex without 0x
+	EIA        *InstrumentEIA `json:"eia,omitempty"`
+	// ForecastHorizon is the forecast horizon in trading days; 0 means we
+	// don't publish a forecast for the symbol.
+	ForecastHorizon int `json:"forecastHorizon,omitempty"`
+
+	// Page copy for /commodity/{symbol}.
+	Description  string   `json:"description,omitempty"`
+	Keywords     string   `json:"keywords,omitempty"`
+	About        string   `json:"about,omitempty"`
+	PriceFactors []string `json:"priceFactors,omitempty"`
+}
+
+// InstrumentEIA names the EIA STEO series for an instrument. Name, when
+// set, labels the outlook in place of the instrument name.
+type InstrumentEIA struct {
+	Series string `json:"series"`
+	Name   string `json:"name,omitempty"`
 }
 
 type OHLCV struct {
@@ -57,6 +129,48 @@ type HeroChart struct {
 	UpdatedAt   string       `json:"updatedAt,omitempty"`   // RFC3339, last bar's wall-clock time
 	Source      string       `json:"source"`                // "pyth" | "yahoo"
 	Bars        []PythCandle `json:"bars"`
+	Provenance  *Provenance  `json:"provenance,omitempty"`
+}
+
+// StreamEvent is a single message on the /api/stream Server-Sent Events
+// feed and the /api/ws socket. ID is monotonic across the whole server so
+// clients can resume with Last-Event-ID after a reconnect. Data carries a
+// Price for "price" and "snapshot" events, a PythCandle for "bar" (the
+// 5-minute hero bar) and "candle" (the 1-minute bar) events, a Tick for
+// "tick" and a NewsArticle for "news", which has no Symbol.
+type StreamEvent struct {
+	ID     uint64 `json:"id"`
+	Type   string `json:"type"` // "price" | "bar" | "snapshot" | "tick" | "candle" | "news"
+	Symbol string `json:"symbol"`
+	Data   any    `json:"data"`
+}
+
+// Tick is a single print from a streaming feed (Pyth), before it is merged
+// into the Price view.
+type Tick struct {
+	Symbol string  `json:"symbol"`
+	Price  float64 `json:"price"`
+	Time   int64   `json:"time"` // publish time, unix milliseconds
+	Source string  `json:"source"`
+}
+
+// SocketMessage is every server-to-client message on /api/ws. Seq counts
+// the messages on one connection from 1 without gaps; ID is the
+// StreamEvent id behind an "event" (resume from it after a reconnect).
+type SocketMessage struct {
+	Seq           uint64              `json:"seq"`
+	Type          string              `json:"type"` // "welcome" | "ack" | "event" | "snapshot" | "error" | "pong"
+	Ref           string              `json:"ref,omitempty"` // echoes the request's ref
+	Op            string              `json:"op,omitempty"`  // the request an ack answers
+	Channel       string              `json:"channel,omitempty"`
+	ID            uint64              `json:"id,omitempty"`
+	Symbol        string              `json:"symbol,omitempty"`
+	Data          any                 `json:"data,omitempty"`
+	Subscriptions map[string][]string `json:"subscriptions,omitempty"` // channel → symbols, ["*"] = all
+	Channels      []string            `json:"channels,omitempty"`      // welcome: every channel offered
+	Resumed       *bool               `json:"resumed,omitempty"`       // resume ack: backlog covered the gap
+	LastID        uint64              `json:"lastId,omitempty"`
+	Error         string              `json:"error,omitempty"`
 }
 
 type ChartData struct {
@@ -64,6 +178,53 @@ type ChartData struct {
 	Name     string  `json:"name"`
 	Interval string  `json:"interval"`
 	Data     []OHLCV `json:"data"`
+	// Provenance.Synthetic is true when Data is a generated stand-in
+	// rather than real market bars (cold start, or a symbol no source
+	// covers).
+	Provenance *Provenance `json:"provenance"`
+}
+
+// IndicatorSet is /api/indicators/{symbol}: technical indicator series
+// aligned index by index with the chart bars at Times.
+type IndicatorSet struct {
+	Symbol     string      `json:"symbol"`
+	Interval   string      `json:"interval"`
+	Times      []int64     `json:"times"`
+	Indicators []Indicator `json:"indicators"`
+	Provenance *Provenance `json:"provenance"` // of the underlying bars
+}
+
+// Indicator is one computed indicator with its output lines, e.g. bb's
+// upper, middle and lower.
+type Indicator struct {
+	ID      string          `json:"id"`      // canonical spec, "bb:20:2"
+	Overlay bool            `json:"overlay"` // in price units, for the price axis
+	Lines   []IndicatorLine `json:"lines"`
+}
+
+type IndicatorLine struct {
+	Name   string `json:"name"`
+	Values Series `json:"values"`
+}
+
+// Series is a numeric series whose NaN entries — an indicator's warm-up —
+// encode as JSON null.
+type Series []float64
+
+func (s Series) MarshalJSON() ([]byte, error) {
+	b := make([]byte, 0, 2+len(s)*8)
+	b = append(b, '[')
+	for i, v := range s {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			b = append(b, "null"...)
+			continue
+		}
+		b = strconv.AppendFloat(b, v, 'g', -1, 64)
+	}
+	return append(b, ']'), nil
 }
 
 type NewsArticle struct {
@@ -111,6 +272,326 @@ type Prediction struct {
 	NaiveMAPE     float64 `json:"naiveMape,omitempty"`     // baseline "no change" MAPE
 	Skill         float64 `json:"skill,omitempty"`         // 1 - mape/naiveMape
 	BacktestSteps int     `json:"backtestSteps,omitempty"` // # of held-out forecasts averaged
+
+	// Calibrated uncertainty. PredictedLow/PredictedHigh are the 80% entry
+	// of Intervals; Fan repeats the intervals for each trading day out to
+	// the horizon for a fan chart.
+	Intervals []PredictionInterval `json:"intervals,omitempty"`
+	Fan       []FanPoint           `json:"fan,omitempty"`
+
+	// Provenance covers the inputs: the live price the forecast starts
+	// from and the daily history it was fitted on.
+	Provenance *Provenance `json:"provenance,omitempty"`
+}
+
+// PredictionInterval is a central prediction interval read from the
+// empirical distribution of the model's out-of-sample errors.
+type PredictionInterval struct {
+	Level float64 `json:"level"` // 0.8 = 80%
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+	// Coverage is how often realised closes fell inside intervals built
+	// the same way in the backtest, each from the errors known at its
+	// origin, over CoverageSamples checks. With no samples the history
+	// was too short: the interval is Gaussian and unchecked.
+	Coverage        float64 `json:"coverage"`
+	CoverageSamples int     `json:"coverageSamples"`
+}
+
+// FanPoint is the forecast distribution Step trading days ahead.
+type FanPoint struct {
+	Step      int                  `json:"step"`
+	Median    float64              `json:"median"`
+	Intervals []PredictionInterval `json:"intervals"`
+}
+
+// ForecastSet is the multi-horizon ensemble forecast for one symbol, served
+// at /api/predictions/{symbol}.
+type ForecastSet struct {
+	Symbol      string            `json:"symbol"`
+	Name        string            `json:"name"`
+	Current     float64           `json:"current"`
+	Horizons    []HorizonForecast `json:"horizons"`
+	Note        string            `json:"note,omitempty"` // why Horizons is empty
+	Disclaimer  string            `json:"disclaimer"`
+	GeneratedAt string            `json:"generatedAt"`
+	Provenance  *Provenance       `json:"provenance,omitempty"`
+}
+
+// HorizonForecast is the inverse-error-weighted ensemble forecast a number
+// of trading days ahead, with the member models behind it.
+type HorizonForecast struct {
+	Horizon   int     `json:"horizon"` // trading days
+	Predicted float64 `json:"predicted"`
+	// Low/High bound the 80% band from a GARCH(1,1) volatility forecast.
+	Low       float64 `json:"low"`
+	High      float64 `json:"high"`
+	Direction string  `json:"direction"` // "bullish" | "bearish" | "neutral"
+	// Backtest of the weighted combination on the members' origins.
+	MAPE          float64         `json:"mape"`
+	NaiveMAPE     float64         `json:"naiveMape"`
+	Skill         float64         `json:"skill"`
+	BacktestSteps int             `json:"backtestSteps"`
+	Volatility    float64         `json:"volatility"` // next-day GARCH σ, 0.02 = 2%
+	Models        []ModelForecast `json:"models"`
+}
+
+// ModelForecast is one ensemble member at one horizon.
+type ModelForecast struct {
+	Model     string  `json:"model"` // "holt-damped" | "arima" | "theta" | "seasonal-naive"
+	Predicted float64 `json:"predicted"`
+	MAPE      float64 `json:"mape"`
+	Weight    float64 `json:"weight"` // 0 = excluded for not beating naive
+}
+
+// TrackRecord is the live, out-of-sample accuracy of published forecasts:
+// each one is scored against the realised close once its horizon has
+// elapsed. Rows are per symbol, model and horizon; Models pools every
+// symbol for each model and horizon.
+type TrackRecord struct {
+	GeneratedAt string           `json:"generatedAt"`
+	Since       string           `json:"since,omitempty"` // first recorded forecast
+	Rows        []TrackRecordRow `json:"rows"`
+	Models      []TrackRecordRow `json:"models"`
+}
+
+// TrackRecordRow scores one group of published forecasts. The accuracy
+// fields are zero until Scored > 0.
+type TrackRecordRow struct {
+	Symbol    string `json:"symbol,omitempty"` // empty in TrackRecord.Models
+	Model     string `json:"model"`
+	Horizon   int    `json:"horizon"` // trading days
+	Published int    `json:"published"`
+	Scored    int    `json:"scored"`
+	Pending   int    `json:"pending"` // horizon not yet elapsed
+	// HitRate is the share of calls whose direction (±0.5% counts as
+	// neutral) matched the realised move.
+	HitRate   float64 `json:"hitRate"`
+	MAPE      float64 `json:"mape"`
+	NaiveMAPE float64 `json:"naiveMape"` // "no change" from the price at issue
+	Skill     float64 `json:"skill"`     // 1 - mape/naiveMape
+	// Coverage is the share of realised closes inside the published 80%
+	// band, over the Banded forecasts that had one.
+	Coverage     *float64 `json:"coverage,omitempty"`
+	Banded       int      `json:"banded,omitempty"`
+	LastScoredAt string   `json:"lastScoredAt,omitempty"`
+}
+
+// RiskReport is /api/risk/{symbol}: realised volatility, drawdown and
+// tail risk from the daily bars, and how the symbol's returns co-move
+// with every Yahoo-backed symbol's. Fractions throughout: 0.35 = 35%.
+type RiskReport struct {
+	Symbol       string              `json:"symbol"`
+	Name         string              `json:"name"`
+	AsOf         string              `json:"asOf,omitempty"` // session date of the latest bar
+	Bars         int                 `json:"bars"`
+	Volatility   []VolatilityWindow  `json:"volatility"`
+	Drawdowns    []Drawdown          `json:"drawdowns"`
+	TailRisk     []TailRisk          `json:"tailRisk"`
+	Correlations []CorrelationMatrix `json:"correlations"`
+	Note         string              `json:"note,omitempty"` // why the lists are empty
+	GeneratedAt  string              `json:"generatedAt"`
+	Provenance   *Provenance         `json:"provenance,omitempty"`
+}
+
+// VolatilityWindow is annualised (√252) realised volatility over the last
+// Days sessions by three estimators: close-to-close log returns, Parkinson
+// (high-low range) and Garman–Klass (open-high-low-close).
+type VolatilityWindow struct {
+	Days         int     `json:"days"`
+	CloseToClose float64 `json:"closeToClose"`
+	Parkinson    float64 `json:"parkinson"`
+	GarmanKlass  float64 `json:"garmanKlass"`
+}
+
+// Drawdown is the largest peak-to-trough fall in the closes over the last
+// Days sessions (0 = all stored history).
+type Drawdown struct {
+	Days      int     `json:"days"`
+	Max       float64 `json:"max"` // 0.42 = the close fell 42% below its peak
+	Peak      string  `json:"peak,omitempty"`
+	Trough    string  `json:"trough,omitempty"`
+	Recovered string  `json:"recovered,omitempty"` // first close back at the peak
+	Current   float64 `json:"current"`             // latest close below the window's high
+}
+
+// TailRisk is historical one-day value at risk over the last Days
+// sessions: the loss not exceeded on Level of days, and the average loss
+// on the days beyond it (expected shortfall).
+type TailRisk struct {
+	Days  int     `json:"days"`
+	Level float64 `json:"level"` // 0.95
+	VaR   float64 `json:"var"`
+	CVaR  float64 `json:"cvar"`
+}
+
+// CorrelationMatrix holds the pairwise correlations of daily log returns
+// over the last Days sessions. Matrix rows and columns follow Symbols; a
+// pair with too few common sessions is null.
+type CorrelationMatrix struct {
+	Days    int      `json:"days"`
+	Symbols []string `json:"symbols"`
+	Matrix  []Series `json:"matrix"`
+}
+
+// Spread is the live value of a named spread between benchmarks, e.g.
+// Brent–WTI or the 3-2-1 crack. Legs are converted into Unit before they
+// are combined; "ratio" spreads divide the first leg by the second.
+type Spread struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Kind        string      `json:"kind"` // "difference" | "ratio"
+	Unit        string      `json:"unit"` // "USD/barrel", "ratio", ...
+	Formula     string      `json:"formula"`
+	Value       float64     `json:"value"`
+	Change      float64     `json:"change"` // vs. the legs' prior closes
+	Legs        []SpreadLeg `json:"legs"`
+	UpdatedAt   string      `json:"updatedAt"`
+	Provenance  *Provenance `json:"provenance,omitempty"`
+}
+
+// SpreadLeg is one benchmark's contribution to a Spread.
+type SpreadLeg struct {
+	Symbol    string  `json:"symbol"`
+	Weight    float64 `json:"weight"`
+	Price     float64 `json:"price"` // in the benchmark's own unit
+	Unit      string  `json:"unit"`
+	Converted float64 `json:"converted"` // Price expressed in the spread's unit
+}
+
+// SpreadChart is a spread's daily history, computed from the legs' daily
+// closes on the dates they all traded.
+type SpreadChart struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	Unit       string        `json:"unit"`
+	Data       []SpreadPoint `json:"data"`
+	Provenance *Provenance   `json:"provenance,omitempty"`
+}
+
+type SpreadPoint struct {
+	Time  int64   `json:"time"`
+	Value float64 `json:"value"`
+}
+
+// ForwardCurve is the futures strip for one benchmark: the next listed
+// contract months, front month first, with term-structure metrics.
+type ForwardCurve struct {
+	Symbol     string       `json:"symbol"`
+	Name       string       `json:"name"`
+	Unit       string       `json:"unit"`
+	Points     []CurvePoint `json:"points"`
+	Metrics    CurveMetrics `json:"metrics"`
+	UpdatedAt  string       `json:"updatedAt"`
+	Provenance *Provenance  `json:"provenance,omitempty"`
+}
+
+type CurvePoint struct {
+	Contract  string  `json:"contract"` // "Dec 2026"
+	Ticker    string  `json:"ticker"`   // "CLZ26.NYM"
+	Month     string  `json:"month"`    // delivery month, "2026-12"
+	Price     float64 `json:"price"`
+	Change    float64 `json:"change"`
+	UpdatedAt string  `json:"updatedAt"`
+	// Consensus is the EIA STEO forecast for the delivery month, when
+	// the STEO covers it — the market's curve next to the institutional
+	// outlook.
+	Consensus *float64 `json:"consensus,omitempty"`
+}
+
+// CurveMetrics summarises a curve's shape. Spreads are deferred minus
+// front, so positive means contango.
+type CurveMetrics struct {
+	Structure string   `json:"structure"` // "contango" | "backwardation" | "flat"
+	M1M2      float64  `json:"m1m2"`
+	M1M6      *float64 `json:"m1m6,omitempty"`
+	M1M12     *float64 `json:"m1m12,omitempty"`
+	// SlopePct is the back month's premium over the front, in percent.
+	SlopePct float64 `json:"slopePct"`
+	// RollYieldPct is the annualised return from rolling a long position
+	// from M1 into M2 at today's prices; positive in backwardation.
+	RollYieldPct float64 `json:"rollYieldPct"`
+}
+
+// CurveHistory is one curve per trading day, by tenor (M1, M2, ...), so
+// the UI can chart how the curve's shape has moved.
+type CurveHistory struct {
+	Symbol    string          `json:"symbol"`
+	Snapshots []CurveSnapshot `json:"snapshots"`
+}
+
+type CurveSnapshot struct {
+	Time    int64        `json:"time"` // unix seconds, start of the trading day
+	Prices  []float64    `json:"prices"`
+	Metrics CurveMetrics `json:"metrics"`
+}
+
+// ErrInvalidAlert wraps every alert validation failure so the API can
+// answer 400 rather than 500.
+var ErrInvalidAlert = errors.New("invalid alert")
+
+// ErrAlertLimit is returned when creating an alert would exceed the
+// server's cap.
+var ErrAlertLimit = errors.New("alert limit reached")
+
+// Alert is a server-side rule evaluated on every price refresh. Which
+// fields apply depends on Type:
+//   - "threshold": Symbol crosses Level; Direction "above", "below" or
+//     "cross" (either way).
+//   - "move": Symbol moves Pct percent within Window (e.g. "15m");
+//     Direction "up", "down" or empty for either.
+//   - "spread": the named spread (Spread = spread id) crosses Level, with
+//     the same Direction values as "threshold".
+//   - "rsi": Symbol's daily RSI(14) changes regime (oversold < 30 <
+//     neutral < 70 < overbought); Regime limits it to entering one regime.
+//
+// Alerts are edge-triggered: a rule fires once when its condition becomes
+// true and re-arms when it turns false again.
+type Alert struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name,omitempty"`
+	Type       string  `json:"type"`
+	Symbol     string  `json:"symbol,omitempty"`
+	Spread     string  `json:"spread,omitempty"`
+	Direction  string  `json:"direction,omitempty"`
+	Level      float64 `json:"level,omitempty"`
+	Pct        float64 `json:"pct,omitempty"`
+	Window     string  `json:"window,omitempty"`
+	Regime     string  `json:"regime,omitempty"`
+	WebhookURL string  `json:"webhookUrl"`
+	// Secret is the HMAC key webhooks are signed with. Write-only: it is
+	// never returned by the API; Signed reports whether one is set.
+	Secret      string `json:"secret,omitempty"`
+	Signed      bool   `json:"signed"`
+	CreatedAt   string `json:"createdAt"`
+	LastFiredAt string `json:"lastFiredAt,omitempty"`
+	FireCount   int    `json:"fireCount"`
+}
+
+// AlertEvent is the JSON body of an alert webhook.
+type AlertEvent struct {
+	AlertID     string  `json:"alertId"`
+	Name        string  `json:"name,omitempty"`
+	Type        string  `json:"type"`
+	Symbol      string  `json:"symbol"` // benchmark, or spread id for "spread"
+	Value       float64 `json:"value"`  // price, % move, spread value or RSI
+	Level       float64 `json:"level,omitempty"`
+	Message     string  `json:"message"`
+	TriggeredAt string  `json:"triggeredAt"`
+}
+
+// AlertDelivery is one webhook delivery and its retry state.
+type AlertDelivery struct {
+	ID         string     `json:"id"`
+	AlertID    string     `json:"alertId"`
+	Event      AlertEvent `json:"event"`
+	Status     string     `json:"status"` // "pending" | "delivered" | "failed"
+	Attempts   int        `json:"attempts"`
+	StatusCode int        `json:"statusCode,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  string     `json:"createdAt"`
+	UpdatedAt  string     `json:"updatedAt"`
 }
 
 // ConsensusForecast holds an institutional outlook (e.g. EIA Short-Term
@@ -153,3 +634,27 @@ type MarketOverview struct {
 	Analysis    MarketAnalysis `json:"analysis"`
 	Predictions []Prediction   `json:"predictions"`
 }
+
+// FeedHealth is one upstream feed's refresh record, judged against its
+// configured thresholds.
+type FeedHealth struct {
+	Name                string   `json:"name"`   // "yahoo.quotes", "pyth", ...
+	Status              string   `json:"status"` // "ok" | "degraded" | "down" | "pending"
+	Critical            bool     `json:"critical"`
+	LastSuccess         string   `json:"lastSuccess,omitempty"`
+	LastError           string   `json:"lastError,omitempty"`
+	LastErrorAt         string   `json:"lastErrorAt,omitempty"`
+	ConsecutiveFailures int      `json:"consecutiveFailures"`
+	CacheAgeSeconds     *float64 `json:"cacheAgeSeconds"` // null until the first success
+	MaxAgeSeconds       float64  `json:"maxAgeSeconds"`
+	MaxFailures         int      `json:"maxFailures"`
+}
+
+// HealthReport is the /api/health/feeds payload. Ready is false while any
+// critical feed is down, which is when /api/health/ready answers 503.
+type HealthReport struct {
+	Status    string       `json:"status"` // "ok" | "degraded" | "unavailable"
+	Ready     bool         `json:"ready"`
+	Feeds     []FeedHealth `json:"feeds"`
+	CheckedAt string       `json:"checkedAt"`
+}
@@ -0,0 +1,533 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"log"
+	"math"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Alert rule types.
+const (
+	alertThreshold = "threshold"
+	alertMove      = "move"
+	alertSpread    = "spread"
+	alertRSI       = "rsi"
+)
+
+const (
+	// alertMaxWindow bounds a move alert's lookback, and with it how much
+	// price history the engine keeps per symbol.
+	alertMaxWindow = 24 * time.Hour
+	// alertRSIPeriod and the regime bands follow LabelRSI's 30/70 extremes.
+	alertRSIPeriod = 14
+	// alertMaxCount caps registered alerts, which bounds evaluation work
+	// and the alerts.json rewritten on every change.
+	alertMaxCount = 200
+)
+
+// AlertEngine evaluates registered alerts against every price refresh and
+// delivers webhooks for the ones that fire. Firings go only to the
+// alert's own webhook, never the public stream. Rules are persisted to a
+// JSON file (when a path is given) so they survive restarts; the delivery
+// log is in memory only.
+type AlertEngine struct {
+	market *MarketDataService
+	path   string
+
+	// client and retryBase drive webhook delivery; see deliver. allowAddr
+	// vets webhook destinations, at validation and again at dial time.
+	client    *http.Client
+	retryBase time.Duration
+	allowAddr func(netip.Addr) bool
+
+	mu         sync.Mutex
+	alerts     []models.Alert // registration order
+	state      map[string]*alertState
+	last       map[string]models.Price // last evaluated quote per symbol
+	samples    map[string][]priceSample
+	deliveries []models.AlertDelivery // oldest-first, capped at alertDeliveryLog
+
+	// run's context bounds webhook deliveries; unsubscribe ends the bus
+	// subscription Start made.
+	run         runGroup
+	unsubscribe func()
+	inflight    sync.WaitGroup
+}
+
+// alertState is the edge-trigger memory for one alert.
+type alertState struct {
+	seen   bool   // first evaluation only records state
+	side   int    // threshold/spread: sign of value − level
+	armed  bool   // move: condition was false last time
+	regime string // rsi: last regime
+}
+
+type priceSample struct {
+	at    time.Time
+	price float64
+}
+
+// NewAlertEngine loads persisted alerts from path ("" keeps them in memory
+// only). Call Start to begin evaluating.
+func NewAlertEngine(market *MarketDataService, path string) (*AlertEngine, error) {
+	e := &AlertEngine{
+		market:    market,
+		path:      path,
+		retryBase: 2 * time.Second,
+		allowAddr: publicAddr,
+		state:     make(map[string]*alertState),
+		last:      make(map[string]models.Price),
+		samples:   make(map[string][]priceSample),
+	}
+	e.client = newWebhookClient(func(a netip.Addr) bool { return e.allowAddr(a) })
+	if path == "" {
+		return e, nil
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return e, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read alerts: %w", err)
+	}
+	if err := json.Unmarshal(raw, &e.alerts); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for _, a := range e.alerts {
+		e.state[a.ID] = &alertState{}
+	}
+	return e, nil
+}
+
+// Start evaluates the alerts on every QuoteUpdated from the market's bus,
+// so either feed's refresh is seen as soon as it lands. Webhook deliveries
+// run under ctx. Safe to call more than once.
+func (e *AlertEngine) Start(ctx context.Context) {
+	if !e.run.start(ctx) {
+		return
+	}
+	e.mu.Lock()
+	e.unsubscribe = e.market.Bus().Subscribe("alerts", func(Event) {
+		e.evaluate(e.market.GetPrices(), time.Now().UTC())
+	}, KindQuoteUpdated)
+	e.mu.Unlock()
+}
+
+// Stop ends evaluation, abandons pending webhook retries and waits for the
+// deliveries in flight to record their outcome. Unsubscribing waits for
+// an evaluation in progress, so none can start a delivery during the
+// wait.
+func (e *AlertEngine) Stop() {
+	e.mu.Lock()
+	unsubscribe := e.unsubscribe
+	e.unsubscribe = nil
+	e.mu.Unlock()
+	if unsubscribe != nil {
+		unsubscribe()
+	}
+	e.run.stop()
+	e.inflight.Wait()
+}
+
+// CreateAlert validates and registers a new alert, up to alertMaxCount.
+func (e *AlertEngine) CreateAlert(a models.Alert) (models.Alert, error) {
+	a, err := normalizeAlert(a, e.market)
+	if err != nil {
+		return models.Alert{}, err
+	}
+	if err := e.checkWebhookHost(a.WebhookURL); err != nil {
+		return models.Alert{}, err
+	}
+	a.ID = newID()
+	a.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	a.LastFiredAt, a.FireCount = "", 0
+
+	e.mu.Lock()
+	if len(e.alerts) >= alertMaxCount {
+		e.mu.Unlock()
+		return models.Alert{}, fmt.Errorf("%w: at most %d alerts", models.ErrAlertLimit, alertMaxCount)
+	}
+	e.alerts = append(e.alerts, a)
+	e.state[a.ID] = &alertState{}
+	err = e.saveLocked()
+	e.mu.Unlock()
+	if err != nil {
+		log.Printf("alerts: %v", err)
+	}
+	return redact(a), nil
+}
+
+// ListAlerts returns every alert in registration order, secrets and
+// webhook URLs redacted.
+func (e *AlertEngine) ListAlerts() []models.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]models.Alert, len(e.alerts))
+	for i, a := range e.alerts {
+		out[i] = redact(a)
+	}
+	return out
+}
+
+func (e *AlertEngine) GetAlert(id string) (models.Alert, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, a := range e.alerts {
+		if a.ID == id {
+			return redact(a), true
+		}
+	}
+	return models.Alert{}, false
+}
+
+// DeleteAlert removes an alert. Deliveries already in flight still finish.
+func (e *AlertEngine) DeleteAlert(id string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, a := range e.alerts {
+		if a.ID == id {
+			e.alerts = append(e.alerts[:i:i], e.alerts[i+1:]...)
+			delete(e.state, id)
+			if err := e.saveLocked(); err != nil {
+				log.Printf("alerts: %v", err)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// redact strips what the API never echoes back: the secret, and the
+// webhook URL's path and query (see redactWebhook).
+func redact(a models.Alert) models.Alert {
+	a.Signed = a.Secret != ""
+	a.Secret = ""
+	a.WebhookURL = redactWebhook(a.WebhookURL)
+	return a
+}
+
+// normalizeAlert checks the fields the alert's type needs and canonicalises
+// symbols and directions.
+func normalizeAlert(a models.Alert, market *MarketDataService) (models.Alert, error) {
+	invalid := func(format string, args ...any) (models.Alert, error) {
+		return models.Alert{}, fmt.Errorf("%w: %s", models.ErrInvalidAlert, fmt.Sprintf(format, args...))
+	}
+	u, err := url.Parse(a.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return invalid("webhookUrl must be an absolute http(s) URL")
+	}
+	a.Type = strings.ToLower(a.Type)
+	a.Symbol = strings.ToUpper(a.Symbol)
+	a.Direction = strings.ToLower(a.Direction)
+
+	needSymbol := func() error {
+		if _, ok := commodityNames[a.Symbol]; !ok {
+			return fmt.Errorf("unknown symbol %q", a.Symbol)
+		}
+		return nil
+	}
+	switch a.Type {
+	case alertThreshold, alertSpread:
+		if a.Type == alertThreshold {
+			if err := needSymbol(); err != nil {
+				return invalid("%v", err)
+			}
+		} else {
+			if _, ok := market.spreadDefinition(a.Spread); !ok {
+				return invalid("unknown spread %q", a.Spread)
+			}
+			a.Spread = strings.ToLower(a.Spread)
+			a.Symbol = ""
+		}
+		if a.Direction == "" {
+			a.Direction = "cross"
+		}
+		if a.Direction != "above" && a.Direction != "below" && a.Direction != "cross" {
+			return invalid("direction must be above, below or cross")
+		}
+	case alertMove:
+		if err := needSymbol(); err != nil {
+			return invalid("%v", err)
+		}
+		if a.Pct <= 0 {
+			return invalid("pct must be positive")
+		}
+		w, err := time.ParseDuration(a.Window)
+		if err != nil || w <= 0 || w > alertMaxWindow {
+			return invalid("window must be a duration up to %s, e.g. \"15m\"", alertMaxWindow)
+		}
+		if a.Direction != "" && a.Direction != "up" && a.Direction != "down" {
+			return invalid("direction must be up, down or empty")
+		}
+	case alertRSI:
+		if err := needSymbol(); err != nil {
+			return invalid("%v", err)
+		}
+		a.Regime = strings.ToLower(a.Regime)
+		if a.Regime != "" && a.Regime != "oversold" && a.Regime != "neutral" && a.Regime != "overbought" {
+			return invalid("regime must be oversold, neutral, overbought or empty")
+		}
+	default:
+		return invalid("type must be threshold, move, spread or rsi")
+	}
+	return a, nil
+}
+
+// evaluate runs every alert whose inputs changed since the last call.
+// Estimates never trigger alerts: their prices are noise.
+func (e *AlertEngine) evaluate(prices []models.Price, now time.Time) {
+	e.mu.Lock()
+	changed := make(map[string]models.Price)
+	for _, p := range prices {
+		if p.Source == sourceEstimate || p.Price <= 0 {
+			continue
+		}
+		if prev, ok := e.last[p.Symbol]; ok && samePriceTick(prev, p) {
+			continue
+		}
+		e.last[p.Symbol] = p
+		changed[p.Symbol] = p
+		e.recordSampleLocked(p.Symbol, p.Price, now)
+	}
+	if len(changed) == 0 || len(e.alerts) == 0 {
+		e.mu.Unlock()
+		return
+	}
+
+	var spreads map[string]models.Spread
+	var fired []models.AlertEvent
+	targets := make(map[string]models.Alert)
+	for i := range e.alerts {
+		a := &e.alerts[i]
+		st := e.state[a.ID]
+		var evt *models.AlertEvent
+		switch a.Type {
+		case alertThreshold:
+			if p, ok := changed[a.Symbol]; ok {
+				evt = crossing(a, st, a.Symbol, p.Price)
+			}
+		case alertSpread:
+			if spreads == nil {
+				spreads = e.spreadsLocked(prices, now)
+			}
+			if sp, ok := spreads[a.Spread]; ok && spreadTouched(sp, changed) {
+				evt = crossing(a, st, a.Spread, sp.Value)
+			}
+		case alertMove:
+			if p, ok := changed[a.Symbol]; ok {
+				evt = e.moveLocked(a, st, p.Price, now)
+			}
+		case alertRSI:
+			if p, ok := changed[a.Symbol]; ok {
+				evt = e.rsiRegime(a, st, p.Price)
+			}
+		}
+		if evt == nil {
+			continue
+		}
+		evt.AlertID, evt.Name, evt.Type = a.ID, a.Name, a.Type
+		evt.TriggeredAt = now.Format(time.RFC3339)
+		a.LastFiredAt = evt.TriggeredAt
+		a.FireCount++
+		fired = append(fired, *evt)
+		targets[a.ID] = *a
+	}
+	if len(fired) > 0 {
+		if err := e.saveLocked(); err != nil {
+			log.Printf("alerts: %v", err)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, evt := range fired {
+		e.dispatch(targets[evt.AlertID], evt)
+	}
+}
+
+// crossing fires when value moves to the alert's side of Level. The first
+// observation only records which side we start on.
+func crossing(a *models.Alert, st *alertState, subject string, value float64) *models.AlertEvent {
+	side := 0
+	switch {
+	case value > a.Level:
+		side = 1
+	case value < a.Level:
+		side = -1
+	}
+	prev, seen := st.side, st.seen
+	st.side, st.seen = side, true
+	if !seen || side == 0 || side == prev {
+		return nil
+	}
+	if (a.Direction == "above" && side < 0) || (a.Direction == "below" && side > 0) {
+		return nil
+	}
+	verb := "rose above"
+	if side < 0 {
+		verb = "fell below"
+	}
+	return &models.AlertEvent{
+		Symbol:  subject,
+		Value:   r4(value),
+		Level:   a.Level,
+		Message: fmt.Sprintf("%s %s %.4g at %.4g", subject, verb, a.Level, value),
+	}
+}
+
+// moveLocked fires when the price has moved Pct percent off the window's
+// low (up) or high (down), re-arming once the move falls back under Pct.
+func (e *AlertEngine) moveLocked(a *models.Alert, st *alertState, price float64, now time.Time) *models.AlertEvent {
+	window, _ := time.ParseDuration(a.Window)
+	lo, hi := price, price
+	for _, s := range e.samples[a.Symbol] {
+		if now.Sub(s.at) > window {
+			continue
+		}
+		lo, hi = math.Min(lo, s.price), math.Max(hi, s.price)
+	}
+	up := (price - lo) / lo * 100
+	down := (hi - price) / hi * 100
+	move := 0.0
+	switch a.Direction {
+	case "up":
+		move = up
+	case "down":
+		move = -down
+	default:
+		move = up
+		if down > up {
+			move = -down
+		}
+	}
+	hit := math.Abs(move) >= a.Pct
+	wasArmed := st.armed || !st.seen
+	st.seen, st.armed = true, !hit
+	if !hit || !wasArmed {
+		return nil
+	}
+	return &models.AlertEvent{
+		Symbol:  a.Symbol,
+		Value:   r2(move),
+		Level:   a.Pct,
+		Message: fmt.Sprintf("%s moved %+.2f%% within %s to %.2f", a.Symbol, move, a.Window, price),
+	}
+}
+
+// rsiRegime classifies daily RSI(14), with the live price standing in for
+// today's close, and fires when the regime changes.
+func (e *AlertEngine) rsiRegime(a *models.Alert, st *alertState, price float64) *models.AlertEvent {
+	bars, _ := e.market.dailyHistory(a.Symbol, 0)
+	if len(bars) <= alertRSIPeriod {
+		return nil
+	}
+	closes := make([]float64, 0, len(bars)+1)
+	for _, b := range bars {
+		closes = append(closes, b.Close)
+	}
+	if exchangeDay(bars[len(bars)-1].Time) == exchangeDay(time.Now().Unix()) {
+		closes[len(closes)-1] = price
+	} else {
+		closes = append(closes, price)
+	}
+	rsi := RSI(closes, alertRSIPeriod)
+	regime := "neutral"
+	switch {
+	case rsi >= 70:
+		regime = "overbought"
+	case rsi <= 30:
+		regime = "oversold"
+	}
+	prev, seen := st.regime, st.seen
+	st.regime, st.seen = regime, true
+	if !seen || regime == prev || (a.Regime != "" && a.Regime != regime) {
+		return nil
+	}
+	return &models.AlertEvent{
+		Symbol:  a.Symbol,
+		Value:   r2(rsi),
+		Message: fmt.Sprintf("%s RSI(14) moved from %s to %s at %.1f", a.Symbol, prev, regime, rsi),
+	}
+}
+
+func (e *AlertEngine) spreadsLocked(prices []models.Price, now time.Time) map[string]models.Spread {
+	out := make(map[string]models.Spread)
+	for _, sp := range e.market.spreadsFrom(prices, now) {
+		if sp.Provenance == nil || !sp.Provenance.Synthetic {
+			out[sp.ID] = sp
+		}
+	}
+	return out
+}
+
+func spreadTouched(sp models.Spread, changed map[string]models.Price) bool {
+	for _, l := range sp.Legs {
+		if _, ok := changed[l.Symbol]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// recordSampleLocked appends a price sample for move alerts, keeping only
+// symbols that have one and only as far back as alertMaxWindow.
+func (e *AlertEngine) recordSampleLocked(symbol string, price float64, now time.Time) {
+	tracked := false
+	for _, a := range e.alerts {
+		tracked = tracked || (a.Type == alertMove && a.Symbol == symbol)
+	}
+	if !tracked {
+		delete(e.samples, symbol)
+		return
+	}
+	samples := append(e.samples[symbol], priceSample{at: now, price: price})
+	cut := 0
+	for cut < len(samples) && now.Sub(samples[cut].at) > alertMaxWindow {
+		cut++
+	}
+	e.samples[symbol] = samples[cut:]
+}
+
+// saveLocked writes the alert list to e.path via a temp file so a crash
+// mid-write can't truncate it.
+func (e *AlertEngine) saveLocked() error {
+	if e.path == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(e.alerts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode alerts: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(e.path), 0o755); err != nil {
+		return fmt.Errorf("save alerts: %w", err)
+	}
+	tmp := e.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("save alerts: %w", err)
+	}
+	if err := os.Rename(tmp, e.path); err != nil {
+		return fmt.Errorf("save alerts: %w", err)
+	}
+	return nil
+}
+
+func newID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
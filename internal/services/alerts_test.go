@@ -0,0 +1,252 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"live-oil-prices-go/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookRecorder is a test receiver that answers with the queued status
+// codes (then 200) and records each body and signature header.
+type webhookRecorder struct {
+	mu     sync.Mutex
+	codes  []int
+	bodies []models.AlertEvent
+	sigs   []string
+	raw    [][]byte
+}
+
+func (rec *webhookRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var evt models.AlertEvent
+	json.Unmarshal(body, &evt)
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.bodies = append(rec.bodies, evt)
+	rec.sigs = append(rec.sigs, r.Header.Get("X-Alert-Signature"))
+	rec.raw = append(rec.raw, body)
+	code := http.StatusOK
+	if len(rec.codes) > 0 {
+		code, rec.codes = rec.codes[0], rec.codes[1:]
+	}
+	w.WriteHeader(code)
+}
+
+func newTestAlertEngine(t *testing.T, path string) *AlertEngine {
+	t.Helper()
+	e, err := NewAlertEngine(newDeterministicMarketDataService(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.retryBase = time.Millisecond
+	// httptest receivers listen on loopback.
+	e.allowAddr = func(netip.Addr) bool { return true }
+	return e
+}
+
+func quote(symbol string, price float64) models.Price {
+	return models.Price{Symbol: symbol, Price: price, Source: "pyth", UpdatedAt: time.Now().UTC().Format(time.RFC3339Nano)}
+}
+
+func TestAlertEngine_ThresholdCrossDeliversSignedWebhook(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	e := newTestAlertEngine(t, "")
+	a, err := e.CreateAlert(models.Alert{Type: "threshold", Symbol: "wti", Direction: "above", Level: 80, WebhookURL: srv.URL, Secret: "s3cret"})
+	if err != nil {
+		t.Fatalf("CreateAlert: %v", err)
+	}
+	if a.Secret != "" || !a.Signed || a.Symbol != "WTI" {
+		t.Fatalf("expected a normalized, redacted alert, got %+v", a)
+	}
+
+	now := time.Now().UTC()
+	e.evaluate([]models.Price{quote("WTI", 79.5)}, now)                    // records the starting side
+	e.evaluate([]models.Price{quote("WTI", 80.2)}, now.Add(time.Second))   // crosses up: fires
+	e.evaluate([]models.Price{quote("WTI", 80.4)}, now.Add(2*time.Second)) // still above: quiet
+	e.evaluate([]models.Price{quote("WTI", 79.9)}, now.Add(3*time.Second)) // crosses down: wrong direction
+	e.inflight.Wait()
+
+	if len(rec.bodies) != 1 || rec.bodies[0].Value != 80.2 || rec.bodies[0].AlertID != a.ID {
+		t.Fatalf("expected exactly one webhook for the upward cross, got %+v", rec.bodies)
+	}
+	ts := strings.TrimPrefix(strings.Split(rec.sigs[0], ",")[0], "t=")
+	var tsInt int64
+	json.Unmarshal([]byte(ts), &tsInt)
+	if rec.sigs[0] != signWebhook("s3cret", tsInt, rec.raw[0]) {
+		t.Fatalf("signature doesn't verify: %q", rec.sigs[0])
+	}
+	if got, _ := e.GetAlert(a.ID); got.FireCount != 1 || got.LastFiredAt == "" {
+		t.Fatalf("expected fire bookkeeping, got %+v", got)
+	}
+	if d := e.Deliveries(a.ID); len(d) != 1 || d[0].Status != deliveryDelivered || d[0].Attempts != 1 {
+		t.Fatalf("expected one delivered entry, got %+v", d)
+	}
+}
+
+func TestAlertEngine_RetriesWithBackoffThenGivesUpOn4xx(t *testing.T) {
+	rec := &webhookRecorder{codes: []int{503, 500}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	e := newTestAlertEngine(t, "")
+	a := models.Alert{ID: "a1", WebhookURL: srv.URL}
+	e.dispatch(a, models.AlertEvent{AlertID: "a1", Message: "test"})
+	e.inflight.Wait()
+	if d := e.Deliveries("a1"); d[0].Status != deliveryDelivered || d[0].Attempts != 3 {
+		t.Fatalf("expected delivery on the third attempt, got %+v", d[0])
+	}
+
+	rec.codes = []int{404}
+	e.dispatch(a, models.AlertEvent{AlertID: "a1", Message: "gone"})
+	e.inflight.Wait()
+	if d := e.Deliveries("a1"); d[0].Status != deliveryFailed || d[0].Attempts != 1 || d[0].StatusCode != 404 {
+		t.Fatalf("expected a 404 to fail without retrying, got %+v", d[0])
+	}
+}
+
+func TestAlertEngine_MoveWithinWindow(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	e := newTestAlertEngine(t, "")
+	if _, err := e.CreateAlert(models.Alert{Type: "move", Symbol: "BRENT", Pct: 1, Window: "10m", WebhookURL: srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Now().UTC()
+	e.evaluate([]models.Price{quote("BRENT", 80)}, t0)
+	e.evaluate([]models.Price{quote("BRENT", 80.5)}, t0.Add(time.Minute))
+	e.evaluate([]models.Price{quote("BRENT", 79.1)}, t0.Add(2*time.Minute))  // −1.74% off the 80.5 high
+	e.evaluate([]models.Price{quote("BRENT", 79.0)}, t0.Add(3*time.Minute))  // still down: no re-fire
+	e.evaluate([]models.Price{quote("BRENT", 79.2)}, t0.Add(20*time.Minute)) // window rolled past the drop: re-arms
+	e.evaluate([]models.Price{quote("BRENT", 80.6)}, t0.Add(22*time.Minute)) // +1.77% off 79.2
+	e.inflight.Wait()
+
+	if len(rec.bodies) != 2 {
+		t.Fatalf("expected a fire for the drop and one for the later rebound, got %+v", rec.bodies)
+	}
+	// Deliveries run concurrently, so the receiver may see them in either order.
+	if a, b := rec.bodies[0].Value, rec.bodies[1].Value; min(a, b) != -1.74 || max(a, b) != 1.77 {
+		t.Fatalf("unexpected move values: %+v", rec.bodies)
+	}
+}
+
+func TestAlertEngine_RSIRegimeChange(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	e := newTestAlertEngine(t, "")
+	e.market.sources = NewSourceRegistry()
+	bars := trendingBars(60, 60, 0.5)
+	e.market.sources.Register(&fakeSource{name: "yahoo", caps: CapHistory, history: map[string][]models.OHLCV{"WTI": bars}}, yahooPriority)
+	if _, err := e.CreateAlert(models.Alert{Type: "rsi", Symbol: "WTI", WebhookURL: srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	e.evaluate([]models.Price{quote("WTI", 90)}, now)                  // overbought uptrend
+	e.evaluate([]models.Price{quote("WTI", 70)}, now.Add(time.Second)) // collapse
+	e.inflight.Wait()
+
+	if len(rec.bodies) != 1 || !strings.Contains(rec.bodies[0].Message, "from overbought") {
+		t.Fatalf("expected one regime-change webhook out of overbought, got %+v", rec.bodies)
+	}
+}
+
+func TestAlertEngine_ValidatesAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	e := newTestAlertEngine(t, path)
+
+	for _, bad := range []models.Alert{
+		{Type: "threshold", Symbol: "WTI", WebhookURL: "ftp://x"},
+		{Type: "threshold", Symbol: "XYZ", WebhookURL: "http://x"},
+		{Type: "move", Symbol: "WTI", Pct: 1, Window: "48h", WebhookURL: "http://x"},
+		{Type: "spread", Spread: "nope", WebhookURL: "http://x"},
+		{Type: "rsi", Symbol: "WTI", Regime: "sideways", WebhookURL: "http://x"},
+		{Type: "bogus", WebhookURL: "http://x"},
+	} {
+		if _, err := e.CreateAlert(bad); !errors.Is(err, models.ErrInvalidAlert) {
+			t.Fatalf("expected %+v to be rejected, got %v", bad, err)
+		}
+	}
+
+	a, err := e.CreateAlert(models.Alert{Type: "spread", Spread: "Brent-WTI", Level: 5, WebhookURL: "http://x", Secret: "k"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded := newTestAlertEngine(t, path)
+	list := reloaded.ListAlerts()
+	if len(list) != 1 || list[0].ID != a.ID || list[0].Spread != "brent-wti" || list[0].Direction != "cross" || !list[0].Signed {
+		t.Fatalf("expected the alert to survive a reload, got %+v", list)
+	}
+	if !reloaded.DeleteAlert(a.ID) || len(newTestAlertEngine(t, path).ListAlerts()) != 0 {
+		t.Fatalf("expected delete to persist")
+	}
+
+	for i := 0; i < alertMaxCount; i++ {
+		e.alerts = append(e.alerts, models.Alert{ID: fmt.Sprint(i)})
+	}
+	if _, err := e.CreateAlert(models.Alert{Type: "threshold", Symbol: "WTI", WebhookURL: "http://x"}); !errors.Is(err, models.ErrAlertLimit) {
+		t.Fatalf("expected the cap to hold, got %v", err)
+	}
+}
+
+// Webhooks can't be aimed at the server's own network: internal targets
+// are refused when the alert is created, and again when the connection is
+// dialled, which is what stops a name that re-resolves after validation.
+func TestAlertEngine_RefusesInternalWebhooks(t *testing.T) {
+	rec := &webhookRecorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	e, err := NewAlertEngine(newDeterministicMarketDataService(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, target := range []string{
+		"http://127.0.0.1:8080/hook", "http://169.254.169.254/latest/meta-data/", "http://10.1.2.3/",
+		"http://192.168.0.10/", "http://[::1]/", "http://[fe80::1]/", "http://localhost:9000/", "http://0.0.0.0/",
+		"http://[::ffff:127.0.0.1]/", "http://100.64.0.1/",
+	} {
+		if _, err := e.CreateAlert(models.Alert{Type: "threshold", Symbol: "WTI", WebhookURL: target}); !errors.Is(err, models.ErrInvalidAlert) {
+			t.Fatalf("expected %s to be refused, got %v", target, err)
+		}
+	}
+	if !publicAddr(netip.MustParseAddr("203.0.113.7")) {
+		t.Fatal("a public address should be allowed")
+	}
+
+	e.retryBase = time.Millisecond
+	e.dispatch(models.Alert{ID: "a1", WebhookURL: srv.URL + "/secret-path"}, models.AlertEvent{AlertID: "a1"})
+	e.inflight.Wait()
+	d := e.Deliveries("a1")
+	if len(rec.bodies) != 0 || d[0].Status != deliveryFailed || !strings.Contains(d[0].Error, errWebhookDestination.Error()) {
+		t.Fatalf("expected the dial to be refused, got %d deliveries and %+v", len(rec.bodies), d[0])
+	}
+	if strings.Contains(d[0].Error, "secret-path") {
+		t.Fatalf("delivery error leaks the webhook URL: %q", d[0].Error)
+	}
+}
+
+func TestRedactWebhook(t *testing.T) {
+	if got := redactWebhook("https://hooks.slack.com/services/T0/B0/XYZ?x=1"); got != "https://hooks.slack.com/..." {
+		t.Fatalf("got %q", got)
+	}
+	a := redact(models.Alert{WebhookURL: "https://example.com/hook/abc", Secret: "k"})
+	if a.WebhookURL != "https://example.com/..." || a.Secret != "" || !a.Signed {
+		t.Fatalf("got %+v", a)
+	}
+}
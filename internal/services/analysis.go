@@ -0,0 +1,302 @@
+package services
+
+import (
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NewsSource is the slice of NewsFeedService that GetAnalysis needs for
+// its news-flow key point.
+type NewsSource interface {
+	GetNews() []models.NewsArticle
+}
+
+// SetNews attaches the news feed used for category counts in GetAnalysis.
+// Optional: without it the news key point is simply omitted.
+func (s *MarketDataService) SetNews(n NewsSource) {
+	s.news = n
+}
+
+// analysisNewsWindow is how far back the news-flow key point counts.
+const analysisNewsWindow = 24 * time.Hour
+
+// Sentiment thresholds on the 0–100 score. The band between them is
+// "neutral" so a market drifting sideways isn't labelled with a direction.
+const (
+	bullishScore = 60.0
+	bearishScore = 40.0
+)
+
+// GetAnalysis derives the market overview from live data: technicals come
+// from the cached WTI forecast, the sentiment score aggregates every real
+// forecast, and each key point is generated from an actual reading (movers,
+// Brent–WTI spread, EIA outlook, news flow). Nothing is canned — when the
+// inputs aren't loaded yet the payload says so and stays neutral.
+func (s *MarketDataService) GetAnalysis() models.MarketAnalysis {
+	now := time.Now().UTC()
+	prices := s.GetPrices()
+	_, forecasts := s.predictions()
+
+	score, agreeing, counted := sentimentScore(forecasts)
+	sentiment := sentimentLabel(score)
+
+	out := models.MarketAnalysis{
+		Sentiment: sentiment,
+		Score:     math.Round(score*10) / 10,
+		UpdatedAt: now.Format(time.RFC3339),
+	}
+
+	wti, hasWTI := forecasts["WTI"]
+	if hasWTI {
+		out.Technical = technicalSignals(wti)
+	}
+
+	bySym := make(map[string]models.Price, len(prices))
+	for _, p := range prices {
+		bySym[p.Symbol] = p
+	}
+
+	var points []string
+	points = append(points, moverPoints(prices)...)
+	if pt, ok := brentWTISpreadPoint(bySym); ok {
+		points = append(points, pt)
+	}
+	for _, sym := range []string{"WTI", "BRENT"} {
+		if pt, ok := s.consensusPoint(sym, bySym[sym]); ok {
+			points = append(points, pt)
+		}
+	}
+	if hasWTI {
+		points = append(points, fmt.Sprintf("WTI RSI(14) at %.1f (%s); %s", wti.RSI14, LabelRSI(wti.RSI14), wtiMALabel(wti)))
+	}
+	if pt, ok := s.newsFlowPoint(now); ok {
+		points = append(points, pt)
+	}
+	if len(points) == 0 {
+		points = append(points, "Live market data is still loading; key points will populate once quotes and history arrive.")
+	}
+	out.KeyPoints = points
+
+	out.Summary = analysisSummary(sentiment, score, counted, agreeing, bySym["WTI"], wti, hasWTI)
+	return out
+}
+
+// sentimentScore blends each real forecast into a 0–100 score: 50 is
+// neutral, 100 is every symbol maximally bullish. Per symbol we weight the
+// forecast direction by its backtest-derived confidence (50%), RSI momentum
+// (30%) and the MACD histogram sign (20%). counted is how many symbols had a
+// forecast; agreeing is how many of those point the same way as the score.
+func sentimentScore(forecasts map[string]ForecastResult) (score float64, agreeing, counted int) {
+	if len(forecasts) == 0 {
+		return 50, 0, 0
+	}
+	var sum float64
+	dirs := make([]float64, 0, len(forecasts))
+	for _, f := range forecasts {
+		dir := directionSign(f.Direction)
+		momentum := clamp((f.RSI14-50)/50, -1, 1)
+		macd := 0.0
+		switch {
+		case f.MACDHist > 0:
+			macd = 1
+		case f.MACDHist < 0:
+			macd = -1
+		}
+		sum += 0.5*dir*f.Confidence + 0.3*momentum + 0.2*macd
+		dirs = append(dirs, dir)
+	}
+	score = clamp(50+50*sum/float64(len(forecasts)), 0, 100)
+	want := directionSign(sentimentLabel(score))
+	for _, d := range dirs {
+		if d == want {
+			agreeing++
+		}
+	}
+	return score, agreeing, len(forecasts)
+}
+
+func directionSign(label string) float64 {
+	switch label {
+	case "bullish":
+		return 1
+	case "bearish":
+		return -1
+	}
+	return 0
+}
+
+func sentimentLabel(score float64) string {
+	switch {
+	case score >= bullishScore:
+		return "bullish"
+	case score <= bearishScore:
+		return "bearish"
+	}
+	return "neutral"
+}
+
+// technicalSignals maps a forecast's indicator readings onto the API's
+// technical block. Signal is the forecast direction expressed as an action.
+func technicalSignals(f ForecastResult) models.TechnicalSignals {
+	signal := "hold"
+	switch f.Direction {
+	case "bullish":
+		signal = "buy"
+	case "bearish":
+		signal = "sell"
+	}
+	return models.TechnicalSignals{
+		RSI:          math.Round(f.RSI14*10) / 10,
+		MACD:         LabelMACD(f.MACDHist),
+		Signal:       signal,
+		MovingAvg50:  r2(f.MA50),
+		MovingAvg200: r2(f.MA200),
+		Trend:        f.Trend,
+	}
+}
+
+func wtiMALabel(f ForecastResult) string {
+	if label := LabelMAConfig(f.MA50, f.MA200); label != "" {
+		return label
+	}
+	return "not enough history for the 200-day average"
+}
+
+// moverPoints lists the three biggest real movers by absolute percentage
+// change. Synthetic estimates are skipped: their "moves" are noise.
+func moverPoints(prices []models.Price) []string {
+	var real []models.Price
+	for _, p := range prices {
+		if p.Source != "estimate" && p.Price > 0 {
+			real = append(real, p)
+		}
+	}
+	sort.SliceStable(real, func(i, j int) bool {
+		return math.Abs(real[i].ChangePct) > math.Abs(real[j].ChangePct)
+	})
+	if len(real) > 3 {
+		real = real[:3]
+	}
+	out := make([]string, 0, len(real))
+	for _, p := range real {
+		verb := "unchanged at"
+		switch {
+		case p.ChangePct > 0:
+			verb = fmt.Sprintf("up %.2f%% to", p.ChangePct)
+		case p.ChangePct < 0:
+			verb = fmt.Sprintf("down %.2f%% to", -p.ChangePct)
+		}
+		out = append(out, fmt.Sprintf("%s %s $%.2f on the session", p.Name, verb, p.Price))
+	}
+	return out
+}
+
+// brentWTISpreadPoint reports the Brent–WTI spread and its move since the
+// prior close (each leg's price minus its change).
+func brentWTISpreadPoint(bySym map[string]models.Price) (string, bool) {
+	brent, okB := bySym["BRENT"]
+	wti, okW := bySym["WTI"]
+	if !okB || !okW || brent.Source == "estimate" || wti.Source == "estimate" || brent.Price <= 0 || wti.Price <= 0 {
+		return "", false
+	}
+	spread := brent.Price - wti.Price
+	prev := (brent.Price - brent.Change) - (wti.Price - wti.Change)
+	delta := spread - prev
+	move := "unchanged on the day"
+	switch {
+	case delta >= 0.005:
+		move = fmt.Sprintf("widened $%.2f on the day", delta)
+	case delta <= -0.005:
+		move = fmt.Sprintf("narrowed $%.2f on the day", -delta)
+	}
+	return fmt.Sprintf("Brent–WTI spread at $%.2f/bbl, %s", spread, move), true
+}
+
+// consensusPoint compares the EIA STEO's next forecast month with the
+// current price.
+func (s *MarketDataService) consensusPoint(symbol string, current models.Price) (string, bool) {
+	cf, ok := s.GetConsensusForecast(symbol)
+	if !ok || len(cf.Months) == 0 || current.Price <= 0 {
+		return "", false
+	}
+	next := cf.Months[0]
+	pct := (next.Value - current.Price) / current.Price * 100
+	side := "above"
+	if pct < 0 {
+		side = "below"
+	}
+	return fmt.Sprintf("EIA STEO sees %s averaging $%.2f in %s, %.1f%% %s the current $%.2f",
+		symbol, next.Value, next.Period, math.Abs(pct), side, current.Price), true
+}
+
+// newsFlowPoint counts articles per category over analysisNewsWindow.
+func (s *MarketDataService) newsFlowPoint(now time.Time) (string, bool) {
+	if s.news == nil {
+		return "", false
+	}
+	counts := make(map[string]int)
+	total := 0
+	for _, a := range s.news.GetNews() {
+		ts, err := time.Parse(time.RFC3339, a.PublishedAt)
+		if err != nil || now.Sub(ts) > analysisNewsWindow || a.Category == "" {
+			continue
+		}
+		counts[a.Category]++
+		total++
+	}
+	if total == 0 {
+		return "", false
+	}
+	cats := make([]string, 0, len(counts))
+	for c := range counts {
+		cats = append(cats, c)
+	}
+	sort.Slice(cats, func(i, j int) bool {
+		if counts[cats[i]] != counts[cats[j]] {
+			return counts[cats[i]] > counts[cats[j]]
+		}
+		return cats[i] < cats[j]
+	})
+	if len(cats) > 4 {
+		cats = cats[:4]
+	}
+	parts := make([]string, len(cats))
+	for i, c := range cats {
+		parts[i] = fmt.Sprintf("%s %d", c, counts[c])
+	}
+	return fmt.Sprintf("News flow, last 24h: %d articles (%s)", total, strings.Join(parts, ", ")), true
+}
+
+func analysisSummary(sentiment string, score float64, counted, agreeing int, wtiPrice models.Price, wti ForecastResult, hasWTI bool) string {
+	if counted == 0 {
+		return "Analysis unavailable — historical data for the forecast models hasn't loaded yet. Sentiment is held at neutral until it does."
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Aggregate sentiment across %d benchmarks is %s (score %.0f/100)", counted, sentiment, score)
+	if sentiment != "neutral" {
+		fmt.Fprintf(&b, ", with %d of %d forecasts pointing the same way", agreeing, counted)
+	}
+	b.WriteString(".")
+	if hasWTI {
+		price := wti.Current
+		if wtiPrice.Price > 0 {
+			price = wtiPrice.Price
+		}
+		fmt.Fprintf(&b, " WTI trades near $%.2f in %s %s; RSI(14) is %.1f and the MACD histogram is %s.",
+			price, articleFor(wti.Trend), wti.Trend, wti.RSI14, LabelMACD(wti.MACDHist))
+		fmt.Fprintf(&b, " The %d-day model projects $%.2f (80%% interval $%.2f–$%.2f).",
+			wti.HorizonDays, wti.Predicted, wti.Low, wti.High)
+	}
+	return b.String()
+}
+
+func articleFor(word string) string {
+	if word != "" && strings.ContainsRune("aeiou", rune(word[0])) {
+		return "an"
+	}
+	return "a"
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeNews []models.NewsArticle
+
+func (f fakeNews) GetNews() []models.NewsArticle { return f }
+
+// trendingBars builds n daily bars rising by step per day.
+func trendingBars(n int, start, step float64) []models.OHLCV {
+	bars := make([]models.OHLCV, n)
+	t0 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	for i := range bars {
+		c := start + step*float64(i) + 0.3*float64(i%3-1)
+		bars[i] = models.OHLCV{Time: t0 + int64(i)*86400, Open: c, High: c + 0.5, Low: c - 0.5, Close: c}
+	}
+	return bars
+}
+
+func TestGetAnalysisColdStartIsNeutral(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	a := svc.GetAnalysis()
+
+	if a.Sentiment != "neutral" || a.Score != 50 {
+		t.Fatalf("expected neutral/50 without history, got %s/%.1f", a.Sentiment, a.Score)
+	}
+	if len(a.KeyPoints) == 0 || a.UpdatedAt == "" {
+		t.Fatalf("expected placeholder key point and timestamp, got %+v", a)
+	}
+	if strings.Contains(a.Summary, "golden cross") {
+		t.Fatalf("summary must not carry canned copy: %q", a.Summary)
+	}
+}
+
+func TestGetAnalysisDerivesFromData(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	svc.sources.Register(&fakeSource{
+		name: "yahoo",
+		caps: CapQuotes | CapHistory,
+		quotes: map[string]models.Price{
+			"WTI":   {Symbol: "WTI", Name: "WTI Crude Oil", Price: 91.5, Change: 1.8, ChangePct: 2.01, Source: "yahoo"},
+			"BRENT": {Symbol: "BRENT", Name: "Brent Crude Oil", Price: 95.4, Change: 1.5, ChangePct: 1.6, Source: "yahoo"},
+		},
+		history: map[string][]models.OHLCV{
+			"WTI":     trendingBars(260, 60, 0.12),
+			"BRENT":   trendingBars(260, 64, 0.12),
+			"NATGAS":  trendingBars(260, 2, 0.005),
+			"HEATING": trendingBars(260, 2, 0.004),
+		},
+	}, yahooPriority)
+	now := time.Now().UTC()
+	svc.SetNews(fakeNews{
+		{Category: "OPEC", PublishedAt: now.Add(-time.Hour).Format(time.RFC3339)},
+		{Category: "OPEC", PublishedAt: now.Add(-2 * time.Hour).Format(time.RFC3339)},
+		{Category: "Refining", PublishedAt: now.Add(-3 * time.Hour).Format(time.RFC3339)},
+		{Category: "Refining", PublishedAt: now.Add(-72 * time.Hour).Format(time.RFC3339)},
+	})
+
+	a := svc.GetAnalysis()
+
+	if a.Sentiment != "bullish" || a.Score <= bullishScore {
+		t.Fatalf("expected a steady uptrend to score bullish, got %s/%.1f", a.Sentiment, a.Score)
+	}
+	if a.Technical.Trend != "uptrend" || a.Technical.MovingAvg50 <= a.Technical.MovingAvg200 {
+		t.Fatalf("expected WTI technicals from history, got %+v", a.Technical)
+	}
+	joined := strings.Join(a.KeyPoints, "\n")
+	for _, want := range []string{
+		"WTI Crude Oil up 2.01% to $91.50",
+		"Brent–WTI spread at $3.90/bbl, narrowed $0.30",
+		"News flow, last 24h: 3 articles (OPEC 2, Refining 1)",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("expected key point %q in:\n%s", want, joined)
+		}
+	}
+	if !strings.Contains(a.Summary, "$91.50") {
+		t.Fatalf("expected summary to quote the live WTI price, got %q", a.Summary)
+	}
+}
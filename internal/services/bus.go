@@ -0,0 +1,181 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/metrics"
+	"live-oil-prices-go/internal/models"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event is something a feed service tells the rest of the process about.
+// Kind names it for subscriptions and metrics.
+type Event interface {
+	Kind() string
+}
+
+// Event kinds.
+const (
+	KindQuoteUpdated     = "quote_updated"
+	KindCandleClosed     = "candle_closed"
+	KindHistoryRefreshed = "history_refreshed"
+	KindOutlookReleased  = "outlook_released"
+	KindArticlesAdded    = "articles_added"
+	KindFeedFailed       = "feed_failed"
+)
+
+// QuoteUpdated is published when a source's quote refresh brought new
+// prices. Quotes holds only the symbols that came back.
+type QuoteUpdated struct {
+	Source string
+	Quotes []models.Price
+}
+
+// CandleClosed is published when a tick source opens a new 1-minute bar,
+// closing Candle.
+type CandleClosed struct {
+	Source string
+	Symbol string
+	Candle models.PythCandle
+}
+
+// HistoryRefreshed is published when a source stored new bars: daily
+// ("1d") or intraday (e.g. "5m") history for Symbols.
+type HistoryRefreshed struct {
+	Source   string
+	Interval string
+	Symbols  []string
+}
+
+// OutlookReleased is published when the EIA STEO values differ from the
+// ones cached before — a new monthly release, or the first fetch.
+type OutlookReleased struct {
+	Forecasts []models.ConsensusForecast
+}
+
+// ArticlesAdded is published when a news refresh finds articles that
+// weren't in the previous one, newest first. The first load is not
+// announced.
+type ArticlesAdded struct {
+	Articles []models.NewsArticle
+}
+
+// FeedFailed is published for every failed refresh cycle of a feed (see
+// the Feed* names), with how many have failed in a row.
+type FeedFailed struct {
+	Feed     string
+	Error    string
+	Failures int
+	At       time.Time
+}
+
+func (QuoteUpdated) Kind() string     { return KindQuoteUpdated }
+func (CandleClosed) Kind() string     { return KindCandleClosed }
+func (HistoryRefreshed) Kind() string { return KindHistoryRefreshed }
+func (OutlookReleased) Kind() string  { return KindOutlookReleased }
+func (ArticlesAdded) Kind() string    { return KindArticlesAdded }
+func (FeedFailed) Kind() string       { return KindFeedFailed }
+
+// busQueueSize is each subscriber's backlog. A subscriber that falls this
+// far behind loses events rather than stalling the poller that publishes.
+const busQueueSize = 256
+
+var (
+	busPublished = metrics.NewCounterVec("oilprices_bus_events_total",
+		"Events published on the internal bus, by kind.", "kind")
+	busDropped = metrics.NewCounterVec("oilprices_bus_dropped_total",
+		"Events a bus subscriber missed because its queue was full.", "subscriber", "kind")
+)
+
+// Bus is the in-process publish/subscribe channel between the feed
+// services and whatever reacts to them: the stream, the alert engine,
+// cache invalidation. Publish never blocks — each subscriber drains its
+// own queue on its own goroutine, in publish order. A nil *Bus discards
+// everything, so services built without one still work.
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[*busSub]struct{}
+}
+
+type busSub struct {
+	name  string
+	kinds []string // empty = every kind
+	ch    chan Event
+	quit  atomic.Bool   // set by cancel: skip whatever is still queued
+	done  chan struct{} // closed when the delivery goroutine returns
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*busSub]struct{})}
+}
+
+// Publish hands e to every subscriber that wants its kind.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	kind := e.Kind()
+	busPublished.Inc(kind)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		if len(sub.kinds) > 0 && !slices.Contains(sub.kinds, kind) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			busDropped.Inc(sub.name, kind)
+		}
+	}
+}
+
+// Subscribe calls fn for every event of the given kinds (all kinds when
+// none are given) until cancel is called. fn runs on a goroutine of its
+// own, one event at a time; name labels the subscriber in metrics and
+// logs. A panicking fn is logged and the subscription carries on.
+//
+// cancel drops the events still queued and returns once fn has finished
+// the one in progress, so after it nothing fn touches is in use. Calling
+// it from fn deadlocks.
+func (b *Bus) Subscribe(name string, fn func(Event), kinds ...string) (cancel func()) {
+	if b == nil {
+		return func() {}
+	}
+	sub := &busSub{name: name, kinds: kinds, ch: make(chan Event, busQueueSize), done: make(chan struct{})}
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		defer close(sub.done)
+		for e := range sub.ch {
+			if !sub.quit.Load() {
+				deliverEvent(sub.name, fn, e)
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			sub.quit.Store(true)
+			b.mu.Lock()
+			delete(b.subs, sub)
+			close(sub.ch)
+			b.mu.Unlock()
+		})
+		<-sub.done
+	}
+}
+
+func deliverEvent(name string, fn func(Event), e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("bus: %s panicked on %s: %v", name, e.Kind(), r)
+		}
+	}()
+	fn(e)
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"live-oil-prices-go/internal/models"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBusDeliversByKind(t *testing.T) {
+	bus := NewBus()
+	quotes := make(chan Event, 4)
+	all := make(chan Event, 4)
+	cancelQuotes := bus.Subscribe("quotes", func(e Event) { quotes <- e }, KindQuoteUpdated)
+	defer cancelQuotes()
+	cancelAll := bus.Subscribe("all", func(e Event) { all <- e })
+	defer cancelAll()
+
+	bus.Publish(ArticlesAdded{Articles: []models.NewsArticle{{ID: "a"}}})
+	bus.Publish(QuoteUpdated{Source: "yahoo", Quotes: []models.Price{{Symbol: "WTI", Price: 80}}})
+
+	select {
+	case e := <-quotes:
+		if q, ok := e.(QuoteUpdated); !ok || q.Quotes[0].Symbol != "WTI" {
+			t.Fatalf("quotes subscriber got %#v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("quote not delivered")
+	}
+	for _, want := range []string{KindArticlesAdded, KindQuoteUpdated} {
+		select {
+		case e := <-all:
+			if e.Kind() != want {
+				t.Fatalf("got %s, want %s (publish order)", e.Kind(), want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s not delivered", want)
+		}
+	}
+	select {
+	case e := <-quotes:
+		t.Fatalf("kind filter let %s through", e.Kind())
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// A stuck subscriber loses events; the publisher and other subscribers
+// carry on.
+func TestBusDropsForSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	release := make(chan struct{})
+	cancelSlow := bus.Subscribe("slow", func(Event) { <-release })
+	defer cancelSlow()
+	defer close(release)
+	got := make(chan Event, busQueueSize+10)
+	cancelFast := bus.Subscribe("fast", func(e Event) { got <- e })
+	defer cancelFast()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < busQueueSize+10; i++ {
+			bus.Publish(FeedFailed{Feed: FeedNews, Failures: i})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a stuck subscriber")
+	}
+	deadline := time.After(time.Second)
+	for n := 0; n < busQueueSize; n++ {
+		select {
+		case <-got:
+		case <-deadline:
+			t.Fatalf("fast subscriber got only %d events", n)
+		}
+	}
+}
+
+func TestBusSurvivesPanicsAndNil(t *testing.T) {
+	bus := NewBus()
+	got := make(chan Event, 2)
+	cancel := bus.Subscribe("flaky", func(e Event) {
+		if f, ok := e.(FeedFailed); ok && f.Failures == 1 {
+			panic("boom")
+		}
+		got <- e
+	})
+	defer cancel()
+	bus.Publish(FeedFailed{Feed: FeedEIA, Failures: 1})
+	bus.Publish(FeedFailed{Feed: FeedEIA, Failures: 2})
+	select {
+	case e := <-got:
+		if e.(FeedFailed).Failures != 2 {
+			t.Fatalf("got %#v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber stopped after a panic")
+	}
+	cancel()
+	cancel() // idempotent
+
+	var none *Bus
+	none.Publish(QuoteUpdated{})
+	none.Subscribe("x", func(Event) { t.Fatal("nil bus delivered") })()
+}
+
+func TestFeedFailuresArePublished(t *testing.T) {
+	bus := NewBus()
+	got := make(chan Event, 2)
+	defer bus.Subscribe("health", func(e Event) { got <- e }, KindFeedFailed)()
+
+	tr := newFeedTracker()
+	now := time.Now()
+	tr.record(FeedNews, nil, now, bus)
+	tr.record(FeedNews, errors.New("rss 503"), now, bus)
+	tr.record(FeedNews, errors.New("rss 503"), now, bus)
+	for want := 1; want <= 2; want++ {
+		select {
+		case e := <-got:
+			f := e.(FeedFailed)
+			if f.Feed != FeedNews || f.Error != "rss 503" || f.Failures != want || !f.At.Equal(now) {
+				t.Fatalf("got %+v", f)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("failure not published")
+		}
+	}
+}
+
+func TestHistoryRefreshInvalidatesPredictions(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.cachedPredictions = []models.Prediction{{Symbol: "WTI"}}
+	svc.cachedPredAt = time.Now()
+	svc.horizonForecasts = map[string]horizonEntry{"WTI/5": {}}
+
+	svc.invalidatePredictions(HistoryRefreshed{Source: "yahoo", Interval: interval5m, Symbols: []string{"WTI"}})
+	if svc.cachedPredAt.IsZero() || svc.horizonForecasts == nil {
+		t.Fatal("intraday bars invalidated the daily models")
+	}
+	svc.invalidatePredictions(HistoryRefreshed{Source: "yahoo", Interval: "1d", Symbols: []string{"WTI"}})
+	if !svc.cachedPredAt.IsZero() || svc.horizonForecasts != nil {
+		t.Fatal("new daily bars left the forecasts cached")
+	}
+}
+
+func TestNewArticlesAndOutlookChanges(t *testing.T) {
+	prev := []models.NewsArticle{{ID: "b"}, {ID: "a"}}
+	if got := newArticles(prev, []models.NewsArticle{{ID: "d"}, {ID: "c"}, {ID: "b"}}); len(got) != 2 || got[0].ID != "d" || got[1].ID != "c" {
+		t.Fatalf("added %+v", got)
+	}
+	if got := newArticles(nil, prev); got != nil {
+		t.Fatalf("first load announced %+v", got)
+	}
+
+	month := []models.ConsensusMonthly{{Period: "2026-11", Value: 71.5}}
+	cache := map[string]models.ConsensusForecast{"WTI": {Symbol: "WTI", ReleaseDate: "2026-10-01T00:00:00Z", Months: month}}
+	refetched := map[string]models.ConsensusForecast{"WTI": {Symbol: "WTI", ReleaseDate: "2026-10-02T00:00:00Z", Months: month}}
+	if outlookChanged(cache, refetched) {
+		t.Fatal("a refetch of the same release counted as new")
+	}
+	revised := map[string]models.ConsensusForecast{"WTI": {Symbol: "WTI", Months: []models.ConsensusMonthly{{Period: "2026-11", Value: 69}}}}
+	if !outlookChanged(cache, revised) || !outlookChanged(nil, cache) {
+		t.Fatal("revised values not detected")
+	}
+}
+
+func TestBusCancelWaitsForSubscriber(t *testing.T) {
+	bus := NewBus()
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls, finished atomic.Int32
+	cancel := bus.Subscribe("slow", func(Event) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		finished.Add(1)
+	})
+	for i := 0; i < 3; i++ {
+		bus.Publish(FeedFailed{Feed: FeedNews, Failures: i})
+	}
+	<-started
+
+	cancelled := make(chan struct{})
+	go func() {
+		cancel()
+		close(cancelled)
+	}()
+	select {
+	case <-cancelled:
+		t.Fatal("cancel returned while the subscriber was still running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-cancelled
+	// The two events still queued were dropped, not delivered.
+	if n := finished.Load(); n != 1 {
+		t.Fatalf("subscriber ran %d times, want 1", n)
+	}
+	cancel() // idempotent
+}
+
+func TestStreamOpenedAfterStopDoesNotSubscribe(t *testing.T) {
+	svc := NewMarketDataService(nil)
+	svc.Stop()
+	_, _, cancel := svc.Subscribe(nil, 0)
+	defer cancel()
+	if n := len(svc.unsubscribe); n != 0 {
+		t.Fatalf("%d bus subscriptions left after Stop", n)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/config"
+	"net/url"
+	"time"
+)
+
+// Yahoo poll cadences, set by Configure.
+var (
+	yahooQuoteEvery    time.Duration
+	yahooHistoryEvery  time.Duration
+	yahooIntradayEvery time.Duration
+)
+
+// Yahoo chart and Google News search prefixes, set by Configure: the
+// symbol or the escaped query is appended.
+var (
+	yahooChartURL string
+	gnewsBase     string
+)
+
+func init() {
+	Configure(config.Default())
+}
+
+// Configure derives every feed's symbol table from cfg's instrument
+// catalogue and applies its upstream URLs, news feeds and poll
+// intervals. It is not safe to call while services are running: call it
+// once at startup, before constructing any service. cfg is assumed
+// validated.
+func Configure(cfg config.Config) {
+	names := make(map[string]string, len(cfg.Instruments))
+	units := make(map[string]string, len(cfg.Instruments))
+	commodities := make([]commodity, 0, len(cfg.Instruments))
+	var (
+		yahoo []yahooSymbol
+		pyth  []pythFeed
+		eia   []eiaSymbol
+		preds []predictionSymbol
+	)
+	for _, in := range cfg.Instruments {
+		names[in.Symbol] = in.Name
+		units[in.Symbol] = in.Unit
+		commodities = append(commodities, commodity{symbol: in.Symbol, name: in.Name, basePrice: in.BasePrice})
+		if in.Yahoo != "" {
+			yahoo = append(yahoo, yahooSymbol{internal: in.Symbol, yahoo: in.Yahoo, name: in.Name})
+		}
+		if in.PythFeedID != "" {
+			pyth = append(pyth, pythFeed{symbol: in.Symbol, feedID: in.PythFeedID})
+		}
+		if in.EIA != nil {
+			eia = append(eia, eiaSymbol{internal: in.Symbol, series: in.EIA.Series, unit: in.Unit})
+		}
+		if in.ForecastHorizon > 0 {
+			preds = append(preds, predictionSymbol{symbol: in.Symbol, name: in.Name, horizon: in.ForecastHorizon})
+		}
+	}
+	allCommodities = commodities
+	commodityNames = names
+	symbolUnits = units
+	yahooSymbols = yahoo
+	pythFeeds = pyth
+	eiaSeries = eia
+	predictionSymbols = preds
+
+	up := cfg.Upstreams
+	yahooChartURL = up.Yahoo
+	hermesEndpoint = up.Pyth
+	eiaSTEOURL = up.EIA
+	gnewsBase = up.GoogleNews
+
+	newsFeeds = make([]feedSource, 0, len(cfg.News))
+	for _, f := range cfg.News {
+		u := f.URL
+		if u == "" {
+			u = gnewsBase + url.QueryEscape(f.Query)
+		}
+		newsFeeds = append(newsFeeds, feedSource{url: u, category: f.Category})
+	}
+
+	iv := cfg.Intervals
+	pythPollEvery = time.Duration(iv.Pyth)
+	yahooQuoteEvery = time.Duration(iv.YahooQuotes)
+	yahooHistoryEvery = time.Duration(iv.YahooHistory)
+	yahooIntradayEvery = time.Duration(iv.YahooIntraday)
+	curveRefreshInterval = time.Duration(iv.Curve)
+	newsRefreshEvery = time.Duration(iv.News)
+	eiaRefreshInterval = time.Duration(iv.EIA)
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/config"
+	"live-oil-prices-go/internal/models"
+	"testing"
+)
+
+func TestConfigureDerivesFeedTables(t *testing.T) {
+	defer Configure(config.Default())
+
+	cfg := config.Default()
+	cfg.Instruments = []models.Instrument{
+		{Symbol: "WTI", Name: "WTI Crude Oil", Unit: "USD/barrel", BasePrice: 70, Yahoo: "CL=F", ForecastHorizon: 7,
+			PythFeedID: "925ca92ff005ae943c158e3563f59698ce7e75c5a8c8dd43303a0a154887b3e6",
+			EIA:        &models.InstrumentEIA{Series: "WTIPUUS"}},
+		{Symbol: "GOLD", Name: "Gold", Unit: "USD/tonne", BasePrice: 77e6, Yahoo: "GC=F"},
+		{Symbol: "URALS", Name: "Urals Crude", Unit: "USD/barrel", BasePrice: 60},
+	}
+	Configure(cfg)
+
+	if len(allCommodities) != 3 || allCommodities[2].symbol != "URALS" || commodityNames["GOLD"] != "Gold" {
+		t.Fatalf("commodities: %+v", allCommodities)
+	}
+	if len(yahooSymbols) != 2 || yahooSymbols[1].yahoo != "GC=F" || yahooSymbols[1].name != "Gold" {
+		t.Fatalf("yahoo: %+v", yahooSymbols)
+	}
+	if len(pythFeeds) != 1 || len(eiaSeries) != 1 || eiaSeries[0].unit != "USD/barrel" {
+		t.Fatalf("pyth %+v eia %+v", pythFeeds, eiaSeries)
+	}
+	if len(predictionSymbols) != 1 || predictionSymbols[0].horizon != 7 {
+		t.Fatalf("predictions: %+v", predictionSymbols)
+	}
+	if symbolUnits["GOLD"] != unitTonne {
+		t.Fatalf("units: %+v", symbolUnits)
+	}
+}
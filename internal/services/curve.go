@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/store"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Forward-curve tracking. Yahoo's continuous tickers (CL=F, BZ=F, ...) only
+// follow the front month, so for the curve we poll each listed contract
+// month individually (CLZ26.NYM, CLF27.NYM, ...) and keep the next
+// curveDepth live ones.
+const (
+	curveDepth = 12
+	// curveCandidates is how many months ahead of next month we probe.
+	// The first one or two may already have expired (Brent rolls two
+	// months out), hence the headroom over curveDepth.
+	curveCandidates = curveDepth + 3
+	// curveFreshness drops contracts with no trade for this long: they
+	// aren't listed yet or expired long ago.
+	curveFreshness = 5 * 24 * time.Hour
+	// curveExpiredLag: a leading contract whose last trade trails the
+	// freshest contract by more than this has expired.
+	curveExpiredLag = 18 * time.Hour
+	// curveHistoryDays bounds the per-day curve snapshots kept in memory.
+	curveHistoryDays = 730
+	// curveFlatPct is the |slope| under which a curve is called flat.
+	curveFlatPct = 0.5
+)
+
+// curveRefreshInterval is set by Configure. Deferred months are thin and
+// the metrics are about shape, not ticks — the ten-minute default is
+// plenty.
+var curveRefreshInterval time.Duration
+
+// monthCodes are the CME futures month letters, January first.
+const monthCodes = "FGHJKMNQUVXZ"
+
+// contractTicker builds Yahoo's ticker for one contract month, e.g.
+// ("CL", 2026, December) → "CLZ26.NYM". Every root we track (including
+// NYMEX Brent, BZ) is listed on NYMEX.
+func contractTicker(root string, year int, month time.Month) string {
+	return fmt.Sprintf("%s%c%02d.NYM", root, monthCodes[month-1], year%100)
+}
+
+// curveRoot is the futures root behind a continuous ticker: "CL=F" → "CL".
+func curveRoot(ys yahooSymbol) string {
+	return strings.TrimSuffix(ys.yahoo, "=F")
+}
+
+func (s *YahooFinanceService) curveLoop(ctx context.Context) {
+	s.refreshCurves(ctx)
+	every(ctx, curveRefreshInterval, s.refreshCurves)
+}
+
+// refreshCurves fetches the strip for every Yahoo symbol in parallel,
+// replaces the cached curves and records today's snapshot.
+func (s *YahooFinanceService) refreshCurves(ctx context.Context) {
+	type result struct {
+		symbol string
+		points []models.CurvePoint
+	}
+	var wg sync.WaitGroup
+	results := make(chan result, len(yahooSymbols))
+	now := time.Now()
+	for _, sym := range yahooSymbols {
+		wg.Add(1)
+		go func(ys yahooSymbol) {
+			defer wg.Done()
+			if pts := s.fetchCurve(ctx, ys, now); len(pts) > 0 {
+				results <- result{symbol: ys.internal, points: pts}
+			}
+		}(sym)
+	}
+	wg.Wait()
+	close(results)
+	if ctx.Err() != nil {
+		// Cut short by Stop: keep the previous curves rather than
+		// replacing them with whatever months were probed in time.
+		return
+	}
+
+	snapshots := make(map[string]models.CurveSnapshot)
+	s.mu.Lock()
+	if s.curves == nil {
+		s.curves = make(map[string][]models.CurvePoint)
+		s.curveHistory = make(map[string][]models.CurveSnapshot)
+	}
+	for r := range results {
+		s.curves[r.symbol] = r.points
+		snap := curveSnapshotOf(r.points)
+		s.curveHistory[r.symbol] = upsertSnapshot(s.curveHistory[r.symbol], snap)
+		snapshots[r.symbol] = snap
+	}
+	s.mu.Unlock()
+
+	s.persistCurves(snapshots)
+}
+
+// fetchCurve probes each candidate contract month in turn. Unlisted and
+// long-expired months either error or carry a stale market time and are
+// skipped; a just-expired front month is trimmed by comparing it with the
+// freshest contract.
+func (s *YahooFinanceService) fetchCurve(ctx context.Context, ys yahooSymbol, now time.Time) []models.CurvePoint {
+	root := curveRoot(ys)
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+
+	var points []models.CurvePoint
+	var asOfs []time.Time
+	for i := 0; i < curveCandidates && ctx.Err() == nil; i++ {
+		m := first.AddDate(0, i, 0)
+		ticker := contractTicker(root, m.Year(), m.Month())
+		done := timeUpstream("yahoo", "curve")
+		q, err := s.fetchQuote(ctx, yahooSymbol{internal: ys.internal, yahoo: ticker, name: ys.name})
+		done(err)
+		if err != nil {
+			continue
+		}
+		asOf, err := time.Parse(time.RFC3339, q.UpdatedAt)
+		if err != nil || q.Price <= 0 || now.Sub(asOf) > curveFreshness {
+			continue
+		}
+		points = append(points, models.CurvePoint{
+			Contract:  fmt.Sprintf("%s %d", m.Month().String()[:3], m.Year()),
+			Ticker:    ticker,
+			Month:     m.Format("2006-01"),
+			Price:     q.Price,
+			Change:    q.Change,
+			UpdatedAt: q.UpdatedAt,
+		})
+		asOfs = append(asOfs, asOf)
+	}
+	if len(points) == 0 {
+		if ctx.Err() == nil {
+			log.Printf("yahoo: no live contract months for %s curve", ys.internal)
+		}
+		return nil
+	}
+	return trimCurve(points, asOfs)
+}
+
+// trimCurve drops leading contracts whose last trade trails the freshest
+// contract by more than curveExpiredLag, then caps the strip at curveDepth.
+func trimCurve(points []models.CurvePoint, asOfs []time.Time) []models.CurvePoint {
+	var freshest time.Time
+	for _, t := range asOfs {
+		if t.After(freshest) {
+			freshest = t
+		}
+	}
+	start := 0
+	for start < len(points) && freshest.Sub(asOfs[start]) > curveExpiredLag {
+		start++
+	}
+	points = points[start:]
+	if len(points) > curveDepth {
+		points = points[:curveDepth]
+	}
+	return points
+}
+
+// curveSnapshotOf stamps a curve with the exchange trading day of its
+// newest quote, so intraday refreshes overwrite the same day's snapshot.
+func curveSnapshotOf(points []models.CurvePoint) models.CurveSnapshot {
+	var newest int64
+	prices := make([]float64, len(points))
+	for i, p := range points {
+		prices[i] = p.Price
+		if t, err := time.Parse(time.RFC3339, p.UpdatedAt); err == nil && t.Unix() > newest {
+			newest = t.Unix()
+		}
+	}
+	day, _ := time.ParseInLocation("2006-01-02", exchangeDay(newest), nyTZ)
+	return models.CurveSnapshot{Time: day.Unix(), Prices: prices}
+}
+
+// upsertSnapshot replaces or appends snap, keeping history oldest-first and
+// bounded to curveHistoryDays.
+func upsertSnapshot(history []models.CurveSnapshot, snap models.CurveSnapshot) []models.CurveSnapshot {
+	if n := len(history); n > 0 && history[n-1].Time == snap.Time {
+		history[n-1] = snap
+	} else {
+		history = append(history, snap)
+	}
+	if len(history) > curveHistoryDays {
+		history = history[len(history)-curveHistoryDays:]
+	}
+	return history
+}
+
+// curveSeries is the store key for one tenor's daily history — the M1
+// series is whichever contract was front month that day.
+func curveSeries(symbol string, tenor int) string {
+	return store.Series("yahoo", symbol, fmt.Sprintf("curve-m%d", tenor))
+}
+
+func (s *YahooFinanceService) persistCurves(snapshots map[string]models.CurveSnapshot) {
+	if s.store == nil {
+		return
+	}
+	for sym, snap := range snapshots {
+		for i, p := range snap.Prices {
+			bar := models.OHLCV{Time: snap.Time, Open: p, High: p, Low: p, Close: p}
+			if err := s.store.UpsertBars(curveSeries(sym, i+1), []models.OHLCV{bar}); err != nil {
+				log.Printf("yahoo: persist curve for %s: %v", sym, err)
+				break
+			}
+		}
+	}
+}
+
+// restoreCurvesLocked rebuilds the per-day curve history from the tenor
+// series. A day keeps its tenors up to the first gap. Caller holds s.mu.
+func (s *YahooFinanceService) restoreCurvesLocked() {
+	since := time.Now().AddDate(0, 0, -curveHistoryDays)
+	for _, ys := range yahooSymbols {
+		byDay := make(map[int64][]float64)
+		for tenor := 1; tenor <= curveDepth; tenor++ {
+			bars, err := s.store.LoadBars(curveSeries(ys.internal, tenor), since)
+			if err != nil {
+				log.Printf("yahoo: restore curve for %s: %v", ys.internal, err)
+				break
+			}
+			for _, b := range bars {
+				if prices := byDay[b.Time]; len(prices) == tenor-1 {
+					byDay[b.Time] = append(prices, b.Close)
+				}
+			}
+		}
+		if len(byDay) == 0 {
+			continue
+		}
+		history := make([]models.CurveSnapshot, 0, len(byDay))
+		for day, prices := range byDay {
+			history = append(history, models.CurveSnapshot{Time: day, Prices: prices})
+		}
+		sort.Slice(history, func(i, j int) bool { return history[i].Time < history[j].Time })
+		s.curveHistory[ys.internal] = history
+	}
+}
+
+// frontContract labels the front month from the cached curve, e.g.
+// "Dec 2026 Contract". Empty until the first curve refresh lands.
+func (s *YahooFinanceService) frontContract(symbol string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if c := s.curves[symbol]; len(c) > 0 {
+		return c[0].Contract + " Contract"
+	}
+	return ""
+}
+
+// CurveSource implementation.
+
+func (s *YahooFinanceService) Curve(symbol string) []models.CurvePoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.CurvePoint(nil), s.curves[symbol]...)
+}
+
+func (s *YahooFinanceService) CurveHistory(symbol string, since time.Time) []models.CurveSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []models.CurveSnapshot
+	for _, snap := range s.curveHistory[symbol] {
+		if snap.Time >= since.Unix() {
+			snap.Prices = append([]float64(nil), snap.Prices...)
+			out = append(out, snap)
+		}
+	}
+	return out
+}
+
+// curveMetrics summarises a strip given front month first.
+func curveMetrics(prices []float64) models.CurveMetrics {
+	m := models.CurveMetrics{Structure: "flat"}
+	if len(prices) < 2 || prices[0] <= 0 || prices[1] <= 0 {
+		return m
+	}
+	front, back := prices[0], prices[len(prices)-1]
+	m.M1M2 = r4(prices[1] - front)
+	if len(prices) >= 6 {
+		v := r4(prices[5] - front)
+		m.M1M6 = &v
+	}
+	if len(prices) >= 12 {
+		v := r4(prices[11] - front)
+		m.M1M12 = &v
+	}
+	m.SlopePct = r2((back - front) / front * 100)
+	m.RollYieldPct = r2((front/prices[1] - 1) * 12 * 100)
+	switch {
+	case m.SlopePct > curveFlatPct:
+		m.Structure = "contango"
+	case m.SlopePct < -curveFlatPct:
+		m.Structure = "backwardation"
+	}
+	return m
+}
+
+// curveSource returns the highest-priority source with a cached curve for
+// symbol.
+func (s *MarketDataService) curveSource(symbol string) (CurveSource, []models.CurvePoint) {
+	for _, src := range s.sources.ForSymbol(symbol, CapQuotes) {
+		if cs, ok := src.(CurveSource); ok {
+			if pts := cs.Curve(symbol); len(pts) > 0 {
+				return cs, pts
+			}
+		}
+	}
+	return nil, nil
+}
+
+// GetForwardCurve returns the current futures strip for symbol with its
+// term-structure metrics and, where the EIA STEO covers a delivery month,
+// the consensus forecast alongside. ok is false when no curve is cached.
+func (s *MarketDataService) GetForwardCurve(symbol string) (models.ForwardCurve, bool) {
+	src, points := s.curveSource(symbol)
+	if src == nil {
+		return models.ForwardCurve{}, false
+	}
+
+	if cf, ok := s.GetConsensusForecast(symbol); ok {
+		byMonth := make(map[string]float64, len(cf.Months))
+		for _, m := range cf.Months {
+			byMonth[m.Period] = m.Value
+		}
+		for i := range points {
+			if v, ok := byMonth[points[i].Month]; ok {
+				points[i].Consensus = &v
+			}
+		}
+	}
+
+	prices := make([]float64, len(points))
+	var asOf time.Time
+	for i, p := range points {
+		prices[i] = p.Price
+		if t, err := time.Parse(time.RFC3339, p.UpdatedAt); err == nil && t.After(asOf) {
+			asOf = t
+		}
+	}
+	now := time.Now().UTC()
+	return models.ForwardCurve{
+		Symbol:     symbol,
+		Name:       commodityNames[symbol],
+		Unit:       symbolUnits[symbol],
+		Points:     points,
+		Metrics:    curveMetrics(prices),
+		UpdatedAt:  now.Format(time.RFC3339),
+		Provenance: stamped(newProvenance([]string{src.Name()}, time.Time{}, asOf), now),
+	}, true
+}
+
+// GetCurveHistory returns the last `days` daily curve snapshots for symbol,
+// each with its metrics, oldest first.
+func (s *MarketDataService) GetCurveHistory(symbol string, days int) (models.CurveHistory, bool) {
+	src, _ := s.curveSource(symbol)
+	if src == nil {
+		return models.CurveHistory{}, false
+	}
+	snaps := src.CurveHistory(symbol, time.Now().AddDate(0, 0, -days))
+	for i := range snaps {
+		snaps[i].Metrics = curveMetrics(snaps[i].Prices)
+	}
+	if snaps == nil {
+		snaps = []models.CurveSnapshot{}
+	}
+	return models.CurveHistory{Symbol: symbol, Snapshots: snaps}, true
+}
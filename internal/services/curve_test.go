@@ -0,0 +1,129 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/store"
+	"testing"
+	"time"
+)
+
+// fakeCurveSource adds a canned curve to fakeSource.
+type fakeCurveSource struct {
+	fakeSource
+	curves  map[string][]models.CurvePoint
+	history []models.CurveSnapshot
+}
+
+func (f *fakeCurveSource) Curve(symbol string) []models.CurvePoint {
+	return append([]models.CurvePoint(nil), f.curves[symbol]...)
+}
+func (f *fakeCurveSource) CurveHistory(string, time.Time) []models.CurveSnapshot {
+	return append([]models.CurveSnapshot(nil), f.history...)
+}
+
+func TestContractTicker(t *testing.T) {
+	if got := contractTicker("CL", 2026, time.December); got != "CLZ26.NYM" {
+		t.Fatalf("expected CLZ26.NYM, got %s", got)
+	}
+	if got := contractTicker(curveRoot(yahooSymbols[1]), 2027, time.January); got != "BZF27.NYM" {
+		t.Fatalf("expected BZF27.NYM, got %s", got)
+	}
+}
+
+func TestTrimCurve_DropsExpiredFrontAndCapsDepth(t *testing.T) {
+	now := time.Date(2026, 10, 22, 18, 0, 0, 0, time.UTC)
+	var points []models.CurvePoint
+	var asOfs []time.Time
+	for i := 0; i < curveCandidates; i++ {
+		asOf := now
+		if i == 0 {
+			asOf = now.Add(-48 * time.Hour) // expired two days ago
+		}
+		points = append(points, models.CurvePoint{Ticker: contractTicker("CL", 2026, time.Month(i%12+1))})
+		asOfs = append(asOfs, asOf)
+	}
+	got := trimCurve(points, asOfs)
+	if len(got) != curveDepth || got[0].Ticker != points[1].Ticker {
+		t.Fatalf("expected %d months starting at the second candidate, got %d starting %s", curveDepth, len(got), got[0].Ticker)
+	}
+}
+
+func TestCurveMetrics(t *testing.T) {
+	contango := curveMetrics([]float64{70, 70.5, 71, 71.4, 71.8, 72, 72.3, 72.5, 72.7, 72.9, 73, 73.5})
+	if contango.Structure != "contango" || contango.M1M2 != 0.5 || *contango.M1M6 != 2 || *contango.M1M12 != 3.5 {
+		t.Fatalf("unexpected contango metrics: %+v", contango)
+	}
+	if contango.SlopePct != 5 || contango.RollYieldPct >= 0 {
+		t.Fatalf("expected 5%% slope and negative roll yield, got %+v", contango)
+	}
+
+	back := curveMetrics([]float64{80, 79, 78})
+	if back.Structure != "backwardation" || back.M1M6 != nil || back.RollYieldPct <= 0 {
+		t.Fatalf("unexpected backwardation metrics: %+v", back)
+	}
+	if flat := curveMetrics([]float64{80, 80.1, 80.2}); flat.Structure != "flat" {
+		t.Fatalf("expected a 0.25%% slope to read flat, got %s", flat.Structure)
+	}
+}
+
+func TestGetForwardCurve_AttachesConsensusAndMetrics(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	svc.sources.Register(&fakeCurveSource{
+		fakeSource: fakeSource{name: "yahoo", caps: CapQuotes},
+		curves: map[string][]models.CurvePoint{"WTI": {
+			{Contract: "Dec 2026", Month: "2026-12", Price: 80, UpdatedAt: "2026-10-22T18:00:00Z"},
+			{Contract: "Jan 2027", Month: "2027-01", Price: 79, UpdatedAt: "2026-10-22T18:00:00Z"},
+		}},
+		history: []models.CurveSnapshot{{Time: 1, Prices: []float64{70, 71}}},
+	}, yahooPriority)
+	svc.eia = &EIAService{apiKey: "test", cache: map[string]models.ConsensusForecast{
+		"WTI": {Symbol: "WTI", Months: []models.ConsensusMonthly{{Period: "2027-01", Value: 77.5}}},
+	}}
+
+	c, ok := svc.GetForwardCurve("WTI")
+	if !ok {
+		t.Fatalf("expected a curve")
+	}
+	if c.Metrics.Structure != "backwardation" || c.Metrics.M1M2 != -1 || c.Unit != "USD/barrel" {
+		t.Fatalf("unexpected curve: %+v", c)
+	}
+	if c.Points[0].Consensus != nil || c.Points[1].Consensus == nil || *c.Points[1].Consensus != 77.5 {
+		t.Fatalf("expected consensus on the Jan 2027 point only, got %+v", c.Points)
+	}
+
+	h, ok := svc.GetCurveHistory("WTI", 30)
+	if !ok || len(h.Snapshots) != 1 || h.Snapshots[0].Metrics.Structure != "contango" {
+		t.Fatalf("expected history with metrics filled in, got %+v", h)
+	}
+	if _, ok := svc.GetForwardCurve("OPEC"); ok {
+		t.Fatalf("expected no curve for a symbol without futures")
+	}
+}
+
+func TestCurveHistory_PersistsAndRestoresByTenor(t *testing.T) {
+	st, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	day := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	points := []models.CurvePoint{{Price: 80, UpdatedAt: day}, {Price: 80.4, UpdatedAt: day}, {Price: 80.9, UpdatedAt: day}}
+	snap := curveSnapshotOf(points)
+	yahoo := &YahooFinanceService{store: st}
+	yahoo.persistCurves(map[string]models.CurveSnapshot{"WTI": snap})
+
+	restored := &YahooFinanceService{
+		store:        st,
+		historyOHLC:  map[string][]models.OHLCV{},
+		history:      map[string][]float64{},
+		intraday:     map[string]intradayBars{},
+		curveHistory: map[string][]models.CurveSnapshot{},
+	}
+	restored.restore()
+	got := restored.CurveHistory("WTI", time.Now().AddDate(0, 0, -7))
+	if len(got) != 1 || got[0].Time != snap.Time || len(got[0].Prices) != 3 || got[0].Prices[2] != 80.9 {
+		t.Fatalf("expected the snapshot back by tenor, got %+v", got)
+	}
+}
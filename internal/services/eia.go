@@ -1,14 +1,17 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/upstream"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"sync"
 	"time"
@@ -28,55 +31,79 @@ import (
 // return empty data and the UI section degrades gracefully (hidden / stub
 // message). This keeps deployments without the key unbroken.
 type EIAService struct {
-	client *http.Client
+	client *upstream.Client
 	apiKey string
 
 	mu        sync.RWMutex
 	cache     map[string]models.ConsensusForecast
 	updatedAt time.Time
+
+	// bus receives OutlookReleased when a refresh brings values that
+	// differ from the cached ones. Nil publishes nothing.
+	bus *Bus
+
+	run runGroup
 }
 
 // eiaSymbol maps our internal symbol id to the EIA STEO series id and the
 // human-readable unit string we surface in the UI.
 //
-// Series IDs verified against the EIA STEO browser
+// Default series IDs verified against the EIA STEO browser
 // (https://www.eia.gov/opendata/browser/steo). The "PUUS" / "EUUS" suffix
 // distinguishes spot prices from futures expectations; we use spot for the
 // crude benchmarks (matches the live spot prices on this site) and Henry
 // Hub spot for natural gas.
-var eiaSeries = []struct {
+type eiaSymbol struct {
 	internal string
 	series   string
 	unit     string
-}{
-	{"WTI", "WTIPUUS", "USD/barrel"},
-	{"BRENT", "BREPUUS", "USD/barrel"},
-	{"NATGAS", "NGHHMCF", "USD/MMBtu"},
 }
 
+// eiaSeries is the STEO series table, set by Configure.
+var eiaSeries []eiaSymbol
+
+// eiaRefreshInterval is how often the STEO is re-fetched, set by
+// Configure. The STEO is published monthly, so the daily default is plenty
+// fresh and very low cost.
+var eiaRefreshInterval time.Duration
+
+// eiaPolicy: the STEO changes monthly, so a slow, patient client that
+// revalidates with ETag / Last-Modified instead of re-downloading.
+var eiaPolicy = upstream.Policy{
+	Timeout:          20 * time.Second,
+	MaxAttempts:      3,
+	BaseDelay:        2 * time.Second,
+	MaxDelay:         30 * time.Second,
+	Rate:             1,
+	Burst:            3,
+	FailureThreshold: 3,
+	Cooldown:         10 * time.Minute,
+	Conditional:      true,
+}
+
+// eiaSTEOURL is the STEO data endpoint, set by Configure.
+var eiaSTEOURL string
+
 const (
-	eiaSTEOURL  = "https://api.eia.gov/v2/steo/data/"
 	eiaSourceID = "EIA STEO"
 	// We surface 6 forward months — the STEO publishes ~24 months ahead but
 	// the further-out values are increasingly speculative; 6 months is the
 	// industry-standard "actionable" window.
 	eiaForwardMonths = 6
-	// Refresh once a day. The STEO is published monthly, so daily polling
-	// is plenty fresh and very low cost.
-	eiaRefreshInterval = 24 * time.Hour
-	// First refresh delay on cold start so we don't block server startup
-	// on a network call that may need to time out.
+	// First refresh delay on cold start, so the month-old outlook doesn't
+	// compete with the price feeds while the server warms up.
 	eiaInitialDelay = 30 * time.Second
 )
 
-// NewEIAService reads EIA_API_KEY from the environment and starts a daily
-// refresh goroutine if a key is configured. Returns a non-nil service
-// either way; methods on a key-less service simply return empty data.
-func NewEIAService() *EIAService {
+// NewEIAService reads EIA_API_KEY from the environment. Returns a non-nil
+// service either way; methods on a key-less service simply return empty
+// data and Start does nothing.
+func NewEIAService(bus *Bus) *EIAService {
 	svc := &EIAService{
-		client: &http.Client{Timeout: 20 * time.Second},
+		client: upstream.New("eia", nil, eiaPolicy),
 		apiKey: os.Getenv("EIA_API_KEY"),
 		cache:  make(map[string]models.ConsensusForecast),
+		bus:    bus,
 	}
 
 	if svc.apiKey == "" {
@@ -84,24 +111,43 @@ func NewEIAService() *EIAService {
 		return svc
 	}
 
-	go svc.refreshLoop()
+	feeds.expect(FeedEIA)
 	return svc
 }
 
-func (s *EIAService) refreshLoop() {
-	time.Sleep(eiaInitialDelay)
-	s.refresh()
-	t := time.NewTicker(eiaRefreshInterval)
-	defer t.Stop()
-	for range t.C {
-		s.refresh()
+// Start begins the daily refresh, the first one eiaInitialDelay in.
+// Nil-safe, and a no-op without an API key.
+func (s *EIAService) Start(ctx context.Context) {
+	if s == nil || s.apiKey == "" {
+		return
+	}
+	s.run.start(ctx, s.refreshLoop)
+}
+
+// Stop cancels the refresh loop and waits for it. Nil-safe.
+func (s *EIAService) Stop() {
+	if s != nil {
+		s.run.stop()
 	}
 }
 
-func (s *EIAService) refresh() {
+func (s *EIAService) refreshLoop(ctx context.Context) {
+	if !sleepCtx(ctx, eiaInitialDelay) {
+		return
+	}
+	s.refresh(ctx)
+	every(ctx, eiaRefreshInterval, s.refresh)
+}
+
+func (s *EIAService) refresh(ctx context.Context) {
 	out := make(map[string]models.ConsensusForecast)
+	cycle := feeds.cycle(FeedEIA, s.bus)
+	defer cycle.end()
 	for _, sym := range eiaSeries {
-		f, err := s.fetchSeries(sym.internal, sym.series, sym.unit)
+		done := timeUpstream("eia", sym.series)
+		f, err := s.fetchSeries(ctx, sym.internal, sym.series, sym.unit)
+		done(err)
+		cycle.observe(err)
 		if err != nil {
 			log.Printf("[eia] %s (%s) refresh failed: %v", sym.internal, sym.series, err)
 			continue
@@ -114,17 +160,37 @@ func (s *EIAService) refresh() {
 		return
 	}
 	s.mu.Lock()
+	changed := outlookChanged(s.cache, out)
 	s.cache = out
 	s.updatedAt = time.Now()
 	s.mu.Unlock()
 	log.Printf("[eia] refreshed %d series", len(out))
+
+	if changed {
+		s.bus.Publish(OutlookReleased{Forecasts: orderedForecasts(out)})
+	}
+}
+
+// outlookChanged reports whether next carries different forward values
+// from prev. ReleaseDate is stamped on every fetch, so it is ignored.
+func outlookChanged(prev, next map[string]models.ConsensusForecast) bool {
+	if len(prev) != len(next) {
+		return true
+	}
+	for sym, f := range next {
+		p, ok := prev[sym]
+		if !ok || !slices.Equal(p.Months, f.Months) {
+			return true
+		}
+	}
+	return false
 }
 
 // fetchSeries pulls the next eiaForwardMonths months of forecast values for
 // a single STEO series. The API returns historical and forecast points in
 // the same response; we filter to dates >= today so we only surface forward
 // expectations.
-func (s *EIAService) fetchSeries(internal, series, unit string) (models.ConsensusForecast, error) {
+func (s *EIAService) fetchSeries(ctx context.Context, internal, series, unit string) (models.ConsensusForecast, error) {
 	now := time.Now().UTC()
 	start := now.Format("2006-01")
 	end := now.AddDate(0, eiaForwardMonths+1, 0).Format("2006-01")
@@ -142,7 +208,7 @@ func (s *EIAService) fetchSeries(internal, series, unit string) (models.Consensu
 	q.Set("length", strconv.Itoa(eiaForwardMonths+2))
 
 	reqURL := eiaSTEOURL + "?" + q.Encode()
-	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return models.ConsensusForecast{}, err
 	}
@@ -205,9 +271,14 @@ func (s *EIAService) GetAll() []models.ConsensusForecast {
 	}
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	out := make([]models.ConsensusForecast, 0, len(s.cache))
+	return orderedForecasts(s.cache)
+}
+
+// orderedForecasts lists cache in eiaSeries order.
+func orderedForecasts(cache map[string]models.ConsensusForecast) []models.ConsensusForecast {
+	out := make([]models.ConsensusForecast, 0, len(cache))
 	for _, sym := range eiaSeries {
-		if v, ok := s.cache[sym.internal]; ok {
+		if v, ok := cache[sym.internal]; ok {
 			out = append(out, v)
 		}
 	}
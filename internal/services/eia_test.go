@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/upstream"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -17,7 +19,7 @@ func TestEIANoKeyDegradesGracefully(t *testing.T) {
 	os.Unsetenv("EIA_API_KEY")
 	defer os.Setenv("EIA_API_KEY", prev)
 
-	svc := NewEIAService()
+	svc := NewEIAService(nil)
 	if svc == nil {
 		t.Fatal("expected non-nil service even without API key")
 	}
@@ -54,18 +56,15 @@ func TestEIAFetchSeriesParsesResponse(t *testing.T) {
 	defer ts.Close()
 
 	svc := &EIAService{
-		client: ts.Client(),
+		client: upstream.New("eia", ts.Client(), upstream.Policy{}),
 		apiKey: "test-key",
 		cache:  make(map[string]models.ConsensusForecast),
 	}
-	// Override the URL by hand: fetchSeries hardcodes eiaSTEOURL, so we
-	// can't easily inject a custom host. Instead exercise the parse by
-	// pointing the client's transport at the test server.
-	// The client.Do will still hit eiaSTEOURL, so use a roundtripper
-	// rewrite below.
-	svc.client = &http.Client{Transport: rewriteTransport{target: ts.URL}}
+	prevURL := eiaSTEOURL
+	eiaSTEOURL = ts.URL + "/v2/steo/data/"
+	defer func() { eiaSTEOURL = prevURL }()
 
-	got, err := svc.fetchSeries("WTI", "WTIPUUS", "USD/barrel")
+	got, err := svc.fetchSeries(context.Background(), "WTI", "WTIPUUS", "USD/barrel")
 	if err != nil {
 		t.Fatalf("fetchSeries: %v", err)
 	}
@@ -88,16 +87,3 @@ func TestEIAFetchSeriesParsesResponse(t *testing.T) {
 		}
 	}
 }
-
-// rewriteTransport rewrites every request URL to point at `target`,
-// preserving the path and query. Lets us point a hardcoded HTTPS endpoint
-// at an httptest server without changing production code.
-type rewriteTransport struct{ target string }
-
-func (rt rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
-	clone := r.Clone(r.Context())
-	clone.URL, _ = clone.URL.Parse(rt.target + r.URL.Path + "?" + r.URL.RawQuery)
-	clone.Host = clone.URL.Host
-	clone.RequestURI = ""
-	return http.DefaultTransport.RoundTrip(clone)
-}
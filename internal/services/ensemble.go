@@ -0,0 +1,369 @@
+package services
+
+import (
+	"fmt"
+	"math"
+)
+
+// forecastHorizons are the trading-day horizons /api/predictions/{symbol}
+// serves: next day, a week, a month and a quarter.
+var forecastHorizons = []int{1, 5, 20, 60}
+
+// forecastModel is one point-forecast method in the ensemble. predict fits
+// on train (oldest first) and returns the forecast h steps past its end.
+type forecastModel struct {
+	name    string
+	predict func(train []float64, h int) float64
+}
+
+// ensembleModels are the ensemble's candidate members. Each is cheap
+// enough to refit at every backtest origin.
+var ensembleModels = []forecastModel{
+	{"holt-damped", holtDampedPredict},
+	{"arima", arimaPredict},
+	{"theta", thetaPredict},
+	{"seasonal-naive", seasonalNaivePredict},
+}
+
+// ModelResult is one ensemble member's forecast and backtest score.
+type ModelResult struct {
+	Name      string
+	Predicted float64
+	MAPE      float64
+	// Weight is the member's share of the ensemble; 0 when it failed to
+	// beat the naive forecast on the backtest.
+	Weight float64
+}
+
+// EnsembleResult is the ensemble forecast at one horizon.
+type EnsembleResult struct {
+	Current     float64
+	Predicted   float64
+	Low         float64 // 80% band from the GARCH variance forecast
+	High        float64
+	HorizonDays int
+	// MAPE, NaiveMAPE and Skill score the weighted combination on the same
+	// backtest origins as its members.
+	MAPE          float64
+	NaiveMAPE     float64
+	Skill         float64
+	BacktestSteps int
+	Models        []ModelResult
+	// Volatility is the GARCH(1,1) daily return volatility forecast for the
+	// next session, as a fraction.
+	Volatility float64
+}
+
+// ForecastEnsemble forecasts horizonDays ahead with every ensemble model
+// and combines them with inverse-MAPE weights from the rolling-origin
+// backtest (the same origins backtestMAPEWithBaseline uses). Models that
+// are materially worse than naive (MAPE > 1.05× naive) get no weight; if
+// none qualifies the ensemble is the naive forecast. Weights are scored on
+// the window they were fitted on, so the ensemble MAPE is slightly
+// optimistic — it is reported alongside the members' for comparison.
+func ForecastEnsemble(closes []float64, horizonDays int) (EnsembleResult, error) {
+	if horizonDays <= 0 {
+		return EnsembleResult{}, fmt.Errorf("horizonDays must be > 0")
+	}
+	if len(closes) < 30 {
+		return EnsembleResult{}, fmt.Errorf("need at least 30 closes, got %d", len(closes))
+	}
+	closes = backAdjustRolls(closes, 0.07)
+	current := closes[len(closes)-1]
+
+	steps := 30
+	if budget := len(closes) - 60 - horizonDays; budget < steps {
+		steps = budget
+	}
+	if steps < 5 {
+		steps = 5
+	}
+
+	out := EnsembleResult{Current: current, HorizonDays: horizonDays}
+	runs := make([]backtestRun, len(ensembleModels))
+	var naiveMAPE float64
+	var totalWeight float64
+	for i, m := range ensembleModels {
+		runs[i] = backtest(closes, horizonDays, steps, m.predict)
+		naiveMAPE = runs[i].mape(runs[i].naive)
+		mr := ModelResult{Name: m.name, Predicted: m.predict(closes, horizonDays), MAPE: runs[i].mape(runs[i].preds)}
+		switch {
+		case len(runs[i].actual) == 0:
+			// Too short to backtest: weigh every model equally.
+			mr.Weight = 1
+		case mr.MAPE <= naiveMAPE*1.05:
+			mr.Weight = 1 / math.Max(mr.MAPE, 1e-6)
+		}
+		totalWeight += mr.Weight
+		out.Models = append(out.Models, mr)
+	}
+
+	bt := runs[0]
+	combined := make([]float64, len(bt.actual))
+	if totalWeight == 0 {
+		out.Predicted = current
+		copy(combined, bt.naive)
+	} else {
+		for i := range out.Models {
+			w := out.Models[i].Weight / totalWeight
+			out.Models[i].Weight = w
+			out.Predicted += w * out.Models[i].Predicted
+			for j := range combined {
+				combined[j] += w * runs[i].preds[j]
+			}
+		}
+	}
+	out.BacktestSteps = len(bt.actual)
+	out.MAPE = bt.mape(combined)
+	out.NaiveMAPE = naiveMAPE
+	if naiveMAPE > 0 {
+		out.Skill = 1 - out.MAPE/naiveMAPE
+	}
+
+	g := fitGARCH(logReturns(closes))
+	out.Volatility = math.Sqrt(g.next)
+	spread := 1.2816 * math.Sqrt(g.horizonVariance(horizonDays))
+	out.Low = out.Predicted * math.Exp(-spread)
+	out.High = out.Predicted * math.Exp(spread)
+	return out, nil
+}
+
+// arimaPredict fits ARIMA(p,1,0) with drift, p in 1..3 chosen by AIC, by
+// least squares on the first differences, and iterates the recursion h
+// steps forward.
+func arimaPredict(train []float64, h int) float64 {
+	n := len(train)
+	d := make([]float64, n-1)
+	for i := 1; i < n; i++ {
+		d[i-1] = train[i] - train[i-1]
+	}
+	var best []float64 // [c, phi1..phip]
+	bestAIC := math.Inf(1)
+	for p := 1; p <= 3; p++ {
+		coef, sse, m := fitAR(d, p)
+		if coef == nil || sse <= 0 {
+			continue
+		}
+		if aic := float64(m)*math.Log(sse/float64(m)) + 2*float64(p+1); aic < bestAIC {
+			bestAIC, best = aic, coef
+		}
+	}
+	if best == nil {
+		return train[n-1]
+	}
+	p := len(best) - 1
+	hist := append([]float64(nil), d[len(d)-p:]...)
+	y := train[n-1]
+	for k := 0; k < h; k++ {
+		next := best[0]
+		for i := 1; i <= p; i++ {
+			next += best[i] * hist[len(hist)-i]
+		}
+		hist = append(hist, next)
+		y += next
+	}
+	return y
+}
+
+// fitAR regresses x[t] on a constant and x[t-1..t-p] by ordinary least
+// squares. It returns the coefficients, the residual sum of squares and
+// the number of fitted points, or nil when the system is singular.
+func fitAR(x []float64, p int) (coef []float64, sse float64, m int) {
+	m = len(x) - p
+	if m <= p+1 {
+		return nil, 0, 0
+	}
+	k := p + 1
+	xtx := make([][]float64, k)
+	for i := range xtx {
+		xtx[i] = make([]float64, k+1) // augmented with X'y
+	}
+	row := make([]float64, k)
+	for t := p; t < len(x); t++ {
+		row[0] = 1
+		for i := 1; i <= p; i++ {
+			row[i] = x[t-i]
+		}
+		for i := 0; i < k; i++ {
+			for j := 0; j < k; j++ {
+				xtx[i][j] += row[i] * row[j]
+			}
+			xtx[i][k] += row[i] * x[t]
+		}
+	}
+	coef = solveLinear(xtx)
+	if coef == nil {
+		return nil, 0, 0
+	}
+	for t := p; t < len(x); t++ {
+		fit := coef[0]
+		for i := 1; i <= p; i++ {
+			fit += coef[i] * x[t-i]
+		}
+		sse += (x[t] - fit) * (x[t] - fit)
+	}
+	return coef, sse, m
+}
+
+// solveLinear solves the augmented k×(k+1) system in place by Gaussian
+// elimination with partial pivoting; nil if it is singular.
+func solveLinear(a [][]float64) []float64 {
+	k := len(a)
+	for col := 0; col < k; col++ {
+		pivot := col
+		for r := col + 1; r < k; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for r := col + 1; r < k; r++ {
+			f := a[r][col] / a[col][col]
+			for c := col; c <= k; c++ {
+				a[r][c] -= f * a[col][c]
+			}
+		}
+	}
+	x := make([]float64, k)
+	for r := k - 1; r >= 0; r-- {
+		v := a[r][k]
+		for c := r + 1; c < k; c++ {
+			v -= a[r][c] * x[c]
+		}
+		x[r] = v / a[r][r]
+	}
+	return x
+}
+
+// thetaPredict is the Theta method (Assimakopoulos & Nikolopoulos 2000) in
+// Hyndman & Billah's form: simple exponential smoothing plus half the
+// slope of the series' linear trend.
+func thetaPredict(train []float64, h int) float64 {
+	n := len(train)
+	// OLS slope of train on t = 0..n-1.
+	meanT := float64(n-1) / 2
+	meanY := 0.0
+	for _, v := range train {
+		meanY += v
+	}
+	meanY /= float64(n)
+	var num, den float64
+	for i, v := range train {
+		dt := float64(i) - meanT
+		num += dt * (v - meanY)
+		den += dt * dt
+	}
+	slope := num / den
+
+	level, alpha := fitSES(train)
+	return level + slope/2*(float64(h-1)+(1-math.Pow(1-alpha, float64(n)))/alpha)
+}
+
+// fitSES grid-searches the simple exponential smoothing constant by
+// one-step in-sample SSE and returns the final level.
+func fitSES(values []float64) (level, alpha float64) {
+	bestSSE := math.Inf(1)
+	for _, a := range []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.8, 0.95} {
+		l, sse := values[0], 0.0
+		for _, v := range values[1:] {
+			sse += (v - l) * (v - l)
+			l = a*v + (1-a)*l
+		}
+		if sse < bestSSE {
+			bestSSE, level, alpha = sse, l, a
+		}
+	}
+	return level, alpha
+}
+
+// seasonalPeriod is one trading week of daily closes.
+const seasonalPeriod = 5
+
+// seasonalNaivePredict repeats the last trading week: the forecast h steps
+// ahead is the close a whole number of weeks before that day.
+func seasonalNaivePredict(train []float64, h int) float64 {
+	n := len(train)
+	back := seasonalPeriod * ((h + seasonalPeriod - 1) / seasonalPeriod)
+	if back > n {
+		return train[n-1]
+	}
+	return train[n+h-back-1]
+}
+
+// garch is a fitted GARCH(1,1) on daily log returns: long-run variance,
+// reaction (alpha) and persistence (beta), plus next's one-step-ahead
+// conditional variance.
+type garch struct {
+	longRun, alpha, beta float64
+	next                 float64
+}
+
+// fitGARCH fits GARCH(1,1) with variance targeting (omega fixed so the
+// unconditional variance equals the sample's) by grid search on the
+// Gaussian log-likelihood. The coarse grid is plenty for band widths and
+// avoids a numerical optimiser.
+func fitGARCH(returns []float64) garch {
+	if len(returns) < 2 {
+		return garch{}
+	}
+	mean := 0.0
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	variance := 0.0
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+	if variance == 0 {
+		return garch{}
+	}
+
+	best := garch{longRun: variance, next: variance}
+	bestLL := math.Inf(-1)
+	for _, a := range []float64{0.02, 0.04, 0.06, 0.08, 0.1, 0.15, 0.2} {
+		for _, b := range []float64{0.7, 0.75, 0.8, 0.85, 0.88, 0.9, 0.92, 0.94, 0.96} {
+			if a+b >= 0.999 {
+				continue
+			}
+			omega := variance * (1 - a - b)
+			s2, ll := variance, 0.0
+			for _, r := range returns {
+				e := r - mean
+				ll -= 0.5 * (math.Log(s2) + e*e/s2)
+				s2 = omega + a*e*e + b*s2
+			}
+			if ll > bestLL {
+				bestLL = ll
+				best = garch{longRun: variance, alpha: a, beta: b, next: s2}
+			}
+		}
+	}
+	return best
+}
+
+// horizonVariance is the variance of the h-day log return: the sum of the
+// conditional variances, which revert geometrically to the long run.
+func (g garch) horizonVariance(h int) float64 {
+	persistence := g.alpha + g.beta
+	total, decay := 0.0, 1.0
+	for k := 0; k < h; k++ {
+		total += g.longRun + decay*(g.next-g.longRun)
+		decay *= persistence
+	}
+	return total
+}
+
+func logReturns(closes []float64) []float64 {
+	out := make([]float64, 0, len(closes))
+	for i := 1; i < len(closes); i++ {
+		if closes[i] > 0 && closes[i-1] > 0 {
+			out = append(out, math.Log(closes[i]/closes[i-1]))
+		}
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomWalk(n int, drift float64, seed int64) []float64 {
+	rng := rand.New(rand.NewSource(seed))
+	out := make([]float64, n)
+	p := 70.0
+	for i := range out {
+		p *= math.Exp(drift + rng.NormFloat64()*0.015)
+		out[i] = p
+	}
+	return out
+}
+
+func TestForecastEnsemble_WeightsAndBands(t *testing.T) {
+	closes := randomWalk(500, 0.0005, 7)
+	for _, h := range forecastHorizons {
+		f, err := ForecastEnsemble(closes, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(f.Models) != len(ensembleModels) {
+			t.Fatalf("h=%d: %d models", h, len(f.Models))
+		}
+		total := 0.0
+		for _, m := range f.Models {
+			if m.Weight < 0 || math.IsNaN(m.Predicted) {
+				t.Fatalf("h=%d: bad member %+v", h, m)
+			}
+			total += m.Weight
+		}
+		if total != 0 && math.Abs(total-1) > 1e-9 {
+			t.Fatalf("h=%d: weights sum to %v", h, total)
+		}
+		if !(f.Low < f.Predicted && f.Predicted < f.High) {
+			t.Fatalf("h=%d: band %v..%v misses %v", h, f.Low, f.High, f.Predicted)
+		}
+		if f.BacktestSteps == 0 || f.NaiveMAPE <= 0 {
+			t.Fatalf("h=%d: no backtest: %+v", h, f)
+		}
+	}
+
+	// Bands widen with the horizon.
+	f1, _ := ForecastEnsemble(closes, 1)
+	f60, _ := ForecastEnsemble(closes, 60)
+	if (f60.High-f60.Low)/f60.Predicted <= (f1.High-f1.Low)/f1.Predicted {
+		t.Fatal("60-day band should be wider than the 1-day band")
+	}
+}
+
+func TestForecastEnsemble_RejectsShortHistory(t *testing.T) {
+	if _, err := ForecastEnsemble(randomWalk(20, 0, 1), 5); err == nil {
+		t.Fatal("expected an error for 20 closes")
+	}
+}
+
+func TestArimaPredict_FollowsDrift(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	closes := make([]float64, 200)
+	for i := range closes {
+		closes[i] = 50 + 0.5*float64(i) + rng.NormFloat64()*0.01
+	}
+	got := arimaPredict(closes, 10)
+	want := closes[len(closes)-1] + 5
+	if math.Abs(got-want) > 0.2 {
+		t.Fatalf("arima 10-step = %.3f, want ≈ %.3f", got, want)
+	}
+}
+
+func TestThetaPredict_HalfTrend(t *testing.T) {
+	closes := make([]float64, 100)
+	for i := range closes {
+		closes[i] = 100 + float64(i)
+	}
+	// SES lags a linear trend; theta adds back half the slope per step,
+	// so the forecast sits above the last value and rises with h.
+	f1, f10 := thetaPredict(closes, 1), thetaPredict(closes, 10)
+	if f1 <= closes[99]-1 || f10-f1 < 4 || f10-f1 > 5 {
+		t.Fatalf("theta forecasts %.2f, %.2f", f1, f10)
+	}
+}
+
+func TestSeasonalNaivePredict_RepeatsLastWeek(t *testing.T) {
+	closes := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for h, want := range map[int]float64{1: 6, 5: 10, 6: 6, 7: 7} {
+		if got := seasonalNaivePredict(closes, h); got != want {
+			t.Fatalf("h=%d: got %v want %v", h, got, want)
+		}
+	}
+}
+
+func TestFitGARCH_RevertsToLongRunVariance(t *testing.T) {
+	rng := rand.New(rand.NewSource(11))
+	returns := make([]float64, 400)
+	for i := range returns {
+		sd := 0.01
+		if i >= 380 {
+			sd = 0.04 // recent volatility spike
+		}
+		returns[i] = rng.NormFloat64() * sd
+	}
+	g := fitGARCH(returns)
+	if g.alpha+g.beta >= 1 || g.next <= g.longRun {
+		t.Fatalf("expected a stationary fit with elevated next-day variance, got %+v", g)
+	}
+	// Average per-day variance decays toward the long run as h grows.
+	if g.horizonVariance(60)/60 >= g.horizonVariance(1) {
+		t.Fatal("per-day variance should revert toward the long run")
+	}
+}
+
+func TestBacktestMAPEWithBaseline_MatchesBacktestRun(t *testing.T) {
+	closes := randomWalk(200, 0, 5)
+	mape, naive, n := backtestMAPEWithBaseline(closes, 5, 20)
+	bt := backtest(closes, 5, 20, holtDampedPredict)
+	if n != 20 || mape != bt.mape(bt.preds) || naive != bt.mape(bt.naive) {
+		t.Fatalf("got (%v, %v, %d)", mape, naive, n)
+	}
+}
+
+func TestGetForecastSet(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	closes := randomWalk(300, 0, 9)
+	bars := make([]models.OHLCV, len(closes))
+	for i, c := range closes {
+		bars[i] = models.OHLCV{Time: int64(i) * 86400, Close: c}
+	}
+	svc.sources.Register(&fakeSource{name: "replay", caps: CapHistory, history: map[string][]models.OHLCV{"WTI": bars}}, 10)
+
+	set, ok := svc.GetForecastSet("WTI", nil)
+	if !ok || len(set.Horizons) != len(forecastHorizons) {
+		t.Fatalf("expected every default horizon, got %v %+v", ok, set)
+	}
+	for i, h := range set.Horizons {
+		if h.Horizon != forecastHorizons[i] || len(h.Models) != len(ensembleModels) {
+			t.Fatalf("horizon %d: %+v", i, h)
+		}
+	}
+	if set, _ := svc.GetForecastSet("WTI", []int{20}); len(set.Horizons) != 1 || set.Horizons[0].Horizon != 20 {
+		t.Fatalf("expected only h=20, got %+v", set.Horizons)
+	}
+	if set, ok := svc.GetForecastSet("OPEC", nil); !ok || len(set.Horizons) != 0 || set.Note == "" {
+		t.Fatalf("expected an empty set with a note for a symbol without history, got %+v", set)
+	}
+	if _, ok := svc.GetForecastSet("XAU", nil); ok {
+		t.Fatal("expected unknown symbol to be rejected")
+	}
+}
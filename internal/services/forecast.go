@@ -2,16 +2,21 @@ package services
 
 import (
 	"fmt"
+	"live-oil-prices-go/internal/indicators"
 	"math"
 )
 
 // ForecastResult bundles a point forecast with diagnostic indicators that
 // can be used to label direction, confidence, and produce analysis prose.
 type ForecastResult struct {
-	Current     float64
-	Predicted   float64
+	Current   float64
+	Predicted float64
+	// Low and High bound the 80% interval at the horizon; Intervals holds
+	// every level there and Fan the same for each step up to it.
 	Low         float64
 	High        float64
+	Intervals   []Interval
+	Fan         []FanStep
 	Direction   string  // "bullish" | "bearish" | "neutral"
 	Confidence  float64 // 0..1
 	RSI14       float64
@@ -42,6 +47,11 @@ type ForecastResult struct {
 	Phi float64
 }
 
+// forecastHistoryBars caps the daily closes fed to Forecast at the two
+// years the periodic refresh keeps, so a full-history backfill for the
+// charts doesn't change (or slow down) the forecasts.
+const forecastHistoryBars = 2 * 252
+
 // Forecast produces a point forecast `horizonDays` steps ahead from `closes`,
 // along with technical indicator readings and an empirically-derived
 // confidence score.
@@ -55,6 +65,10 @@ type ForecastResult struct {
 // the same damped-Holt fit across the most recent ~30 trading days and measure
 // the actual h-step-ahead MAPE; that empirical error — combined with how well
 // the trend agrees with the technical indicators — produces the score.
+//
+// Intervals: 50/80/95% bands from the quantiles of the out-of-sample errors
+// over a longer replay (see holtForecastErrors), each checked for coverage
+// against outcomes it could not have seen.
 func Forecast(closes []float64, horizonDays int) (ForecastResult, error) {
 	if horizonDays <= 0 {
 		return ForecastResult{}, fmt.Errorf("horizonDays must be > 0")
@@ -112,20 +126,31 @@ func Forecast(closes []float64, horizonDays int) (ForecastResult, error) {
 	// forecast (predict no change). It would be dishonest to publish a
 	// confident-looking arrow when the model can't beat "tomorrow looks
 	// like today" on its own backtest.
-	if n >= 10 && mape > 0 && naiveMape > 0 && mape > naiveMape*1.05 {
+	naiveFallback := n >= 10 && mape > 0 && naiveMape > 0 && mape > naiveMape*1.05
+	if naiveFallback {
 		predicted = current
 		direction = "neutral"
 	}
 
-	// Prediction interval: prefer the empirical out-of-sample error scaled
-	// to the current price; fall back to in-sample residual std if the
-	// backtest didn't run for some reason. 1.28 ≈ 80% one-sided z.
-	band := 1.28 * residStd * math.Sqrt(float64(horizonDays))
-	if mape > 0 && n >= 5 {
-		band = 1.28 * mape * current
+	// Prediction intervals: empirical quantiles of the out-of-sample errors
+	// of whichever forecast we publish, per step out to the horizon, so the
+	// 80% band really covers ~80% of outcomes rather than assuming a shape.
+	// Short histories fall back to a Gaussian on the in-sample residuals.
+	modelErrs, naiveErrs := holtForecastErrors(closes, horizonDays)
+	point := func(h int) float64 { return dampedHoltForecast(level, trend, phi, h) }
+	errs := modelErrs
+	if naiveFallback {
+		point = func(int) float64 { return current }
+		errs = naiveErrs
+	}
+	fan := forecastFan(point, errs, residStd/current)
+	intervals := fan[len(fan)-1].Intervals
+	var low, high float64
+	for _, iv := range intervals {
+		if iv.Level == 0.8 {
+			low, high = iv.Low, iv.High
+		}
 	}
-	low := predicted - band
-	high := predicted + band
 
 	confidence := computeConfidence(mape, naiveMape, n, rsi, hist, trendLabel, direction)
 
@@ -134,6 +159,8 @@ func Forecast(closes []float64, horizonDays int) (ForecastResult, error) {
 		Predicted:     predicted,
 		Low:           low,
 		High:          high,
+		Intervals:     intervals,
+		Fan:           fan,
 		Direction:     direction,
 		Confidence:    confidence,
 		RSI14:         rsi,
@@ -156,33 +183,18 @@ func Forecast(closes []float64, horizonDays int) (ForecastResult, error) {
 // SMA returns the simple moving average over the last `period` values.
 // Returns 0 if there are fewer than `period` values.
 func SMA(values []float64, period int) float64 {
-	if period <= 0 || len(values) < period {
-		return 0
-	}
-	sum := 0.0
-	for _, v := range values[len(values)-period:] {
-		sum += v
-	}
-	return sum / float64(period)
+	return lastOr(indicators.SMA(values, period), 0)
 }
 
 // EMA returns the full exponential moving average series.
 // EMA[0..period-2] are zero; EMA[period-1] is the SMA seed; subsequent values
 // use the recursive EMA formula with alpha = 2 / (period + 1).
 func EMA(values []float64, period int) []float64 {
-	out := make([]float64, len(values))
-	if period <= 0 || len(values) < period {
-		return out
-	}
-	alpha := 2.0 / float64(period+1)
-	seed := 0.0
-	for i := 0; i < period; i++ {
-		seed += values[i]
-	}
-	seed /= float64(period)
-	out[period-1] = seed
-	for i := period; i < len(values); i++ {
-		out[i] = alpha*values[i] + (1-alpha)*out[i-1]
+	out := indicators.EMA(values, period)
+	for i, v := range out {
+		if math.IsNaN(v) {
+			out[i] = 0
+		}
 	}
 	return out
 }
@@ -190,36 +202,7 @@ func EMA(values []float64, period int) []float64 {
 // RSI returns the Wilder-smoothed Relative Strength Index for the most recent
 // bar. Returns 50 (neutral) when there is insufficient data.
 func RSI(closes []float64, period int) float64 {
-	if period <= 0 || len(closes) <= period {
-		return 50
-	}
-	avgGain, avgLoss := 0.0, 0.0
-	for i := 1; i <= period; i++ {
-		ch := closes[i] - closes[i-1]
-		if ch > 0 {
-			avgGain += ch
-		} else {
-			avgLoss -= ch
-		}
-	}
-	avgGain /= float64(period)
-	avgLoss /= float64(period)
-	for i := period + 1; i < len(closes); i++ {
-		ch := closes[i] - closes[i-1]
-		gain, loss := 0.0, 0.0
-		if ch > 0 {
-			gain = ch
-		} else {
-			loss = -ch
-		}
-		avgGain = (avgGain*float64(period-1) + gain) / float64(period)
-		avgLoss = (avgLoss*float64(period-1) + loss) / float64(period)
-	}
-	if avgLoss == 0 {
-		return 100
-	}
-	rs := avgGain / avgLoss
-	return 100 - 100/(1+rs)
+	return lastOr(indicators.RSI(closes, period), 50)
 }
 
 // MACD returns the (macd, signal, histogram) values for the most recent bar
@@ -228,17 +211,17 @@ func MACD(closes []float64, fast, slow, signalPeriod int) (float64, float64, flo
 	if len(closes) < slow+signalPeriod {
 		return 0, 0, 0
 	}
-	emaFast := EMA(closes, fast)
-	emaSlow := EMA(closes, slow)
-	macdLine := make([]float64, len(closes))
-	for i := slow - 1; i < len(closes); i++ {
-		macdLine[i] = emaFast[i] - emaSlow[i]
-	}
-	macdTail := macdLine[slow-1:]
-	signalTail := EMA(macdTail, signalPeriod)
-	macd := macdLine[len(macdLine)-1]
-	signal := signalTail[len(signalTail)-1]
-	return macd, signal, macd - signal
+	m := indicators.MACD(closes, fast, slow, signalPeriod)
+	return lastOr(m.MACD, 0), lastOr(m.Signal, 0), lastOr(m.Histogram, 0)
+}
+
+// lastOr returns the latest value of an indicator series, or def during
+// its warm-up.
+func lastOr(series []float64, def float64) float64 {
+	if len(series) == 0 || math.IsNaN(series[len(series)-1]) {
+		return def
+	}
+	return series[len(series)-1]
 }
 
 // fitHoltLinear is retained for tests and as a documented baseline. New code
@@ -348,38 +331,67 @@ func dampedHoltForecast(level, trend, phi float64, h int) float64 {
 //
 // Returns (modelMAPE, naiveMAPE, steps). MAPEs are fractions (0.04 = 4%).
 func backtestMAPEWithBaseline(closes []float64, horizon, steps int) (float64, float64, int) {
+	bt := backtest(closes, horizon, steps, holtDampedPredict)
+	return bt.mape(bt.preds), bt.mape(bt.naive), len(bt.actual)
+}
+
+// backtestRun is one rolling-origin replay: per origin, the model's
+// h-step forecast, the naive forecast (the origin's close) and the actual
+// value h steps later. Origins with a zero actual are skipped.
+type backtestRun struct {
+	origins []int
+	preds   []float64
+	naive   []float64
+	actual  []float64
+}
+
+// backtest replays predict from the last `steps` origins that leave room
+// for a 60-close training window and a horizon-step outcome. Every model
+// scored with the same (closes, horizon, steps) sees the same origins, so
+// their runs can be combined index by index.
+func backtest(closes []float64, horizon, steps int, predict func(train []float64, h int) float64) backtestRun {
+	var bt backtestRun
 	if steps < 1 || horizon < 1 {
-		return 0, 0, 0
+		return bt
 	}
 	minTrain := 60
 	if len(closes) < minTrain+horizon+steps {
 		steps = len(closes) - minTrain - horizon
 		if steps < 1 {
-			return 0, 0, 0
+			return bt
 		}
 	}
-
-	totalModel := 0.0
-	totalNaive := 0.0
-	n := 0
 	startOrigin := len(closes) - steps - horizon
 	for i := 0; i < steps; i++ {
 		origin := startOrigin + i
-		train := closes[:origin+1]
-		level, trend, _, _, phi, _ := fitHoltDamped(train)
-		yhat := dampedHoltForecast(level, trend, phi, horizon)
 		actual := closes[origin+horizon]
 		if actual == 0 {
 			continue
 		}
-		totalModel += math.Abs(yhat-actual) / math.Abs(actual)
-		totalNaive += math.Abs(closes[origin]-actual) / math.Abs(actual)
-		n++
+		bt.origins = append(bt.origins, origin)
+		bt.preds = append(bt.preds, predict(closes[:origin+1], horizon))
+		bt.naive = append(bt.naive, closes[origin])
+		bt.actual = append(bt.actual, actual)
 	}
-	if n == 0 {
-		return 0, 0, 0
+	return bt
+}
+
+// mape scores forecasts (aligned with bt.actual) as a fraction.
+func (bt backtestRun) mape(forecasts []float64) float64 {
+	if len(bt.actual) == 0 {
+		return 0
+	}
+	total := 0.0
+	for i, a := range bt.actual {
+		total += math.Abs(forecasts[i]-a) / math.Abs(a)
 	}
-	return totalModel / float64(n), totalNaive / float64(n), n
+	return total / float64(len(bt.actual))
+}
+
+// holtDampedPredict is the damped-Holt model as a plain h-step predictor.
+func holtDampedPredict(train []float64, h int) float64 {
+	level, trend, _, _, phi, _ := fitHoltDamped(train)
+	return dampedHoltForecast(level, trend, phi, h)
 }
 
 // backAdjustRolls returns a copy of `values` with futures contract-roll
@@ -0,0 +1,324 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Feed names reported by /api/health/feeds and used in HealthPolicy.
+const (
+	FeedYahooQuotes   = "yahoo.quotes"
+	FeedYahooHistory  = "yahoo.history"
+	FeedYahooIntraday = "yahoo.intraday"
+	FeedPyth          = "pyth"
+	FeedEIA           = "eia"
+	FeedNews          = "news"
+)
+
+// FeedThreshold decides when a feed counts as down: no success for longer
+// than MaxAge, or MaxFailures refresh cycles failing in a row. A down
+// Critical feed makes the instance unready.
+type FeedThreshold struct {
+	MaxAge      time.Duration
+	MaxFailures int
+	Critical    bool
+}
+
+// HealthPolicy holds a threshold per feed name.
+type HealthPolicy map[string]FeedThreshold
+
+// DefaultHealthPolicy allows each feed a few missed polls. Only Yahoo
+// quotes and Pyth are critical: without them /api/prices degrades to
+// estimates, while stale history, news or EIA still leave a usable
+// instance.
+func DefaultHealthPolicy() HealthPolicy {
+	return HealthPolicy{
+		FeedYahooQuotes:   {MaxAge: 5 * time.Minute, MaxFailures: 5, Critical: true},
+		FeedYahooHistory:  {MaxAge: 26 * time.Hour, MaxFailures: 3},
+		FeedYahooIntraday: {MaxAge: 30 * time.Minute, MaxFailures: 5},
+		FeedPyth:          {MaxAge: 2 * time.Minute, MaxFailures: 10, Critical: true},
+		FeedEIA:           {MaxAge: 72 * time.Hour, MaxFailures: 3},
+		FeedNews:          {MaxAge: time.Hour, MaxFailures: 5},
+	}
+}
+
+// ParseHealthPolicy applies overrides to the defaults. maxAge is a list of
+// feed=duration pairs ("yahoo.quotes=10m,pyth=5m"); maxFailures is either
+// a bare count for every feed or feed=count pairs; critical, when set,
+// replaces the set of critical feeds ("none" clears it). Empty strings
+// keep the defaults.
+func ParseHealthPolicy(maxAge, maxFailures, critical string) (HealthPolicy, error) {
+	p := DefaultHealthPolicy()
+	pairs := func(s string, fn func(feed, val string) error) error {
+		for _, kv := range strings.Split(s, ",") {
+			kv = strings.TrimSpace(kv)
+			if kv == "" {
+				continue
+			}
+			feed, val, ok := strings.Cut(kv, "=")
+			if !ok {
+				return fmt.Errorf("%q: want feed=value", kv)
+			}
+			feed = strings.TrimSpace(feed)
+			if _, known := p[feed]; !known {
+				return fmt.Errorf("unknown feed %q", feed)
+			}
+			if err := fn(feed, strings.TrimSpace(val)); err != nil {
+				return fmt.Errorf("%s: %w", feed, err)
+			}
+		}
+		return nil
+	}
+
+	err := pairs(maxAge, func(feed, val string) error {
+		d, err := time.ParseDuration(val)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid max age %q", val)
+		}
+		t := p[feed]
+		t.MaxAge = d
+		p[feed] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if n, err := strconv.Atoi(strings.TrimSpace(maxFailures)); err == nil {
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid max failures %d", n)
+		}
+		for feed, t := range p {
+			t.MaxFailures = n
+			p[feed] = t
+		}
+	} else {
+		err := pairs(maxFailures, func(feed, val string) error {
+			n, err := strconv.Atoi(val)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid max failures %q", val)
+			}
+			t := p[feed]
+			t.MaxFailures = n
+			p[feed] = t
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if critical = strings.TrimSpace(critical); critical != "" {
+		want := map[string]bool{}
+		if critical != "none" {
+			for _, feed := range strings.Split(critical, ",") {
+				feed = strings.TrimSpace(feed)
+				if _, known := p[feed]; !known {
+					return nil, fmt.Errorf("unknown feed %q", feed)
+				}
+				want[feed] = true
+			}
+		}
+		for feed, t := range p {
+			t.Critical = want[feed]
+			p[feed] = t
+		}
+	}
+	return p, nil
+}
+
+// feedState is the running record of one feed's refresh cycles.
+type feedState struct {
+	lastSuccess time.Time
+	lastError   string
+	lastErrorAt time.Time
+	failures    int // consecutive failed cycles
+}
+
+// feedTracker records refresh outcomes per feed. Services report into the
+// package-level feeds tracker; a feed that was never expected or
+// attempted (EIA without a key) is left out of the report.
+type feedTracker struct {
+	mu     sync.Mutex
+	states map[string]*feedState
+}
+
+func newFeedTracker() *feedTracker {
+	return &feedTracker{states: make(map[string]*feedState)}
+}
+
+var feeds = newFeedTracker()
+
+// lastSuccess is when name last completed a refresh cycle, or zero.
+func (t *feedTracker) lastSuccess(name string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if st, ok := t.states[name]; ok {
+		return st.lastSuccess
+	}
+	return time.Time{}
+}
+
+// expect lists a feed as pending before its first refresh completes.
+func (t *feedTracker) expect(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.states[name]; !ok {
+		t.states[name] = &feedState{}
+	}
+}
+
+// record notes one refresh cycle's outcome, publishing a FeedFailed on
+// bus when it failed.
+func (t *feedTracker) record(name string, err error, at time.Time, bus *Bus) {
+	t.mu.Lock()
+	st, ok := t.states[name]
+	if !ok {
+		st = &feedState{}
+		t.states[name] = st
+	}
+	if err == nil {
+		st.lastSuccess = at
+		st.failures = 0
+		t.mu.Unlock()
+		return
+	}
+	st.lastError = err.Error()
+	st.lastErrorAt = at
+	st.failures++
+	failures := st.failures
+	t.mu.Unlock()
+	bus.Publish(FeedFailed{Feed: name, Error: err.Error(), Failures: failures, At: at})
+}
+
+// cycle starts a refresh that fans out over several fetches (one per
+// symbol). The cycle succeeds if any fetch does, so one delisted ticker
+// doesn't mark the whole feed down.
+func (t *feedTracker) cycle(name string, bus *Bus) *feedCycle {
+	return &feedCycle{tracker: t, name: name, bus: bus}
+}
+
+type feedCycle struct {
+	tracker   *feedTracker
+	name      string
+	bus       *Bus
+	mu        sync.Mutex
+	successes int
+	lastErr   error
+}
+
+func (c *feedCycle) observe(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if errors.Is(err, context.Canceled) {
+		// The service is stopping; that says nothing about the feed.
+		return
+	}
+	if err != nil {
+		c.lastErr = err
+		return
+	}
+	c.successes++
+}
+
+func (c *feedCycle) end() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case c.successes > 0:
+		c.tracker.record(c.name, nil, time.Now(), c.bus)
+	case c.lastErr != nil:
+		c.tracker.record(c.name, c.lastErr, time.Now(), c.bus)
+	}
+}
+
+// report evaluates every tracked feed against policy.
+func (t *feedTracker) report(policy HealthPolicy, now time.Time) models.HealthReport {
+	t.mu.Lock()
+	names := make([]string, 0, len(t.states))
+	for name := range t.states {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := models.HealthReport{
+		Status:    "ok",
+		Ready:     true,
+		Feeds:     make([]models.FeedHealth, 0, len(names)),
+		CheckedAt: now.UTC().Format(time.RFC3339),
+	}
+	for _, name := range names {
+		st := *t.states[name]
+		th, ok := policy[name]
+		if !ok {
+			th = FeedThreshold{MaxAge: time.Hour, MaxFailures: 5}
+		}
+		fh := models.FeedHealth{
+			Name:                name,
+			Critical:            th.Critical,
+			LastError:           st.lastError,
+			ConsecutiveFailures: st.failures,
+			MaxAgeSeconds:       th.MaxAge.Seconds(),
+			MaxFailures:         th.MaxFailures,
+		}
+		if !st.lastErrorAt.IsZero() {
+			fh.LastErrorAt = st.lastErrorAt.UTC().Format(time.RFC3339)
+		}
+		switch {
+		case st.lastSuccess.IsZero() && st.failures == 0:
+			fh.Status = "pending"
+		case st.lastSuccess.IsZero(),
+			now.Sub(st.lastSuccess) > th.MaxAge,
+			st.failures >= th.MaxFailures:
+			fh.Status = "down"
+		case st.failures > 0:
+			fh.Status = "degraded"
+		default:
+			fh.Status = "ok"
+		}
+		if !st.lastSuccess.IsZero() {
+			fh.LastSuccess = st.lastSuccess.UTC().Format(time.RFC3339)
+			age := r2(now.Sub(st.lastSuccess).Seconds())
+			fh.CacheAgeSeconds = &age
+		}
+
+		if fh.Status != "ok" && out.Status == "ok" {
+			out.Status = "degraded"
+		}
+		// A critical feed that hasn't finished its first refresh holds
+		// readiness too, so traffic waits for real prices.
+		if th.Critical && (fh.Status == "down" || fh.Status == "pending") {
+			out.Ready = false
+			out.Status = "unavailable"
+		}
+		out.Feeds = append(out.Feeds, fh)
+	}
+	t.mu.Unlock()
+	return out
+}
+
+// HealthMonitor judges the feeds services report against a policy.
+type HealthMonitor struct {
+	policy HealthPolicy
+	feeds  *feedTracker
+}
+
+// NewHealthMonitor reports on the process-wide feed tracker. A nil policy
+// uses DefaultHealthPolicy.
+func NewHealthMonitor(policy HealthPolicy) *HealthMonitor {
+	if policy == nil {
+		policy = DefaultHealthPolicy()
+	}
+	return &HealthMonitor{policy: policy, feeds: feeds}
+}
+
+// Report returns every feed's status and whether the instance is ready.
+func (m *HealthMonitor) Report() models.HealthReport {
+	return m.feeds.report(m.policy, time.Now())
+}
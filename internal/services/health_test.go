@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFeedTrackerReport(t *testing.T) {
+	tr := newFeedTracker()
+	now := time.Now()
+	policy := DefaultHealthPolicy()
+
+	tr.expect(FeedYahooQuotes)
+	tr.expect(FeedNews)
+	rep := tr.report(policy, now)
+	if rep.Ready || rep.Status != "unavailable" {
+		t.Fatalf("pending critical feed should hold readiness, got %+v", rep)
+	}
+
+	tr.record(FeedYahooQuotes, nil, now.Add(-time.Minute), nil)
+	tr.record(FeedNews, errors.New("rss 503"), now, nil)
+	rep = tr.report(policy, now)
+	if !rep.Ready || rep.Status != "degraded" {
+		t.Fatalf("non-critical failure should degrade but stay ready, got %+v", rep)
+	}
+	for _, f := range rep.Feeds {
+		switch f.Name {
+		case FeedNews:
+			if f.Status != "down" || f.LastError != "rss 503" || f.ConsecutiveFailures != 1 || f.CacheAgeSeconds != nil {
+				t.Fatalf("news: %+v", f)
+			}
+		case FeedYahooQuotes:
+			if f.Status != "ok" || f.CacheAgeSeconds == nil || *f.CacheAgeSeconds != 60 {
+				t.Fatalf("yahoo.quotes: %+v", f)
+			}
+		}
+	}
+
+	// Stale beyond MaxAge flips the critical feed down.
+	rep = tr.report(policy, now.Add(10*time.Minute))
+	if rep.Ready {
+		t.Fatalf("stale quotes should be unready, got %+v", rep)
+	}
+
+	for i := 0; i < policy[FeedYahooQuotes].MaxFailures; i++ {
+		tr.record(FeedYahooQuotes, errors.New("timeout"), now, nil)
+	}
+	if rep = tr.report(policy, now); rep.Ready {
+		t.Fatalf("%d consecutive failures should be unready", policy[FeedYahooQuotes].MaxFailures)
+	}
+}
+
+func TestFeedCycleSucceedsOnAnyFetch(t *testing.T) {
+	tr := newFeedTracker()
+	c := tr.cycle(FeedYahooHistory, nil)
+	c.observe(errors.New("delisted"))
+	c.observe(nil)
+	c.end()
+	if st := tr.states[FeedYahooHistory]; st.failures != 0 || st.lastSuccess.IsZero() {
+		t.Fatalf("one good fetch should count as success, got %+v", st)
+	}
+}
+
+func TestParseHealthPolicy(t *testing.T) {
+	p, err := ParseHealthPolicy("yahoo.quotes=10m", "news=9", "pyth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p[FeedYahooQuotes].MaxAge != 10*time.Minute || p[FeedNews].MaxFailures != 9 {
+		t.Fatalf("overrides not applied: %+v", p)
+	}
+	if p[FeedYahooQuotes].Critical || !p[FeedPyth].Critical {
+		t.Fatalf("critical set not replaced: %+v", p)
+	}
+
+	p, err = ParseHealthPolicy("", "2", "none")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, th := range p {
+		if th.MaxFailures != 2 || th.Critical {
+			t.Fatalf("%s: %+v", name, th)
+		}
+	}
+
+	for _, bad := range [][3]string{{"bogus=1m", "", ""}, {"pyth=soon", "", ""}, {"", "pyth=0", ""}, {"", "", "bogus"}} {
+		if _, err := ParseHealthPolicy(bad[0], bad[1], bad[2]); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
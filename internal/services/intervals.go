@@ -0,0 +1,171 @@
+package services
+
+import (
+	"math"
+	"sort"
+)
+
+// intervalLevels are the central coverage levels of the prediction
+// intervals, with the standard-normal z used when there are too few
+// out-of-sample errors to calibrate on.
+var intervalLevels = []struct{ level, z float64 }{
+	{0.5, 0.6745},
+	{0.8, 1.2816},
+	{0.95, 1.9600},
+}
+
+// calibrationOrigins is how many rolling origins feed the error
+// distribution behind the intervals — more than the 30 the MAPE backtest
+// replays, since the 95% tails need the samples.
+const calibrationOrigins = 120
+
+// minCalibrationErrors is the fewest out-of-sample errors an empirical
+// interval is read from; below that the interval is Gaussian.
+const minCalibrationErrors = 20
+
+// Interval is a central prediction interval.
+type Interval struct {
+	Level     float64 // 0.8 = 80%
+	Low, High float64
+	// Coverage is the share of backtest outcomes that fell inside
+	// intervals built the same way from only the errors already realised
+	// at each origin, over CoverageSamples checks. No samples means the
+	// history was too short to check.
+	Coverage        float64
+	CoverageSamples int
+}
+
+// FanStep is the forecast distribution Step trading days ahead.
+type FanStep struct {
+	Step      int
+	Median    float64
+	Intervals []Interval
+}
+
+// originError is the log error log(actual/forecast) of a forecast made at
+// close index origin.
+type originError struct {
+	origin int
+	err    float64
+}
+
+// holtForecastErrors replays the damped-Holt fit at up to
+// calibrationOrigins rolling origins and returns, for each step 1..maxH,
+// the out-of-sample log errors of its forecasts and of the naive
+// no-change forecast, oldest origin first. One fit per origin serves
+// every step.
+func holtForecastErrors(closes []float64, maxH int) (model, naive [][]originError) {
+	model = make([][]originError, maxH)
+	naive = make([][]originError, maxH)
+	n := len(closes)
+	first := n - 1 - calibrationOrigins
+	if first < 59 {
+		first = 59 // the backtest's 60-close minimum training window
+	}
+	for o := first; o < n-1; o++ {
+		base := closes[o]
+		if base <= 0 {
+			continue
+		}
+		level, trend, _, _, phi, _ := fitHoltDamped(closes[:o+1])
+		for h := 1; h <= maxH && o+h < n; h++ {
+			actual := closes[o+h]
+			if actual <= 0 {
+				continue
+			}
+			if pred := dampedHoltForecast(level, trend, phi, h); pred > 0 {
+				model[h-1] = append(model[h-1], originError{o, math.Log(actual / pred)})
+			}
+			naive[h-1] = append(naive[h-1], originError{o, math.Log(actual / base)})
+		}
+	}
+	return model, naive
+}
+
+// forecastFan builds the fan from point(h), the published forecast h
+// steps ahead, and errs, the step-h errors of that forecast. Each interval
+// is the point scaled by the empirical quantiles of the errors; with too
+// few of them it falls back to a Gaussian on sigma, the one-step log
+// volatility, widened by √h.
+func forecastFan(point func(h int) float64, errs [][]originError, sigma float64) []FanStep {
+	fan := make([]FanStep, len(errs))
+	for i, e := range errs {
+		h := i + 1
+		p := point(h)
+		step := FanStep{Step: h, Median: p}
+		values := sortedErrors(e)
+		empirical := len(values) >= minCalibrationErrors
+		if empirical {
+			step.Median = p * math.Exp(quantile(values, 0.5))
+		}
+		for _, lv := range intervalLevels {
+			iv := Interval{Level: lv.level}
+			if empirical {
+				lo, hi := errorBand(values, lv.level)
+				iv.Low, iv.High = p*math.Exp(lo), p*math.Exp(hi)
+				iv.Coverage, iv.CoverageSamples = intervalCoverage(e, h, lv.level)
+			} else {
+				spread := lv.z * sigma * math.Sqrt(float64(h))
+				iv.Low, iv.High = p*math.Exp(-spread), p*math.Exp(spread)
+			}
+			step.Intervals = append(step.Intervals, iv)
+		}
+		fan[i] = step
+	}
+	return fan
+}
+
+// intervalCoverage is the backtest's honesty check on the intervals: at
+// each origin it builds the level interval from only the errors whose
+// outcome was already known there (origin + h ≤ this origin) and counts
+// how often the realised error lands inside. Origins with fewer than
+// minCalibrationErrors known errors are skipped.
+func intervalCoverage(errs []originError, h int, level float64) (coverage float64, samples int) {
+	inside, known := 0, 0
+	for _, e := range errs {
+		for known < len(errs) && errs[known].origin+h <= e.origin {
+			known++
+		}
+		if known < minCalibrationErrors {
+			continue
+		}
+		lo, hi := errorBand(sortedErrors(errs[:known]), level)
+		samples++
+		if lo <= e.err && e.err <= hi {
+			inside++
+		}
+	}
+	if samples == 0 {
+		return 0, 0
+	}
+	return float64(inside) / float64(samples), samples
+}
+
+// errorBand returns the central level interval of sorted errors.
+func errorBand(sorted []float64, level float64) (lo, hi float64) {
+	tail := (1 - level) / 2
+	return quantile(sorted, tail), quantile(sorted, 1-tail)
+}
+
+func sortedErrors(errs []originError) []float64 {
+	out := make([]float64, len(errs))
+	for i, e := range errs {
+		out[i] = e.err
+	}
+	sort.Float64s(out)
+	return out
+}
+
+// quantile interpolates linearly between the order statistics of sorted
+// (Hyndman & Fan type 7, the R and NumPy default).
+func quantile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p * float64(len(sorted)-1)
+	i := int(pos)
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (pos-float64(i))*(sorted[i+1]-sorted[i])
+}
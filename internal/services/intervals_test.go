@@ -0,0 +1,76 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuantile(t *testing.T) {
+	sorted := []float64{1, 2, 3, 4, 5}
+	for p, want := range map[float64]float64{0: 1, 0.25: 2, 0.5: 3, 0.9: 4.6, 1: 5} {
+		if got := quantile(sorted, p); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("quantile(%v) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestForecast_IntervalsAreCalibrated(t *testing.T) {
+	f, err := Forecast(randomWalk(400, 0, 17), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Fan) != 7 || len(f.Intervals) != len(intervalLevels) {
+		t.Fatalf("fan %d steps, %d intervals", len(f.Fan), len(f.Intervals))
+	}
+	for i, iv := range f.Intervals {
+		if iv.CoverageSamples < 50 {
+			t.Fatalf("%v: only %d coverage checks", iv.Level, iv.CoverageSamples)
+		}
+		// A random walk's errors are stationary, so out-of-sample coverage
+		// should land near nominal.
+		if math.Abs(iv.Coverage-iv.Level) > 0.2 {
+			t.Fatalf("%v interval covered %.2f", iv.Level, iv.Coverage)
+		}
+		if i > 0 && !(iv.Low < f.Intervals[i-1].Low && iv.High > f.Intervals[i-1].High) {
+			t.Fatalf("%v interval doesn't nest the %v one", iv.Level, f.Intervals[i-1].Level)
+		}
+		if iv.Level == 0.8 && (iv.Low != f.Low || iv.High != f.High) {
+			t.Fatal("Low/High should be the 80% interval")
+		}
+	}
+	first, last := f.Fan[0].Intervals[1], f.Fan[6].Intervals[1]
+	if last.High-last.Low <= first.High-first.Low {
+		t.Fatal("the fan should widen with the horizon")
+	}
+}
+
+func TestForecast_ShortHistoryFallsBackToGaussian(t *testing.T) {
+	f, err := Forecast(randomWalk(70, 0, 4), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, iv := range f.Intervals {
+		if iv.CoverageSamples != 0 || !(iv.Low < f.Predicted && f.Predicted < iv.High) {
+			t.Fatalf("expected an unchecked band around the forecast, got %+v", iv)
+		}
+	}
+}
+
+func TestIntervalCoverage_OnlyUsesRealisedErrors(t *testing.T) {
+	// Constant errors until the last three origins jump: intervals built
+	// from the past can't see the jump coming, so those three miss.
+	var errs []originError
+	for o := 0; o < 40; o++ {
+		e := 0.01
+		if o >= 37 {
+			e = 1
+		}
+		errs = append(errs, originError{o, e})
+	}
+	cov, n := intervalCoverage(errs, 5, 0.8)
+	// Origin o knows the errors of origins 0..o-5; 24 is the first to know
+	// minCalibrationErrors of them.
+	if n != 16 || cov != 13.0/16 {
+		t.Fatalf("coverage %.3f over %d checks", cov, n)
+	}
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/store"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ledgerEvery is how often the ledger samples the published forecasts and
+// scores the ones whose horizon has elapsed. Daily bars only refresh
+// hourly, so looking more often finds nothing new.
+const ledgerEvery = 30 * time.Minute
+
+// ensembleModel names the weighted combination in the ledger; its members
+// are recorded under their own names.
+const ensembleModel = "ensemble"
+
+// ledgerEntry is one published forecast. Target and Realized are set once
+// it has been scored.
+type ledgerEntry struct {
+	ID        string  `json:"id"`
+	Symbol    string  `json:"symbol"`
+	Model     string  `json:"model"`
+	Horizon   int     `json:"horizon"` // trading days
+	IssuedAt  string  `json:"issuedAt"`
+	IssueDay  string  `json:"issueDay"` // exchange session date at issue
+	Base      float64 `json:"base"`     // price the forecast was made from
+	Predicted float64 `json:"predicted"`
+	Low       float64 `json:"low,omitempty"`
+	High      float64 `json:"high,omitempty"`
+	Direction string  `json:"direction"`
+
+	TargetDay string  `json:"targetDay,omitempty"`
+	Realized  float64 `json:"realized,omitempty"`
+	ScoredAt  string  `json:"scoredAt,omitempty"`
+}
+
+// ledgerScore settles the entry with the same ID.
+type ledgerScore struct {
+	ID        string  `json:"id"`
+	TargetDay string  `json:"targetDay"`
+	Realized  float64 `json:"realized"`
+	ScoredAt  string  `json:"scoredAt"`
+}
+
+// ledgerLine is one line of the ledger file: a forecast when it is
+// published, a score when its horizon has elapsed. The file is only ever
+// appended to, through store.AppendLines: a line torn by a crash is
+// skipped on load and never swallows the next one.
+type ledgerLine struct {
+	Forecast *ledgerEntry `json:"forecast,omitempty"`
+	Score    *ledgerScore `json:"score,omitempty"`
+}
+
+// ForecastLedger records the forecasts the API publishes and scores them
+// against the realised close, so their accuracy is measured live rather
+// than only on the in-sample replay backtest. Predictions are recomputed
+// every predictionTTL; the ledger keeps the first one per symbol, model,
+// horizon and trading session, which is the call a daily reader saw.
+type ForecastLedger struct {
+	market *MarketDataService
+	path   string // JSON lines; "" keeps the ledger in memory
+
+	loops runGroup
+
+	mu      sync.Mutex
+	entries []*ledgerEntry
+	byID    map[string]*ledgerEntry
+	issued  map[string]bool // ledgerKey of every entry
+}
+
+// NewForecastLedger loads the ledger at path, if it exists.
+func NewForecastLedger(market *MarketDataService, path string) (*ForecastLedger, error) {
+	l := &ForecastLedger{
+		market: market,
+		path:   path,
+		byID:   make(map[string]*ledgerEntry),
+		issued: make(map[string]bool),
+	}
+	if path == "" {
+		return l, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read forecast ledger: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		var line ledgerLine
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			log.Printf("forecast ledger: %s:%d: %v — skipped", path, n, err)
+			continue
+		}
+		switch {
+		case line.Forecast != nil:
+			l.insertLocked(line.Forecast)
+		case line.Score != nil:
+			if e, ok := l.byID[line.Score.ID]; ok {
+				settle(e, *line.Score)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read forecast ledger: %w", err)
+	}
+	return l, nil
+}
+
+// Start launches the record-and-score loop. Safe to call more than once.
+func (l *ForecastLedger) Start(ctx context.Context) {
+	l.loops.start(ctx, l.loop)
+}
+
+// Stop ends the loop, waiting out a pass in progress so the file isn't
+// left with a half-written line.
+func (l *ForecastLedger) Stop() {
+	l.loops.stop()
+}
+
+func (l *ForecastLedger) loop(ctx context.Context) {
+	l.run(time.Now().UTC())
+	every(ctx, ledgerEvery, func(context.Context) {
+		l.run(time.Now().UTC())
+	})
+}
+
+func (l *ForecastLedger) run(now time.Time) {
+	l.score(now)
+	l.record(now)
+}
+
+func ledgerKey(e *ledgerEntry) string {
+	return fmt.Sprintf("%s|%s|%d|%s", e.Symbol, e.Model, e.Horizon, e.IssueDay)
+}
+
+func (l *ForecastLedger) insertLocked(e *ledgerEntry) {
+	l.entries = append(l.entries, e)
+	l.byID[e.ID] = e
+	l.issued[ledgerKey(e)] = true
+}
+
+func settle(e *ledgerEntry, s ledgerScore) {
+	e.TargetDay, e.Realized, e.ScoredAt = s.TargetDay, s.Realized, s.ScoredAt
+}
+
+// record adds the currently published forecasts that aren't in the ledger
+// for the latest session yet: the headline /api/predictions call and every
+// horizon of the /api/predictions/{symbol} ensemble with its members.
+// Forecasts built on synthetic prices or fallbacks are not recorded. The
+// session is the newest daily bar's, not the calendar day, so a weekend or
+// holiday run doesn't log Friday's forecasts again.
+func (l *ForecastLedger) record(now time.Time) {
+	var batch []*ledgerEntry
+	preds, forecasts := l.market.predictions()
+	for _, p := range preds {
+		f, ok := forecasts[p.Symbol]
+		if !ok || p.Current <= 0 || isSynthetic(p.Provenance) {
+			continue
+		}
+		batch = append(batch, &ledgerEntry{
+			Symbol: p.Symbol, Model: p.Model, Horizon: f.HorizonDays,
+			Base: p.Current, Predicted: p.Predicted, Low: p.PredictedLow, High: p.PredictedHigh,
+			Direction: p.Direction,
+		})
+	}
+	for _, c := range allCommodities {
+		set, _ := l.market.GetForecastSet(c.symbol, nil)
+		if set.Current <= 0 || isSynthetic(set.Provenance) {
+			continue
+		}
+		for _, h := range set.Horizons {
+			batch = append(batch, &ledgerEntry{
+				Symbol: set.Symbol, Model: ensembleModel, Horizon: h.Horizon,
+				Base: set.Current, Predicted: h.Predicted, Low: h.Low, High: h.High,
+				Direction: h.Direction,
+			})
+			for _, m := range h.Models {
+				batch = append(batch, &ledgerEntry{
+					Symbol: set.Symbol, Model: m.Model, Horizon: h.Horizon,
+					Base: set.Current, Predicted: m.Predicted,
+					Direction: callDirection(set.Current, m.Predicted),
+				})
+			}
+		}
+	}
+
+	issuedAt := now.Format(time.RFC3339)
+	sessions := make(map[string]string)
+	for _, e := range batch {
+		if _, ok := sessions[e.Symbol]; !ok {
+			sessions[e.Symbol] = l.lastSession(e.Symbol)
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var lines []ledgerLine
+	for _, e := range batch {
+		e.IssuedAt, e.IssueDay = issuedAt, sessions[e.Symbol]
+		if e.IssueDay == "" || l.issued[ledgerKey(e)] {
+			continue
+		}
+		e.ID = newID()
+		l.insertLocked(e)
+		lines = append(lines, ledgerLine{Forecast: e})
+	}
+	if err := l.appendLocked(lines); err != nil {
+		log.Printf("forecast ledger: %v", err)
+	}
+}
+
+// lastSession is the exchange date of symbol's newest daily bar, or ""
+// without history.
+func (l *ForecastLedger) lastSession(symbol string) string {
+	bars, _ := l.market.dailyHistory(symbol, 0)
+	if len(bars) == 0 {
+		return ""
+	}
+	return exchangeDay(bars[len(bars)-1].Time)
+}
+
+func isSynthetic(p *models.Provenance) bool {
+	return p != nil && p.Synthetic
+}
+
+// score settles every pending forecast whose target session has closed.
+// The target is the Horizon-th daily bar after the issue session, matching
+// the models, which step one daily close at a time from a series ending
+// with the live price.
+func (l *ForecastLedger) score(now time.Time) {
+	today := exchangeDay(now.Unix())
+	pending := make(map[string][]*ledgerEntry)
+	l.mu.Lock()
+	for _, e := range l.entries {
+		if e.TargetDay == "" {
+			pending[e.Symbol] = append(pending[e.Symbol], e)
+		}
+	}
+	l.mu.Unlock()
+
+	var scores []ledgerScore
+	scoredAt := now.Format(time.RFC3339)
+	for symbol, entries := range pending {
+		bars, _ := l.market.dailyHistory(symbol, 0)
+		days := make([]string, len(bars))
+		for i, b := range bars {
+			days[i] = exchangeDay(b.Time)
+		}
+		for _, e := range entries {
+			i := sort.SearchStrings(days, e.IssueDay)
+			if i < len(days) && days[i] == e.IssueDay {
+				i++
+			}
+			i += e.Horizon - 1
+			// A bar for today is still trading.
+			if i >= len(bars) || days[i] >= today || bars[i].Close <= 0 {
+				continue
+			}
+			scores = append(scores, ledgerScore{ID: e.ID, TargetDay: days[i], Realized: bars[i].Close, ScoredAt: scoredAt})
+		}
+	}
+	if len(scores) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lines := make([]ledgerLine, len(scores))
+	for i := range scores {
+		settle(l.byID[scores[i].ID], scores[i])
+		lines[i] = ledgerLine{Score: &scores[i]}
+	}
+	if err := l.appendLocked(lines); err != nil {
+		log.Printf("forecast ledger: %v", err)
+	}
+}
+
+func (l *ForecastLedger) appendLocked(lines []ledgerLine) error {
+	if l.path == "" || len(lines) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("save forecast ledger: %w", err)
+	}
+	docs := make([]any, len(lines))
+	for i, line := range lines {
+		docs[i] = line
+	}
+	if err := store.AppendLines(l.path, docs); err != nil {
+		return fmt.Errorf("save forecast ledger: %w", err)
+	}
+	return nil
+}
+
+// trackTally accumulates one TrackRecordRow.
+type trackTally struct {
+	row           models.TrackRecordRow
+	hits          int
+	ape, naiveAPE float64
+	inside        int
+}
+
+func (t *trackTally) add(e *ledgerEntry) {
+	t.row.Published++
+	if e.TargetDay == "" {
+		t.row.Pending++
+		return
+	}
+	t.row.Scored++
+	if e.Direction == callDirection(e.Base, e.Realized) {
+		t.hits++
+	}
+	t.ape += math.Abs(e.Predicted-e.Realized) / e.Realized
+	t.naiveAPE += math.Abs(e.Base-e.Realized) / e.Realized
+	if e.High > e.Low {
+		t.row.Banded++
+		if e.Low <= e.Realized && e.Realized <= e.High {
+			t.inside++
+		}
+	}
+	if e.ScoredAt > t.row.LastScoredAt {
+		t.row.LastScoredAt = e.ScoredAt
+	}
+}
+
+func (t *trackTally) result() models.TrackRecordRow {
+	r := t.row
+	if r.Scored > 0 {
+		n := float64(r.Scored)
+		r.HitRate = math.Round(float64(t.hits)/n*1000) / 1000
+		r.MAPE = math.Round(t.ape/n*10000) / 10000
+		r.NaiveMAPE = math.Round(t.naiveAPE/n*10000) / 10000
+		if t.naiveAPE > 0 {
+			r.Skill = math.Round((1-t.ape/t.naiveAPE)*1000) / 1000
+		}
+	}
+	if r.Banded > 0 {
+		c := math.Round(float64(t.inside)/float64(r.Banded)*1000) / 1000
+		r.Coverage = &c
+	}
+	return r
+}
+
+// TrackRecord reports the ledger's live accuracy, for one symbol or, with
+// symbol == "", all of them.
+func (l *ForecastLedger) TrackRecord(symbol string) models.TrackRecord {
+	rows := make(map[string]*trackTally)
+	pooled := make(map[string]*trackTally)
+	tally := func(m map[string]*trackTally, sym string, e *ledgerEntry) {
+		key := fmt.Sprintf("%s|%s|%d", sym, e.Model, e.Horizon)
+		t, ok := m[key]
+		if !ok {
+			t = &trackTally{row: models.TrackRecordRow{Symbol: sym, Model: e.Model, Horizon: e.Horizon}}
+			m[key] = t
+		}
+		t.add(e)
+	}
+
+	out := models.TrackRecord{GeneratedAt: time.Now().UTC().Format(time.RFC3339)}
+	l.mu.Lock()
+	for _, e := range l.entries {
+		if symbol != "" && e.Symbol != symbol {
+			continue
+		}
+		if out.Since == "" || e.IssuedAt < out.Since {
+			out.Since = e.IssuedAt
+		}
+		tally(rows, e.Symbol, e)
+		tally(pooled, "", e)
+	}
+	l.mu.Unlock()
+
+	out.Rows = sortedRows(rows)
+	out.Models = sortedRows(pooled)
+	return out
+}
+
+func sortedRows(m map[string]*trackTally) []models.TrackRecordRow {
+	out := make([]models.TrackRecordRow, 0, len(m))
+	for _, t := range m {
+		out = append(out, t.result())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.Horizon < b.Horizon
+	})
+	return out
+}
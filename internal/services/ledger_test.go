@@ -0,0 +1,148 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestForecastLedger_RecordsScoresAndReloads(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	src := &fakeSource{name: "replay", caps: CapHistory, history: map[string][]models.OHLCV{}}
+	svc.sources.Register(src, 10)
+
+	// Daily bars at 15:00 UTC (10:00 in New York), one per calendar day.
+	t0 := time.Date(2025, 1, 1, 15, 0, 0, 0, time.UTC)
+	closes := randomWalk(400, 0, 21)
+	bars := make([]models.OHLCV, len(closes))
+	for i, c := range closes {
+		bars[i] = models.OHLCV{Time: t0.AddDate(0, 0, i).Unix(), Close: c}
+	}
+	src.history["WTI"] = bars[:300]
+
+	path := filepath.Join(t.TempDir(), "forecasts.jsonl")
+	l, err := NewForecastLedger(svc, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued := t0.AddDate(0, 0, 300) // the day after the last bar
+	l.record(issued)
+	published := len(l.entries)
+	want := len(forecastHorizons) * (1 + len(ensembleModels))
+	if published < want {
+		t.Fatalf("recorded %d forecasts, want at least %d", published, want)
+	}
+	l.record(issued.Add(time.Hour))
+	if len(l.entries) != published {
+		t.Fatalf("second run on the same session added %d entries", len(l.entries)-published)
+	}
+	// No session has traded since (a weekend, say): nothing new either.
+	l.record(issued.AddDate(0, 0, 2))
+	if len(l.entries) != published {
+		t.Fatalf("run without a new session added %d entries", len(l.entries)-published)
+	}
+	if e := l.entries[0]; e.IssueDay != exchangeDay(bars[299].Time) {
+		t.Fatalf("issued on %s, want the last bar's session", e.IssueDay)
+	}
+
+	// Nothing has elapsed yet.
+	l.score(issued)
+	if rec := l.TrackRecord("WTI"); rec.Rows[0].Scored != 0 {
+		t.Fatalf("scored before the horizon elapsed: %+v", rec.Rows[0])
+	}
+
+	// Eleven more sessions: the 1- and 5-day calls settle, the rest wait.
+	src.history["WTI"] = bars[:311]
+	l.score(t0.AddDate(0, 0, 311))
+	rows := map[int]models.TrackRecordRow{}
+	for _, r := range l.TrackRecord("WTI").Rows {
+		if r.Model == ensembleModel {
+			rows[r.Horizon] = r
+		}
+	}
+	if r := rows[1]; r.Scored != 1 || r.Pending != 0 || r.Coverage == nil || r.Banded != 1 || r.NaiveMAPE <= 0 {
+		t.Fatalf("h=1: %+v", r)
+	}
+	if r := rows[20]; r.Scored != 0 || r.Pending != 1 || r.Coverage != nil {
+		t.Fatalf("h=20: %+v", r)
+	}
+	for _, e := range l.entries {
+		if e.Model == ensembleModel && e.Horizon == 5 {
+			if e.Realized != bars[304].Close || e.TargetDay != exchangeDay(bars[304].Time) {
+				t.Fatalf("h=5 scored against %s %.2f, want bar 304", e.TargetDay, e.Realized)
+			}
+		}
+	}
+
+	reloaded, err := NewForecastLedger(svc, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := l.TrackRecord(""), reloaded.TrackRecord("")
+	if len(a.Rows) != len(b.Rows) || len(b.Models) == 0 {
+		t.Fatalf("reloaded %d rows, want %d", len(b.Rows), len(a.Rows))
+	}
+	for i := range a.Rows {
+		if a.Rows[i].Scored != b.Rows[i].Scored || a.Rows[i].MAPE != b.Rows[i].MAPE {
+			t.Fatalf("row %d differs after reload: %+v vs %+v", i, a.Rows[i], b.Rows[i])
+		}
+	}
+}
+
+func TestForecastLedger_AppendAfterTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forecasts.jsonl")
+	l, err := NewForecastLedger(nil, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &ledgerEntry{ID: "a", Symbol: "WTI", Model: ensembleModel, Horizon: 1, IssueDay: "2026-04-01"}
+	if err := l.appendLocked([]ledgerLine{{Forecast: a}}); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a crash halfway through the next forecast.
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`{"forecast":{"id":"b","sym`)
+	f.Close()
+
+	c := &ledgerEntry{ID: "c", Symbol: "WTI", Model: ensembleModel, Horizon: 1, IssueDay: "2026-04-02"}
+	score := &ledgerScore{ID: "a", TargetDay: "2026-04-02", Realized: 81}
+	if err := l.appendLocked([]ledgerLine{{Forecast: c}, {Score: score}}); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewForecastLedger(nil, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.entries) != 2 || reloaded.byID["c"] == nil {
+		t.Fatalf("expected forecasts a and c after the torn line, got %d entries", len(reloaded.entries))
+	}
+	if e := reloaded.byID["a"]; e.Realized != 81 {
+		t.Fatalf("expected a to be scored after the torn line, got %+v", e)
+	}
+}
+
+func TestTrackTally(t *testing.T) {
+	var tt trackTally
+	for _, e := range []*ledgerEntry{
+		{Base: 100, Predicted: 102, Low: 98, High: 106, Direction: "bullish", TargetDay: "d", Realized: 104},
+		{Base: 100, Predicted: 102, Low: 98, High: 106, Direction: "bullish", TargetDay: "d", Realized: 90},
+		{Base: 100, Predicted: 100, Direction: "neutral"},
+	} {
+		tt.add(e)
+	}
+	r := tt.result()
+	if r.Published != 3 || r.Scored != 2 || r.Pending != 1 {
+		t.Fatalf("counts: %+v", r)
+	}
+	if r.HitRate != 0.5 || r.Coverage == nil || *r.Coverage != 0.5 || r.Banded != 2 {
+		t.Fatalf("hit rate / coverage: %+v", r)
+	}
+	// |102-104|/104 and |102-90|/90 vs |100-104|/104 and |100-90|/90.
+	if r.MAPE != 0.0763 || r.NaiveMAPE != 0.0748 || r.Skill >= 0 {
+		t.Fatalf("errors: %+v", r)
+	}
+}
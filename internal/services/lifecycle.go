@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Lifecycle is implemented by services that do background work. Their
+// constructors only set up state (and reload it from the store); nothing
+// touches the network until Start, which launches the work under ctx and
+// returns immediately. Stop cancels it and waits for it to finish. Both
+// are safe to call more than once, and a stopped service stays stopped.
+type Lifecycle interface {
+	Start(ctx context.Context)
+	Stop()
+}
+
+// runGroup owns a service's background goroutines: start launches them
+// under a cancellable child of the caller's context, stop cancels that
+// and waits for them to return.
+type runGroup struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// start runs each loop on its own goroutine. Only the first call (before
+// any stop) does anything; it reports whether this was it.
+func (g *runGroup) start(parent context.Context, loops ...func(context.Context)) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.ctx != nil {
+		return false
+	}
+	g.ctx, g.cancel = context.WithCancel(parent)
+	for _, loop := range loops {
+		g.wg.Add(1)
+		go func(loop func(context.Context)) {
+			defer g.wg.Done()
+			loop(g.ctx)
+		}(loop)
+	}
+	return true
+}
+
+// stop cancels the loops and waits for them. A group stopped before it
+// was started can't be started afterwards.
+func (g *runGroup) stop() {
+	g.mu.Lock()
+	if g.ctx == nil {
+		g.ctx, g.cancel = context.WithCancel(context.Background())
+	}
+	g.cancel()
+	g.mu.Unlock()
+	g.wg.Wait()
+}
+
+// context is the context the loops run under, for work started outside
+// them (a webhook delivery). Before start it is context.Background().
+func (g *runGroup) context() context.Context {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.ctx == nil {
+		return context.Background()
+	}
+	return g.ctx
+}
+
+// every calls fn each interval until ctx is done. The first call is one
+// interval in; callers that want an immediate run make it themselves.
+func every(ctx context.Context, interval time.Duration, fn func(context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(ctx)
+		}
+	}
+}
+
+// sleepCtx waits for d, returning false if ctx is done first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunGroupStartsOnceAndStopWaits(t *testing.T) {
+	var g runGroup
+	var running, starts atomic.Int32
+	loop := func(ctx context.Context) {
+		starts.Add(1)
+		running.Add(1)
+		defer running.Add(-1)
+		<-ctx.Done()
+	}
+	if !g.start(context.Background(), loop, loop) || g.start(context.Background(), loop) {
+		t.Fatal("start should only take effect once")
+	}
+	for running.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	g.stop()
+	if n := running.Load(); n != 0 {
+		t.Fatalf("stop returned with %d loops running", n)
+	}
+	g.stop()
+	if starts.Load() != 2 {
+		t.Fatalf("%d loops started", starts.Load())
+	}
+
+	var stopped runGroup
+	stopped.stop()
+	if stopped.start(context.Background(), loop) {
+		t.Fatal("a group stopped before starting must stay stopped")
+	}
+}
+
+// The constructor must not touch the network; Start fetches right away and
+// Stop ends the polling.
+func TestNewsFeedLifecycle(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, `<rss><channel><item><title>Brent rallies</title><link>https://example.com/a</link><pubDate>Mon, 01 Jan 2024 10:00:00 +0000</pubDate></item></channel></rss>`)
+	}))
+	defer srv.Close()
+
+	prevFeeds, prevEvery := newsFeeds, newsRefreshEvery
+	newsFeeds = []feedSource{{url: srv.URL, category: "Oil Markets"}}
+	newsRefreshEvery = time.Hour
+	defer func() { newsFeeds, newsRefreshEvery = prevFeeds, prevEvery }()
+
+	svc := NewNewsFeedService(nil)
+	if hits.Load() != 0 {
+		t.Fatal("constructor fetched")
+	}
+	svc.Start(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for len(svc.GetNews()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	svc.Stop()
+	svc.Stop()
+	if len(svc.GetNews()) != 1 || hits.Load() != 1 {
+		t.Fatalf("%d articles after %d fetches", len(svc.GetNews()), hits.Load())
+	}
+}
+
+// Stopping the engine abandons a webhook waiting to retry instead of
+// sleeping out the backoff.
+func TestAlertEngineStopAbandonsRetries(t *testing.T) {
+	rec := &webhookRecorder{codes: []int{503}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	e := newTestAlertEngine(t, "")
+	e.retryBase = time.Hour
+	e.Start(context.Background())
+	e.dispatch(models.Alert{ID: "a1", WebhookURL: srv.URL}, models.AlertEvent{AlertID: "a1", Message: "test"})
+	for d := e.Deliveries("a1"); len(d) == 0 || d[0].Attempts == 0; d = e.Deliveries("a1") {
+		time.Sleep(time.Millisecond)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		e.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop waited out the retry backoff")
+	}
+	if d := e.Deliveries("a1"); d[0].Status != deliveryFailed || d[0].Attempts != 1 {
+		t.Fatalf("delivery %+v", d[0])
+	}
+}
@@ -1,29 +1,66 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"hash/fnv"
 	"io"
 	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/store"
 	"math"
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
 )
 
 type MarketDataService struct {
+	// rng draws the synthetic fallback quotes. GetPrices runs on request
+	// goroutines and the bus subscribers at once, so it's behind rngMu.
+	rngMu      sync.Mutex
 	rng        *rand.Rand
 	basePrices map[string]float64
-	yahoo      *YahooFinanceService
-	pyth       *PythService
 	eia        *EIAService
 
+	// sources holds every PriceSource (Yahoo, Pyth, ...) with its per-symbol
+	// priority. Quotes, history and intraday bars are all resolved through
+	// it, so a new feed only needs registering, not a change here.
+	sources *SourceRegistry
+
 	// Predictions are computed with a damped-Holt fit + 30-step rolling-origin
 	// backtest per symbol, which is heavy enough that we don't want to do it
 	// on every /api/predictions hit or every page render. Cached for predictionTTL.
+	// cachedForecasts keeps the raw ForecastResult behind each real
+	// prediction so GetAnalysis can reuse the indicator readings.
 	predictionsMu     sync.RWMutex
 	cachedPredictions []models.Prediction
+	cachedForecasts   map[string]ForecastResult
 	cachedPredAt      time.Time
+	// horizonForecasts caches GetForecastSet's ensemble fits by
+	// "SYMBOL/horizon", also for predictionTTL.
+	horizonForecasts map[string]horizonEntry
+
+	// news feeds the category counts in GetAnalysis. Optional.
+	news NewsSource
+
+	// customSpreads are user-defined spreads registered with AddSpreads;
+	// they sit alongside (and can replace) builtinSpreads.
+	customSpreads []SpreadDefinition
+
+	// stream fans price/bar changes out to /api/stream subscribers. The
+	// bus subscription that feeds it is made lazily on the first
+	// Subscribe so tests and one-shot tools never start it.
+	stream     *StreamHub
+	streamOnce sync.Once
+
+	// bus carries the feed services' events. NewMarketDataService creates
+	// it and hands it to every feed it builds; see Bus. unsubscribe holds
+	// the cancel funcs of this service's own subscriptions, for Stop;
+	// once stopped, the lazy stream subscription is no longer made.
+	bus         *Bus
+	subsMu      sync.Mutex
+	unsubscribe []func()
+	stopped     bool
 }
 
 // predictionTTL bounds how stale GetPredictions can be. The underlying
@@ -31,28 +68,117 @@ type MarketDataService struct {
 // still keeping the model off the critical request path.
 const predictionTTL = 60 * time.Second
 
-func NewMarketDataService() *MarketDataService {
-	bases := map[string]float64{
-		"WTI":     72.45,
-		"BRENT":   76.82,
-		"NATGAS":  3.24,
-		"HEATING": 2.35,
-		"RBOB":    2.18,
-		"OPEC":    74.50,
-		"DUBAI":   75.10,
-		"MURBAN":  76.30,
-		"WCS":     58.20,
-		"GASOIL":  685.50,
-	}
-	return &MarketDataService{
+// Default source priorities. Pyth ticks rank above Yahoo so they overlay
+// Yahoo's delayed quote wherever both cover a symbol.
+const (
+	yahooPriority = 10
+	pythPriority  = 20
+)
+
+// NewMarketDataService wires up the upstream feeds without starting them;
+// call Start. st is the persistent store shared by the Yahoo and Pyth
+// services; pass nil to run purely in memory.
+func NewMarketDataService(st store.Store) *MarketDataService {
+	bases := make(map[string]float64, len(allCommodities))
+	for _, c := range allCommodities {
+		bases[c.symbol] = c.basePrice
+	}
+	bus := NewBus()
+	sources := NewSourceRegistry()
+	sources.Register(NewYahooFinanceService(st, bus), yahooPriority)
+	sources.Register(NewPythService(st, bus), pythPriority)
+	s := &MarketDataService{
 		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
 		basePrices: bases,
-		yahoo:      NewYahooFinanceService(),
-		pyth:       NewPythService(),
-		eia:        NewEIAService(),
+		eia:        NewEIAService(bus),
+		sources:    sources,
+		stream:     NewStreamHub(),
+		bus:        bus,
+	}
+	s.subscribe("predictions", s.invalidatePredictions, KindHistoryRefreshed)
+	return s
+}
+
+// Start starts every registered source that has a Lifecycle, and the EIA
+// outlook. Sources registered later must be started by whoever adds them.
+func (s *MarketDataService) Start(ctx context.Context) {
+	for _, src := range s.sources.All() {
+		if lc, ok := src.(Lifecycle); ok {
+			lc.Start(ctx)
+		}
+	}
+	s.eia.Start(ctx)
+}
+
+// Stop stops the sources and the EIA outlook, waiting for their loops to
+// return, then ends the stream's and the prediction cache's
+// subscriptions, waiting for a delivery in progress. Stream subscribers
+// stay connected but get no further events, and a stream first opened
+// after Stop never subscribes.
+func (s *MarketDataService) Stop() {
+	for _, src := range s.sources.All() {
+		if lc, ok := src.(Lifecycle); ok {
+			lc.Stop()
+		}
+	}
+	s.eia.Stop()
+
+	s.subsMu.Lock()
+	unsubscribe := s.unsubscribe
+	s.unsubscribe, s.stopped = nil, true
+	s.subsMu.Unlock()
+	for _, cancel := range unsubscribe {
+		cancel()
 	}
 }
 
+// subscribe adds one of this service's own bus subscriptions, unless
+// Stop has already run.
+func (s *MarketDataService) subscribe(name string, fn func(Event), kinds ...string) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.unsubscribe = append(s.unsubscribe, s.bus.Subscribe(name, fn, kinds...))
+}
+
+// Bus is the event bus the feeds publish on. Services built outside
+// NewMarketDataService (a news feed, a replay source) should publish on
+// it too so the stream and alerts see them.
+func (s *MarketDataService) Bus() *Bus {
+	return s.bus
+}
+
+// invalidatePredictions drops the cached forecasts when new daily bars
+// land, so the next request refits on them instead of waiting out
+// predictionTTL. Intraday refreshes don't feed the models.
+func (s *MarketDataService) invalidatePredictions(e Event) {
+	if h, ok := e.(HistoryRefreshed); !ok || h.Interval != "1d" {
+		return
+	}
+	s.predictionsMu.Lock()
+	s.cachedPredAt = time.Time{}
+	s.horizonForecasts = nil
+	s.predictionsMu.Unlock()
+}
+
+// Sources exposes the provider registry so callers can register extra
+// feeds (an internal desk feed, a CSV replay) or re-rank existing ones.
+func (s *MarketDataService) Sources() *SourceRegistry {
+	return s.sources
+}
+
+// SourceHealth reports the health of every registered provider.
+func (s *MarketDataService) SourceHealth() []SourceHealth {
+	srcs := s.sources.All()
+	out := make([]SourceHealth, len(srcs))
+	for i, src := range srcs {
+		out[i] = src.Health()
+	}
+	return out
+}
+
 // GetConsensusForecasts returns the institutional outlook (EIA STEO) for
 // every benchmark we publish. Returns an empty slice when EIA_API_KEY isn't
 // configured — the UI hides the section gracefully in that case.
@@ -71,95 +197,133 @@ func (s *MarketDataService) GetConsensusForecast(symbol string) (models.Consensu
 	return s.eia.Get(symbol)
 }
 
-var commodityNames = map[string]string{
-	"WTI": "WTI Crude Oil", "BRENT": "Brent Crude Oil",
-	"NATGAS": "Natural Gas", "HEATING": "Heating Oil",
-	"RBOB": "RBOB Gasoline", "OPEC": "OPEC Basket",
-	"DUBAI": "Dubai Crude", "MURBAN": "Murban Crude",
-	"WCS": "Western Canadian Select", "GASOIL": "ICE Gasoil",
+// commodity is one symbol served by GetPrices. basePrice seeds the
+// synthetic estimate used when no source quotes it.
+type commodity struct {
+	symbol    string
+	name      string
+	basePrice float64
 }
 
-var allCommodities = []struct {
-	symbol string
-	name   string
-}{
-	{"WTI", "WTI Crude Oil"},
-	{"BRENT", "Brent Crude Oil"},
-	{"NATGAS", "Natural Gas"},
-	{"HEATING", "Heating Oil"},
-	{"RBOB", "RBOB Gasoline"},
-	{"OPEC", "OPEC Basket"},
-	{"DUBAI", "Dubai Crude"},
-	{"MURBAN", "Murban Crude"},
-	{"WCS", "Western Canadian Select"},
-	{"GASOIL", "ICE Gasoil"},
-}
+// allCommodities (in display order) and commodityNames are set by
+// Configure.
+var (
+	allCommodities []commodity
+	commodityNames map[string]string
+)
 
 func (s *MarketDataService) GetPrices() []models.Price {
-	var yahooData map[string]models.Price
-	if s.yahoo != nil {
-		yahooData = s.yahoo.GetPrices()
-	}
-	var pythData map[string]PythQuote
-	if s.pyth != nil {
-		pythData = s.pyth.GetQuotes()
-	}
-	now := time.Now().UTC().Format(time.RFC3339)
+	snapshot := s.quoteSnapshot()
+	nowT := time.Now().UTC()
+	now := nowT.Format(time.RFC3339)
 	prices := make([]models.Price, len(allCommodities))
 
 	for i, c := range allCommodities {
-		yp, hasYahoo := yahooData[c.symbol]
-		pq, hasPyth := pythData[c.symbol]
-
-		switch {
-		case hasPyth && hasYahoo:
-			// Real-time Pyth tick over Yahoo daily metadata.
-			prices[i] = applyPyth(yp, pq)
-			continue
-		case hasPyth:
-			// Pyth-only: surface the live tick, no daily baseline.
-			prices[i] = applyPyth(models.Price{Symbol: c.symbol, Name: c.name}, pq)
-			continue
-		case hasYahoo:
-			prices[i] = yp
+		if p, ok := s.mergedQuote(c.symbol, snapshot); ok {
+			if p.Name == "" {
+				p.Name = c.name
+			}
+			p.Provenance = stamped(p.Provenance, nowT)
+			prices[i] = p
 			continue
 		}
 		// Synthetic fallback for commodities without a Yahoo Finance ticker
 		base := s.basePrices[c.symbol]
 		volatility := base * 0.008
+		dayVolatility := base * 0.02
+		s.rngMu.Lock()
 		change := (s.rng.Float64() - 0.45) * volatility
 		price := base + change
-		changePct := (change / base) * 100
-		dayVolatility := base * 0.02
 		high := price + s.rng.Float64()*dayVolatility
 		low := price - s.rng.Float64()*dayVolatility
 		volume := int64(500000 + s.rng.Intn(2000000))
+		s.rngMu.Unlock()
+		changePct := (change / base) * 100
 		prices[i] = models.Price{
-			Symbol:    c.symbol,
-			Name:      c.name,
-			Price:     math.Round(price*100) / 100,
-			Change:    math.Round(change*100) / 100,
-			ChangePct: math.Round(changePct*100) / 100,
-			High:      math.Round(high*100) / 100,
-			Low:       math.Round(low*100) / 100,
-			Volume:    volume,
-			UpdatedAt: now,
-			Source:    "estimate",
+			Symbol:     c.symbol,
+			Name:       c.name,
+			Price:      math.Round(price*100) / 100,
+			Change:     math.Round(change*100) / 100,
+			ChangePct:  math.Round(changePct*100) / 100,
+			High:       math.Round(high*100) / 100,
+			Low:        math.Round(low*100) / 100,
+			Volume:     volume,
+			UpdatedAt:  now,
+			Source:     sourceEstimate,
+			Provenance: syntheticProvenance(nowT),
 		}
 	}
 	return prices
 }
 
+// quoteSnapshot reads every quoting source's cache once, keyed by source
+// name, so a GetPrices call sees one consistent view per source.
+func (s *MarketDataService) quoteSnapshot() map[string]map[string]models.Price {
+	out := make(map[string]map[string]models.Price)
+	for _, src := range s.sources.All() {
+		if src.Capabilities().Has(CapQuotes) {
+			out[src.Name()] = src.Quotes()
+		}
+	}
+	return out
+}
+
+// mergedQuote resolves one symbol's price from the snapshot in registry
+// priority order (see mergeQuotes).
+func (s *MarketDataService) mergedQuote(symbol string, snapshot map[string]map[string]models.Price) (models.Price, bool) {
+	var quotes []models.Price
+	var ticks []bool
+	for _, src := range s.sources.ForSymbol(symbol, CapQuotes) {
+		if q, ok := snapshot[src.Name()][symbol]; ok {
+			if q.Provenance == nil {
+				// Third-party sources needn't know about provenance; the
+				// registry still knows who supplied the quote.
+				asOf, _ := time.Parse(time.RFC3339, q.UpdatedAt)
+				q.Provenance = newProvenance([]string{src.Name()}, time.Time{}, asOf)
+			}
+			quotes = append(quotes, q)
+			ticks = append(ticks, src.Capabilities().Has(CapTicks))
+		}
+	}
+	return mergeQuotes(quotes, ticks)
+}
+
+// latestQuote returns the merged real quote for one symbol, if any source
+// has it.
+func (s *MarketDataService) latestQuote(symbol string) (models.Price, bool) {
+	return s.mergedQuote(symbol, s.quoteSnapshot())
+}
+
+// dailyHistory returns daily bars from the highest-priority history
+// source that has any for symbol, along with that source's name.
+func (s *MarketDataService) dailyHistory(symbol string, days int) ([]models.OHLCV, string) {
+	for _, src := range s.sources.ForSymbol(symbol, CapHistory) {
+		if bars := src.History(symbol, days); len(bars) > 0 {
+			return bars, src.Name()
+		}
+	}
+	return nil, ""
+}
+
+// liveSource returns the highest-priority tick source that can build live
+// bars for symbol, or nil.
+func (s *MarketDataService) liveSource(symbol string) LiveBarSource {
+	for _, src := range s.sources.ForSymbol(symbol, CapTicks) {
+		if live, ok := src.(LiveBarSource); ok {
+			return live
+		}
+	}
+	return nil
+}
+
 func (s *MarketDataService) GetChartData(symbol string, days int, interval string) models.ChartData {
 	base, ok := s.basePrices[symbol]
 	if !ok {
 		base = 72.0
 	}
 
-	if s.yahoo != nil {
-		if yp, ok := s.yahoo.GetPrices()[symbol]; ok {
-			base = yp.Price
-		}
+	if q, ok := s.latestQuote(symbol); ok {
+		base = q.Price
 	}
 
 	name := symbol
@@ -168,45 +332,238 @@ func (s *MarketDataService) GetChartData(symbol string, days int, interval strin
 	}
 
 	if interval == "" {
-		switch {
-		case days <= 7:
-			interval = "2h"
-		case days <= 30:
-			interval = "4h"
-		default:
-			interval = "1d"
+		interval = defaultChartInterval(days)
+	}
+	bucketSec, intraday := chartIntervals[interval]
+	if !intraday {
+		interval = "1d"
+	}
+	out := models.ChartData{Symbol: symbol, Name: name, Interval: interval}
+
+	// Prefer REAL cached bars when available. This is what the user sees
+	// on /charts and we want it to be actual market history, not a
+	// randomly regenerated series: daily bars straight from the history
+	// source, intraday intervals resampled from the 5-minute cache plus
+	// the live 1-minute candles. If the caches haven't loaded yet (cold
+	// start) or no source tracks this symbol (estimates: OPEC, DUBAI,
+	// etc.), we fall through to the synthetic generator below.
+	now := time.Now()
+	if intraday {
+		since := now.Add(-time.Duration(days) * 24 * time.Hour)
+		if bars, sources := s.intradayChart(symbol, since, bucketSec); len(bars) > 0 {
+			out.Data = bars
+			out.Provenance = stamped(newProvenance(sources, barsFetchedAt(sources, false), barsAsOf(bars)), now)
+			return out
+		}
+	} else if bars, source := s.dailyHistory(symbol, days); len(bars) > 0 {
+		sources := []string{source}
+		out.Data = bars
+		out.Provenance = stamped(newProvenance(sources, barsFetchedAt(sources, true), barsAsOf(bars)), now)
+		return out
+	}
+
+	// Synthetic fallback, flagged as such so clients never mistake it for
+	// market data. We seed a per-call RNG with a hash of (symbol, days,
+	// today's UTC date) so flipping back and forth between tabs returns
+	// the SAME chart instead of a freshly randomised series. The series
+	// naturally rolls over once a day when the seed changes.
+	rng := rand.New(rand.NewSource(syntheticChartSeed(symbol, days, interval)))
+	if intraday {
+		out.Data = s.generateIntraday(rng, base, days, bucketSec)
+	} else {
+		out.Data = s.generateDaily(rng, base, days)
+	}
+	out.Provenance = syntheticProvenance(now)
+	return out
+}
+
+// chartIntervals maps every intraday interval /api/charts accepts to its
+// bucket size in seconds. Anything else is served as daily bars.
+var chartIntervals = map[string]int64{
+	"1m":  60,
+	"5m":  300,
+	"15m": 900,
+	"1h":  3600,
+	"2h":  7200,
+	"4h":  14400,
+}
+
+// defaultChartInterval picks a bar size that keeps a days-long chart
+// readable.
+func defaultChartInterval(days int) string {
+	switch {
+	case days <= 7:
+		return "2h"
+	case days <= 30:
+		return "4h"
+	default:
+		return "1d"
+	}
+}
+
+// minChartWindow is how far back charts may always reach; symbols with no
+// stored history are served the synthetic series over this window.
+const minChartWindow = 365 * 24 * time.Hour
+
+// ChartWindow reports the earliest time the chart API can serve for symbol
+// at interval: the oldest stored daily bar (at least minChartWindow back)
+// or the start of the intraday cache. ok is false for an interval the
+// chart API doesn't support ("" counts as daily).
+func (s *MarketDataService) ChartWindow(symbol, interval string) (earliest time.Time, ok bool) {
+	now := time.Now()
+	if _, intraday := chartIntervals[interval]; intraday {
+		return now.Add(-yahooIntradayRange), true
+	}
+	if interval != "" && interval != "1d" {
+		return time.Time{}, false
+	}
+	earliest = now.Add(-minChartWindow)
+	if bars, _ := s.dailyHistory(symbol, 0); len(bars) > 0 {
+		if first := time.Unix(bars[0].Time, 0); first.Before(earliest) {
+			earliest = first
 		}
 	}
+	return earliest, true
+}
 
-	// Prefer REAL cached Yahoo OHLCV daily history when available. This
-	// is what the user sees on /charts and we want it to be actual market
-	// history, not a randomly regenerated series. If the cache hasn't
-	// loaded yet (cold start) or this symbol isn't tracked by Yahoo
-	// (estimates: OPEC, DUBAI, etc.), we fall through to the synthetic
-	// generator below.
-	if interval == "1d" && s.yahoo != nil {
-		if bars := s.yahoo.GetDailyHistory(symbol, days); len(bars) > 0 {
-			return models.ChartData{Symbol: symbol, Name: name, Interval: interval, Data: bars}
+// FetchFullHistory asks the highest-priority history source that supports
+// it to backfill symbol's entire daily record. Symbols no such source
+// tracks are left as they are.
+func (s *MarketDataService) FetchFullHistory(ctx context.Context, symbol string) error {
+	for _, src := range s.sources.ForSymbol(symbol, CapHistory) {
+		if fh, ok := src.(FullHistorySource); ok {
+			return fh.FetchFullHistory(ctx, symbol)
 		}
 	}
+	return nil
+}
+
+// maxSyntheticRangeDays bounds GetChartRange's generated fallback.
+const maxSyntheticRangeDays = 10 * 366
+
+// GetChartRange returns bars with from <= time <= to at interval ("1d",
+// any intraday interval GetChartData accepts, or "" to pick one from the
+// span), resolved from the same daily history and intraday caches. With no
+// real bars in range it falls back to a synthetic series flagged in the
+// provenance, like GetChartData.
+func (s *MarketDataService) GetChartRange(symbol, interval string, from, to time.Time) models.ChartData {
+	name := symbol
+	if n, ok := commodityNames[symbol]; ok {
+		name = n
+	}
+	if interval == "" {
+		interval = defaultChartInterval(int(to.Sub(from).Hours() / 24))
+	}
+	bucketSec, intraday := chartIntervals[interval]
+	if !intraday {
+		interval = "1d"
+	}
+	out := models.ChartData{Symbol: symbol, Name: name, Interval: interval}
+	now := time.Now()
 
-	// Synthetic fallback. We seed a per-call RNG with a hash of
-	// (symbol, days, today's UTC date) so flipping back and forth between
-	// tabs returns the SAME chart instead of a freshly randomised series.
-	// The series naturally rolls over once a day when the seed changes.
+	var bars []models.OHLCV
+	var sources []string
+	if intraday {
+		bars, sources = s.intradayChart(symbol, from, bucketSec)
+	} else if b, source := s.dailyHistory(symbol, 0); len(b) > 0 {
+		bars, sources = b, []string{source}
+	}
+	if bars = barsBetween(bars, from, to); len(bars) > 0 {
+		out.Data = bars
+		out.Provenance = stamped(newProvenance(sources, barsFetchedAt(sources, !intraday), barsAsOf(bars)), now)
+		return out
+	}
+
+	base, ok := s.basePrices[symbol]
+	if !ok {
+		base = 72.0
+	}
+	if q, ok := s.latestQuote(symbol); ok {
+		base = q.Price
+	}
+	// The generator runs back from now; cap it so an ancient `from`
+	// can't allocate an unbounded series.
+	days := min(int(now.Sub(from).Hours()/24)+1, maxSyntheticRangeDays)
 	rng := rand.New(rand.NewSource(syntheticChartSeed(symbol, days, interval)))
+	if intraday {
+		out.Data = barsBetween(s.generateIntraday(rng, base, days, bucketSec), from, to)
+	} else {
+		out.Data = barsBetween(s.generateDaily(rng, base, days), from, to)
+	}
+	out.Provenance = syntheticProvenance(now)
+	return out
+}
 
-	var data []models.OHLCV
-	switch interval {
-	case "2h":
-		data = s.generateIntraday(rng, base, days, 2)
-	case "4h":
-		data = s.generateIntraday(rng, base, days, 4)
-	default:
-		data = s.generateDaily(rng, base, days)
+// barsBetween trims oldest-first bars to from <= Time <= to.
+func barsBetween(bars []models.OHLCV, from, to time.Time) []models.OHLCV {
+	lo := sort.Search(len(bars), func(i int) bool { return bars[i].Time >= from.Unix() })
+	hi := sort.Search(len(bars), func(i int) bool { return bars[i].Time > to.Unix() })
+	if lo >= hi {
+		return nil
+	}
+	return bars[lo:hi]
+}
+
+// intradayChart builds real bars since `since` at bucketSec resolution. The
+// base series is the highest-priority intraday source (Yahoo's 5-minute
+// cache) — skipped for 1m, which it's too coarse for — and any live
+// 1-minute candles newer than it are appended so the right edge is current.
+// The result is resampled to the requested bucket; sources names every
+// provider that contributed bars.
+func (s *MarketDataService) intradayChart(symbol string, since time.Time, bucketSec int64) (bars []models.OHLCV, sources []string) {
+	if bucketSec >= heroBucketSec {
+		for _, src := range s.sources.ForSymbol(symbol, CapIntraday) {
+			if b, _ := src.Intraday(symbol, since); len(b) > 0 {
+				bars = b
+				sources = append(sources, src.Name())
+				break
+			}
+		}
 	}
 
-	return models.ChartData{Symbol: symbol, Name: name, Interval: interval, Data: data}
+	if live := s.liveSource(symbol); live != nil {
+		tailFrom := since.Unix()
+		if n := len(bars); n > 0 {
+			// A 5-minute base bar covers [Time, Time+300); only candles
+			// past it add information.
+			tailFrom = bars[n-1].Time + heroBucketSec
+		}
+		n := len(bars)
+		for _, c := range live.GetCandles(symbol, 0) {
+			if c.Time >= tailFrom {
+				bars = append(bars, models.OHLCV{Time: c.Time, Open: c.Open, High: c.High, Low: c.Low, Close: c.Close})
+			}
+		}
+		if len(bars) > n {
+			// Live candles sit on the right edge, so they lead the list.
+			sources = append([]string{live.Name()}, sources...)
+		}
+	}
+	return resampleOHLCV(bars, bucketSec), sources
+}
+
+// resampleOHLCV aggregates oldest-first bars into UTC-aligned buckets of
+// bucketSec: first open, max high, min low, last close, summed volume.
+// Empty buckets (market closed) are left out rather than filled.
+func resampleOHLCV(bars []models.OHLCV, bucketSec int64) []models.OHLCV {
+	if len(bars) == 0 || bucketSec <= 0 {
+		return nil
+	}
+	out := make([]models.OHLCV, 0, len(bars))
+	for _, b := range bars {
+		bucket := b.Time - b.Time%bucketSec
+		if n := len(out); n > 0 && out[n-1].Time == bucket {
+			cur := &out[n-1]
+			cur.High = math.Max(cur.High, b.High)
+			cur.Low = math.Min(cur.Low, b.Low)
+			cur.Close = b.Close
+			cur.Volume += b.Volume
+			continue
+		}
+		b.Time = bucket
+		out = append(out, b)
+	}
+	return out
 }
 
 // syntheticChartSeed produces a stable per-day seed for the synthetic
@@ -260,13 +617,23 @@ func (s *MarketDataService) generateDaily(rng *rand.Rand, base float64, days int
 	return allData
 }
 
-func (s *MarketDataService) generateIntraday(rng *rand.Rand, base float64, days int, hoursPerCandle int) []models.OHLCV {
-	candlesPerDay := 24 / hoursPerCandle
+// maxSyntheticBars caps the synthetic intraday series so a 1m request over
+// a long window doesn't generate hundreds of thousands of fake bars.
+const maxSyntheticBars = 2000
+
+func (s *MarketDataService) generateIntraday(rng *rand.Rand, base float64, days int, bucketSec int64) []models.OHLCV {
+	candlesPerDay := int(86400 / bucketSec)
+	if days*candlesPerDay > maxSyntheticBars {
+		days = maxSyntheticBars/candlesPerDay + 1
+	}
 	data := make([]models.OHLCV, 0, days*candlesPerDay)
 	price := base - (base * 0.03)
 	calendarDays := int(float64(days)*1.5) + 5
 	startTime := time.Now().AddDate(0, 0, -calendarDays)
 	now := time.Now()
+	// Scale the per-bar move with the bucket so finer intervals don't
+	// wander further than coarse ones over the same window.
+	volScale := math.Sqrt(float64(bucketSec) / 7200)
 
 	for d := 0; d <= calendarDays; d++ {
 		dayStart := startTime.AddDate(0, 0, d)
@@ -276,11 +643,11 @@ func (s *MarketDataService) generateIntraday(rng *rand.Rand, base float64, days
 		dayStart = time.Date(dayStart.Year(), dayStart.Month(), dayStart.Day(), 0, 0, 0, 0, time.UTC)
 
 		for c := 0; c < candlesPerDay; c++ {
-			t := dayStart.Add(time.Duration(c*hoursPerCandle) * time.Hour)
+			t := dayStart.Add(time.Duration(int64(c)*bucketSec) * time.Second)
 			if t.After(now) {
 				break
 			}
-			vol := price * 0.004
+			vol := price * 0.004 * volScale
 			change := (rng.Float64() - 0.48) * vol
 			price += change
 			open := price
@@ -295,6 +662,9 @@ func (s *MarketDataService) generateIntraday(rng *rand.Rand, base float64, days
 		}
 	}
 	targetCount := days * candlesPerDay
+	if targetCount > maxSyntheticBars {
+		targetCount = maxSyntheticBars
+	}
 	if len(data) > targetCount {
 		return data[len(data)-targetCount:]
 	}
@@ -303,64 +673,85 @@ func (s *MarketDataService) generateIntraday(rng *rand.Rand, base float64, days
 
 func r2(v float64) float64 { return math.Round(v*100) / 100 }
 
-// predictionSymbols defines which symbols we publish a forecast for, and the
-// horizon used. We only forecast symbols backed by real Yahoo history; falling
-// back to a flat "no signal" prediction if history is not yet loaded.
-var predictionSymbols = []struct {
+// predictionSymbol is a symbol we publish a forecast for, and the horizon
+// used. We only forecast symbols backed by real Yahoo history; falling back
+// to a flat "no signal" prediction if history is not yet loaded.
+type predictionSymbol struct {
 	symbol  string
 	name    string
 	horizon int
-}{
-	{"WTI", "WTI Crude Oil", 7},
-	{"BRENT", "Brent Crude Oil", 7},
-	{"NATGAS", "Natural Gas", 7},
-	{"HEATING", "Heating Oil", 7},
 }
 
+// predictionSymbols is set by Configure.
+var predictionSymbols []predictionSymbol
+
 const predictionDisclaimer = "Statistical forecast for informational purposes only. Not investment advice."
 
 func (s *MarketDataService) GetPredictions() []models.Prediction {
+	out, _ := s.predictions()
+	now := time.Now()
+	for i := range out {
+		out[i].Provenance = stamped(out[i].Provenance, now)
+	}
+	return out
+}
+
+// predictions returns the cached predictions and the forecasts behind
+// them, recomputing both when the cache is older than predictionTTL.
+func (s *MarketDataService) predictions() ([]models.Prediction, map[string]ForecastResult) {
 	// Fast path: serve the cached slice if it's fresh.
 	s.predictionsMu.RLock()
 	if time.Since(s.cachedPredAt) < predictionTTL && len(s.cachedPredictions) > 0 {
 		out := make([]models.Prediction, len(s.cachedPredictions))
 		copy(out, s.cachedPredictions)
+		forecasts := s.cachedForecasts
 		s.predictionsMu.RUnlock()
-		return out
+		return out, forecasts
 	}
 	s.predictionsMu.RUnlock()
 
-	out := s.computePredictions()
+	start := time.Now()
+	out, forecasts := s.computePredictions()
+	predictionSeconds.Observe(time.Since(start).Seconds())
 
 	s.predictionsMu.Lock()
 	s.cachedPredictions = out
+	s.cachedForecasts = forecasts
 	s.cachedPredAt = time.Now()
 	s.predictionsMu.Unlock()
 
-	return out
+	return append([]models.Prediction(nil), out...), forecasts
 }
 
 // computePredictions runs the damped-Holt + backtest pipeline for each
 // configured symbol. Called by GetPredictions when the cache is stale.
-func (s *MarketDataService) computePredictions() []models.Prediction {
+// Symbols that fell back (too little history) have no forecast entry.
+func (s *MarketDataService) computePredictions() ([]models.Prediction, map[string]ForecastResult) {
 	prices := s.GetPrices()
 	pm := make(map[string]float64)
 	sources := make(map[string]string)
+	provs := make(map[string]*models.Provenance)
 	for _, p := range prices {
 		pm[p.Symbol] = p.Price
 		sources[p.Symbol] = p.Source
+		provs[p.Symbol] = p.Provenance
 	}
 
 	out := make([]models.Prediction, 0, len(predictionSymbols))
+	forecasts := make(map[string]ForecastResult, len(predictionSymbols))
 	for _, ps := range predictionSymbols {
 		current := pm[ps.symbol]
-		var history []float64
-		if s.yahoo != nil {
-			history = s.yahoo.GetHistory(ps.symbol)
+		bars, historySource := s.dailyHistory(ps.symbol, forecastHistoryBars)
+		history := make([]float64, len(bars))
+		for i, b := range bars {
+			history[i] = b.Close
 		}
+		prov := predictionProvenance(provs[ps.symbol], historySource)
 
 		if len(history) < 30 {
-			out = append(out, fallbackPrediction(ps.symbol, ps.name, current, ps.horizon))
+			fb := fallbackPrediction(ps.symbol, ps.name, current, ps.horizon)
+			fb.Provenance = prov
+			out = append(out, fb)
 			continue
 		}
 
@@ -373,10 +764,13 @@ func (s *MarketDataService) computePredictions() []models.Prediction {
 
 		f, err := Forecast(closes, ps.horizon)
 		if err != nil {
-			out = append(out, fallbackPrediction(ps.symbol, ps.name, current, ps.horizon))
+			fb := fallbackPrediction(ps.symbol, ps.name, current, ps.horizon)
+			fb.Provenance = prov
+			out = append(out, fb)
 			continue
 		}
 
+		forecasts[ps.symbol] = f
 		predSource := sources[ps.symbol]
 		if predSource == "" {
 			predSource = "yahoo"
@@ -407,11 +801,174 @@ func (s *MarketDataService) computePredictions() []models.Prediction {
 			NaiveMAPE:     math.Round(f.NaiveMAPE*10000) / 10000,
 			Skill:         math.Round(f.Skill*1000) / 1000,
 			BacktestSteps: f.BacktestSteps,
+			Intervals:     predictionIntervals(f.Intervals),
+			Fan:           fanPoints(f.Fan),
+			Provenance:    prov,
 		})
 	}
+	return out, forecasts
+}
+
+type horizonEntry struct {
+	forecast models.HorizonForecast
+	at       time.Time
+}
+
+// GetForecastSet returns the ensemble forecast for symbol at each of
+// horizons (trading days; nil means forecastHorizons). ok is false for a
+// symbol outside the catalogue. A symbol without enough real history gets
+// an empty Horizons list and a Note saying why. Each (symbol, horizon) fit
+// is cached for predictionTTL.
+func (s *MarketDataService) GetForecastSet(symbol string, horizons []int) (models.ForecastSet, bool) {
+	name, ok := commodityNames[symbol]
+	if !ok {
+		return models.ForecastSet{}, false
+	}
+	if horizons == nil {
+		horizons = forecastHorizons
+	}
+
+	var current float64
+	var price *models.Provenance
+	if q, ok := s.latestQuote(symbol); ok {
+		current, price = q.Price, q.Provenance
+	}
+	bars, historySource := s.dailyHistory(symbol, forecastHistoryBars)
+	now := time.Now()
+	set := models.ForecastSet{
+		Symbol:      symbol,
+		Name:        name,
+		Current:     r2(current),
+		Horizons:    []models.HorizonForecast{},
+		Disclaimer:  predictionDisclaimer,
+		GeneratedAt: now.UTC().Format(time.RFC3339),
+		Provenance:  stamped(predictionProvenance(price, historySource), now),
+	}
+	// Splice the live price on as the latest close, as computePredictions
+	// does.
+	closes := make([]float64, 0, len(bars)+1)
+	for _, b := range bars {
+		closes = append(closes, b.Close)
+	}
+	if current > 0 && len(closes) > 0 {
+		closes = append(closes, current)
+	}
+	if len(closes) < 30 {
+		set.Note = "Forecast unavailable — insufficient historical data loaded yet."
+		return set, true
+	}
+	if current == 0 {
+		set.Current = r2(closes[len(closes)-1])
+	}
+
+	for _, h := range horizons {
+		key := fmt.Sprintf("%s/%d", symbol, h)
+		s.predictionsMu.RLock()
+		e, cached := s.horizonForecasts[key]
+		s.predictionsMu.RUnlock()
+		if !cached || now.Sub(e.at) >= predictionTTL {
+			f, err := ForecastEnsemble(closes, h)
+			if err != nil {
+				continue
+			}
+			e = horizonEntry{forecast: horizonForecast(f), at: now}
+			s.predictionsMu.Lock()
+			if s.horizonForecasts == nil {
+				s.horizonForecasts = make(map[string]horizonEntry)
+			}
+			s.horizonForecasts[key] = e
+			s.predictionsMu.Unlock()
+		}
+		set.Horizons = append(set.Horizons, e.forecast)
+	}
+	return set, true
+}
+
+// horizonForecast rounds an EnsembleResult for the API.
+func horizonForecast(f EnsembleResult) models.HorizonForecast {
+	hf := models.HorizonForecast{
+		Horizon:       f.HorizonDays,
+		Predicted:     r2(f.Predicted),
+		Low:           r2(f.Low),
+		High:          r2(f.High),
+		Direction:     callDirection(f.Current, f.Predicted),
+		MAPE:          math.Round(f.MAPE*10000) / 10000,
+		NaiveMAPE:     math.Round(f.NaiveMAPE*10000) / 10000,
+		Skill:         math.Round(f.Skill*1000) / 1000,
+		BacktestSteps: f.BacktestSteps,
+		Volatility:    math.Round(f.Volatility*10000) / 10000,
+	}
+	for _, m := range f.Models {
+		hf.Models = append(hf.Models, models.ModelForecast{
+			Model:     m.Name,
+			Predicted: r2(m.Predicted),
+			MAPE:      math.Round(m.MAPE*10000) / 10000,
+			Weight:    math.Round(m.Weight*1000) / 1000,
+		})
+	}
+	return hf
+}
+
+func predictionIntervals(ivs []Interval) []models.PredictionInterval {
+	out := make([]models.PredictionInterval, len(ivs))
+	for i, iv := range ivs {
+		out[i] = models.PredictionInterval{
+			Level:           iv.Level,
+			Low:             r2(iv.Low),
+			High:            r2(iv.High),
+			Coverage:        math.Round(iv.Coverage*1000) / 1000,
+			CoverageSamples: iv.CoverageSamples,
+		}
+	}
+	return out
+}
+
+func fanPoints(fan []FanStep) []models.FanPoint {
+	out := make([]models.FanPoint, len(fan))
+	for i, st := range fan {
+		out[i] = models.FanPoint{Step: st.Step, Median: r2(st.Median), Intervals: predictionIntervals(st.Intervals)}
+	}
 	return out
 }
 
+// callDirection classifies a move from current to target: more than 0.5%
+// either way is bullish or bearish, anything smaller neutral.
+func callDirection(current, target float64) string {
+	if current <= 0 {
+		return "neutral"
+	}
+	switch delta := (target - current) / current; {
+	case delta > 0.005:
+		return "bullish"
+	case delta < -0.005:
+		return "bearish"
+	}
+	return "neutral"
+}
+
+// predictionProvenance combines the live price's provenance with the
+// history source the model was fitted on. The forecast is only as real as
+// the price it starts from, so a synthetic price marks it synthetic.
+func predictionProvenance(price *models.Provenance, historySource string) *models.Provenance {
+	var sources []string
+	var p models.Provenance
+	if price != nil {
+		p = *price
+		sources = append(sources, price.Sources...)
+	}
+	if historySource != "" {
+		seen := false
+		for _, src := range sources {
+			seen = seen || src == historySource
+		}
+		if !seen {
+			sources = append(sources, historySource)
+		}
+	}
+	p.Sources = sources
+	return &p
+}
+
 // fallbackPrediction is returned when we don't yet have enough history loaded
 // (e.g. cold start, or Yahoo unreachable). The "predicted" value mirrors the
 // current price so the UI doesn't show a misleading move.
@@ -512,10 +1069,11 @@ func buildAnalysis(name string, f ForecastResult) string {
 // symbol so the homepage hero chart can render true real-time bars. Returns
 // nil if Pyth is unavailable or hasn't accumulated any ticks yet.
 func (s *MarketDataService) GetPythCandles(symbol string, max int) []models.PythCandle {
-	if s.pyth == nil {
+	live := s.liveSource(symbol)
+	if live == nil {
 		return nil
 	}
-	return s.pyth.GetCandles(symbol, max)
+	return live.GetCandles(symbol, max)
 }
 
 // pythLiveWindow defines how recent the latest Pyth tick must be for us to
@@ -571,20 +1129,21 @@ const heroBucketSec int64 = 300
 func (s *MarketDataService) GetHeroChart(symbol string, _ int) models.HeroChart {
 	out := models.HeroChart{Symbol: symbol, Bars: []models.PythCandle{}}
 
+	live := s.liveSource(symbol)
 	pythLive := false
 	var pythPublishedAt time.Time
-	if s.pyth != nil {
-		if q, ok := s.pyth.GetQuote(symbol); ok && time.Since(q.PublishedAt) <= pythLiveWindow {
+	if live != nil {
+		if ts, ok := live.LastTick(symbol); ok && time.Since(ts) <= pythLiveWindow {
 			pythLive = true
-			pythPublishedAt = q.PublishedAt
+			pythPublishedAt = ts
 		}
 	}
 
-	// 1) Rolling 24h of Yahoo intraday — the primary hero data source.
-	if s.yahoo != nil {
-		bars, interval := s.yahoo.GetRolling24hIntraday(symbol)
+	// 1) Rolling 24h of intraday bars (Yahoo) — the primary hero data source.
+	for _, src := range s.sources.ForSymbol(symbol, CapIntraday) {
+		bars, interval := src.Intraday(symbol, time.Now().Add(-24*time.Hour))
 		if len(bars) > 0 {
-			out.Source = "yahoo"
+			out.Source = src.Name()
 			out.Interval = interval
 			// SessionDate carries today's NY-local date so the frontend
 			// can compute the session-boundary markers (17:00 / 18:00 ET
@@ -596,10 +1155,12 @@ func (s *MarketDataService) GetHeroChart(symbol string, _ int) models.HeroChart
 			// when the feed is fresh — Yahoo only refreshes every ~5 min
 			// server-side, so without this overlay the rightmost bar
 			// would visibly lag the spot price by up to 5 minutes.
-			if pythLive && s.pyth != nil {
+			sources := []string{src.Name()}
+			if pythLive {
 				bucketStart := pythPublishedAt.Truncate(time.Duration(heroBucketSec) * time.Second).Unix()
-				if bar, ok := s.pyth.GetBucketBar(symbol, bucketStart, heroBucketSec); ok {
+				if bar, ok := live.GetBucketBar(symbol, bucketStart, heroBucketSec); ok {
 					out.Bars = mergeLiveBucket(out.Bars, bar)
+					sources = append([]string{live.Name()}, sources...)
 				}
 			}
 
@@ -611,22 +1172,28 @@ func (s *MarketDataService) GetHeroChart(symbol string, _ int) models.HeroChart
 				last := out.Bars[len(out.Bars)-1]
 				out.UpdatedAt = time.Unix(last.Time, 0).UTC().Format(time.RFC3339)
 			}
+			out.Provenance = heroProvenance(sources, out.UpdatedAt)
 			return out
 		}
 	}
 
 	// 2) No recent Yahoo bars (weekend, cold-start before first refresh).
 	// Fall back to the most recent complete prior session.
-	if s.yahoo != nil {
-		bars, sessionDate, interval := s.yahoo.GetPriorSessionIntraday(symbol)
+	for _, src := range s.sources.ForSymbol(symbol, CapIntraday) {
+		session, ok := src.(SessionSource)
+		if !ok {
+			continue
+		}
+		bars, sessionDate, interval := session.GetPriorSessionIntraday(symbol)
 		if len(bars) > 0 {
 			out.Mode = "prior-session"
 			out.Interval = interval
-			out.Source = "yahoo"
+			out.Source = src.Name()
 			out.SessionDate = sessionDate
 			out.Bars = ohlcvToCandles(bars)
 			last := bars[len(bars)-1]
 			out.UpdatedAt = time.Unix(last.Time, 0).UTC().Format(time.RFC3339)
+			out.Provenance = heroProvenance([]string{src.Name()}, out.UpdatedAt)
 			return out
 		}
 	}
@@ -634,10 +1201,10 @@ func (s *MarketDataService) GetHeroChart(symbol string, _ int) models.HeroChart
 	// 3) Last resort — show whatever Pyth has accumulated since boot,
 	// even if Yahoo is completely cold. Common right after server
 	// startup before the first Yahoo intraday refresh has landed.
-	if s.pyth != nil {
-		bars := s.pyth.GetCandles(symbol, 0)
+	if live != nil {
+		bars := live.GetCandles(symbol, 0)
 		if len(bars) > 0 {
-			out.Source = "pyth"
+			out.Source = live.Name()
 			out.Interval = "1m"
 			out.Bars = bars
 			out.SessionDate = nyTodayDate()
@@ -648,6 +1215,7 @@ func (s *MarketDataService) GetHeroChart(symbol string, _ int) models.HeroChart
 				out.Mode = "today-paused"
 				out.UpdatedAt = time.Unix(bars[len(bars)-1].Time, 0).UTC().Format(time.RFC3339)
 			}
+			out.Provenance = heroProvenance([]string{live.Name()}, out.UpdatedAt)
 			return out
 		}
 	}
@@ -657,6 +1225,12 @@ func (s *MarketDataService) GetHeroChart(symbol string, _ int) models.HeroChart
 	return out
 }
 
+// heroProvenance stamps a hero payload whose newest data is updatedAt.
+func heroProvenance(sources []string, updatedAt string) *models.Provenance {
+	asOf, _ := time.Parse(time.RFC3339, updatedAt)
+	return stamped(newProvenance(sources, barsFetchedAt(sources, false), asOf), time.Now())
+}
+
 // nyTodayDate returns today's NY-local date as YYYY-MM-DD. Mirrors the
 // helper in yahoo.go but lives here too so market_data.go has no cross-
 // service-internal dependency.
@@ -711,30 +1285,3 @@ func mergeLiveBucket(series []models.PythCandle, live models.PythCandle) []model
 	// timestamp). Trust Yahoo, drop the stale Pyth bucket.
 	return series
 }
-
-func (s *MarketDataService) GetAnalysis() models.MarketAnalysis {
-	now := time.Now().UTC().Format(time.RFC3339)
-	wtiPrice := s.basePrices["WTI"]
-	if s.yahoo != nil {
-		if yp, ok := s.yahoo.GetPrices()["WTI"]; ok {
-			wtiPrice = yp.Price
-		}
-	}
-	return models.MarketAnalysis{
-		Sentiment: "bullish", Score: 72,
-		Summary: fmt.Sprintf("The crude oil market is displaying bullish momentum with WTI trading near $%.2f. Technical indicators are aligned with an upward bias as the 50-day moving average has crossed above the 200-day MA, forming a golden cross pattern. Fundamental drivers including OPEC+ supply discipline, declining US inventories, and resilient global demand support the constructive outlook. Key risk factors include potential demand slowdown from economic headwinds and the possibility of OPEC+ policy changes.", wtiPrice),
-		KeyPoints: []string{
-			"OPEC+ production cuts extended through Q3 2026, removing ~2.2 million bpd from market",
-			"US crude inventories fell 4.2 million barrels, 3rd consecutive weekly draw",
-			"China crude imports at record 12.4 million bpd supporting global demand",
-			"Technical golden cross pattern on WTI daily chart signals bullish trend",
-			"Geopolitical risk premium elevated due to Middle East tensions",
-			"IEA raised 2026 demand growth forecast to 1.4 million bpd",
-		},
-		Technical: models.TechnicalSignals{
-			RSI: 58.4, MACD: "bullish crossover", Signal: "buy",
-			MovingAvg50: r2(wtiPrice - 1.20), MovingAvg200: r2(wtiPrice - 3.50), Trend: "uptrend",
-		},
-		UpdatedAt: now,
-	}
-}
@@ -1,8 +1,10 @@
 package services
 
 import (
+	"live-oil-prices-go/internal/models"
 	"math"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 )
@@ -176,23 +178,70 @@ func TestGetPredictionsTrackCurrentPrices(t *testing.T) {
 	}
 }
 
-func TestGetAnalysisIncludesCoreFields(t *testing.T) {
-	svc := newDeterministicMarketDataService()
-	analysis := svc.GetAnalysis()
-
-	if analysis.Sentiment != "bullish" {
-		t.Fatalf("expected bullish sentiment, got %q", analysis.Sentiment)
+func TestResampleOHLCVAggregatesBuckets(t *testing.T) {
+	t0 := int64(1_700_000_100) // aligned to 15m
+	bars := []models.OHLCV{
+		{Time: t0, Open: 80, High: 80.5, Low: 79.8, Close: 80.2, Volume: 10},
+		{Time: t0 + 300, Open: 80.2, High: 81, Low: 80.1, Close: 80.9, Volume: 5},
+		{Time: t0 + 600, Open: 80.9, High: 80.9, Low: 79.5, Close: 79.7, Volume: 7},
+		{Time: t0 + 900, Open: 79.7, High: 79.9, Low: 79.6, Close: 79.8, Volume: 1},
 	}
-	if analysis.Score < 0 || analysis.Score > 100 {
-		t.Fatalf("expected score between 0 and 100, got %.2f", analysis.Score)
+	got := resampleOHLCV(bars, 900)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 buckets, got %d: %+v", len(got), got)
 	}
-	if len(analysis.KeyPoints) == 0 {
-		t.Fatalf("expected analysis key points")
+	want := models.OHLCV{Time: t0, Open: 80, High: 81, Low: 79.5, Close: 79.7, Volume: 22}
+	if got[0] != want {
+		t.Fatalf("unexpected first bucket: got %+v want %+v", got[0], want)
 	}
-	if analysis.Technical.Trend == "" {
-		t.Fatalf("expected technical trend")
+}
+
+func TestGetChartDataIntradayUsesRealBars(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	now := time.Now().Truncate(time.Hour).Unix()
+	var bars []models.OHLCV
+	for i := int64(24); i > 0; i-- {
+		ts := now - i*300
+		bars = append(bars, models.OHLCV{Time: ts, Open: 80, High: 81, Low: 79, Close: 80.5})
+	}
+	svc.sources.Register(&fakeSource{name: "yahoo", caps: CapIntraday, intraday: map[string][]models.OHLCV{"NATGAS": bars}}, yahooPriority)
+	polled := time.Date(2026, 3, 2, 15, 4, 5, 0, time.UTC)
+	feeds.record(FeedYahooIntraday, nil, polled, nil)
+
+	got := svc.GetChartData("NATGAS", 1, "1h")
+	if got.Provenance == nil || got.Provenance.Synthetic || got.Provenance.Sources[0] != "yahoo" {
+		t.Fatalf("expected real yahoo bars, got provenance %+v", got.Provenance)
+	}
+	if got.Provenance.FetchedAt != polled.Format(time.RFC3339) {
+		t.Fatalf("expected fetchedAt from the last intraday poll, got %q", got.Provenance.FetchedAt)
+	}
+	if len(got.Data) < 2 || len(got.Data) > 3 {
+		t.Fatalf("expected 24 five-minute bars to resample into 2–3 hourly bars, got %d", len(got.Data))
+	}
+	for _, b := range got.Data {
+		if b.Time%3600 != 0 {
+			t.Fatalf("expected hour-aligned buckets, got %d", b.Time)
+		}
 	}
-	if analysis.UpdatedAt == "" {
-		t.Fatalf("expected updatedAt")
+
+	if fallback := svc.GetChartData("OPEC", 1, "1h"); fallback.Provenance == nil || !fallback.Provenance.Synthetic {
+		t.Fatalf("expected an uncovered symbol to be flagged synthetic")
 	}
 }
+
+// Run with -race: synthetic quotes are drawn from one shared source.
+func TestGetPricesConcurrently(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				svc.GetPrices()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/metrics"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Upstream and compute instrumentation exported on /metrics.
+var (
+	upstreamRequests = metrics.NewCounterVec("oilprices_upstream_requests_total",
+		"Upstream fetches by upstream, endpoint (or feed) and result (success|failure).",
+		"upstream", "endpoint", "result")
+	upstreamLatency = metrics.NewHistogramVec("oilprices_upstream_request_duration_seconds",
+		"Upstream fetch latency, including retries inside the fetch.",
+		metrics.DefBuckets, "upstream", "endpoint")
+	pythTicks = metrics.NewCounterVec("oilprices_pyth_ticks_total",
+		"New Pyth publishes accepted, by symbol. rate() gives the tick rate.",
+		"symbol")
+	predictionSeconds = metrics.NewHistogramVec("oilprices_prediction_compute_seconds",
+		"Time to recompute the prediction set on a cache miss.",
+		[]float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30})
+
+	// upstreamSuccess is when each (upstream, endpoint) last answered,
+	// which is also how old the cache it feeds is.
+	upstreamSuccessMu sync.Mutex
+	upstreamSuccess   = map[[2]string]time.Time{}
+)
+
+func init() {
+	metrics.NewGaugeFunc("oilprices_upstream_cache_age_seconds",
+		"Seconds since the last successful fetch from each upstream endpoint, i.e. the age of the data it cached.",
+		[]string{"upstream", "endpoint"},
+		func(emit func(float64, ...string)) {
+			upstreamSuccessMu.Lock()
+			defer upstreamSuccessMu.Unlock()
+			keys := make([][2]string, 0, len(upstreamSuccess))
+			for k := range upstreamSuccess {
+				keys = append(keys, k)
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				return keys[i][0]+"\xff"+keys[i][1] < keys[j][0]+"\xff"+keys[j][1]
+			})
+			now := time.Now()
+			for _, k := range keys {
+				emit(now.Sub(upstreamSuccess[k]).Seconds(), k[0], k[1])
+			}
+		})
+}
+
+// timeUpstream starts timing one upstream fetch; call the returned func
+// with the fetch's error when it finishes.
+func timeUpstream(upstream, endpoint string) func(error) {
+	start := time.Now()
+	return func(err error) {
+		upstreamLatency.Observe(time.Since(start).Seconds(), upstream, endpoint)
+		if err != nil {
+			upstreamRequests.Inc(upstream, endpoint, "failure")
+			return
+		}
+		upstreamRequests.Inc(upstream, endpoint, "success")
+		upstreamSuccessMu.Lock()
+		upstreamSuccess[[2]string{upstream, endpoint}] = time.Now()
+		upstreamSuccessMu.Unlock()
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/xml"
@@ -8,6 +9,7 @@ import (
 	"html"
 	"io"
 	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/upstream"
 	"log"
 	"net/http"
 	"regexp"
@@ -61,47 +63,75 @@ type feedSource struct {
 type NewsFeedService struct {
 	mu       sync.RWMutex
 	articles []models.NewsArticle
-	client   *http.Client
+	client   *upstream.Client
 	feeds    []feedSource
+
+	// bus receives ArticlesAdded whenever a refresh turns up articles the
+	// previous one didn't have. Nil publishes nothing.
+	bus *Bus
+
+	run runGroup
 }
 
-const gnewsBase = "https://news.google.com/rss/search?hl=en-US&gl=US&ceid=US:en&q="
+// newsFeeds (one per category) and newsRefreshEvery are set by Configure.
+var (
+	newsFeeds        []feedSource
+	newsRefreshEvery time.Duration
+)
+
+// newsPolicy spaces the per-category fetches and revalidates each feed
+// with ETag / Last-Modified, so an unchanged feed costs a 304.
+var newsPolicy = upstream.Policy{
+	Timeout:          15 * time.Second,
+	MaxAttempts:      3,
+	BaseDelay:        time.Second,
+	MaxDelay:         10 * time.Second,
+	Rate:             2,
+	Burst:            4,
+	FailureThreshold: 5,
+	Cooldown:         5 * time.Minute,
+	Conditional:      true,
+}
 
-func NewNewsFeedService() *NewsFeedService {
+func NewNewsFeedService(bus *Bus) *NewsFeedService {
 	svc := &NewsFeedService{
-		client: &http.Client{Timeout: 15 * time.Second},
-		feeds: []feedSource{
-			{url: gnewsBase + "crude+oil+price+WTI+Brent", category: "Oil Markets"},
-			{url: gnewsBase + "OPEC+oil+production+output", category: "OPEC"},
-			{url: gnewsBase + "natural+gas+LNG+Henry+Hub", category: "Natural Gas"},
-			{url: gnewsBase + "oil+refining+gasoline+diesel+fuel", category: "Refining"},
-			{url: gnewsBase + "oil+drilling+extraction+upstream+shale", category: "Extraction"},
-			{url: gnewsBase + "oil+gas+engineering+technology+energy+innovation", category: "Technology"},
-			{url: gnewsBase + "international+energy+policy+geopolitics+oil+sanctions", category: "International"},
-			{url: gnewsBase + "oil+gas+inventory+EIA+stockpile+storage", category: "Inventory"},
-		},
+		client: upstream.New("google_news", nil, newsPolicy),
+		feeds:  append([]feedSource(nil), newsFeeds...),
+		bus:    bus,
 	}
 
-	go svc.refresh()
+	feeds.expect(FeedNews)
+	return svc
+}
 
-	go func() {
-		ticker := time.NewTicker(10 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			svc.refresh()
-		}
-	}()
+// Start fetches every feed right away and then every newsRefreshEvery.
+func (s *NewsFeedService) Start(ctx context.Context) {
+	s.run.start(ctx, s.loop)
+}
 
-	return svc
+// Stop cancels the refresh loop and any fetch in flight, and waits for
+// them.
+func (s *NewsFeedService) Stop() {
+	s.run.stop()
 }
 
-func (s *NewsFeedService) refresh() {
+func (s *NewsFeedService) loop(ctx context.Context) {
+	s.refresh(ctx)
+	every(ctx, newsRefreshEvery, s.refresh)
+}
+
+func (s *NewsFeedService) refresh(ctx context.Context) {
 	var allArticles []models.NewsArticle
 	seen := make(map[string]bool)
 	successCount := 0
+	cycle := feeds.cycle(FeedNews, s.bus)
+	defer cycle.end()
 
 	for _, feed := range s.feeds {
-		articles, err := s.fetchFeed(feed)
+		done := timeUpstream("google_news", feed.category)
+		articles, err := s.fetchFeed(ctx, feed)
+		done(err)
+		cycle.observe(err)
 		if err != nil {
 			log.Printf("RSS fetch error [%s]: %v", feed.category, err)
 			continue
@@ -118,6 +148,10 @@ func (s *NewsFeedService) refresh() {
 			}
 		}
 	}
+	if ctx.Err() != nil {
+		// Stopped mid-refresh: keep the last complete set.
+		return
+	}
 
 	sort.Slice(allArticles, func(i, j int) bool {
 		ti, _ := time.Parse(time.RFC3339, allArticles[i].PublishedAt)
@@ -130,14 +164,39 @@ func (s *NewsFeedService) refresh() {
 	}
 
 	s.mu.Lock()
+	previous := s.articles
 	s.articles = allArticles
 	s.mu.Unlock()
 
+	if added := newArticles(previous, allArticles); len(added) > 0 {
+		s.bus.Publish(ArticlesAdded{Articles: added})
+	}
+
 	log.Printf("News feed refreshed: %d articles from %d/%d feeds", len(allArticles), successCount, len(s.feeds))
 }
 
-func (s *NewsFeedService) fetchFeed(feed feedSource) ([]models.NewsArticle, error) {
-	req, err := http.NewRequest("GET", feed.url, nil)
+// newArticles returns the articles in next that prev didn't have, in
+// next's (newest-first) order. The first load (an empty prev) isn't news,
+// so it returns nothing.
+func newArticles(prev, next []models.NewsArticle) []models.NewsArticle {
+	if len(prev) == 0 {
+		return nil
+	}
+	known := make(map[string]bool, len(prev))
+	for _, a := range prev {
+		known[a.ID] = true
+	}
+	var added []models.NewsArticle
+	for _, a := range next {
+		if !known[a.ID] {
+			added = append(added, a)
+		}
+	}
+	return added
+}
+
+func (s *NewsFeedService) fetchFeed(ctx context.Context, feed feedSource) ([]models.NewsArticle, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", feed.url, nil)
 	if err != nil {
 		return nil, err
 	}
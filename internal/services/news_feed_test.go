@@ -1,8 +1,10 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/upstream"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -100,9 +102,9 @@ func TestFetchFeedParsesAndFiltersItems(t *testing.T) {
 	defer srv.Close()
 
 	svc := &NewsFeedService{
-		client: srv.Client(),
+		client: upstream.New("google_news", srv.Client(), upstream.Policy{}),
 	}
-	articles, err := svc.fetchFeed(feedSource{url: srv.URL, category: "Oil Markets"})
+	articles, err := svc.fetchFeed(context.Background(), feedSource{url: srv.URL, category: "Oil Markets"})
 	if err != nil {
 		t.Fatalf("fetchFeed returned error: %v", err)
 	}
@@ -160,13 +162,13 @@ func TestRefreshDeduplicatesAndSortsByPublishedAt(t *testing.T) {
 	defer srv.Close()
 
 	svc := &NewsFeedService{
-		client: srv.Client(),
+		client: upstream.New("google_news", srv.Client(), upstream.Policy{}),
 		feeds: []feedSource{
 			{url: srv.URL + "/feedA", category: "Oil Markets"},
 			{url: srv.URL + "/feedB", category: "Oil Markets"},
 		},
 	}
-	svc.refresh()
+	svc.refresh(context.Background())
 	articles := svc.GetNews()
 
 	if len(articles) != 2 {
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"time"
+)
+
+// sourceEstimate is the provenance source name for synthetic values.
+const sourceEstimate = "estimate"
+
+// newProvenance builds a provenance block for data whose newest point is
+// asOf. A zero fetchedAt is left out of the payload.
+func newProvenance(sources []string, fetchedAt, asOf time.Time) *models.Provenance {
+	p := &models.Provenance{Sources: append([]string(nil), sources...)}
+	if !fetchedAt.IsZero() {
+		p.FetchedAt = fetchedAt.UTC().Format(time.RFC3339)
+	}
+	if !asOf.IsZero() {
+		p.AsOf = asOf.UTC().Format(time.RFC3339)
+	}
+	return p
+}
+
+// barsFetchedAt is when the feed behind the leading source of a bar series
+// last refreshed: Yahoo's daily history or intraday poll (daily picks
+// which), or Pyth's tick stream. A source no feed tracks (a replay) or one
+// that hasn't succeeded since boot (bars restored from the store) falls
+// through to the next; zero when none has.
+func barsFetchedAt(sources []string, daily bool) time.Time {
+	for _, src := range sources {
+		var feed string
+		switch {
+		case src == "yahoo" && daily:
+			feed = FeedYahooHistory
+		case src == "yahoo":
+			feed = FeedYahooIntraday
+		case src == "pyth":
+			feed = FeedPyth
+		default:
+			continue
+		}
+		if at := feeds.lastSuccess(feed); !at.IsZero() {
+			return at
+		}
+	}
+	return time.Time{}
+}
+
+// syntheticProvenance marks generated stand-in values.
+func syntheticProvenance(now time.Time) *models.Provenance {
+	p := newProvenance([]string{sourceEstimate}, time.Time{}, now)
+	p.Synthetic = true
+	return p
+}
+
+// stamped returns a copy of p with AgeSeconds measured at now. Provenance
+// blocks live inside cached values, so they're never mutated in place.
+func stamped(p *models.Provenance, now time.Time) *models.Provenance {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	out.Sources = append([]string(nil), p.Sources...)
+	if asOf, err := time.Parse(time.RFC3339, p.AsOf); err == nil {
+		if age := int64(now.Sub(asOf) / time.Second); age > 0 {
+			out.AgeSeconds = age
+		} else {
+			out.AgeSeconds = 0
+		}
+	}
+	return &out
+}
+
+// barsAsOf is the start time of the newest bar, the as-of for a series.
+func barsAsOf(bars []models.OHLCV) time.Time {
+	if len(bars) == 0 {
+		return time.Time{}
+	}
+	return time.Unix(bars[len(bars)-1].Time, 0)
+}
@@ -1,10 +1,14 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/store"
+	"live-oil-prices-go/internal/upstream"
 	"log"
 	"math"
 	"net/http"
@@ -38,16 +42,30 @@ import (
 // which means we can legally surface the live values on a public website
 // as long as we attribute the source ("Powered by Pyth Network").
 
-const (
-	hermesEndpoint = "https://hermes.pyth.network/v2/updates/price/latest"
+// pythPollEvery is how often we hit Hermes for the latest tick, set by
+// Configure. Hermes publishes new aggregates ~every 400ms during market
+// hours and the public endpoint comfortably handles >1 req/s; the 2s
+// default is a compromise between candle-update responsiveness on the
+// homepage chart and being a polite citizen on a free, unauthenticated API.
+var pythPollEvery time.Duration
+
+// pythPolicy keeps retries inside one poll interval: a miss costs a tick,
+// and the next poll is only seconds away.
+var pythPolicy = upstream.Policy{
+	Timeout:          8 * time.Second,
+	MaxAttempts:      2,
+	BaseDelay:        250 * time.Millisecond,
+	MaxDelay:         time.Second,
+	Rate:             5,
+	Burst:            2,
+	FailureThreshold: 5,
+	Cooldown:         30 * time.Second,
+}
 
-	// pythPollEvery is how often we hit Hermes for the latest tick. Hermes
-	// publishes new aggregates ~every 400ms during market hours and the
-	// public endpoint comfortably handles >1 req/s; we run at 2s as a
-	// compromise between candle-update responsiveness on the homepage chart
-	// and being a polite citizen on a free, unauthenticated API.
-	pythPollEvery = 2 * time.Second
+// hermesEndpoint is the latest-price URL, set by Configure.
+var hermesEndpoint string
 
+const (
 	// pythCacheRetention is how long we keep a Pyth quote in the cache after
 	// the last publish. The WTI CFD pauses for the weekend (~63h) and can
 	// pause longer over US/UK holidays, so 5 days lets us survive Easter
@@ -64,6 +82,13 @@ const (
 	// 12 hours, which covers a full NYMEX session plus the after-hours move
 	// and is plenty for the homepage's 30m–6h windows.
 	pythMaxCandles = 720
+
+	// pythTickRetention / pythCandleRetention bound how much Pyth history
+	// the persistent store keeps. Raw ticks are only needed to rebuild the
+	// in-progress bar after a restart, so a week covers any long weekend;
+	// closed 1-minute bars are kept for a month for intraday charting.
+	pythTickRetention   = 7 * 24 * time.Hour
+	pythCandleRetention = 30 * 24 * time.Hour
 )
 
 // pythFeed maps an internal symbol to a Pyth Hermes feed id. We keep this
@@ -74,12 +99,9 @@ type pythFeed struct {
 	feedID string // Pyth Hermes price feed id (hex, no 0x prefix)
 }
 
-// pythFeeds is the static list of feeds the poller subscribes to. WTI's
-// USOILSPOT/USD CFD is a continuous spot product (no expiry), so the feed
-// id is safe to hard-code.
-var pythFeeds = []pythFeed{
-	{symbol: "WTI", feedID: "925ca92ff005ae943c158e3563f59698ce7e75c5a8c8dd43303a0a154887b3e6"},
-}
+// pythFeeds is the list of feeds the poller subscribes to, set by
+// Configure.
+var pythFeeds []pythFeed
 
 // pythRawResponse mirrors the parsed fields we care about from Hermes.
 type pythRawResponse struct {
@@ -100,6 +122,7 @@ type PythQuote struct {
 	Price       float64
 	Confidence  float64 // ±$ at 1-sigma
 	PublishedAt time.Time
+	FetchedAt   time.Time // when Hermes returned this publish to us
 }
 
 // Stale returns true if the publish time is older than maxAge. Hermes
@@ -122,59 +145,76 @@ func (q PythQuote) IsLive() bool {
 // buffer per symbol so the frontend can render a true streaming chart.
 // Safe for concurrent use.
 type PythService struct {
-	client  *http.Client
+	client  *upstream.Client
 	mu      sync.RWMutex
 	quotes  map[string]PythQuote
 	candles map[string][]models.PythCandle // keyed by internal symbol
-	stop    chan struct{}
+	run     runGroup
+
+	// store persists every tick and each closed 1-minute bar so a restart
+	// resumes the live session instead of starting from an empty chart.
+	// Nil disables persistence.
+	store store.Store
+
+	// lastRefresh is when Hermes last answered successfully, reported by
+	// Health.
+	lastRefresh time.Time
+
+	// bus receives QuoteUpdated for fresh prints and CandleClosed for
+	// every finished 1-minute bar. Nil publishes nothing.
+	bus *Bus
 }
 
-func NewPythService() *PythService {
+func NewPythService(st store.Store, bus *Bus) *PythService {
 	svc := &PythService{
-		client:  &http.Client{Timeout: 8 * time.Second},
+		client:  upstream.New("pyth", nil, pythPolicy),
 		quotes:  make(map[string]PythQuote),
 		candles: make(map[string][]models.PythCandle),
-		stop:    make(chan struct{}),
+		store:   st,
+		bus:     bus,
 	}
-	// Prime once synchronously so the very first /api/prices call after
-	// startup already has Pyth data when the server is healthy.
-	if err := svc.refresh(); err != nil {
-		log.Printf("pyth: initial refresh failed: %v", err)
+	if len(pythFeeds) > 0 {
+		feeds.expect(FeedPyth)
 	}
-	go svc.loop()
+	svc.restore()
 	return svc
 }
 
-// Stop terminates the background poller. Safe to call multiple times.
+// Start begins polling Hermes every pythPollEvery, the first time right
+// away. Until that lands, quotes and candles come from restore.
+func (s *PythService) Start(ctx context.Context) {
+	s.run.start(ctx, s.loop)
+}
+
+// Stop terminates the background poller, cancelling a request in flight,
+// and waits for it. Safe to call multiple times.
 func (s *PythService) Stop() {
-	select {
-	case <-s.stop:
-	default:
-		close(s.stop)
-	}
+	s.run.stop()
 }
 
-func (s *PythService) loop() {
-	ticker := time.NewTicker(pythPollEvery)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-s.stop:
-			return
-		case <-ticker.C:
-			if err := s.refresh(); err != nil {
-				log.Printf("pyth: refresh failed: %v", err)
-			}
+func (s *PythService) loop(ctx context.Context) {
+	poll := func(ctx context.Context) {
+		if err := s.refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("pyth: refresh failed: %v", err)
 		}
 	}
+	poll(ctx)
+	every(ctx, pythPollEvery, poll)
 }
 
 // refresh issues a single batched call to Hermes for all configured feeds
 // and updates the cache atomically.
-func (s *PythService) refresh() error {
+func (s *PythService) refresh(ctx context.Context) (err error) {
 	if len(pythFeeds) == 0 {
 		return nil
 	}
+	done := timeUpstream("pyth", "hermes")
+	defer func() {
+		done(err)
+		if !errors.Is(err, context.Canceled) {
+			feeds.record(FeedPyth, err, time.Now(), s.bus)
+		}
+	}()
 
 	q := url.Values{}
 	for _, f := range pythFeeds {
@@ -183,7 +223,7 @@ func (s *PythService) refresh() error {
 	q.Set("parsed", "true")
 	q.Set("encoding", "hex")
 
-	req, err := http.NewRequest("GET", hermesEndpoint+"?"+q.Encode(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", hermesEndpoint+"?"+q.Encode(), nil)
 	if err != nil {
 		return fmt.Errorf("build request: %w", err)
 	}
@@ -216,6 +256,7 @@ func (s *PythService) refresh() error {
 		bySymbol[f.feedID] = f
 	}
 
+	fetchedAt := time.Now().UTC()
 	updates := make(map[string]PythQuote, len(parsed.Parsed))
 	for _, p := range parsed.Parsed {
 		feed, ok := bySymbol[p.ID]
@@ -240,20 +281,133 @@ func (s *PythService) refresh() error {
 			Price:       price,
 			Confidence:  conf,
 			PublishedAt: time.Unix(p.Price.PublishTime, 0).UTC(),
+			FetchedAt:   fetchedAt,
 		}
 	}
 
+	fresh := make(map[string]PythQuote, len(updates))
+	closed := make(map[string]models.PythCandle)
 	s.mu.Lock()
 	for sym, q := range updates {
+		// Hermes keeps returning the last publish while the market is
+		// paused; only genuinely new prints are worth persisting.
+		if prev, ok := s.quotes[sym]; !ok || q.PublishedAt.After(prev.PublishedAt) {
+			fresh[sym] = q
+			pythTicks.Inc(sym)
+		}
 		s.quotes[sym] = q
-		s.appendTickLocked(sym, q.Price, q.PublishedAt)
+		if bar, ok := s.appendTickLocked(sym, q.Price, q.PublishedAt); ok {
+			closed[sym] = bar
+		}
 	}
+	s.lastRefresh = time.Now()
 	s.mu.Unlock()
+
+	s.persist(fresh, closed)
+	s.publish(fresh, closed)
 	return nil
 }
 
+// publish tells the bus about this refresh's new prints and closed bars.
+func (s *PythService) publish(fresh map[string]PythQuote, closed map[string]models.PythCandle) {
+	if len(fresh) > 0 {
+		quotes := make([]models.Price, 0, len(fresh))
+		for _, q := range fresh {
+			quotes = append(quotes, q.price())
+		}
+		s.bus.Publish(QuoteUpdated{Source: s.Name(), Quotes: quotes})
+	}
+	for sym, bar := range closed {
+		s.bus.Publish(CandleClosed{Source: s.Name(), Symbol: sym, Candle: bar})
+	}
+}
+
+// restore reloads the candle ring and latest quote for every feed from the
+// persistent store. Closed 1-minute bars come back directly; the bar that
+// was still in progress at shutdown is rebuilt from the raw ticks that
+// followed the last closed bar.
+func (s *PythService) restore() {
+	if s.store == nil {
+		return
+	}
+	now := time.Now()
+	since := now.Add(-time.Duration(pythMaxCandles) * pythCandleInterval)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range pythFeeds {
+		candleSeries := store.Series("pyth", f.symbol, "1m")
+		tickSeries := store.Series("pyth", f.symbol, "tick")
+		_ = s.store.Prune(tickSeries, now.Add(-pythTickRetention))
+		_ = s.store.Prune(candleSeries, now.Add(-pythCandleRetention))
+
+		bars, err := s.store.LoadBars(candleSeries, since)
+		if err != nil {
+			log.Printf("pyth: restore candles for %s: %v", f.symbol, err)
+		}
+		candles := make([]models.PythCandle, 0, len(bars))
+		for _, b := range bars {
+			candles = append(candles, ohlcvToPythCandle(b))
+		}
+		s.candles[f.symbol] = candles
+
+		tickSince := since
+		if n := len(candles); n > 0 {
+			tickSince = time.Unix(candles[n-1].Time, 0).Add(pythCandleInterval)
+		}
+		ticks, err := s.store.LoadTicks(tickSeries, tickSince)
+		if err != nil {
+			log.Printf("pyth: restore ticks for %s: %v", f.symbol, err)
+		}
+		for _, t := range ticks {
+			s.appendTickLocked(f.symbol, t.Price, time.UnixMilli(t.Time).UTC())
+			s.quotes[f.symbol] = PythQuote{
+				Symbol:      f.symbol,
+				Price:       t.Price,
+				Confidence:  t.Confidence,
+				PublishedAt: time.UnixMilli(t.Time).UTC(),
+			}
+		}
+		if len(candles) > 0 || len(ticks) > 0 {
+			log.Printf("pyth: restored %d candles and %d ticks for %s", len(candles), len(ticks), f.symbol)
+		}
+	}
+}
+
+// persist writes the latest ticks and any bars that closed during this
+// refresh. Errors are logged, never fatal — the in-memory cache is still
+// the source of truth for serving.
+func (s *PythService) persist(quotes map[string]PythQuote, closed map[string]models.PythCandle) {
+	if s.store == nil {
+		return
+	}
+	for sym, q := range quotes {
+		tick := store.Tick{Time: q.PublishedAt.UnixMilli(), Price: q.Price, Confidence: q.Confidence}
+		if err := s.store.AppendTicks(store.Series("pyth", sym, "tick"), []store.Tick{tick}); err != nil {
+			log.Printf("pyth: persist tick for %s: %v", sym, err)
+		}
+	}
+	for sym, bar := range closed {
+		if err := s.store.UpsertBars(store.Series("pyth", sym, "1m"), []models.OHLCV{pythCandleToOHLCV(bar)}); err != nil {
+			log.Printf("pyth: persist candle for %s: %v", sym, err)
+		}
+	}
+}
+
+// pythCandleToOHLCV / ohlcvToPythCandle convert between the candle shapes.
+// Pyth has no traded volume, so the stored bar reuses Volume for the tick
+// count.
+func pythCandleToOHLCV(c models.PythCandle) models.OHLCV {
+	return models.OHLCV{Time: c.Time, Open: c.Open, High: c.High, Low: c.Low, Close: c.Close, Volume: int64(c.Ticks)}
+}
+
+func ohlcvToPythCandle(b models.OHLCV) models.PythCandle {
+	return models.PythCandle{Time: b.Time, Open: b.Open, High: b.High, Low: b.Low, Close: b.Close, Ticks: int(b.Volume)}
+}
+
 // appendTickLocked folds a single price tick into the per-symbol 1-minute
-// candle buffer. Caller must hold s.mu (write lock).
+// candle buffer. Caller must hold s.mu (write lock). When the tick opens a
+// new bucket, the bar it closed is returned with ok=true so the caller can
+// persist it.
 //
 // Bucketing: each tick is assigned to the floor(ts / 1min) bucket. If the
 // last bar in the buffer is the same bucket, we update its high/low/close;
@@ -264,9 +418,9 @@ func (s *PythService) refresh() error {
 // the last weekend tick on Friday and Monday morning's open). Streaming
 // chart libraries handle gaps gracefully by drawing a discontinuity, which
 // is the honest representation of "no data published".
-func (s *PythService) appendTickLocked(symbol string, price float64, ts time.Time) {
+func (s *PythService) appendTickLocked(symbol string, price float64, ts time.Time) (closed models.PythCandle, ok bool) {
 	if price <= 0 {
-		return
+		return models.PythCandle{}, false
 	}
 	bucket := ts.Truncate(pythCandleInterval).Unix()
 	bars := s.candles[symbol]
@@ -283,9 +437,12 @@ func (s *PythService) appendTickLocked(symbol string, price float64, ts time.Tim
 		bar.Ticks++
 		bars[n-1] = bar
 		s.candles[symbol] = bars
-		return
+		return models.PythCandle{}, false
 	}
 
+	if n := len(bars); n > 0 && bars[n-1].Time < bucket {
+		closed, ok = bars[n-1], true
+	}
 	bars = append(bars, models.PythCandle{
 		Time: bucket, Open: price, High: price, Low: price, Close: price, Ticks: 1,
 	})
@@ -293,6 +450,7 @@ func (s *PythService) appendTickLocked(symbol string, price float64, ts time.Tim
 		bars = bars[len(bars)-pythMaxCandles:]
 	}
 	s.candles[symbol] = bars
+	return closed, ok
 }
 
 // GetBucketBar aggregates every Pyth 1-minute candle whose start falls in
@@ -389,6 +547,48 @@ func (s *PythService) GetQuote(symbol string) (PythQuote, bool) {
 	return q, true
 }
 
+// PriceSource implementation. Pyth only publishes raw ticks, so its
+// quotes are overlaid on a full quote from a lower-priority source rather
+// than replacing it, and it serves no daily or intraday history.
+
+func (s *PythService) Name() string { return "pyth" }
+
+func (s *PythService) Capabilities() Capability { return CapQuotes | CapTicks }
+
+func (s *PythService) Quotes() map[string]models.Price {
+	quotes := s.GetQuotes()
+	out := make(map[string]models.Price, len(quotes))
+	for sym, q := range quotes {
+		out[sym] = q.price()
+	}
+	return out
+}
+
+func (s *PythService) History(string, int) []models.OHLCV { return nil }
+
+func (s *PythService) Intraday(string, time.Time) ([]models.OHLCV, string) { return nil, "" }
+
+// Health reports Hermes as healthy while polls keep succeeding. A paused
+// market (weekend) is still healthy: the endpoint answers, it just repeats
+// the last publish.
+func (s *PythService) Health() SourceHealth {
+	s.mu.RLock()
+	last := s.lastRefresh
+	s.mu.RUnlock()
+	h := SourceHealth{Name: s.Name(), LastUpdate: last}
+	h.Healthy = !last.IsZero() && time.Since(last) < 10*pythPollEvery
+	if !h.Healthy {
+		h.Detail = "no successful Hermes poll recently"
+	}
+	return h
+}
+
+// LastTick returns the publish time of the latest cached quote.
+func (s *PythService) LastTick(symbol string) (time.Time, bool) {
+	q, ok := s.GetQuote(symbol)
+	return q.PublishedAt, ok
+}
+
 // scalePrice converts Hermes' (mantissa string, exponent) tuple into a
 // human-scale float. Pyth scales prices to integers to avoid floating-point
 // rounding on-chain, e.g. ("8336710", -5) → 83.36710.
@@ -400,67 +600,111 @@ func scalePrice(mantissa string, expo int) (float64, bool) {
 	return float64(n) * math.Pow10(expo), true
 }
 
-// applyPyth merges a Pyth quote onto a Yahoo-derived Price record. We keep
-// Yahoo's daily OHLC/volume/contract metadata (Pyth doesn't expose those),
-// but use Pyth's tick price as the canonical "live" value and recompute the
-// day's change against the previous session close.
-//
-// If yahoo is the zero value the function returns a Price built solely from
-// the Pyth quote and a synthetic name lookup, so symbols that aren't on
-// Yahoo at all still surface a real-time number.
+// applyPyth merges a Pyth quote onto a Yahoo-derived Price record. See
+// overlayTick for the merge rules.
 func applyPyth(yahoo models.Price, q PythQuote) models.Price {
-	merged := yahoo
+	return overlayTick(yahoo, q.price())
+}
+
+// price renders the quote as a tick-only Price (no session metadata), the
+// shape PythService.Quotes hands to the source registry.
+func (q PythQuote) price() models.Price {
+	return models.Price{
+		Symbol:     q.Symbol,
+		Price:      q.Price,
+		UpdatedAt:  q.PublishedAt.Format(time.RFC3339),
+		Source:     "pyth",
+		Provenance: newProvenance([]string{"pyth"}, q.FetchedAt, q.PublishedAt),
+	}
+}
+
+// overlayTick merges a live tick onto a full quote from a slower source.
+// We keep the base's daily OHLC/volume/contract metadata (tick feeds don't
+// expose those), but use the tick price as the canonical "live" value and
+// recompute the day's change against the previous session close.
+//
+// If base is the zero value the function returns a Price built solely from
+// the tick and a synthetic name lookup, so symbols that aren't on Yahoo at
+// all still surface a real-time number.
+func overlayTick(base, tick models.Price) models.Price {
+	merged := base
 	if merged.Symbol == "" {
-		merged.Symbol = q.Symbol
-		if name, ok := commodityNames[q.Symbol]; ok {
+		merged.Symbol = tick.Symbol
+		if name, ok := commodityNames[tick.Symbol]; ok {
 			merged.Name = name
 		} else {
-			merged.Name = q.Symbol
+			merged.Name = tick.Symbol
 		}
 	}
 
-	merged.Price = round2(q.Price)
+	merged.Price = round2(tick.Price)
 
-	prevClose := yahoo.Price - yahoo.Change
+	prevClose := base.Price - base.Change
 	change := 0.0
 	changePct := 0.0
 	if prevClose > 0 {
-		change = q.Price - prevClose
+		change = tick.Price - prevClose
 		changePct = (change / prevClose) * 100
 	}
 
 	// Yahoo's chartPreviousClose can be wildly stale when a futures contract
 	// rolls — a "+1%" day suddenly looks like "-15%" because Yahoo is
 	// comparing the new front month against the old expiring one. If the
-	// implied move is extreme, fall back to Yahoo's already-computed change
-	// and adjust it by the small delta between Yahoo's last refresh and
-	// Pyth's live tick.
+	// implied move is extreme, fall back to the base's already-computed
+	// change and adjust it by the small delta between the base's last
+	// refresh and the live tick.
 	const sanityPctThreshold = 10.0
-	if math.Abs(changePct) > sanityPctThreshold && yahoo.Price > 0 {
-		priceDelta := q.Price - yahoo.Price
-		change = yahoo.Change + priceDelta
-		changePct = (change / yahoo.Price) * 100
+	baselineFallback, suppressed := false, false
+	if math.Abs(changePct) > sanityPctThreshold && base.Price > 0 {
+		baselineFallback = true
+		priceDelta := tick.Price - base.Price
+		change = base.Change + priceDelta
+		changePct = (change / base.Price) * 100
 		if math.Abs(changePct) > sanityPctThreshold {
 			// Both baselines are unreliable — show the live price but no
 			// directional indicator rather than misleading the user.
 			change = 0
 			changePct = 0
+			suppressed = true
 		}
 	}
 
 	merged.Change = round2(change)
 	merged.ChangePct = round2(changePct)
+	merged.Provenance = overlayProvenance(base, tick, baselineFallback, suppressed)
 
 	// Extend the intraday high/low band if the live tick has moved beyond
-	// the values Yahoo reported on its last daily refresh.
-	if yahoo.High == 0 || q.Price > yahoo.High {
-		merged.High = round2(q.Price)
+	// the values the base reported on its last refresh.
+	if base.High == 0 || tick.Price > base.High {
+		merged.High = round2(tick.Price)
 	}
-	if yahoo.Low == 0 || (q.Price < yahoo.Low && q.Price > 0) {
-		merged.Low = round2(q.Price)
+	if base.Low == 0 || (tick.Price < base.Low && tick.Price > 0) {
+		merged.Low = round2(tick.Price)
 	}
 
-	merged.UpdatedAt = q.PublishedAt.Format(time.RFC3339)
-	merged.Source = "pyth"
+	merged.UpdatedAt = tick.UpdatedAt
+	merged.Source = tick.Source
 	return merged
 }
+
+// overlayProvenance describes a tick-over-quote merge: the tick's source
+// leads, followed by whatever fed the base quote.
+func overlayProvenance(base, tick models.Price, baselineFallback, suppressed bool) *models.Provenance {
+	sources := []string{tick.Source}
+	var fetchedAt time.Time
+	if tick.Provenance != nil {
+		sources = tick.Provenance.Sources
+		fetchedAt, _ = time.Parse(time.RFC3339, tick.Provenance.FetchedAt)
+	}
+	if base.Provenance != nil {
+		sources = append(append([]string(nil), sources...), base.Provenance.Sources...)
+		suppressed = suppressed || base.Provenance.ChangeSuppressed
+	} else if base.Source != "" {
+		sources = append(append([]string(nil), sources...), base.Source)
+	}
+	asOf, _ := time.Parse(time.RFC3339, tick.UpdatedAt)
+	p := newProvenance(sources, fetchedAt, asOf)
+	p.BaselineFallback = baselineFallback
+	p.ChangeSuppressed = suppressed
+	return p
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/upstream"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -123,6 +124,11 @@ func TestApplyPyth_GuardsAgainstYahooRollArtifact(t *testing.T) {
 	if got.Price != 83.37 {
 		t.Errorf("expected live Pyth price to still be surfaced, got %v", got.Price)
 	}
+	if p := got.Provenance; p == nil || !p.BaselineFallback || !p.ChangeSuppressed {
+		t.Errorf("expected provenance to flag the fallback and suppression, got %+v", p)
+	} else if len(p.Sources) != 2 || p.Sources[0] != "pyth" || p.Sources[1] != "yahoo" {
+		t.Errorf("expected sources [pyth yahoo], got %v", p.Sources)
+	}
 }
 
 func TestApplyPyth_NoYahooBaseline(t *testing.T) {
@@ -196,15 +202,15 @@ func TestPythService_Refresh(t *testing.T) {
 	defer srv.Close()
 
 	svc := &PythService{
-		client: srv.Client(),
+		client: upstream.New("pyth", srv.Client(), upstream.Policy{}),
 		quotes: make(map[string]PythQuote),
-		stop:   make(chan struct{}),
 	}
 
 	// The real `refresh` builds the URL from the constant, so we re-implement
 	// the request inline here to validate parsing + caching behaviour
 	// deterministically against the fake server.
-	resp, err := svc.client.Get(srv.URL + "?ids[]=" + wtiID + "&parsed=true&encoding=hex")
+	req, _ := http.NewRequest("GET", srv.URL+"?ids[]="+wtiID+"&parsed=true&encoding=hex", nil)
+	resp, err := svc.client.Do(req)
 	if err != nil {
 		t.Fatalf("test fetch: %v", err)
 	}
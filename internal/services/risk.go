@@ -0,0 +1,334 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// Risk windows, in sessions. Volatility: two weeks, a month, a quarter,
+// half a year and a year. Drawdown: a year and all stored history (0).
+var (
+	volatilityWindows  = []int{10, 20, 60, 120, 252}
+	drawdownWindows    = []int{252, 0}
+	tailRiskWindows    = []int{252, 504}
+	tailRiskLevels     = []float64{0.95, 0.99}
+	correlationWindows = []int{20, 60, 252}
+)
+
+// tradingDays annualises daily volatility.
+const tradingDays = 252
+
+// GetRisk computes the risk report for symbol from its cached daily bars.
+// The return into each contract roll (see rollSessions) is dropped and
+// drawdowns are measured on closes back-adjusted across the rolls, so a
+// roll gap doesn't read as a crash while real moves of any size count.
+// ok is false for a symbol outside the catalogue; one without history gets
+// empty lists and a Note.
+func (s *MarketDataService) GetRisk(symbol string) (models.RiskReport, bool) {
+	name, ok := commodityNames[symbol]
+	if !ok {
+		return models.RiskReport{}, false
+	}
+	now := time.Now()
+	bars, source := s.dailyHistory(symbol, 0)
+	bars = validBars(bars)
+	out := models.RiskReport{
+		Symbol:       symbol,
+		Name:         name,
+		Bars:         len(bars),
+		Volatility:   []models.VolatilityWindow{},
+		Drawdowns:    []models.Drawdown{},
+		TailRisk:     []models.TailRisk{},
+		Correlations: []models.CorrelationMatrix{},
+		GeneratedAt:  now.UTC().Format(time.RFC3339),
+	}
+	if len(bars) < volatilityWindows[0]+1 {
+		out.Note = "Risk metrics unavailable — insufficient daily history loaded yet."
+		return out, true
+	}
+	out.AsOf = exchangeDay(bars[len(bars)-1].Time)
+	out.Provenance = stamped(newProvenance([]string{source}, barsFetchedAt([]string{source}, true), barsAsOf(bars)), now)
+
+	closes := make([]float64, len(bars))
+	for i, b := range bars {
+		closes[i] = b.Close
+	}
+	rolls := rollSessions(rollRoot(symbol), bars)
+	closes = adjustAtRolls(closes, rolls)
+	logs := dropRolls(logReturns(closes), rolls)
+
+	for _, days := range volatilityWindows {
+		if days+1 > len(bars) {
+			break
+		}
+		out.Volatility = append(out.Volatility, models.VolatilityWindow{
+			Days:         days,
+			CloseToClose: round4(annualisedVol(logs[max(len(logs)-days, 0):])),
+			Parkinson:    round4(parkinsonVol(bars[len(bars)-days:])),
+			GarmanKlass:  round4(garmanKlassVol(bars[len(bars)-days:])),
+		})
+	}
+
+	for _, days := range drawdownWindows {
+		from := 0
+		if days > 0 {
+			if days > len(closes) {
+				continue
+			}
+			from = len(closes) - days
+		}
+		dd := maxDrawdown(closes[from:])
+		out.Drawdowns = append(out.Drawdowns, models.Drawdown{
+			Days:      days,
+			Max:       round4(dd.max),
+			Peak:      dayAt(bars[from:], dd.peak),
+			Trough:    dayAt(bars[from:], dd.trough),
+			Recovered: dayAt(bars[from:], dd.recovered),
+			Current:   round4(dd.current),
+		})
+	}
+
+	returns := dropRolls(simpleReturns(closes), rolls)
+	for _, days := range tailRiskWindows {
+		if days > len(returns) {
+			break
+		}
+		for _, level := range tailRiskLevels {
+			v, cv := historicalVaR(returns[len(returns)-days:], level)
+			out.TailRisk = append(out.TailRisk, models.TailRisk{Days: days, Level: level, VaR: round4(v), CVaR: round4(cv)})
+		}
+	}
+
+	out.Correlations = s.correlations(symbol, correlationWindows)
+	return out, true
+}
+
+// validBars drops bars with a missing or inverted price, which Yahoo
+// occasionally serves for a holiday session.
+func validBars(bars []models.OHLCV) []models.OHLCV {
+	out := make([]models.OHLCV, 0, len(bars))
+	for _, b := range bars {
+		if b.Open > 0 && b.Low > 0 && b.Close > 0 && b.High >= b.Low {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+func round4(v float64) float64 { return math.Round(v*10000) / 10000 }
+
+// dayAt is the session date of bars[i], or "" for i < 0.
+func dayAt(bars []models.OHLCV, i int) string {
+	if i < 0 {
+		return ""
+	}
+	return exchangeDay(bars[i].Time)
+}
+
+// annualisedVol is the close-to-close volatility: the annualised sample
+// standard deviation of daily log returns r.
+func annualisedVol(r []float64) float64 {
+	if len(r) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, v := range r {
+		mean += v
+	}
+	mean /= float64(len(r))
+	ss := 0.0
+	for _, v := range r {
+		ss += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(ss / float64(len(r)-1) * tradingDays)
+}
+
+// parkinsonVol estimates annualised volatility from each session's
+// high-low range (Parkinson 1980): σ² = E[ln(H/L)²] / (4 ln 2).
+func parkinsonVol(bars []models.OHLCV) float64 {
+	if len(bars) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, b := range bars {
+		hl := math.Log(b.High / b.Low)
+		sum += hl * hl
+	}
+	return math.Sqrt(sum / float64(len(bars)) / (4 * math.Ln2) * tradingDays)
+}
+
+// garmanKlassVol adds the open-to-close move to the range (Garman & Klass
+// 1980): σ² = E[½ ln(H/L)² − (2 ln 2 − 1) ln(C/O)²].
+func garmanKlassVol(bars []models.OHLCV) float64 {
+	if len(bars) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, b := range bars {
+		hl, co := math.Log(b.High/b.Low), math.Log(b.Close/b.Open)
+		sum += 0.5*hl*hl - (2*math.Ln2-1)*co*co
+	}
+	return math.Sqrt(math.Max(sum, 0) / float64(len(bars)) * tradingDays)
+}
+
+// drawdown indexes into the closes it was measured on; -1 when unset.
+type drawdown struct {
+	max, current            float64
+	peak, trough, recovered int
+}
+
+// maxDrawdown finds the largest fall from a running peak, and when (if
+// ever) the closes got back to that peak.
+func maxDrawdown(closes []float64) drawdown {
+	dd := drawdown{peak: -1, trough: -1, recovered: -1}
+	runPeak := 0
+	for i, c := range closes {
+		if c > closes[runPeak] {
+			runPeak = i
+		}
+		if fall := 1 - c/closes[runPeak]; fall > dd.max {
+			dd.max, dd.peak, dd.trough = fall, runPeak, i
+		}
+	}
+	if dd.trough >= 0 {
+		for i := dd.trough + 1; i < len(closes); i++ {
+			if closes[i] >= closes[dd.peak] {
+				dd.recovered = i
+				break
+			}
+		}
+	}
+	if len(closes) > 0 {
+		dd.current = 1 - closes[len(closes)-1]/closes[runPeak]
+	}
+	return dd
+}
+
+func simpleReturns(closes []float64) []float64 {
+	out := make([]float64, 0, len(closes))
+	for i := 1; i < len(closes); i++ {
+		out = append(out, closes[i]/closes[i-1]-1)
+	}
+	return out
+}
+
+// historicalVaR is the one-day loss at level from the empirical return
+// distribution, and the mean loss on the days at or beyond it. Losses are
+// positive fractions.
+func historicalVaR(returns []float64, level float64) (valueAtRisk, cvar float64) {
+	sorted := append([]float64(nil), returns...)
+	sort.Float64s(sorted)
+	cut := quantile(sorted, 1-level)
+	sum, n := 0.0, 0
+	for _, r := range sorted {
+		if r > cut {
+			break
+		}
+		sum += r
+		n++
+	}
+	if n > 0 {
+		cvar = -sum / float64(n)
+	}
+	return -cut, cvar
+}
+
+// correlations builds a correlation matrix of daily log returns across
+// every Yahoo-backed symbol with history (and symbol itself) for each
+// window. Returns into a roll session are left out. Returns are matched
+// by session date; the window is the last `days` sessions any of them
+// traded, and a pair needs two thirds of it in common.
+func (s *MarketDataService) correlations(symbol string, windows []int) []models.CorrelationMatrix {
+	var symbols []string
+	var series []map[string]float64
+	add := func(sym string) {
+		bars, _ := s.dailyHistory(sym, 0)
+		bars = validBars(bars)
+		if len(bars) < 2 {
+			return
+		}
+		rolls := rollSessions(rollRoot(sym), bars)
+		byDay := make(map[string]float64, len(bars))
+		for i := 1; i < len(bars); i++ {
+			if !rolls[i] {
+				byDay[exchangeDay(bars[i].Time)] = math.Log(bars[i].Close / bars[i-1].Close)
+			}
+		}
+		symbols = append(symbols, sym)
+		series = append(series, byDay)
+	}
+	add(symbol)
+	for _, ys := range yahooSymbols {
+		if ys.internal != symbol {
+			add(ys.internal)
+		}
+	}
+
+	daySet := make(map[string]bool)
+	for _, byDay := range series {
+		for d := range byDay {
+			daySet[d] = true
+		}
+	}
+	days := make([]string, 0, len(daySet))
+	for d := range daySet {
+		days = append(days, d)
+	}
+	sort.Strings(days)
+
+	out := make([]models.CorrelationMatrix, 0, len(windows))
+	for _, w := range windows {
+		if w > len(days) {
+			break
+		}
+		window := days[len(days)-w:]
+		m := models.CorrelationMatrix{Days: w, Symbols: symbols, Matrix: make([]models.Series, len(symbols))}
+		for i := range symbols {
+			m.Matrix[i] = make(models.Series, len(symbols))
+		}
+		for i := range symbols {
+			for j := i; j < len(symbols); j++ {
+				c := pairCorrelation(series[i], series[j], window, (2*w+2)/3)
+				m.Matrix[i][j], m.Matrix[j][i] = c, c
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// pairCorrelation is the Pearson correlation of a and b over the days both
+// have, rounded; NaN with fewer than minCommon of them or a flat series.
+func pairCorrelation(a, b map[string]float64, days []string, minCommon int) float64 {
+	var xs, ys []float64
+	for _, d := range days {
+		x, okA := a[d]
+		y, okB := b[d]
+		if okA && okB {
+			xs, ys = append(xs, x), append(ys, y)
+		}
+	}
+	if len(xs) < minCommon || len(xs) < 3 {
+		return math.NaN()
+	}
+	n := float64(len(xs))
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx, my = mx/n, my/n
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return math.NaN()
+	}
+	return math.Round(sxy/math.Sqrt(sxx*syy)*1000) / 1000
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRangeVolatilityEstimators(t *testing.T) {
+	// Every session spans ±1% around an unchanged open and close, so
+	// Parkinson and Garman–Klass agree: σ² = ½ ln(H/L)² per day for GK and
+	// ln(H/L)² / 4ln2 for Parkinson.
+	bars := make([]models.OHLCV, 20)
+	for i := range bars {
+		bars[i] = models.OHLCV{Open: 100, High: 101, Low: 99, Close: 100}
+	}
+	hl := math.Log(101.0 / 99.0)
+	if got, want := parkinsonVol(bars), math.Sqrt(hl*hl/(4*math.Ln2)*252); math.Abs(got-want) > 1e-12 {
+		t.Fatalf("Parkinson = %v, want %v", got, want)
+	}
+	if got, want := garmanKlassVol(bars), math.Sqrt(0.5*hl*hl*252); math.Abs(got-want) > 1e-12 {
+		t.Fatalf("Garman–Klass = %v, want %v", got, want)
+	}
+	// Alternating ±x log moves: sample σ of [x, -x, x, ...] over n returns.
+	closes := []float64{100}
+	for i := 0; i < 10; i++ {
+		f := 1.02
+		if i%2 == 1 {
+			f = 1 / 1.02
+		}
+		closes = append(closes, closes[len(closes)-1]*f)
+	}
+	x := math.Log(1.02)
+	want := math.Sqrt(10 * x * x / 9 * 252) // the moves average to zero
+	if got := annualisedVol(logReturns(closes)); math.Abs(got-want) > 1e-9 {
+		t.Fatalf("close-to-close = %v, want %v", got, want)
+	}
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	dd := maxDrawdown([]float64{100, 120, 90, 110, 125, 100})
+	if dd.max != 0.25 || dd.peak != 1 || dd.trough != 2 || dd.recovered != 4 || math.Abs(dd.current-0.2) > 1e-12 {
+		t.Fatalf("got %+v", dd)
+	}
+	if dd := maxDrawdown([]float64{1, 2, 3}); dd.max != 0 || dd.peak != -1 || dd.current != 0 {
+		t.Fatalf("rising series: %+v", dd)
+	}
+}
+
+func TestHistoricalVaR(t *testing.T) {
+	// Returns -10%..+9% in 1% steps: the 5% quantile interpolates to
+	// -9.05%, and the tail beyond it holds only -10%.
+	returns := make([]float64, 20)
+	for i := range returns {
+		returns[i] = float64(i-10) / 100
+	}
+	v, cv := historicalVaR(returns, 0.95)
+	if math.Abs(v-0.0905) > 1e-12 || math.Abs(cv-0.10) > 1e-12 {
+		t.Fatalf("VaR %v CVaR %v", v, cv)
+	}
+}
+
+func TestGetRisk(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	src := &fakeSource{name: "replay", caps: CapHistory, history: map[string][]models.OHLCV{}}
+	svc.sources.Register(src, 10)
+
+	t0 := time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC)
+	series := func(closes []float64) []models.OHLCV {
+		bars := make([]models.OHLCV, len(closes))
+		for i, c := range closes {
+			bars[i] = models.OHLCV{Time: t0.AddDate(0, 0, i).Unix(), Open: c, High: c * 1.01, Low: c * 0.99, Close: c}
+		}
+		return bars
+	}
+	wti := randomWalk(300, 0, 3)
+	src.history["WTI"] = series(wti)
+	src.history["BRENT"] = series(wti) // moves in lockstep
+	src.history["NATGAS"] = series(randomWalk(300, 0, 4))
+
+	if _, ok := svc.GetRisk("NOPE"); ok {
+		t.Fatal("unknown symbol reported ok")
+	}
+	r, ok := svc.GetRisk("WTI")
+	if !ok || r.Bars != 300 || r.Provenance == nil || r.AsOf == "" {
+		t.Fatalf("report: %+v", r)
+	}
+	if len(r.Volatility) != len(volatilityWindows) || len(r.Drawdowns) != 2 {
+		t.Fatalf("%d volatility windows, %d drawdowns", len(r.Volatility), len(r.Drawdowns))
+	}
+	for _, v := range r.Volatility {
+		if v.CloseToClose <= 0 || v.Parkinson <= 0 || v.GarmanKlass <= 0 {
+			t.Fatalf("window %d: %+v", v.Days, v)
+		}
+	}
+	// 299 returns: the year window fits, the two-year one doesn't.
+	if len(r.TailRisk) != len(tailRiskLevels) || r.TailRisk[1].VaR < r.TailRisk[0].VaR || r.TailRisk[0].CVaR < r.TailRisk[0].VaR {
+		t.Fatalf("tail risk: %+v", r.TailRisk)
+	}
+
+	if len(r.Correlations) != len(correlationWindows) {
+		t.Fatalf("%d correlation matrices", len(r.Correlations))
+	}
+	m := r.Correlations[0]
+	idx := map[string]int{}
+	for i, s := range m.Symbols {
+		idx[s] = i
+	}
+	if m.Symbols[0] != "WTI" || len(m.Symbols) != 3 {
+		t.Fatalf("symbols %v", m.Symbols)
+	}
+	if m.Matrix[0][0] != 1 || m.Matrix[idx["WTI"]][idx["BRENT"]] != 1 {
+		t.Fatalf("matrix %v", m.Matrix)
+	}
+	if c := m.Matrix[idx["WTI"]][idx["NATGAS"]]; math.IsNaN(c) || c >= 1 || c != m.Matrix[idx["NATGAS"]][idx["WTI"]] {
+		t.Fatalf("WTI/NATGAS correlation %v", c)
+	}
+
+	empty, ok := svc.GetRisk("HEATING")
+	if !ok || empty.Note == "" || empty.Volatility == nil || empty.Correlations == nil {
+		t.Fatalf("no-history report: %+v", empty)
+	}
+}
+
+func TestPairCorrelationNeedsOverlap(t *testing.T) {
+	a := map[string]float64{"d1": 1, "d2": 2, "d3": 3, "d4": 4}
+	b := map[string]float64{"d3": 1, "d4": 2}
+	if c := pairCorrelation(a, b, []string{"d1", "d2", "d3", "d4"}, 3); !math.IsNaN(c) {
+		t.Fatalf("two common days gave %v", c)
+	}
+}
+
+func TestRollSessions(t *testing.T) {
+	// CLG24 last traded on Mon 22 Jan 2024 and CLH24 on Tue 20 Feb (the
+	// 25th was a Sunday), so the 23 Jan and 21 Feb sessions are rolls.
+	t0 := time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC)
+	bars := make([]models.OHLCV, 60)
+	for i := range bars {
+		bars[i] = models.OHLCV{Time: t0.AddDate(0, 0, i).Unix(), Close: 100}
+	}
+	rolls := rollSessions("CL", bars)
+	if len(rolls) != 2 || !rolls[22] || !rolls[51] {
+		t.Fatalf("CL rolls %v", rolls)
+	}
+	if rolls := rollSessions("GC", bars); rolls != nil {
+		t.Fatalf("no calendar, got rolls %v", rolls)
+	}
+}
+
+// A real 10% fall is a tail day, not a roll: it sets the 99% VaR, while a
+// bigger gap on a roll session is left out.
+func TestGetRiskKeepsRealTailDays(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	src := &fakeSource{name: "replay", caps: CapHistory, history: map[string][]models.OHLCV{}}
+	svc.sources.Register(src, 10)
+
+	t0 := time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC)
+	bars := make([]models.OHLCV, 300)
+	for i := range bars {
+		bars[i].Time = t0.AddDate(0, 0, i).Unix()
+	}
+	rolls := rollSessions("CL", bars)
+	gap := 0
+	for i := range rolls {
+		gap = max(gap, i)
+	}
+	crashes := map[int]bool{100: true, 150: true, 200: true, 250: true}
+	c := 100.0
+	for i := range bars {
+		switch {
+		case i == 0:
+		case i == gap:
+			c *= 0.85
+		case crashes[i]:
+			if rolls[i] {
+				t.Fatalf("crash day %d is a roll", i)
+			}
+			c *= 0.9
+		case i%2 == 1:
+			c *= 1.005
+		default:
+			c /= 1.005
+		}
+		bars[i].Open, bars[i].High, bars[i].Low, bars[i].Close = c, c*1.01, c*0.99, c
+	}
+	src.history["WTI"] = bars
+
+	r, ok := svc.GetRisk("WTI")
+	if !ok || len(r.TailRisk) != len(tailRiskLevels) {
+		t.Fatalf("report: %+v", r)
+	}
+	if tr := r.TailRisk[1]; tr.Level != 0.99 || tr.VaR != 0.1 || tr.CVaR != 0.1 {
+		t.Fatalf("99%% tail risk %+v, want the 10%% days and not the roll gap", tr)
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"sort"
+	"time"
+)
+
+// Yahoo's continuous tickers (CL=F, ...) switch to the next contract when
+// the front month stops trading, so the first session after each last
+// trade date compares two different contracts. lastTradeRules give that
+// date for the contract expiring in a calendar month, per futures root,
+// from the exchange rulebooks. Exchange holidays aren't modelled, only
+// weekends: a roll next to one can be placed a session off, which costs
+// one ordinary return rather than keeping a roll gap.
+var lastTradeRules = map[string]func(year int, month time.Month) time.Time{
+	// NYMEX crude: three business days before the 25th of the month
+	// before delivery, or before the last business day ahead of the 25th
+	// when the 25th isn't one.
+	"CL": func(year int, month time.Month) time.Time {
+		d := time.Date(year, month, 25, 0, 0, 0, 0, time.UTC)
+		if !businessDay(d) {
+			d = businessDaysBefore(d, 1)
+		}
+		return businessDaysBefore(d, 3)
+	},
+	// NYMEX natural gas: three business days before the first day of the
+	// delivery month.
+	"NG": func(year int, month time.Month) time.Time {
+		return businessDaysBefore(time.Date(year, month+1, 1, 0, 0, 0, 0, time.UTC), 3)
+	},
+	// Brent (last business day of the second month before delivery),
+	// heating oil and RBOB (of the month before): either way, the last
+	// business day of the month.
+	"BZ": lastBusinessDay,
+	"HO": lastBusinessDay,
+	"RB": lastBusinessDay,
+}
+
+func businessDay(d time.Time) bool {
+	return d.Weekday() != time.Saturday && d.Weekday() != time.Sunday
+}
+
+// businessDaysBefore steps back n weekdays from d, not counting d.
+func businessDaysBefore(d time.Time, n int) time.Time {
+	for n > 0 {
+		d = d.AddDate(0, 0, -1)
+		if businessDay(d) {
+			n--
+		}
+	}
+	return d
+}
+
+func lastBusinessDay(year int, month time.Month) time.Time {
+	return businessDaysBefore(time.Date(year, month+1, 1, 0, 0, 0, 0, time.UTC), 1)
+}
+
+// rollRoot is the futures root behind symbol's Yahoo ticker, or "" for a
+// symbol Yahoo doesn't track.
+func rollRoot(symbol string) string {
+	for _, ys := range yahooSymbols {
+		if ys.internal == symbol {
+			return curveRoot(ys)
+		}
+	}
+	return ""
+}
+
+// rollSessions are the indices of the bars that open a new front
+// contract: the first session after each last trade date. The return into
+// such a bar spans two contracts. nil for a root without a calendar, whose
+// returns are then all kept.
+func rollSessions(root string, bars []models.OHLCV) map[int]bool {
+	rule, ok := lastTradeRules[root]
+	if !ok || len(bars) < 2 {
+		return nil
+	}
+	first := time.Unix(bars[0].Time, 0).In(nyTZ)
+	last := time.Unix(bars[len(bars)-1].Time, 0).In(nyTZ)
+	rolls := make(map[int]bool)
+	for m := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.UTC); !m.After(last); m = m.AddDate(0, 1, 0) {
+		expiry := rule(m.Year(), m.Month()).Format("2006-01-02")
+		i := sort.Search(len(bars), func(i int) bool { return exchangeDay(bars[i].Time) > expiry })
+		if i > 0 && i < len(bars) {
+			rolls[i] = true
+		}
+	}
+	return rolls
+}
+
+// adjustAtRolls back-adjusts closes across the given roll sessions, as
+// backAdjustRolls does across any large gap: everything before a roll is
+// scaled so the roll-day return is zero. The latest close is unchanged.
+func adjustAtRolls(closes []float64, rolls map[int]bool) []float64 {
+	out := append([]float64(nil), closes...)
+	for i := 1; i < len(out); i++ {
+		if rolls[i] && out[i-1] > 0 {
+			ratio := out[i] / out[i-1]
+			for j := 0; j < i; j++ {
+				out[j] *= ratio
+			}
+		}
+	}
+	return out
+}
+
+// dropRolls removes the returns into roll sessions from returns, where
+// returns[k] is the return into bar k+1.
+func dropRolls(returns []float64, rolls map[int]bool) []float64 {
+	out := make([]float64, 0, len(returns))
+	for k, r := range returns {
+		if !rolls[k+1] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"live-oil-prices-go/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Capability is a bitmask describing what a PriceSource can serve. The
+// registry uses it to skip sources that can't answer a given question, so
+// a tick-only feed never shadows a source with real daily history.
+type Capability uint8
+
+const (
+	// CapQuotes: Quotes() returns a latest price per symbol.
+	CapQuotes Capability = 1 << iota
+	// CapHistory: History() returns daily OHLCV bars.
+	CapHistory
+	// CapIntraday: Intraday() returns intraday bars.
+	CapIntraday
+	// CapTicks marks a quote as a raw tick with no session metadata (no
+	// daily high/low, change or contract). Tick quotes are overlaid on the
+	// next lower-priority full quote instead of replacing it.
+	CapTicks
+)
+
+// Has reports whether every bit in want is set.
+func (c Capability) Has(want Capability) bool { return c&want == want }
+
+// SourceHealth is a point-in-time summary of a source's upstream state.
+type SourceHealth struct {
+	Name       string    `json:"name"`
+	Healthy    bool      `json:"healthy"`
+	LastUpdate time.Time `json:"lastUpdate"`
+	Detail     string    `json:"detail,omitempty"`
+}
+
+// PriceSource is a market-data provider MarketDataService can merge. All
+// methods read from the provider's own cache and must be cheap and safe
+// for concurrent use; polling the upstream is the provider's business.
+//
+// Methods outside the source's Capabilities should return nil/empty.
+type PriceSource interface {
+	Name() string
+	Capabilities() Capability
+	Quotes() map[string]models.Price
+	// History returns up to `days` most recent daily bars, oldest-first.
+	// days <= 0 returns everything cached.
+	History(symbol string, days int) []models.OHLCV
+	// Intraday returns every cached intraday bar at or after since,
+	// oldest-first, along with the bar interval (e.g. "5m").
+	Intraday(symbol string, since time.Time) (bars []models.OHLCV, interval string)
+	Health() SourceHealth
+}
+
+// LiveBarSource is implemented by tick-level sources (Pyth) that can build
+// an in-progress bar for any bucket. The hero chart and /api/stream use it
+// to keep the rightmost bar moving between intraday refreshes.
+type LiveBarSource interface {
+	PriceSource
+	// LastTick returns the publish time of the most recent tick.
+	LastTick(symbol string) (time.Time, bool)
+	GetBucketBar(symbol string, bucketStart, bucketSec int64) (models.PythCandle, bool)
+	GetCandles(symbol string, max int) []models.PythCandle
+}
+
+// SessionSource is implemented by sources that can serve the most recent
+// complete prior trading session — the hero chart's weekend stand-in.
+type SessionSource interface {
+	PriceSource
+	GetPriorSessionIntraday(symbol string) (bars []models.OHLCV, sessionDate, interval string)
+}
+
+// FullHistorySource is implemented by history sources that can backfill
+// a symbol's entire daily record on demand, beyond what they keep fresh.
+type FullHistorySource interface {
+	PriceSource
+	FetchFullHistory(ctx context.Context, symbol string) error
+}
+
+// CurveSource is implemented by sources that track individual futures
+// contract months (Yahoo) and can serve the forward curve.
+type CurveSource interface {
+	PriceSource
+	// Curve returns the live contract months, front month first.
+	Curve(symbol string) []models.CurvePoint
+	// CurveHistory returns one snapshot per trading day since `since`,
+	// oldest first, with prices by tenor. Metrics are left for the caller.
+	CurveHistory(symbol string, since time.Time) []models.CurveSnapshot
+}
+
+// SourceRegistry holds the registered PriceSources and their priority per
+// symbol. Higher priority wins. Safe for concurrent use; a nil registry
+// reads as empty so a bare MarketDataService falls back to estimates.
+type SourceRegistry struct {
+	mu        sync.RWMutex
+	sources   []registeredSource        // registration order
+	overrides map[string]map[string]int // symbol -> source name -> priority
+}
+
+type registeredSource struct {
+	src      PriceSource
+	priority int
+}
+
+func NewSourceRegistry() *SourceRegistry {
+	return &SourceRegistry{overrides: make(map[string]map[string]int)}
+}
+
+// Register adds src with a default priority applied to every symbol.
+// Registering a second source under an existing name replaces it.
+func (r *SourceRegistry) Register(src PriceSource, priority int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, rs := range r.sources {
+		if rs.src.Name() == src.Name() {
+			r.sources[i] = registeredSource{src: src, priority: priority}
+			return
+		}
+	}
+	r.sources = append(r.sources, registeredSource{src: src, priority: priority})
+}
+
+// SetPriority overrides a source's priority for one symbol, e.g. to prefer
+// an internal feed for WTI only.
+func (r *SourceRegistry) SetPriority(symbol, source string, priority int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.overrides[symbol]
+	if !ok {
+		m = make(map[string]int)
+		r.overrides[symbol] = m
+	}
+	m[source] = priority
+}
+
+// Get returns a registered source by name.
+func (r *SourceRegistry) Get(name string) (PriceSource, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rs := range r.sources {
+		if rs.src.Name() == name {
+			return rs.src, true
+		}
+	}
+	return nil, false
+}
+
+// All returns every registered source in registration order.
+func (r *SourceRegistry) All() []PriceSource {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]PriceSource, len(r.sources))
+	for i, rs := range r.sources {
+		out[i] = rs.src
+	}
+	return out
+}
+
+// ForSymbol returns the sources offering every capability in want, highest
+// priority first. Ties keep registration order.
+func (r *SourceRegistry) ForSymbol(symbol string, want Capability) []PriceSource {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	type ranked struct {
+		src      PriceSource
+		priority int
+	}
+	var list []ranked
+	for _, rs := range r.sources {
+		if !rs.src.Capabilities().Has(want) {
+			continue
+		}
+		p := rs.priority
+		if o, ok := r.overrides[symbol][rs.src.Name()]; ok {
+			p = o
+		}
+		list = append(list, ranked{rs.src, p})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].priority > list[j].priority })
+	out := make([]PriceSource, len(list))
+	for i, rk := range list {
+		out[i] = rk.src
+	}
+	return out
+}
+
+// mergeQuotes folds quotes for one symbol, given highest-priority first,
+// into a single Price. The highest-priority full quote wins; any tick quote
+// ranked above it is overlaid on top (live price, daily metadata from the
+// full quote). ok is false when no source had a quote.
+func mergeQuotes(quotes []models.Price, ticks []bool) (merged models.Price, ok bool) {
+	for i := len(quotes) - 1; i >= 0; i-- {
+		if ticks[i] {
+			merged = overlayTick(merged, quotes[i])
+		} else {
+			merged = quotes[i]
+		}
+		ok = true
+	}
+	return merged, ok
+}
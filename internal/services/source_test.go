@@ -0,0 +1,143 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"testing"
+	"time"
+)
+
+// fakeSource is a canned PriceSource for registry tests.
+type fakeSource struct {
+	name     string
+	caps     Capability
+	quotes   map[string]models.Price
+	history  map[string][]models.OHLCV
+	intraday map[string][]models.OHLCV
+}
+
+func (f *fakeSource) Name() string                    { return f.name }
+func (f *fakeSource) Capabilities() Capability        { return f.caps }
+func (f *fakeSource) Quotes() map[string]models.Price { return f.quotes }
+func (f *fakeSource) History(symbol string, _ int) []models.OHLCV {
+	return f.history[symbol]
+}
+func (f *fakeSource) Intraday(symbol string, _ time.Time) ([]models.OHLCV, string) {
+	return f.intraday[symbol], "5m"
+}
+func (f *fakeSource) Health() SourceHealth { return SourceHealth{Name: f.name, Healthy: true} }
+
+func TestSourceRegistry_ForSymbolOrdersByPriorityAndOverride(t *testing.T) {
+	r := NewSourceRegistry()
+	r.Register(&fakeSource{name: "a", caps: CapQuotes}, 10)
+	r.Register(&fakeSource{name: "b", caps: CapQuotes}, 20)
+	r.Register(&fakeSource{name: "c", caps: CapHistory}, 30)
+
+	got := r.ForSymbol("WTI", CapQuotes)
+	if len(got) != 2 || got[0].Name() != "b" || got[1].Name() != "a" {
+		t.Fatalf("expected [b a], got %v", names(got))
+	}
+
+	r.SetPriority("WTI", "a", 50)
+	if got := r.ForSymbol("WTI", CapQuotes); got[0].Name() != "a" {
+		t.Fatalf("expected per-symbol override to promote a, got %v", names(got))
+	}
+	if got := r.ForSymbol("BRENT", CapQuotes); got[0].Name() != "b" {
+		t.Fatalf("override must not leak to other symbols, got %v", names(got))
+	}
+}
+
+func TestGetPrices_TickSourceOverlaysFullQuote(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	svc.sources.Register(&fakeSource{name: "full", caps: CapQuotes, quotes: map[string]models.Price{
+		"WTI": {Symbol: "WTI", Name: "WTI Crude Oil", Price: 80, Change: 1, High: 81, Low: 79, Contract: "Dec 2026", Source: "full"},
+	}}, 10)
+	svc.sources.Register(&fakeSource{name: "tick", caps: CapQuotes | CapTicks, quotes: map[string]models.Price{
+		"WTI":    {Symbol: "WTI", Price: 82, UpdatedAt: "2026-01-01T00:00:00Z", Source: "tick"},
+		"MURBAN": {Symbol: "MURBAN", Price: 77, UpdatedAt: "2026-01-01T00:00:00Z", Source: "tick"},
+	}}, 20)
+
+	bySym := map[string]models.Price{}
+	for _, p := range svc.GetPrices() {
+		bySym[p.Symbol] = p
+	}
+
+	wti := bySym["WTI"]
+	if wti.Price != 82 || wti.Source != "tick" || wti.Contract != "Dec 2026" || wti.High != 82 {
+		t.Fatalf("expected tick overlaid on full quote, got %+v", wti)
+	}
+	if wti.Change != 3 {
+		t.Fatalf("expected change recomputed vs prior close 79, got %.2f", wti.Change)
+	}
+	if m := bySym["MURBAN"]; m.Price != 77 || m.Name != "Murban Crude" {
+		t.Fatalf("expected tick-only quote with catalogue name, got %+v", m)
+	}
+	if bySym["BRENT"].Source != "estimate" {
+		t.Fatalf("expected uncovered symbol to fall back to estimate, got %+v", bySym["BRENT"])
+	}
+	if p := wti.Provenance; p == nil || len(p.Sources) != 2 || p.Sources[0] != "tick" || p.Sources[1] != "full" {
+		t.Fatalf("expected merged provenance [tick full], got %+v", wti.Provenance)
+	}
+	if p := bySym["BRENT"].Provenance; p == nil || !p.Synthetic {
+		t.Fatalf("expected estimate to be flagged synthetic, got %+v", p)
+	}
+}
+
+func TestGetChartData_UsesHistorySource(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	bars := []models.OHLCV{{Time: 1, Close: 70}, {Time: 2, Close: 71}}
+	svc.sources.Register(&fakeSource{name: "replay", caps: CapHistory, history: map[string][]models.OHLCV{"WTI": bars}}, 10)
+
+	got := svc.GetChartData("WTI", 90, "1d")
+	if len(got.Data) != 2 || got.Data[1].Close != 71 {
+		t.Fatalf("expected bars from the registered history source, got %+v", got.Data)
+	}
+}
+
+func TestGetChartRange_FiltersRealBarsAndFlagsFallback(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	day := int64(86400)
+	bars := []models.OHLCV{{Time: 10 * day, Close: 70}, {Time: 11 * day, Close: 71}, {Time: 12 * day, Close: 72}}
+	svc.sources.Register(&fakeSource{name: "replay", caps: CapHistory, history: map[string][]models.OHLCV{"WTI": bars}}, 10)
+
+	got := svc.GetChartRange("WTI", "1d", time.Unix(11*day, 0), time.Unix(12*day, 0))
+	if len(got.Data) != 2 || got.Data[0].Close != 71 || got.Provenance.Synthetic {
+		t.Fatalf("expected the two real bars in range, got %+v", got.Data)
+	}
+
+	got = svc.GetChartRange("WTI", "1d", time.Unix(20*day, 0), time.Unix(21*day, 0))
+	if got.Provenance == nil || !got.Provenance.Synthetic {
+		t.Fatalf("expected a synthetic fallback outside the history, got %+v", got.Provenance)
+	}
+}
+
+func TestChartWindow_ReachesBackToStoredHistory(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	first := time.Now().AddDate(-12, 0, 0)
+	bars := []models.OHLCV{{Time: first.Unix(), Close: 30}, {Time: time.Now().Unix(), Close: 70}}
+	svc.sources.Register(&fakeSource{name: "replay", caps: CapHistory, history: map[string][]models.OHLCV{"WTI": bars}}, 10)
+
+	if got, ok := svc.ChartWindow("WTI", "1d"); !ok || got.Unix() != first.Unix() {
+		t.Fatalf("expected the oldest stored bar, got %s %v", got, ok)
+	}
+	if got, _ := svc.ChartWindow("OPEC", ""); time.Since(got) < minChartWindow-time.Minute {
+		t.Fatalf("expected at least a year for an unstored symbol, got %s", got)
+	}
+	if got, _ := svc.ChartWindow("WTI", "5m"); time.Since(got) > yahooIntradayRange+time.Minute {
+		t.Fatalf("intraday window should stop at the cache, got %s", got)
+	}
+	if _, ok := svc.ChartWindow("WTI", "3d"); ok {
+		t.Fatal("expected an unsupported interval to be rejected")
+	}
+}
+
+func names(srcs []PriceSource) []string {
+	out := make([]string, len(srcs))
+	for i, s := range srcs {
+		out[i] = s.Name()
+	}
+	return out
+}
@@ -0,0 +1,441 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spread kinds. A difference spread is the weighted sum of its legs (the
+// negative weights are the "short" side) divided by Divisor; a ratio spread
+// is the first leg divided by the second.
+const (
+	spreadDifference = "difference"
+	spreadRatio      = "ratio"
+)
+
+// Price units. Each benchmark quotes in its exchange's native unit, so the
+// legs of a spread have to be converted to a common unit before they can be
+// combined — RBOB and heating oil are $/gal, crude is $/bbl.
+const (
+	unitBarrel = "USD/barrel"
+	unitGallon = "USD/gallon"
+	unitMMBtu  = "USD/MMBtu"
+	unitTonne  = "USD/tonne"
+	unitRatio  = "ratio"
+)
+
+// perBarrel is how many of each unit make up one barrel of crude. Gallons
+// are exact; tonnes use the standard gasoil density (7.45 bbl/t) and MMBtu
+// the EIA's 5.8 MMBtu per barrel of crude energy equivalence.
+var perBarrel = map[string]float64{
+	unitBarrel: 1,
+	unitGallon: 42,
+	unitTonne:  1 / 7.45,
+	unitMMBtu:  5.8,
+}
+
+// symbolUnits is the unit each benchmark's price is quoted in, set by
+// Configure from the instrument catalogue.
+var symbolUnits map[string]string
+
+// convertPrice re-expresses a price quoted per `from` as a price per `to`.
+func convertPrice(price float64, from, to string) float64 {
+	if from == to {
+		return price
+	}
+	return price * perBarrel[from] / perBarrel[to]
+}
+
+// SpreadDefinition describes a named spread. It's also the on-disk format
+// for custom spreads (SPREADS_FILE), e.g.
+//
+//	{"id": "crack-321", "name": "3-2-1 crack spread", "unit": "USD/barrel",
+//	 "legs": [{"symbol": "RBOB", "weight": 2}, {"symbol": "HEATING", "weight": 1},
+//	          {"symbol": "WTI", "weight": -3}],
+//	 "divisor": 3}
+//
+// Kind defaults to "difference". For "ratio" spreads the weights are
+// ignored; Unit may be a price unit to compare the legs on a common basis
+// (e.g. USD/MMBtu for energy parity) or "ratio"/empty to divide the raw
+// quotes.
+type SpreadDefinition struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Kind        string            `json:"kind,omitempty"`
+	Unit        string            `json:"unit,omitempty"`
+	Legs        []SpreadLegWeight `json:"legs"`
+	Divisor     float64           `json:"divisor,omitempty"`
+}
+
+type SpreadLegWeight struct {
+	Symbol string  `json:"symbol"`
+	Weight float64 `json:"weight,omitempty"`
+}
+
+// builtinSpreads are the spreads the desk watches every day. Custom
+// definitions with the same ID replace them.
+var builtinSpreads = []SpreadDefinition{
+	{
+		ID: "brent-wti", Name: "Brent–WTI", Unit: unitBarrel,
+		Description: "Waterborne Brent premium over landlocked WTI.",
+		Legs:        []SpreadLegWeight{{"BRENT", 1}, {"WTI", -1}},
+	},
+	{
+		ID: "crack-321", Name: "3-2-1 crack spread", Unit: unitBarrel,
+		Description: "Refining margin: two barrels of gasoline and one of heating oil from three of WTI, per barrel.",
+		Legs:        []SpreadLegWeight{{"RBOB", 2}, {"HEATING", 1}, {"WTI", -3}},
+		Divisor:     3,
+	},
+	{
+		ID: "wcs-wti", Name: "WCS–WTI differential", Unit: unitBarrel,
+		Description: "Canadian heavy sour discount to WTI.",
+		Legs:        []SpreadLegWeight{{"WCS", 1}, {"WTI", -1}},
+	},
+	{
+		ID: "wti-natgas", Name: "WTI / Henry Hub ratio", Kind: spreadRatio, Unit: unitRatio,
+		Description: "Oil-to-gas price ratio; ~5.8 is energy parity.",
+		Legs:        []SpreadLegWeight{{"WTI", 0}, {"NATGAS", 0}},
+	},
+}
+
+// normalized fills in defaults and checks the definition can be computed.
+func (d SpreadDefinition) normalized() (SpreadDefinition, error) {
+	d.ID = strings.ToLower(strings.TrimSpace(d.ID))
+	if d.ID == "" {
+		return d, fmt.Errorf("spread definition: missing id")
+	}
+	if d.Name == "" {
+		d.Name = d.ID
+	}
+	if d.Kind == "" {
+		d.Kind = spreadDifference
+	}
+	if d.Divisor == 0 {
+		d.Divisor = 1
+	}
+	legs := make([]SpreadLegWeight, len(d.Legs))
+	for i, l := range d.Legs {
+		l.Symbol = strings.ToUpper(l.Symbol)
+		if _, ok := symbolUnits[l.Symbol]; !ok {
+			return d, fmt.Errorf("spread %q: unknown symbol %q", d.ID, l.Symbol)
+		}
+		legs[i] = l
+	}
+	d.Legs = legs
+	switch d.Kind {
+	case spreadDifference:
+		if len(d.Legs) < 2 {
+			return d, fmt.Errorf("spread %q: needs at least two legs", d.ID)
+		}
+		if d.Unit == "" {
+			d.Unit = unitBarrel
+		}
+		if _, ok := perBarrel[d.Unit]; !ok {
+			return d, fmt.Errorf("spread %q: unknown unit %q", d.ID, d.Unit)
+		}
+	case spreadRatio:
+		if len(d.Legs) != 2 {
+			return d, fmt.Errorf("spread %q: a ratio takes exactly two legs", d.ID)
+		}
+		if d.Unit == "" {
+			d.Unit = unitRatio
+		}
+		if _, ok := perBarrel[d.Unit]; !ok && d.Unit != unitRatio {
+			return d, fmt.Errorf("spread %q: unknown unit %q", d.ID, d.Unit)
+		}
+	default:
+		return d, fmt.Errorf("spread %q: unknown kind %q", d.ID, d.Kind)
+	}
+	return d, nil
+}
+
+// LoadSpreadDefinitions reads a JSON array of SpreadDefinitions from path.
+func LoadSpreadDefinitions(path string) ([]SpreadDefinition, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read spreads: %w", err)
+	}
+	var defs []SpreadDefinition
+	if err := json.Unmarshal(raw, &defs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return defs, nil
+}
+
+// AddSpreads registers custom spread definitions alongside the built-ins,
+// replacing any with the same ID. Call before serving requests. Nothing is
+// added if any definition is invalid.
+func (s *MarketDataService) AddSpreads(defs ...SpreadDefinition) error {
+	valid := make([]SpreadDefinition, 0, len(defs))
+	for _, d := range defs {
+		n, err := d.normalized()
+		if err != nil {
+			return err
+		}
+		valid = append(valid, n)
+	}
+	for _, d := range valid {
+		replaced := false
+		for i := range s.customSpreads {
+			if s.customSpreads[i].ID == d.ID {
+				s.customSpreads[i] = d
+				replaced = true
+			}
+		}
+		if !replaced {
+			s.customSpreads = append(s.customSpreads, d)
+		}
+	}
+	return nil
+}
+
+// spreadDefinitions is the built-ins followed by custom spreads, with a
+// custom definition taking a built-in's place when the IDs match.
+func (s *MarketDataService) spreadDefinitions() []SpreadDefinition {
+	out := make([]SpreadDefinition, 0, len(builtinSpreads)+len(s.customSpreads))
+	custom := make(map[string]SpreadDefinition, len(s.customSpreads))
+	for _, d := range s.customSpreads {
+		custom[d.ID] = d
+	}
+	for _, d := range builtinSpreads {
+		if c, ok := custom[d.ID]; ok {
+			out = append(out, c)
+			delete(custom, d.ID)
+			continue
+		}
+		n, _ := d.normalized()
+		out = append(out, n)
+	}
+	for _, d := range s.customSpreads {
+		if _, ok := custom[d.ID]; ok {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func (s *MarketDataService) spreadDefinition(id string) (SpreadDefinition, bool) {
+	id = strings.ToLower(id)
+	for _, d := range s.spreadDefinitions() {
+		if d.ID == id {
+			return d, true
+		}
+	}
+	return SpreadDefinition{}, false
+}
+
+// legUnit is the unit a leg is converted into before combining: the
+// spread's unit, or the leg's own unit for a raw-quote ratio.
+func (d SpreadDefinition) legUnit(symbol string) string {
+	if d.Unit == unitRatio {
+		return symbolUnits[symbol]
+	}
+	return d.Unit
+}
+
+// combine evaluates the spread from leg prices already converted into
+// legUnit. ok is false when a ratio's denominator is zero.
+func (d SpreadDefinition) combine(converted []float64) (float64, bool) {
+	if d.Kind == spreadRatio {
+		if converted[1] == 0 {
+			return 0, false
+		}
+		return converted[0] / converted[1], true
+	}
+	var sum float64
+	for i, l := range d.Legs {
+		sum += l.Weight * converted[i]
+	}
+	return sum / d.Divisor, true
+}
+
+// formula renders the definition as arithmetic, e.g.
+// "(2*RBOB + HEATING - 3*WTI) / 3".
+func (d SpreadDefinition) formula() string {
+	if d.Kind == spreadRatio {
+		return d.Legs[0].Symbol + " / " + d.Legs[1].Symbol
+	}
+	var b strings.Builder
+	for i, l := range d.Legs {
+		w := l.Weight
+		switch {
+		case i == 0 && w < 0:
+			b.WriteString("-")
+		case i > 0 && w < 0:
+			b.WriteString(" - ")
+		case i > 0:
+			b.WriteString(" + ")
+		}
+		if w = math.Abs(w); w != 1 {
+			b.WriteString(strconv.FormatFloat(w, 'f', -1, 64) + "*")
+		}
+		b.WriteString(l.Symbol)
+	}
+	if d.Divisor == 1 {
+		return b.String()
+	}
+	return "(" + b.String() + ") / " + strconv.FormatFloat(d.Divisor, 'f', -1, 64)
+}
+
+// GetSpreads computes every defined spread from the current prices. Change
+// is measured against the spread rebuilt from each leg's prior close.
+func (s *MarketDataService) GetSpreads() []models.Spread {
+	return s.spreadsFrom(s.GetPrices(), time.Now().UTC())
+}
+
+// spreadsFrom computes every defined spread from a given price snapshot.
+func (s *MarketDataService) spreadsFrom(prices []models.Price, now time.Time) []models.Spread {
+	bySym := make(map[string]models.Price, len(prices))
+	for _, p := range prices {
+		bySym[p.Symbol] = p
+	}
+
+	var out []models.Spread
+	for _, d := range s.spreadDefinitions() {
+		legs := make([]models.SpreadLeg, len(d.Legs))
+		cur := make([]float64, len(d.Legs))
+		prev := make([]float64, len(d.Legs))
+		provs := make([]*models.Provenance, len(d.Legs))
+		for i, l := range d.Legs {
+			p := bySym[l.Symbol]
+			unit := d.legUnit(l.Symbol)
+			cur[i] = convertPrice(p.Price, symbolUnits[l.Symbol], unit)
+			prev[i] = convertPrice(p.Price-p.Change, symbolUnits[l.Symbol], unit)
+			provs[i] = p.Provenance
+			legs[i] = models.SpreadLeg{
+				Symbol:    l.Symbol,
+				Weight:    l.Weight,
+				Price:     p.Price,
+				Unit:      symbolUnits[l.Symbol],
+				Converted: r4(cur[i]),
+			}
+		}
+		value, ok := d.combine(cur)
+		if !ok {
+			continue
+		}
+		sp := models.Spread{
+			ID:          d.ID,
+			Name:        d.Name,
+			Description: d.Description,
+			Kind:        d.Kind,
+			Unit:        d.Unit,
+			Formula:     d.formula(),
+			Value:       r4(value),
+			Legs:        legs,
+			UpdatedAt:   now.Format(time.RFC3339),
+			Provenance:  spreadProvenance(provs, now),
+		}
+		if before, ok := d.combine(prev); ok {
+			sp.Change = r4(value - before)
+		}
+		out = append(out, sp)
+	}
+	return out
+}
+
+// spreadProvenance merges the legs' provenance: every contributing source,
+// and the oldest as-of, since a spread is only as fresh as its stalest leg.
+func spreadProvenance(legs []*models.Provenance, now time.Time) *models.Provenance {
+	var sources []string
+	var asOf time.Time
+	var synthetic, suppressed, fallback bool
+	for _, p := range legs {
+		if p == nil {
+			continue
+		}
+		for _, src := range p.Sources {
+			if !slices.Contains(sources, src) {
+				sources = append(sources, src)
+			}
+		}
+		if t, err := time.Parse(time.RFC3339, p.AsOf); err == nil && (asOf.IsZero() || t.Before(asOf)) {
+			asOf = t
+		}
+		synthetic = synthetic || p.Synthetic
+		suppressed = suppressed || p.ChangeSuppressed
+		fallback = fallback || p.BaselineFallback
+	}
+	out := newProvenance(sources, time.Time{}, asOf)
+	out.Synthetic, out.ChangeSuppressed, out.BaselineFallback = synthetic, suppressed, fallback
+	return stamped(out, now)
+}
+
+// GetSpreadChart computes a spread's daily series from the legs' daily
+// closes, keeping only the days every leg traded, and returns the last
+// `days` points. ok is false for an unknown spread ID.
+func (s *MarketDataService) GetSpreadChart(id string, days int) (models.SpreadChart, bool) {
+	d, ok := s.spreadDefinition(id)
+	if !ok {
+		return models.SpreadChart{}, false
+	}
+	out := models.SpreadChart{ID: d.ID, Name: d.Name, Unit: d.Unit, Data: []models.SpreadPoint{}}
+
+	closes := make([]map[int64]float64, len(d.Legs))
+	var sources []string
+	for i, l := range d.Legs {
+		bars, src := s.dailyHistory(l.Symbol, 0)
+		if len(bars) == 0 {
+			return out, true
+		}
+		closes[i] = make(map[int64]float64, len(bars))
+		for _, b := range bars {
+			closes[i][tradingDay(b.Time)] = convertPrice(b.Close, symbolUnits[l.Symbol], d.legUnit(l.Symbol))
+		}
+		if !slices.Contains(sources, src) {
+			sources = append(sources, src)
+		}
+	}
+
+	dayKeys := make([]int64, 0, len(closes[0]))
+	for day := range closes[0] {
+		dayKeys = append(dayKeys, day)
+	}
+	sort.Slice(dayKeys, func(i, j int) bool { return dayKeys[i] < dayKeys[j] })
+
+	vals := make([]float64, len(d.Legs))
+	for _, day := range dayKeys {
+		complete := true
+		for i := range closes {
+			v, ok := closes[i][day]
+			if !ok {
+				complete = false
+				break
+			}
+			vals[i] = v
+		}
+		if !complete {
+			continue
+		}
+		if v, ok := d.combine(vals); ok {
+			out.Data = append(out.Data, models.SpreadPoint{Time: day * 86400, Value: r4(v)})
+		}
+	}
+	if days > 0 && len(out.Data) > days {
+		out.Data = out.Data[len(out.Data)-days:]
+	}
+	if len(out.Data) > 0 {
+		asOf := time.Unix(out.Data[len(out.Data)-1].Time, 0)
+		out.Provenance = stamped(newProvenance(sources, time.Time{}, asOf), time.Now())
+	}
+	return out, true
+}
+
+// tradingDay maps a daily bar's timestamp to a day number. Daily bars are
+// stamped at midnight exchange-local time — 04:00 UTC for NYMEX but 23:00
+// UTC the day before for ICE London in summer — so shifting by 12h before
+// truncating lands both on the same calendar day.
+func tradingDay(ts int64) int64 {
+	return (ts + 12*3600) / 86400
+}
+
+func r4(v float64) float64 { return math.Round(v*10000) / 10000 }
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGetSpreads_ConvertsGallonLegsToBarrels(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	svc.sources.Register(&fakeSource{name: "yahoo", caps: CapQuotes, quotes: map[string]models.Price{
+		"WTI":     {Symbol: "WTI", Price: 80, Change: 1},
+		"BRENT":   {Symbol: "BRENT", Price: 84, Change: 0.5},
+		"RBOB":    {Symbol: "RBOB", Price: 2.50},
+		"HEATING": {Symbol: "HEATING", Price: 2.80},
+		"NATGAS":  {Symbol: "NATGAS", Price: 3.20},
+	}}, yahooPriority)
+
+	bySpread := map[string]models.Spread{}
+	for _, sp := range svc.GetSpreads() {
+		bySpread[sp.ID] = sp
+	}
+
+	if b := bySpread["brent-wti"]; b.Value != 4 || b.Change != -0.5 {
+		t.Fatalf("expected Brent–WTI 4.00 narrowing 0.50, got %+v", b)
+	}
+	// (2×2.50×42 + 2.80×42 − 3×80) / 3 = (210 + 117.6 − 240) / 3 = 29.2
+	crack := bySpread["crack-321"]
+	if math.Abs(crack.Value-29.2) > 1e-9 {
+		t.Fatalf("expected 3-2-1 crack of $29.20/bbl, got %.4f", crack.Value)
+	}
+	if crack.Formula != "(2*RBOB + HEATING - 3*WTI) / 3" || crack.Legs[0].Converted != 105 {
+		t.Fatalf("unexpected crack formula/legs: %q %+v", crack.Formula, crack.Legs)
+	}
+	if r := bySpread["wti-natgas"]; r.Value != 25 || r.Unit != "ratio" {
+		t.Fatalf("expected raw WTI/NATGAS ratio of 25, got %+v", r)
+	}
+	if !bySpread["wcs-wti"].Provenance.Synthetic {
+		t.Fatalf("expected a spread with an estimated leg to be flagged synthetic")
+	}
+}
+
+func TestAddSpreads_CustomDefinitions(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	if err := svc.AddSpreads(SpreadDefinition{ID: "bad", Legs: []SpreadLegWeight{{"WTI", 1}, {"NOPE", -1}}}); err == nil {
+		t.Fatalf("expected an unknown symbol to be rejected")
+	}
+	if err := svc.AddSpreads(SpreadDefinition{ID: "r", Kind: "ratio", Legs: []SpreadLegWeight{{Symbol: "WTI"}}}); err == nil {
+		t.Fatalf("expected a one-legged ratio to be rejected")
+	}
+	err := svc.AddSpreads(
+		SpreadDefinition{ID: "Dubai-Brent", Legs: []SpreadLegWeight{{"dubai", 1}, {"BRENT", -1}}},
+		SpreadDefinition{ID: "brent-wti", Name: "Brent over WTI", Kind: "ratio", Legs: []SpreadLegWeight{{Symbol: "BRENT"}, {Symbol: "WTI"}}},
+	)
+	if err != nil {
+		t.Fatalf("AddSpreads: %v", err)
+	}
+
+	defs := svc.spreadDefinitions()
+	if len(defs) != len(builtinSpreads)+1 {
+		t.Fatalf("expected the override to replace a built-in, got %d definitions", len(defs))
+	}
+	if defs[0].ID != "brent-wti" || defs[0].Kind != spreadRatio {
+		t.Fatalf("expected brent-wti overridden in place, got %+v", defs[0])
+	}
+	last := defs[len(defs)-1]
+	if last.ID != "dubai-brent" || last.Unit != unitBarrel || last.Legs[0].Symbol != "DUBAI" {
+		t.Fatalf("expected normalized custom spread appended, got %+v", last)
+	}
+}
+
+func TestGetSpreadChart_AlignsLegsByTradingDay(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.sources = NewSourceRegistry()
+	day := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC).Unix()
+	nymex := func(d int64) int64 { return day + d*86400 + 4*3600 } // midnight ET
+	ice := func(d int64) int64 { return day + d*86400 - 3600 }     // midnight BST
+	svc.sources.Register(&fakeSource{name: "yahoo", caps: CapHistory, history: map[string][]models.OHLCV{
+		"WTI":   {{Time: nymex(0), Close: 70}, {Time: nymex(1), Close: 71}, {Time: nymex(2), Close: 72}},
+		"BRENT": {{Time: ice(0), Close: 74}, {Time: ice(2), Close: 75.5}},
+	}}, yahooPriority)
+
+	chart, ok := svc.GetSpreadChart("BRENT-WTI", 90)
+	if !ok {
+		t.Fatalf("expected built-in spread to resolve case-insensitively")
+	}
+	if len(chart.Data) != 2 || chart.Data[0].Value != 4 || chart.Data[1].Value != 3.5 {
+		t.Fatalf("expected two aligned days [4 3.5], got %+v", chart.Data)
+	}
+	if chart.Data[1].Time != day+2*86400 {
+		t.Fatalf("expected points stamped at UTC midnight, got %d", chart.Data[1].Time)
+	}
+	if chart.Provenance == nil || chart.Provenance.Sources[0] != "yahoo" {
+		t.Fatalf("expected yahoo provenance, got %+v", chart.Provenance)
+	}
+	if _, ok := svc.GetSpreadChart("nope", 90); ok {
+		t.Fatalf("expected unknown spread to report !ok")
+	}
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"sync"
+	"time"
+)
+
+const (
+	// streamBacklogSize caps the replay buffer used for Last-Event-ID
+	// resume. At a price, tick, candle and bar event per 2s for the
+	// Pyth-backed symbol plus a Yahoo refresh burst every 30s, 2048 events
+	// is roughly 10–15 minutes of history — far longer than any sane
+	// reconnect gap.
+	streamBacklogSize = 2048
+
+	// streamSubscriberBuffer is the per-client channel depth. A client that
+	// falls this far behind is disconnected rather than allowed to block the
+	// publisher; it reconnects with Last-Event-ID and replays the backlog.
+	streamSubscriberBuffer = 64
+)
+
+// StreamHub fans live market events out to any number of subscribers and
+// keeps a bounded backlog so reconnecting clients can resume from the last
+// event id they saw. Safe for concurrent use.
+type StreamHub struct {
+	mu      sync.Mutex
+	lastID  uint64
+	backlog []models.StreamEvent // oldest-first, capped at streamBacklogSize
+	subs    map[*streamSub]struct{}
+}
+
+type streamSub struct {
+	symbols map[string]bool // nil means "every symbol"
+	ch      chan models.StreamEvent
+	closed  bool
+}
+
+// wants reports whether the subscriber's symbol filter lets symbol
+// through. Events without a symbol (news) always pass.
+func (sub *streamSub) wants(symbol string) bool {
+	return sub.symbols == nil || symbol == "" || sub.symbols[symbol]
+}
+
+func NewStreamHub() *StreamHub {
+	return &StreamHub{subs: make(map[*streamSub]struct{})}
+}
+
+// Publish stamps the event with the next id, records it in the backlog and
+// delivers it to every matching subscriber. Delivery never blocks: a
+// subscriber whose buffer is full is dropped (its channel closed) so one
+// slow client can't stall the feed for everyone else.
+func (h *StreamHub) Publish(evt models.StreamEvent) models.StreamEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	evt.ID = h.lastID
+	h.backlog = append(h.backlog, evt)
+	if len(h.backlog) > streamBacklogSize {
+		h.backlog = h.backlog[len(h.backlog)-streamBacklogSize:]
+	}
+
+	for sub := range h.subs {
+		if !sub.wants(evt.Symbol) {
+			continue
+		}
+		select {
+		case sub.ch <- evt:
+		default:
+			h.dropLocked(sub)
+		}
+	}
+	return evt
+}
+
+// Subscribe registers a new subscriber for the given symbols (empty = all).
+// If lastEventID is non-zero, every retained event newer than it is
+// returned as backlog for the caller to replay before reading the channel;
+// resumed reports whether the backlog fully covers the gap (false when the
+// id has already been evicted, in which case the caller should send a
+// fresh snapshot instead).
+//
+// The returned cancel func must be called when the subscriber goes away.
+func (h *StreamHub) Subscribe(symbols []string, lastEventID uint64) (backlog []models.StreamEvent, events <-chan models.StreamEvent, resumed bool, cancel func()) {
+	sub := &streamSub{ch: make(chan models.StreamEvent, streamSubscriberBuffer)}
+	if len(symbols) > 0 {
+		sub.symbols = make(map[string]bool, len(symbols))
+		for _, s := range symbols {
+			sub.symbols[s] = true
+		}
+	}
+
+	h.mu.Lock()
+	if lastEventID > 0 && lastEventID <= h.lastID {
+		resumed = len(h.backlog) > 0 && h.backlog[0].ID <= lastEventID+1
+		for _, evt := range h.backlog {
+			if evt.ID > lastEventID && sub.wants(evt.Symbol) {
+				backlog = append(backlog, evt)
+			}
+		}
+		if lastEventID == h.lastID {
+			resumed = true
+		}
+	}
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	cancel = func() {
+		h.mu.Lock()
+		h.dropLocked(sub)
+		h.mu.Unlock()
+	}
+	return backlog, sub.ch, resumed, cancel
+}
+
+// LastID returns the id of the most recently published event.
+func (h *StreamHub) LastID() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastID
+}
+
+// dropLocked unregisters a subscriber and closes its channel exactly once.
+// Caller must hold h.mu.
+func (h *StreamHub) dropLocked(sub *streamSub) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(h.subs, sub)
+	close(sub.ch)
+}
+
+// streamState remembers the last value pushed per symbol so pollStream
+// only publishes genuine changes.
+type streamState struct {
+	prices  map[string]models.Price
+	bars    map[string]models.PythCandle
+	ticks   map[string]time.Time
+	candles map[string]models.PythCandle
+}
+
+// Subscribe attaches a client to the live price/bar stream. On a fresh
+// connection (or when the requested Last-Event-ID has aged out of the
+// backlog) the current prices are returned as "snapshot" events so the
+// client never has to call /api/prices first.
+func (s *MarketDataService) Subscribe(symbols []string, lastEventID uint64) ([]models.StreamEvent, <-chan models.StreamEvent, func()) {
+	s.streamOnce.Do(func() {
+		if s.stream == nil {
+			s.stream = NewStreamHub()
+		}
+		s.startStream()
+	})
+
+	backlog, events, resumed, cancel := s.stream.Subscribe(symbols, lastEventID)
+	if resumed {
+		return backlog, events, cancel
+	}
+
+	want := make(map[string]bool, len(symbols))
+	for _, sym := range symbols {
+		want[sym] = true
+	}
+	snapID := s.stream.LastID()
+	snapshot := make([]models.StreamEvent, 0, len(allCommodities))
+	for _, p := range s.GetPrices() {
+		if len(want) > 0 && !want[p.Symbol] {
+			continue
+		}
+		snapshot = append(snapshot, models.StreamEvent{ID: snapID, Type: "snapshot", Symbol: p.Symbol, Data: p})
+	}
+	return snapshot, events, cancel
+}
+
+// startStream feeds the hub from the bus: every quote refresh or closed
+// candle re-diffs the caches, and new articles go out as "news" events.
+// The bus delivers to one subscriber sequentially, so state needs no lock.
+func (s *MarketDataService) startStream() {
+	state := streamState{
+		prices:  make(map[string]models.Price),
+		bars:    make(map[string]models.PythCandle),
+		ticks:   make(map[string]time.Time),
+		candles: make(map[string]models.PythCandle),
+	}
+	s.subscribe("stream", func(e Event) {
+		if added, ok := e.(ArticlesAdded); ok {
+			s.publishNews(added.Articles)
+			return
+		}
+		s.pollStream(&state)
+	}, KindQuoteUpdated, KindCandleClosed, KindArticlesAdded)
+}
+
+// pollStream diffs the merged price view and each symbol's latest tick,
+// live 1-minute candle and hero bucket bar against what was last
+// published and emits an event for every change.
+func (s *MarketDataService) pollStream(state *streamState) {
+	for _, p := range s.GetPrices() {
+		// Synthetic estimates are re-rolled on every GetPrices call, so
+		// diffing them would flood the stream with noise. They're still
+		// delivered once in the connection snapshot.
+		if p.Source == "estimate" {
+			continue
+		}
+		if prev, ok := state.prices[p.Symbol]; ok && samePriceTick(prev, p) {
+			continue
+		}
+		state.prices[p.Symbol] = p
+		s.stream.Publish(models.StreamEvent{Type: "price", Symbol: p.Symbol, Data: p})
+	}
+
+	for _, c := range allCommodities {
+		live := s.liveSource(c.symbol)
+		if live == nil {
+			continue
+		}
+		ts, ok := live.LastTick(c.symbol)
+		if !ok || time.Since(ts) > pythLiveWindow {
+			continue
+		}
+		if !ts.Equal(state.ticks[c.symbol]) {
+			if q, ok := live.Quotes()[c.symbol]; ok {
+				state.ticks[c.symbol] = ts
+				s.stream.Publish(models.StreamEvent{Type: "tick", Symbol: c.symbol, Data: models.Tick{
+					Symbol: c.symbol, Price: q.Price, Time: ts.UnixMilli(), Source: live.Name(),
+				}})
+			}
+		}
+		if candles := live.GetCandles(c.symbol, 1); len(candles) == 1 && candles[0] != state.candles[c.symbol] {
+			state.candles[c.symbol] = candles[0]
+			s.stream.Publish(models.StreamEvent{Type: "candle", Symbol: c.symbol, Data: candles[0]})
+		}
+		bucketStart := ts.Truncate(time.Duration(heroBucketSec) * time.Second).Unix()
+		bar, ok := live.GetBucketBar(c.symbol, bucketStart, heroBucketSec)
+		if !ok {
+			continue
+		}
+		if prev, ok := state.bars[c.symbol]; ok && prev == bar {
+			continue
+		}
+		state.bars[c.symbol] = bar
+		s.stream.Publish(models.StreamEvent{Type: "bar", Symbol: c.symbol, Data: bar})
+	}
+}
+
+// publishNews streams newly added articles, given newest first, oldest
+// first.
+func (s *MarketDataService) publishNews(articles []models.NewsArticle) {
+	for i := len(articles) - 1; i >= 0; i-- {
+		s.stream.Publish(models.StreamEvent{Type: "news", Data: articles[i]})
+	}
+}
+
+func samePriceTick(a, b models.Price) bool {
+	return a.Price == b.Price && a.Change == b.Change && a.High == b.High &&
+		a.Low == b.Low && a.UpdatedAt == b.UpdatedAt
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"live-oil-prices-go/internal/models"
+	"testing"
+	"time"
+)
+
+func TestStreamHub_FiltersBySymbol(t *testing.T) {
+	hub := NewStreamHub()
+	_, events, _, cancel := hub.Subscribe([]string{"WTI"}, 0)
+	defer cancel()
+
+	hub.Publish(models.StreamEvent{Type: "price", Symbol: "BRENT"})
+	hub.Publish(models.StreamEvent{Type: "price", Symbol: "WTI"})
+
+	select {
+	case evt := <-events:
+		if evt.Symbol != "WTI" {
+			t.Fatalf("expected only WTI events, got %q", evt.Symbol)
+		}
+		if evt.ID != 2 {
+			t.Fatalf("expected id 2 (ids are global, not per-subscriber), got %d", evt.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a WTI event")
+	}
+}
+
+func TestStreamHub_ResumesFromLastEventID(t *testing.T) {
+	hub := NewStreamHub()
+	for i := 0; i < 5; i++ {
+		hub.Publish(models.StreamEvent{Type: "price", Symbol: "WTI"})
+	}
+
+	backlog, _, resumed, cancel := hub.Subscribe(nil, 3)
+	defer cancel()
+	if !resumed {
+		t.Fatal("expected resume to succeed for an id still in the backlog")
+	}
+	if len(backlog) != 2 || backlog[0].ID != 4 || backlog[1].ID != 5 {
+		t.Fatalf("expected events 4 and 5 replayed, got %+v", backlog)
+	}
+}
+
+func TestStreamHub_ResumeFailsOnceEvicted(t *testing.T) {
+	hub := NewStreamHub()
+	for i := 0; i < streamBacklogSize+10; i++ {
+		hub.Publish(models.StreamEvent{Type: "price", Symbol: "WTI"})
+	}
+	_, _, resumed, cancel := hub.Subscribe(nil, 2)
+	defer cancel()
+	if resumed {
+		t.Fatal("expected resume to fail once the id has aged out of the backlog")
+	}
+}
+
+// A subscriber that stops reading must be dropped rather than blocking the
+// publisher (which runs on a bus subscriber).
+func TestStreamHub_DropsSlowSubscriber(t *testing.T) {
+	hub := NewStreamHub()
+	_, events, _, cancel := hub.Subscribe(nil, 0)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < streamSubscriberBuffer+5; i++ {
+			hub.Publish(models.StreamEvent{Type: "price", Symbol: "WTI"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publisher blocked on a slow subscriber")
+	}
+
+	n := 0
+	for range events {
+		n++
+	}
+	if n != streamSubscriberBuffer {
+		t.Fatalf("expected %d buffered events before the drop, got %d", streamSubscriberBuffer, n)
+	}
+}
+
+func TestMarketDataService_SubscribeSendsSnapshot(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.stream = NewStreamHub()
+	svc.streamOnce.Do(func() {}) // don't start the background poller in tests
+
+	backlog, _, cancel := svc.Subscribe([]string{"WTI", "BRENT"}, 0)
+	defer cancel()
+	if len(backlog) != 2 {
+		t.Fatalf("expected a 2-symbol snapshot, got %d events", len(backlog))
+	}
+	for _, evt := range backlog {
+		if evt.Type != "snapshot" {
+			t.Fatalf("expected snapshot events, got %q", evt.Type)
+		}
+		if _, ok := evt.Data.(models.Price); !ok {
+			t.Fatalf("expected snapshot data to be a Price, got %T", evt.Data)
+		}
+	}
+}
+
+func TestPollStream_PublishesOnlyChanges(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.stream = NewStreamHub()
+	yahoo := &YahooFinanceService{prices: map[string]models.Price{
+		"WTI": {Symbol: "WTI", Price: 80, UpdatedAt: "2026-01-01T00:00:00Z", Source: "yahoo"},
+	}}
+	svc.sources = NewSourceRegistry()
+	svc.sources.Register(yahoo, yahooPriority)
+	state := streamState{prices: map[string]models.Price{}, bars: map[string]models.PythCandle{}}
+
+	svc.pollStream(&state)
+	svc.pollStream(&state)
+	if got := svc.stream.LastID(); got != 1 {
+		t.Fatalf("expected one price event for an unchanged quote, got %d", got)
+	}
+
+	yahoo.prices["WTI"] = models.Price{Symbol: "WTI", Price: 80.5, UpdatedAt: "2026-01-01T00:00:30Z", Source: "yahoo"}
+	svc.pollStream(&state)
+	if got := svc.stream.LastID(); got != 2 {
+		t.Fatalf("expected a second event after the price moved, got %d", got)
+	}
+}
+
+func TestPollStream_PublishesTicksAndCandles(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.stream = NewStreamHub()
+	now := time.Now().UTC()
+	pyth := &PythService{
+		quotes:  map[string]PythQuote{"WTI": {Symbol: "WTI", Price: 80, PublishedAt: now, FetchedAt: now}},
+		candles: map[string][]models.PythCandle{"WTI": {{Time: now.Truncate(time.Minute).Unix(), Open: 80, High: 80, Low: 80, Close: 80, Ticks: 1}}},
+	}
+	svc.sources = NewSourceRegistry()
+	svc.sources.Register(pyth, pythPriority)
+	state := streamState{
+		prices:  map[string]models.Price{},
+		bars:    map[string]models.PythCandle{},
+		ticks:   map[string]time.Time{},
+		candles: map[string]models.PythCandle{},
+	}
+	_, events, _, cancel := svc.stream.Subscribe(nil, 0)
+	defer cancel()
+	drain := func() map[string]int {
+		counts := map[string]int{}
+		for {
+			select {
+			case evt := <-events:
+				counts[evt.Type]++
+			default:
+				return counts
+			}
+		}
+	}
+
+	svc.pollStream(&state)
+	if got := drain(); got["tick"] != 1 || got["candle"] != 1 {
+		t.Fatalf("first poll published %v", got)
+	}
+
+	svc.pollStream(&state)
+	if got := drain(); got["tick"] != 0 || got["candle"] != 0 {
+		t.Fatalf("unchanged tick republished: %v", got)
+	}
+
+	next := now.Add(2 * time.Second)
+	pyth.quotes["WTI"] = PythQuote{Symbol: "WTI", Price: 80.2, PublishedAt: next, FetchedAt: next}
+	pyth.candles["WTI"][0].Close, pyth.candles["WTI"][0].Ticks = 80.2, 2
+	svc.pollStream(&state)
+	if got := drain(); got["tick"] != 1 || got["candle"] != 1 {
+		t.Fatalf("second tick published %v", got)
+	}
+}
+
+// The stream is driven by bus events, not a timer: a QuoteUpdated re-diffs
+// the caches and ArticlesAdded goes straight out, oldest first.
+func TestStreamFollowsBus(t *testing.T) {
+	svc := newDeterministicMarketDataService()
+	svc.bus = NewBus()
+	now := time.Now().UTC()
+	svc.sources = NewSourceRegistry()
+	svc.sources.Register(&PythService{
+		quotes:  map[string]PythQuote{"WTI": {Symbol: "WTI", Price: 80, PublishedAt: now, FetchedAt: now}},
+		candles: map[string][]models.PythCandle{},
+	}, pythPriority)
+
+	_, events, cancel := svc.Subscribe([]string{"WTI"}, 0)
+	defer cancel()
+	next := func() models.StreamEvent {
+		t.Helper()
+		select {
+		case evt := <-events:
+			return evt
+		case <-time.After(time.Second):
+			t.Fatal("no stream event")
+			return models.StreamEvent{}
+		}
+	}
+
+	svc.bus.Publish(ArticlesAdded{Articles: []models.NewsArticle{{ID: "newer"}, {ID: "new"}}})
+	if a, b := next(), next(); a.Type != "news" || a.Data.(models.NewsArticle).ID != "new" || b.Data.(models.NewsArticle).ID != "newer" {
+		t.Fatalf("news events %+v, %+v", a, b)
+	}
+
+	svc.bus.Publish(QuoteUpdated{Source: "pyth"})
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		seen[next().Type] = true
+	}
+	if !seen["price"] || !seen["tick"] {
+		t.Fatalf("quote update streamed %v", seen)
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"live-oil-prices-go/internal/models"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// alertMaxAttempts caps webhook retries. With the 2s base doubling
+	// each time, the last attempt lands ~30s after the first.
+	alertMaxAttempts = 5
+	// alertDeliveryLog is how many deliveries the in-memory log keeps.
+	alertDeliveryLog = 500
+)
+
+// Delivery statuses.
+const (
+	deliveryPending   = "pending"
+	deliveryDelivered = "delivered"
+	deliveryFailed    = "failed"
+)
+
+// dispatch logs a pending delivery and sends it in the background so a
+// slow receiver never stalls evaluation.
+func (e *AlertEngine) dispatch(a models.Alert, evt models.AlertEvent) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	d := models.AlertDelivery{
+		ID:        newID(),
+		AlertID:   a.ID,
+		Event:     evt,
+		Status:    deliveryPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	e.mu.Lock()
+	e.deliveries = append(e.deliveries, d)
+	if len(e.deliveries) > alertDeliveryLog {
+		e.deliveries = e.deliveries[len(e.deliveries)-alertDeliveryLog:]
+	}
+	e.mu.Unlock()
+
+	ctx := e.run.context()
+	e.inflight.Add(1)
+	go func() {
+		defer e.inflight.Done()
+		e.deliver(ctx, a, d)
+	}()
+}
+
+// deliver POSTs the event, retrying network errors, 429s and 5xx with
+// exponential backoff from e.retryBase. Any other status is final, and so
+// is ctx ending: the delivery is marked failed with the context's error.
+func (e *AlertEngine) deliver(ctx context.Context, a models.Alert, d models.AlertDelivery) {
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		e.updateDelivery(d.ID, 0, 0, err, deliveryFailed)
+		return
+	}
+	delay := e.retryBase
+	for attempt := 1; attempt <= alertMaxAttempts; attempt++ {
+		code, err := e.post(ctx, a, d.ID, attempt, body)
+		status := deliveryPending
+		switch {
+		case err == nil && code >= 200 && code < 300:
+			status = deliveryDelivered
+		case err == nil && code != http.StatusTooManyRequests && code < 500:
+			status = deliveryFailed
+		case attempt == alertMaxAttempts, ctx.Err() != nil:
+			status = deliveryFailed
+		}
+		if err == nil && status != deliveryDelivered {
+			err = fmt.Errorf("status %d", code)
+		}
+		e.updateDelivery(d.ID, attempt, code, err, status)
+		if status != deliveryPending {
+			return
+		}
+		if !sleepCtx(ctx, delay) {
+			e.updateDelivery(d.ID, attempt, code, ctx.Err(), deliveryFailed)
+			return
+		}
+		delay *= 2
+	}
+}
+
+// post sends one attempt. When the alert has a secret the body is signed:
+//
+//	X-Alert-Signature: t=<unix seconds>,v1=<hex HMAC-SHA256(secret, "<t>.<body>")>
+//
+// Receivers should recompute the HMAC and reject stale timestamps.
+func (e *AlertEngine) post(ctx context.Context, a models.Alert, deliveryID string, attempt int, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "live-oil-prices-alerts/1")
+	req.Header.Set("X-Alert-Id", a.ID)
+	req.Header.Set("X-Alert-Delivery", deliveryID)
+	req.Header.Set("X-Alert-Attempt", strconv.Itoa(attempt))
+	if a.Secret != "" {
+		req.Header.Set("X-Alert-Signature", signWebhook(a.Secret, time.Now().Unix(), body))
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		// The URL in a *url.Error ends up in the delivery log.
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			ue.URL = redactWebhook(ue.URL)
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+func signWebhook(secret string, ts int64, body []byte) string {
+	t := strconv.FormatInt(ts, 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// errWebhookDestination is the dial error for a refused webhook address.
+var errWebhookDestination = errors.New("webhook destination not allowed")
+
+// newWebhookClient dials only addresses allowed accepts. The check runs on
+// the resolved address of every connection, redirects included, so a name
+// that re-resolves to an internal address after validation (DNS
+// rebinding) is still refused. There is deliberately no proxy: the check
+// would see the proxy's address rather than the receiver's.
+func newWebhookClient(allowed func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !allowed(ap.Addr()) {
+				return fmt.Errorf("%w: %s", errWebhookDestination, ap.Addr())
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        16,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+var (
+	sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10") // carrier-grade NAT
+	thisNetwork        = netip.MustParsePrefix("0.0.0.0/8")
+)
+
+// publicAddr reports whether webhooks may be sent to a: anything but
+// loopback, private (RFC 1918, ULA), link-local (which includes the
+// 169.254.169.254 metadata service), multicast, unspecified and shared
+// address space.
+func publicAddr(a netip.Addr) bool {
+	a = a.Unmap()
+	switch {
+	case !a.IsValid(), a.IsLoopback(), a.IsPrivate(), a.IsUnspecified(),
+		a.IsLinkLocalUnicast(), a.IsLinkLocalMulticast(), a.IsInterfaceLocalMulticast(), a.IsMulticast(),
+		sharedAddressSpace.Contains(a), thisNetwork.Contains(a):
+		return false
+	}
+	return true
+}
+
+// checkWebhookHost refuses a webhook URL aimed at an address allowAddr
+// rejects: IP literals and localhost names outright, and host names whose
+// current DNS answers include one. A name that doesn't resolve yet is let
+// through; the dial-time check in newWebhookClient has the final say.
+func (e *AlertEngine) checkWebhookHost(rawURL string) error {
+	refused := fmt.Errorf("%w: webhookUrl must not target a loopback, private or link-local address", models.ErrInvalidAlert)
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", models.ErrInvalidAlert, err)
+	}
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !e.allowAddr(addr) {
+			return refused
+		}
+		return nil
+	}
+	if h := strings.ToLower(strings.TrimSuffix(host, ".")); h == "localhost" || strings.HasSuffix(h, ".localhost") {
+		return refused
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, a := range addrs {
+		if !e.allowAddr(a) {
+			return refused
+		}
+	}
+	return nil
+}
+
+// redactWebhook keeps a webhook URL's scheme and host. The path and query
+// of hosted receivers (Slack, Discord, ...) are the credential.
+func redactWebhook(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "redacted"
+	}
+	return u.Scheme + "://" + u.Host + "/..."
+}
+
+func (e *AlertEngine) updateDelivery(id string, attempt, code int, err error, status string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := len(e.deliveries) - 1; i >= 0; i-- {
+		d := &e.deliveries[i]
+		if d.ID != id {
+			continue
+		}
+		d.Attempts, d.StatusCode, d.Status = attempt, code, status
+		d.Error = ""
+		if err != nil {
+			d.Error = err.Error()
+		}
+		d.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+		return
+	}
+}
+
+// Deliveries returns the delivery log for one alert ("" for all), newest
+// first.
+func (e *AlertEngine) Deliveries(alertID string) []models.AlertDelivery {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := []models.AlertDelivery{}
+	for i := len(e.deliveries) - 1; i >= 0; i-- {
+		if alertID == "" || e.deliveries[i].AlertID == alertID {
+			out = append(out, e.deliveries[i])
+		}
+	}
+	return out
+}
@@ -1,10 +1,13 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/store"
+	"live-oil-prices-go/internal/upstream"
 	"log"
 	"math"
 	"net/http"
@@ -19,13 +22,8 @@ type yahooSymbol struct {
 	name     string
 }
 
-var yahooSymbols = []yahooSymbol{
-	{"WTI", "CL=F", "WTI Crude Oil"},
-	{"BRENT", "BZ=F", "Brent Crude Oil"},
-	{"NATGAS", "NG=F", "Natural Gas"},
-	{"HEATING", "HO=F", "Heating Oil"},
-	{"RBOB", "RB=F", "RBOB Gasoline"},
-}
+// yahooSymbols is the Yahoo Finance ticker table, set by Configure.
+var yahooSymbols []yahooSymbol
 
 type yahooChartResponse struct {
 	Chart struct {
@@ -55,12 +53,12 @@ type yahooChartResponse struct {
 	} `json:"chart"`
 }
 
-// intradayBars holds the FULL ~5-day cached intraday series for one symbol.
-// We keep every bar Yahoo returns (typically ~5 trading sessions worth of
-// 5-min bars) and let callers filter on demand — `GetRolling24hIntraday`
-// slices out the last 24 hours for the homepage hero, and
-// `GetPriorSessionIntraday` walks back to the most recent complete session
-// for the weekend / cold-start fallback.
+// intradayBars holds the FULL cached intraday series for one symbol: up to
+// yahooIntradayRange (60 days) of 5-min bars, merged across refreshes. We
+// let callers filter on demand — `GetRolling24hIntraday` slices out the
+// last 24 hours for the homepage hero, `GetIntradaySince` feeds the chart
+// API's resampled intervals, and `GetPriorSessionIntraday` walks back to
+// the most recent complete session for the weekend / cold-start fallback.
 type intradayBars struct {
 	bars      []models.OHLCV // oldest-first, NY-local mixed days
 	fetchedAt time.Time
@@ -68,51 +66,129 @@ type intradayBars struct {
 }
 
 type YahooFinanceService struct {
-	client     *http.Client
+	client     *upstream.Client
 	mu         sync.RWMutex
 	prices     map[string]models.Price
 	history    map[string][]float64    // 2y of daily closes (legacy, kept for prediction models)
-	historyOHLC map[string][]models.OHLCV // 2y of daily OHLCV bars used for the main chart
+	historyOHLC map[string][]models.OHLCV // daily OHLCV bars for the main chart: 2y, or more after a full backfill
 	intraday   map[string]intradayBars
+
+	// curves is the latest forward curve per symbol (front month first);
+	// curveHistory keeps one snapshot per trading day by tenor.
+	curves       map[string][]models.CurvePoint
+	curveHistory map[string][]models.CurveSnapshot
+
+	// store persists daily and 5-minute bars so a restart can serve charts
+	// from disk before the first network refresh lands. Nil disables it.
+	store store.Store
+
+	// lastRefresh is when the quote loop last got at least one price back,
+	// reported by Health.
+	lastRefresh time.Time
+
+	// fullHistoryAt is when FetchFullHistory last ran per symbol.
+	fullHistoryAt map[string]time.Time
+
+	// bus receives QuoteUpdated after each quote poll and HistoryRefreshed
+	// whenever daily or intraday bars land. Nil publishes nothing.
+	bus *Bus
+
+	run runGroup
 }
 
-func NewYahooFinanceService() *YahooFinanceService {
+// Intraday cache windows. Yahoo serves 5-minute bars for at most 60 days,
+// so that is what we backfill (and restore from the store on boot) to back
+// the 15m/1h/4h chart intervals; the 5-minute poll only asks for the last
+// few days and merges them in.
+const (
+	yahooIntradayRange    = 60 * 24 * time.Hour
+	yahooIntradayBackfill = "60d"
+	yahooIntradayPoll     = "5d"
+)
+
+// yahooPolicy governs every call to Yahoo's chart API. The quote, history,
+// intraday and curve loops share it, and at start-up the curve probes
+// alone make over a hundred calls, so the rate limit is what keeps a
+// cold start from drawing 429s.
+var yahooPolicy = upstream.Policy{
+	Timeout:          15 * time.Second,
+	MaxAttempts:      3,
+	BaseDelay:        time.Second,
+	MaxDelay:         10 * time.Second,
+	Rate:             8,
+	Burst:            20,
+	FailureThreshold: 5,
+	Cooldown:         time.Minute,
+}
+
+// Daily history ranges: the periodic refresh pulls two years; a full
+// backfill (FetchFullHistory) asks for everything, 10+ years for the
+// energy futures.
+const (
+	yahooHistoryRange     = "2y"
+	yahooFullHistoryRange = "max"
+)
+
+func NewYahooFinanceService(st store.Store, bus *Bus) *YahooFinanceService {
 	svc := &YahooFinanceService{
-		client:      &http.Client{Timeout: 15 * time.Second},
-		prices:      make(map[string]models.Price),
-		history:     make(map[string][]float64),
-		historyOHLC: make(map[string][]models.OHLCV),
-		intraday:    make(map[string]intradayBars),
-	}
-	svc.refresh()
-	svc.refreshHistory()
-	svc.refreshIntraday()
-	go svc.loop()
-	go svc.historyLoop()
-	go svc.intradayLoop()
+		client:       upstream.New("yahoo", nil, yahooPolicy),
+		prices:       make(map[string]models.Price),
+		history:      make(map[string][]float64),
+		historyOHLC:  make(map[string][]models.OHLCV),
+		intraday:     make(map[string]intradayBars),
+		curves:       make(map[string][]models.CurvePoint),
+		curveHistory: make(map[string][]models.CurveSnapshot),
+		store:        st,
+		bus:          bus,
+
+		fullHistoryAt: make(map[string]time.Time),
+	}
+	feeds.expect(FeedYahooQuotes)
+	feeds.expect(FeedYahooHistory)
+	feeds.expect(FeedYahooIntraday)
+	svc.restore()
 	return svc
 }
 
-func (s *YahooFinanceService) loop() {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-	for range ticker.C {
-		s.refresh()
-	}
+// Start begins polling Yahoo: quotes, daily and intraday history, and the
+// futures curves, each on its own loop. The first fetches happen on those
+// loops, so Start doesn't wait on Yahoo; until they land the caches serve
+// whatever restore loaded.
+func (s *YahooFinanceService) Start(ctx context.Context) {
+	s.run.start(ctx, s.loop, s.historyLoop, s.intradayLoop, s.curveLoop)
+}
+
+// Stop cancels the polling loops and any fetch in flight, and waits for
+// them to return.
+func (s *YahooFinanceService) Stop() {
+	s.run.stop()
+}
+
+func (s *YahooFinanceService) loop(ctx context.Context) {
+	s.refresh(ctx)
+	every(ctx, yahooQuoteEvery, s.refresh)
 }
 
-// historyLoop refreshes the 2-year daily-close history every 6 hours.
+// historyLoop fetches the 2-year daily history and the 60-day intraday
+// backfill at once, then refreshes both every yahooHistoryEvery (6 hours
+// by default).
 // Daily candles only roll over after market close so polling more often is
-// wasteful; this is purely to pick up the new daily bar each session.
-func (s *YahooFinanceService) historyLoop() {
-	ticker := time.NewTicker(6 * time.Hour)
-	defer ticker.Stop()
-	for range ticker.C {
-		s.refreshHistory()
+// wasteful; this is purely to pick up the new daily bar each session. The
+// full 60-day intraday backfill rides along to heal any gap left by a
+// Yahoo outage longer than the 5-minute poll's window, and the store is
+// tidied after both.
+func (s *YahooFinanceService) historyLoop(ctx context.Context) {
+	backfill := func(ctx context.Context) {
+		s.refreshHistory(ctx)
+		s.refreshIntraday(ctx, yahooIntradayBackfill)
+		s.tidyStore(time.Now())
 	}
+	backfill(ctx)
+	every(ctx, yahooHistoryEvery, backfill)
 }
 
-// intradayLoop refreshes the cached 5-minute intraday bars every 5 minutes.
+// intradayLoop refreshes the cached 5-minute intraday bars every
+// yahooIntradayEvery (5 minutes by default).
 // The hero chart falls back to this series whenever Pyth is paused (weekend
 // or holiday) so the homepage always has something meaningful to render.
 //
@@ -120,15 +196,15 @@ func (s *YahooFinanceService) historyLoop() {
 // near the session close show up promptly (so when markets reopen Monday
 // we don't strand on Friday's snapshot for an hour), but slow enough to be
 // trivial load on Yahoo's free endpoint.
-func (s *YahooFinanceService) intradayLoop() {
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
-	for range ticker.C {
-		s.refreshIntraday()
-	}
+func (s *YahooFinanceService) intradayLoop(ctx context.Context) {
+	every(ctx, yahooIntradayEvery, func(ctx context.Context) {
+		s.refreshIntraday(ctx, yahooIntradayPoll)
+	})
 }
 
-func (s *YahooFinanceService) refresh() {
+func (s *YahooFinanceService) refresh(ctx context.Context) {
+	cycle := feeds.cycle(FeedYahooQuotes, s.bus)
+	defer cycle.end()
 	var wg sync.WaitGroup
 	results := make(chan models.Price, len(yahooSymbols))
 
@@ -136,7 +212,10 @@ func (s *YahooFinanceService) refresh() {
 		wg.Add(1)
 		go func(ys yahooSymbol) {
 			defer wg.Done()
-			p, err := s.fetchQuote(ys)
+			done := timeUpstream("yahoo", "quote")
+			p, err := s.fetchQuote(ctx, ys)
+			done(err)
+			cycle.observe(err)
 			if err != nil {
 				log.Printf("yahoo: failed to fetch %s (%s): %v", ys.internal, ys.yahoo, err)
 				return
@@ -148,20 +227,27 @@ func (s *YahooFinanceService) refresh() {
 	wg.Wait()
 	close(results)
 
+	quotes := make([]models.Price, 0, len(yahooSymbols))
 	s.mu.Lock()
 	for p := range results {
 		s.prices[p.Symbol] = p
+		s.lastRefresh = time.Now()
+		quotes = append(quotes, p)
 	}
 	s.mu.Unlock()
+
+	if len(quotes) > 0 {
+		s.bus.Publish(QuoteUpdated{Source: s.Name(), Quotes: quotes})
+	}
 }
 
-func (s *YahooFinanceService) fetchQuote(sym yahooSymbol) (models.Price, error) {
+func (s *YahooFinanceService) fetchQuote(ctx context.Context, sym yahooSymbol) (models.Price, error) {
 	url := fmt.Sprintf(
-		"https://query1.finance.yahoo.com/v8/finance/chart/%s?range=5d&interval=1d&includePrePost=false",
-		sym.yahoo,
+		"%s%s?range=5d&interval=1d&includePrePost=false",
+		yahooChartURL, sym.yahoo,
 	)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return models.Price{}, err
 	}
@@ -247,7 +333,7 @@ func (s *YahooFinanceService) fetchQuote(sym yahooSymbol) (models.Price, error)
 		}
 	}
 
-	change, changePct := computeChange(price, priorDailyClose, meta.ChartPreviousClose)
+	change, changePct, suppressed := computeChangeGuarded(price, priorDailyClose, meta.ChartPreviousClose)
 
 	if dayHigh == 0 {
 		dayHigh = price
@@ -256,20 +342,30 @@ func (s *YahooFinanceService) fetchQuote(sym yahooSymbol) (models.Price, error)
 		dayLow = price
 	}
 
-	contract := parseContractMonth(meta.ShortName, sym.name)
+	// Brent's shortName has no month; the curve knows the live front month.
+	contract, ok := contractFromShortName(meta.ShortName)
+	if !ok {
+		if contract = s.frontContract(sym.internal); contract == "" {
+			contract = parseContractMonth(meta.ShortName, sym.name)
+		}
+	}
+	marketTime := time.Unix(meta.RegularMarketTime, 0).UTC()
+	prov := newProvenance([]string{"yahoo"}, time.Now(), marketTime)
+	prov.ChangeSuppressed = suppressed
 
 	return models.Price{
-		Symbol:    sym.internal,
-		Name:      sym.name,
-		Price:     round2(price),
-		Change:    round2(change),
-		ChangePct: round2(changePct),
-		High:      round2(dayHigh),
-		Low:       round2(dayLow),
-		Volume:    volume,
-		UpdatedAt: time.Unix(meta.RegularMarketTime, 0).UTC().Format(time.RFC3339),
-		Contract:  contract,
-		Source:    "yahoo",
+		Symbol:     sym.internal,
+		Name:       sym.name,
+		Price:      round2(price),
+		Change:     round2(change),
+		ChangePct:  round2(changePct),
+		High:       round2(dayHigh),
+		Low:        round2(dayLow),
+		Volume:     volume,
+		UpdatedAt:  marketTime.Format(time.RFC3339),
+		Contract:   contract,
+		Source:     "yahoo",
+		Provenance: prov,
 	}, nil
 }
 
@@ -301,12 +397,14 @@ func (s *YahooFinanceService) GetHistory(symbol string) []float64 {
 // symbol in parallel and updates the cache. Both the OHLCV history (used by
 // the main chart) and a closes-only projection (used by the prediction
 // models) are derived from the same network call.
-func (s *YahooFinanceService) refreshHistory() {
+func (s *YahooFinanceService) refreshHistory(ctx context.Context) {
 	type result struct {
 		symbol string
 		bars   []models.OHLCV
 		closes []float64
 	}
+	cycle := feeds.cycle(FeedYahooHistory, s.bus)
+	defer cycle.end()
 	var wg sync.WaitGroup
 	results := make(chan result, len(yahooSymbols))
 
@@ -314,7 +412,10 @@ func (s *YahooFinanceService) refreshHistory() {
 		wg.Add(1)
 		go func(ys yahooSymbol) {
 			defer wg.Done()
-			bars, err := s.fetchHistory(ys)
+			done := timeUpstream("yahoo", "history")
+			bars, err := s.fetchHistory(ctx, ys, yahooHistoryRange)
+			done(err)
+			cycle.observe(err)
 			if err != nil {
 				log.Printf("yahoo: failed to fetch history for %s (%s): %v", ys.internal, ys.yahoo, err)
 				return
@@ -331,24 +432,105 @@ func (s *YahooFinanceService) refreshHistory() {
 	close(results)
 
 	s.mu.Lock()
+	persisted := make(map[string][]models.OHLCV)
 	for r := range results {
 		s.history[r.symbol] = r.closes
-		s.historyOHLC[r.symbol] = r.bars
+		// Merge rather than replace so bars older than the 2y window
+		// (from the store or a full backfill) stay served.
+		s.historyOHLC[r.symbol] = mergeBars(s.historyOHLC[r.symbol], r.bars, 0)
+		persisted[r.symbol] = r.bars
 	}
 	s.mu.Unlock()
+
+	s.persistBars("1d", persisted)
+	s.publishHistory("1d", persisted)
+}
+
+// restore seeds the daily and intraday caches from the persistent store so
+// GetChartData and GetHeroChart have real bars to serve while the first
+// network refresh is still in flight (or when Yahoo is down at boot).
+func (s *YahooFinanceService) restore() {
+	if s.store == nil {
+		return
+	}
+	intradaySince := time.Now().Add(-yahooIntradayRange)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ys := range yahooSymbols {
+		daily, err := s.store.LoadBars(store.Series("yahoo", ys.internal, "1d"), time.Time{})
+		if err != nil {
+			log.Printf("yahoo: restore daily history for %s: %v", ys.internal, err)
+		}
+		if len(daily) >= 30 {
+			closes := make([]float64, len(daily))
+			for i, b := range daily {
+				closes[i] = b.Close
+			}
+			s.historyOHLC[ys.internal] = daily
+			s.history[ys.internal] = closes
+		}
+
+		bars, err := s.store.LoadBars(store.Series("yahoo", ys.internal, "5m"), intradaySince)
+		if err != nil {
+			log.Printf("yahoo: restore intraday for %s: %v", ys.internal, err)
+		}
+		if len(bars) > 0 {
+			s.intraday[ys.internal] = intradayBars{
+				bars:      bars,
+				fetchedAt: time.Unix(bars[len(bars)-1].Time, 0).UTC(),
+				interval:  interval5m,
+			}
+		}
+	}
+	s.restoreCurvesLocked()
+}
+
+// persistBars upserts freshly fetched bars for each symbol into the store.
+// Failures are logged and otherwise ignored: the cache still serves.
+func (s *YahooFinanceService) persistBars(interval string, bySymbol map[string][]models.OHLCV) {
+	if s.store == nil {
+		return
+	}
+	for sym, bars := range bySymbol {
+		if err := s.store.UpsertBars(store.Series("yahoo", sym, interval), bars); err != nil {
+			log.Printf("yahoo: persist %s bars for %s: %v", interval, sym, err)
+		}
+	}
 }
 
-// fetchHistory pulls 2y of daily OHLCV bars for a Yahoo symbol. We keep
+// tidyStore drops stored 5-minute bars older than yahooIntradayRange, which
+// restore wouldn't load anyway, and compacts both intervals: every
+// intraday poll appends another version of the bar still forming, and
+// every daily refresh one of today's.
+func (s *YahooFinanceService) tidyStore(now time.Time) {
+	if s.store == nil {
+		return
+	}
+	for _, ys := range yahooSymbols {
+		intraday := store.Series("yahoo", ys.internal, interval5m)
+		if err := s.store.Prune(intraday, now.Add(-yahooIntradayRange)); err != nil {
+			log.Printf("yahoo: prune %s: %v", intraday, err)
+		}
+		for _, series := range []string{intraday, store.Series("yahoo", ys.internal, "1d")} {
+			if err := s.store.Compact(series); err != nil {
+				log.Printf("yahoo: compact %s: %v", series, err)
+			}
+		}
+	}
+}
+
+// fetchHistory pulls daily OHLCV bars over rangeParam ("2y", "max") for a
+// Yahoo symbol. We keep
 // every bar that has a valid (positive, non-NaN) close; bars with null
 // individual O/H/L are repaired by falling back to the close so the chart
 // renders without gaps.
-func (s *YahooFinanceService) fetchHistory(sym yahooSymbol) ([]models.OHLCV, error) {
+func (s *YahooFinanceService) fetchHistory(ctx context.Context, sym yahooSymbol, rangeParam string) ([]models.OHLCV, error) {
 	url := fmt.Sprintf(
-		"https://query1.finance.yahoo.com/v8/finance/chart/%s?range=2y&interval=1d&includePrePost=false",
-		sym.yahoo,
+		"%s%s?range=%s&interval=1d&includePrePost=false",
+		yahooChartURL, sym.yahoo, rangeParam,
 	)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -366,7 +548,8 @@ func (s *YahooFinanceService) fetchHistory(sym yahooSymbol) ([]models.OHLCV, err
 		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
 	}
 
-	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	// 26 years of daily bars is ~2MB of JSON; leave room for range=max.
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
 	if err != nil {
 		return nil, fmt.Errorf("read body: %w", err)
 	}
@@ -456,53 +639,176 @@ func (s *YahooFinanceService) GetDailyHistory(symbol string, days int) []models.
 	return out
 }
 
-// intradayHotSymbols are the symbols we proactively keep an intraday series
-// cached for. WTI is the only one used by the homepage hero, but we cache
-// Brent too because the per-commodity detail pages can fall back to the
-// same machinery when their own live feed isn't available.
-var intradayHotSymbols = map[string]bool{
-	"WTI":   true,
-	"BRENT": true,
+// FetchFullHistory backfills symbol's daily cache with every bar Yahoo has
+// and persists it, so the deeper history survives restarts. Calls within
+// yahooHistoryEvery of the last one are no-ops, as are symbols Yahoo
+// doesn't track.
+func (s *YahooFinanceService) FetchFullHistory(ctx context.Context, symbol string) error {
+	var ys yahooSymbol
+	for _, y := range yahooSymbols {
+		if y.internal == symbol {
+			ys = y
+		}
+	}
+	if ys.yahoo == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	if t, ok := s.fullHistoryAt[symbol]; ok && time.Since(t) < yahooHistoryEvery {
+		s.mu.Unlock()
+		return nil
+	}
+	s.fullHistoryAt[symbol] = time.Now()
+	s.mu.Unlock()
+
+	done := timeUpstream("yahoo", "history_full")
+	bars, err := s.fetchHistory(ctx, ys, yahooFullHistoryRange)
+	done(err)
+	if err != nil {
+		s.mu.Lock()
+		delete(s.fullHistoryAt, symbol)
+		s.mu.Unlock()
+		return fmt.Errorf("yahoo: full history for %s: %w", symbol, err)
+	}
+
+	s.mu.Lock()
+	s.historyOHLC[symbol] = mergeBars(s.historyOHLC[symbol], bars, 0)
+	s.mu.Unlock()
+	// Most of a backfill is older than the newest stored bar, which
+	// persistBars' upsert would skip.
+	if s.store != nil {
+		if err := s.store.InsertBars(store.Series("yahoo", symbol, "1d"), bars); err != nil {
+			log.Printf("yahoo: persist full history for %s: %v", symbol, err)
+		}
+	}
+	s.bus.Publish(HistoryRefreshed{Source: s.Name(), Interval: "1d", Symbols: []string{symbol}})
+	return nil
+}
+
+// PriceSource implementation: Yahoo is the full-metadata source for
+// quotes, daily history and 5-minute intraday bars.
+
+func (s *YahooFinanceService) Name() string { return "yahoo" }
+
+func (s *YahooFinanceService) Capabilities() Capability {
+	return CapQuotes | CapHistory | CapIntraday
 }
 
-// refreshIntraday fans out a fetchIntraday call for each hot symbol in
-// parallel and replaces the cache atomically. Errors are logged but the
-// previous cached value is kept so a transient Yahoo failure doesn't blank
-// the chart.
-func (s *YahooFinanceService) refreshIntraday() {
+func (s *YahooFinanceService) Quotes() map[string]models.Price { return s.GetPrices() }
+
+func (s *YahooFinanceService) History(symbol string, days int) []models.OHLCV {
+	return s.GetDailyHistory(symbol, days)
+}
+
+func (s *YahooFinanceService) Intraday(symbol string, since time.Time) ([]models.OHLCV, string) {
+	return s.GetIntradaySince(symbol, since)
+}
+
+// Health is healthy while the 30s quote loop keeps landing prices; four
+// missed rounds in a row flips it.
+func (s *YahooFinanceService) Health() SourceHealth {
+	s.mu.RLock()
+	last := s.lastRefresh
+	s.mu.RUnlock()
+	h := SourceHealth{Name: s.Name(), LastUpdate: last}
+	h.Healthy = !last.IsZero() && time.Since(last) < 2*time.Minute
+	if !h.Healthy {
+		h.Detail = "no quotes fetched recently"
+	}
+	return h
+}
+
+// interval5m is the Yahoo intraday bar size we cache and persist.
+const interval5m = "5m"
+
+// refreshIntraday fans out a fetchIntraday call for every Yahoo symbol in
+// parallel and merges the bars into the cache, trimming anything older
+// than yahooIntradayRange. Errors are logged but the previous cached value
+// is kept so a transient Yahoo failure doesn't blank the chart.
+func (s *YahooFinanceService) refreshIntraday(ctx context.Context, rangeParam string) {
 	type result struct {
 		symbol string
-		bars   intradayBars
+		bars   []models.OHLCV
 	}
+	cycle := feeds.cycle(FeedYahooIntraday, s.bus)
+	defer cycle.end()
 	var wg sync.WaitGroup
-	results := make(chan result, len(intradayHotSymbols))
+	results := make(chan result, len(yahooSymbols))
 	for _, sym := range yahooSymbols {
-		if !intradayHotSymbols[sym.internal] {
-			continue
-		}
 		wg.Add(1)
 		go func(ys yahooSymbol) {
 			defer wg.Done()
-			bars, err := s.fetchIntraday(ys, "5m", "5d")
+			done := timeUpstream("yahoo", "intraday")
+			bars, err := s.fetchIntraday(ctx, ys, interval5m, rangeParam)
+			done(err)
+			cycle.observe(err)
 			if err != nil {
 				log.Printf("yahoo: intraday fetch failed for %s (%s): %v", ys.internal, ys.yahoo, err)
 				return
 			}
-			results <- result{symbol: ys.internal, bars: intradayBars{
-				bars:      bars,
-				fetchedAt: time.Now().UTC(),
-				interval:  "5m",
-			}}
+			results <- result{symbol: ys.internal, bars: bars}
 		}(sym)
 	}
 	wg.Wait()
 	close(results)
 
+	cutoff := time.Now().Add(-yahooIntradayRange).Unix()
+	now := time.Now().UTC()
 	s.mu.Lock()
+	persisted := make(map[string][]models.OHLCV)
 	for r := range results {
-		s.intraday[r.symbol] = r.bars
+		s.intraday[r.symbol] = intradayBars{
+			bars:      mergeBars(s.intraday[r.symbol].bars, r.bars, cutoff),
+			fetchedAt: now,
+			interval:  interval5m,
+		}
+		persisted[r.symbol] = r.bars
 	}
 	s.mu.Unlock()
+
+	s.persistBars(interval5m, persisted)
+	s.publishHistory(interval5m, persisted)
+}
+
+// publishHistory announces the symbols a history refresh brought bars for.
+func (s *YahooFinanceService) publishHistory(interval string, bySymbol map[string][]models.OHLCV) {
+	if len(bySymbol) == 0 {
+		return
+	}
+	symbols := make([]string, 0, len(bySymbol))
+	for sym := range bySymbol {
+		symbols = append(symbols, sym)
+	}
+	sort.Strings(symbols)
+	s.bus.Publish(HistoryRefreshed{Source: s.Name(), Interval: interval, Symbols: symbols})
+}
+
+// mergeBars unions two oldest-first bar series by timestamp, preferring
+// fresh on conflict (the latest bar is revised until it closes), and drops
+// bars older than cutoff.
+func mergeBars(cached, fresh []models.OHLCV, cutoff int64) []models.OHLCV {
+	out := make([]models.OHLCV, 0, len(cached)+len(fresh))
+	i, j := 0, 0
+	for i < len(cached) || j < len(fresh) {
+		var b models.OHLCV
+		switch {
+		case j >= len(fresh) || (i < len(cached) && cached[i].Time < fresh[j].Time):
+			b = cached[i]
+			i++
+		case i >= len(cached) || fresh[j].Time < cached[i].Time:
+			b = fresh[j]
+			j++
+		default: // same timestamp
+			b = fresh[j]
+			i++
+			j++
+		}
+		if b.Time >= cutoff {
+			out = append(out, b)
+		}
+	}
+	return out
 }
 
 // nyToday returns the current NY-local calendar date as YYYY-MM-DD.
@@ -528,20 +834,42 @@ func (s *YahooFinanceService) GetRolling24hIntraday(symbol string) (bars []model
 	if !ok || len(cached.bars) == 0 {
 		return nil, ""
 	}
-	cutoff := time.Now().UTC().Add(-24 * time.Hour).Unix()
-	// Bars are stored oldest-first, so binary-search the cutoff for cheap
-	// slicing instead of scanning the whole 5-day buffer on every poll.
-	idx := sort.Search(len(cached.bars), func(i int) bool {
-		return cached.bars[i].Time >= cutoff
-	})
-	if idx >= len(cached.bars) {
+	return barsSince(cached.bars, time.Now().UTC().Add(-24*time.Hour)), cached.interval
+}
+
+// GetIntradaySince returns every cached 5-minute bar at or after since,
+// oldest-first, along with the bar interval. Backs the intraday intervals
+// on /api/charts, which resample these bars.
+func (s *YahooFinanceService) GetIntradaySince(symbol string, since time.Time) (bars []models.OHLCV, interval string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cached, ok := s.intraday[symbol]
+	if !ok {
+		return nil, ""
+	}
+	out := barsSince(cached.bars, since)
+	if len(out) == 0 {
 		return nil, ""
 	}
-	out := make([]models.OHLCV, len(cached.bars)-idx)
-	copy(out, cached.bars[idx:])
 	return out, cached.interval
 }
 
+// barsSince copies the tail of an oldest-first series starting at since.
+// Bars are stored oldest-first, so binary-search the cutoff for cheap
+// slicing instead of scanning the whole 60-day buffer on every poll.
+func barsSince(bars []models.OHLCV, since time.Time) []models.OHLCV {
+	cutoff := since.Unix()
+	idx := sort.Search(len(bars), func(i int) bool {
+		return bars[i].Time >= cutoff
+	})
+	if idx >= len(bars) {
+		return nil
+	}
+	out := make([]models.OHLCV, len(bars)-idx)
+	copy(out, bars[idx:])
+	return out
+}
+
 // GetPriorSessionIntraday returns intraday bars for the most recent
 // COMPLETE exchange-local trading day STRICTLY BEFORE today, along with
 // that day's date and the bar interval. Used as the weekend / cold-start
@@ -560,9 +888,13 @@ func (s *YahooFinanceService) GetPriorSessionIntraday(symbol string) (bars []mod
 	const minBarsPerSession = 10
 	today := nyToday()
 
+	// Only the last week can hold the most recent complete session (even
+	// across a long holiday weekend), so skip the rest of the 60-day buffer.
+	recent := barsSince(cached.bars, time.Now().Add(-7*24*time.Hour))
+
 	// First pass — count bars per NY-local day for days strictly before today.
 	dayCounts := make(map[string]int)
-	for _, b := range cached.bars {
+	for _, b := range recent {
 		d := exchangeDay(b.Time)
 		if d < today {
 			dayCounts[d]++
@@ -580,7 +912,7 @@ func (s *YahooFinanceService) GetPriorSessionIntraday(symbol string) (bars []mod
 	}
 
 	out := make([]models.OHLCV, 0, dayCounts[pick])
-	for _, b := range cached.bars {
+	for _, b := range recent {
 		if exchangeDay(b.Time) == pick {
 			out = append(out, b)
 		}
@@ -596,13 +928,13 @@ func (s *YahooFinanceService) GetPriorSessionIntraday(symbol string) (bars []mod
 //
 // Bars whose close is null/NaN/<=0 are dropped; missing individual O/H/L
 // fields are repaired from the close so we never emit half-formed candles.
-func (s *YahooFinanceService) fetchIntraday(sym yahooSymbol, interval, rangeParam string) ([]models.OHLCV, error) {
+func (s *YahooFinanceService) fetchIntraday(ctx context.Context, sym yahooSymbol, interval, rangeParam string) ([]models.OHLCV, error) {
 	url := fmt.Sprintf(
-		"https://query1.finance.yahoo.com/v8/finance/chart/%s?range=%s&interval=%s&includePrePost=false",
-		sym.yahoo, rangeParam, interval,
+		"%s%s?range=%s&interval=%s&includePrePost=false",
+		yahooChartURL, sym.yahoo, rangeParam, interval,
 	)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -693,6 +1025,26 @@ var monthNames = []string{
 // parseContractMonth extracts a clean contract label like "May 2026" from
 // Yahoo Finance's shortName. Falls back to deriving from current date.
 func parseContractMonth(shortName, baseName string) string {
+	if label, ok := contractFromShortName(shortName); ok {
+		return label
+	}
+	// shortName doesn't have month info (e.g. Brent), derive from date
+	now := time.Now()
+	month := now.Month()
+	year := now.Year()
+	if now.Day() >= 20 {
+		month++
+		if month > 12 {
+			month = 1
+			year++
+		}
+	}
+	return fmt.Sprintf("%s %d Contract", month.String()[:3], year)
+}
+
+// contractFromShortName reads "Mon YY" out of a Yahoo shortName such as
+// "Crude Oil Dec 26".
+func contractFromShortName(shortName string) (string, bool) {
 	for _, m := range monthNames {
 		idx := -1
 		for i := 0; i <= len(shortName)-len(m); i++ {
@@ -708,21 +1060,10 @@ func parseContractMonth(shortName, baseName string) string {
 		// Expect " YY" after month name
 		if len(rest) >= 3 && rest[0] == ' ' && rest[1] >= '0' && rest[1] <= '9' && rest[2] >= '0' && rest[2] <= '9' {
 			yearStr := rest[1:3]
-			return fmt.Sprintf("%s 20%s Contract", m, yearStr)
-		}
-	}
-	// shortName doesn't have month info (e.g. Brent), derive from date
-	now := time.Now()
-	month := now.Month()
-	year := now.Year()
-	if now.Day() >= 20 {
-		month++
-		if month > 12 {
-			month = 1
-			year++
+			return fmt.Sprintf("%s 20%s Contract", m, yearStr), true
 		}
 	}
-	return fmt.Sprintf("%s %d Contract", month.String()[:3], year)
+	return "", false
 }
 
 func round2(v float64) float64 {
@@ -768,17 +1109,24 @@ const extremeChangePct = 10.0
 // As a defence-in-depth, we also suppress any final result whose magnitude
 // exceeds extremeChangePct.
 func computeChange(price, priorDailyClose, metaPrevClose float64) (float64, float64) {
+	change, pct, _ := computeChangeGuarded(price, priorDailyClose, metaPrevClose)
+	return change, pct
+}
+
+// computeChangeGuarded is computeChange that also reports whether the
+// extreme-move guard fired, so the quote's provenance can say so.
+func computeChangeGuarded(price, priorDailyClose, metaPrevClose float64) (change, pct float64, suppressed bool) {
 	prev := priorDailyClose
 	if prev <= 0 {
 		prev = metaPrevClose
 	}
 	if prev <= 0 || price <= 0 {
-		return 0, 0
+		return 0, 0, false
 	}
-	change := price - prev
-	pct := (change / prev) * 100
+	change = price - prev
+	pct = (change / prev) * 100
 	if math.Abs(pct) > extremeChangePct {
-		return 0, 0
+		return 0, 0, true
 	}
-	return change, pct
+	return change, pct, false
 }
@@ -1,8 +1,16 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"live-oil-prices-go/internal/store"
 	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestComputeChange_PrefersPriorDailyClose(t *testing.T) {
@@ -38,6 +46,9 @@ func TestComputeChange_SuppressesExtremeMoves(t *testing.T) {
 	if change != 0 || pct != 0 {
 		t.Errorf("expected extreme move to be suppressed, got change=%v pct=%v", change, pct)
 	}
+	if _, _, suppressed := computeChangeGuarded(83.30, 100, 0); !suppressed {
+		t.Error("expected the guarded variant to report the suppression")
+	}
 }
 
 func TestComputeChange_HandlesZeroAndNegativeInputs(t *testing.T) {
@@ -61,3 +72,74 @@ func TestComputeChange_KeepsLargeButPlausibleMoves(t *testing.T) {
 		t.Errorf("expected 8%% move to pass the guard, got change=%v pct=%v", change, pct)
 	}
 }
+
+func TestMergeBars_UnionsAndPrefersFresh(t *testing.T) {
+	cached := []models.OHLCV{{Time: 100, Close: 1}, {Time: 200, Close: 2}, {Time: 300, Close: 3}}
+	fresh := []models.OHLCV{{Time: 300, Close: 3.5}, {Time: 400, Close: 4}}
+
+	got := mergeBars(cached, fresh, 200)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 bars after trimming, got %+v", got)
+	}
+	if got[0].Time != 200 || got[1].Close != 3.5 || got[2].Time != 400 {
+		t.Fatalf("unexpected merge result: %+v", got)
+	}
+}
+
+// The range=max backfill reaches further back than anything the 2y
+// refresh stored, and has to survive a restart.
+func TestFetchFullHistorySurvivesRestart(t *testing.T) {
+	start := time.Date(1990, 1, 2, 0, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ts, closes []string
+		for i := 0; i < 40; i++ {
+			ts = append(ts, fmt.Sprint(start.AddDate(0, 0, i).Unix()))
+			closes = append(closes, fmt.Sprint(20+i))
+		}
+		ts = append(ts, fmt.Sprint(time.Now().Add(-24*time.Hour).Unix()))
+		closes = append(closes, "80")
+		c := strings.Join(closes, ",")
+		fmt.Fprintf(w, `{"chart":{"result":[{"timestamp":[%s],"indicators":{"quote":[{"open":[%s],"high":[%s],"low":[%s],"close":[%s],"volume":[]}]}}]}}`,
+			strings.Join(ts, ","), c, c, c, c)
+	}))
+	defer srv.Close()
+	prevURL := yahooChartURL
+	yahooChartURL = srv.URL + "/"
+	defer func() { yahooChartURL = prevURL }()
+
+	st, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The regular refresh has already stored yesterday's bar.
+	st.UpsertBars(store.Series("yahoo", "WTI", "1d"), []models.OHLCV{{Time: time.Now().Add(-24 * time.Hour).Unix(), Close: 80}})
+
+	if err := NewYahooFinanceService(st, nil).FetchFullHistory(context.Background(), "WTI"); err != nil {
+		t.Fatal(err)
+	}
+	restarted := NewYahooFinanceService(st, nil)
+	bars := restarted.GetDailyHistory("WTI", 0)
+	if len(bars) != 41 || bars[0].Time != start.Unix() || bars[0].Close != 20 {
+		t.Fatalf("expected the backfill back after a restart, got %d bars starting %+v", len(bars), bars[0])
+	}
+}
+
+func TestTidyStoreDropsStaleIntraday(t *testing.T) {
+	st, err := store.OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	series := store.Series("yahoo", "WTI", interval5m)
+	old := now.Add(-yahooIntradayRange - 62*24*time.Hour).Unix()
+	recent := now.Add(-time.Hour).Unix()
+	st.UpsertBars(series, []models.OHLCV{{Time: old, Close: 70}})
+	st.UpsertBars(series, []models.OHLCV{{Time: recent, Close: 80}})
+	st.UpsertBars(series, []models.OHLCV{{Time: recent, Close: 81}})
+
+	NewYahooFinanceService(st, nil).tidyStore(now)
+	bars, _ := st.LoadBars(series, time.Time{})
+	if len(bars) != 1 || bars[0].Close != 81 {
+		t.Fatalf("expected only the latest recent bar, got %+v", bars)
+	}
+}
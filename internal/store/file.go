@@ -0,0 +1,413 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"live-oil-prices-go/internal/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore is the default embedded Store: a directory tree of append-only
+// NDJSON segment files, one directory per series.
+//
+//	<root>/pyth/WTI/tick/2026-10-17.ndjson   ticks, one segment per UTC day
+//	<root>/yahoo/WTI/5m/2026-10.ndjson       bars, one segment per UTC month
+//
+// Every write is an O_APPEND of whole lines. A crash mid-write can leave a
+// partial final line; the next append terminates it first (AppendLines),
+// so it stays a line of its own that the loader skips rather than
+// swallowing the record written after it. Upserts append a
+// new version of the bar and the loader keeps the last one per timestamp,
+// which keeps writes cheap without ever rewriting a file in place; Compact
+// later replaces a segment full of old versions with a fresh copy.
+type FileStore struct {
+	root string
+
+	mu   sync.Mutex
+	last map[string]models.OHLCV // newest bar written/loaded per series
+}
+
+const (
+	tickSegmentLayout = "2006-01-02"
+	barSegmentLayout  = "2006-01"
+	segmentExt        = ".ndjson"
+)
+
+// OpenFileStore creates root if needed and returns a store rooted there.
+func OpenFileStore(root string) (*FileStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+	return &FileStore{root: root, last: make(map[string]models.OHLCV)}, nil
+}
+
+// Root returns the directory the store writes to.
+func (s *FileStore) Root() string { return s.root }
+
+func (s *FileStore) AppendTicks(series string, ticks []Tick) error {
+	if len(ticks) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bySeg := make(map[string][]any)
+	var order []string
+	for _, t := range ticks {
+		seg := time.UnixMilli(t.Time).UTC().Format(tickSegmentLayout)
+		if _, ok := bySeg[seg]; !ok {
+			order = append(order, seg)
+		}
+		bySeg[seg] = append(bySeg[seg], t)
+	}
+	for _, seg := range order {
+		if err := s.appendLines(series, seg, bySeg[seg]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) LoadTicks(series string, since time.Time) ([]Tick, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	segs, err := s.segments(series, tickSegmentLayout, since)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := since.UnixMilli()
+	var out []Tick
+	for _, path := range segs {
+		err := readLines(path, func(line []byte) {
+			var t Tick
+			if json.Unmarshal(line, &t) == nil && t.Time >= cutoff {
+				out = append(out, t)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time < out[j].Time })
+	return out, nil
+}
+
+// UpsertBars persists bars (oldest-first) that are new or changed since
+// the last write for this series. Bars older than the newest one already
+// stored are skipped: upstream revisions to settled history are rare and
+// not worth an unbounded rewrite on every refresh.
+func (s *FileStore) UpsertBars(series string, bars []models.OHLCV) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last, hasLast := s.last[series]
+	bySeg := make(map[string][]any)
+	var order []string
+	for _, b := range bars {
+		if hasLast && (b.Time < last.Time || b == last) {
+			continue
+		}
+		seg := time.Unix(b.Time, 0).UTC().Format(barSegmentLayout)
+		if _, ok := bySeg[seg]; !ok {
+			order = append(order, seg)
+		}
+		bySeg[seg] = append(bySeg[seg], b)
+		last, hasLast = b, true
+	}
+	for _, seg := range order {
+		if err := s.appendLines(series, seg, bySeg[seg]); err != nil {
+			return err
+		}
+	}
+	if hasLast {
+		s.last[series] = last
+	}
+	return nil
+}
+
+// InsertBars merges bars (oldest-first) into the series whatever their
+// age. It reads each segment the bars fall in and appends only the bars
+// that are missing or changed there, so repeating a backfill costs reads
+// rather than disk.
+func (s *FileStore) InsertBars(series string, bars []models.OHLCV) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bySeg := make(map[string][]models.OHLCV)
+	var order []string
+	for _, b := range bars {
+		seg := time.Unix(b.Time, 0).UTC().Format(barSegmentLayout)
+		if _, ok := bySeg[seg]; !ok {
+			order = append(order, seg)
+		}
+		bySeg[seg] = append(bySeg[seg], b)
+	}
+	last, hasLast := s.last[series]
+	for _, seg := range order {
+		stored := make(map[int64]models.OHLCV)
+		err := readLines(filepath.Join(s.dir(series), seg+segmentExt), func(line []byte) {
+			var b models.OHLCV
+			if json.Unmarshal(line, &b) == nil {
+				stored[b.Time] = b
+			}
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		var fresh []any
+		for _, b := range bySeg[seg] {
+			if old, ok := stored[b.Time]; !ok || old != b {
+				fresh = append(fresh, b)
+			}
+			if !hasLast || b.Time >= last.Time {
+				last, hasLast = b, true
+			}
+		}
+		if len(fresh) == 0 {
+			continue
+		}
+		if err := s.appendLines(series, seg, fresh); err != nil {
+			return err
+		}
+	}
+	if hasLast {
+		s.last[series] = last
+	}
+	return nil
+}
+
+func (s *FileStore) LoadBars(series string, since time.Time) ([]models.OHLCV, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	segs, err := s.segments(series, barSegmentLayout, since)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := since.Unix()
+	byTime := make(map[int64]models.OHLCV)
+	for _, path := range segs {
+		err := readLines(path, func(line []byte) {
+			var b models.OHLCV
+			if json.Unmarshal(line, &b) == nil && b.Time >= cutoff {
+				byTime[b.Time] = b
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	out := make([]models.OHLCV, 0, len(byTime))
+	for _, b := range byTime {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time < out[j].Time })
+	if n := len(out); n > 0 && out[n-1].Time >= s.last[series].Time {
+		s.last[series] = out[n-1]
+	}
+	return out, nil
+}
+
+func (s *FileStore) Prune(series string, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir(series))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), segmentExt)
+		end, ok := segmentEnd(name)
+		if !ok || !end.Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dir(series), e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compact rewrites each bar segment of series that holds more than one
+// line for some timestamp (or an unreadable line), keeping the last
+// version of every bar. The copy is written beside the segment and
+// renamed over it, so a crash leaves one or the other whole.
+func (s *FileStore) Compact(series string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	segs, err := s.segments(series, barSegmentLayout, time.Time{})
+	if err != nil {
+		return err
+	}
+	for _, path := range segs {
+		byTime := make(map[int64]models.OHLCV)
+		lines := 0
+		err := readLines(path, func(line []byte) {
+			lines++
+			var b models.OHLCV
+			if json.Unmarshal(line, &b) == nil {
+				byTime[b.Time] = b
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if lines == len(byTime) {
+			continue
+		}
+		bars := make([]models.OHLCV, 0, len(byTime))
+		for _, b := range byTime {
+			bars = append(bars, b)
+		}
+		sort.Slice(bars, func(i, j int) bool { return bars[i].Time < bars[j].Time })
+		docs := make([]any, len(bars))
+		for i, b := range bars {
+			docs[i] = b
+		}
+		tmp := path + ".tmp"
+		if err := writeLines(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, docs); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return nil
+}
+
+// Close is a no-op: FileStore holds no open file handles between calls.
+func (s *FileStore) Close() error { return nil }
+
+func (s *FileStore) dir(series string) string {
+	return filepath.Join(s.root, filepath.FromSlash(series))
+}
+
+// appendLines writes one JSON document per line to a segment file.
+// Caller must hold s.mu.
+func (s *FileStore) appendLines(series, segment string, docs []any) error {
+	dir := s.dir(series)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return AppendLines(filepath.Join(dir, segment+segmentExt), docs)
+}
+
+// AppendLines appends one JSON document per line to path, creating it if
+// needed. If a crash left the file ending mid-line, a newline is written
+// first so the partial line doesn't run into the first new document.
+func AppendLines(path string, docs []any) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if torn, err := endsMidLine(f); err != nil {
+		f.Close()
+		return err
+	} else if torn {
+		w.WriteByte('\n')
+	}
+	return encodeLines(f, w, docs)
+}
+
+// endsMidLine reports whether f is non-empty and its last byte isn't a
+// newline.
+func endsMidLine(f *os.File) (bool, error) {
+	fi, err := f.Stat()
+	if err != nil || fi.Size() == 0 {
+		return false, err
+	}
+	var last [1]byte
+	if _, err := f.ReadAt(last[:], fi.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
+
+// writeLines opens path with flag and writes one JSON document per line.
+func writeLines(path string, flag int, docs []any) error {
+	f, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return err
+	}
+	return encodeLines(f, bufio.NewWriter(f), docs)
+}
+
+// encodeLines writes docs to w, one per line, then flushes w and closes f.
+func encodeLines(f *os.File, w *bufio.Writer, docs []any) error {
+	enc := json.NewEncoder(w)
+	for _, d := range docs {
+		if err := enc.Encode(d); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// segments lists the segment files of a series that may contain data at or
+// after since, oldest-first. Caller must hold s.mu.
+func (s *FileStore) segments(series, layout string, since time.Time) ([]string, error) {
+	entries, err := os.ReadDir(s.dir(series))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		stem := strings.TrimSuffix(name, segmentExt)
+		if _, err := time.Parse(layout, stem); err != nil {
+			continue
+		}
+		if end, ok := segmentEnd(stem); ok && !end.After(since) {
+			continue
+		}
+		out = append(out, filepath.Join(s.dir(series), name))
+	}
+	sort.Strings(out) // ISO dates sort chronologically
+	return out, nil
+}
+
+// segmentEnd returns the exclusive end of a day or month segment.
+func segmentEnd(stem string) (time.Time, bool) {
+	if t, err := time.Parse(tickSegmentLayout, stem); err == nil {
+		return t.AddDate(0, 0, 1), true
+	}
+	if t, err := time.Parse(barSegmentLayout, stem); err == nil {
+		return t.AddDate(0, 1, 0), true
+	}
+	return time.Time{}, false
+}
+
+func readLines(path string, fn func([]byte)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		if line := sc.Bytes(); len(line) > 0 {
+			fn(line)
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,219 @@
+package store
+
+import (
+	"live-oil-prices-go/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSeriesSanitisesParts(t *testing.T) {
+	if got := Series("yahoo", "CL=F", "5m"); got != "yahoo/CL_F/5m" {
+		t.Fatalf("unexpected series key %q", got)
+	}
+	if got := Series("pyth", "../../etc", "1m"); strings.Contains(got, "..") || strings.Count(got, "/") != 2 {
+		t.Fatalf("expected path traversal to be neutralised, got %q", got)
+	}
+}
+
+func TestFileStoreTicksRoundTrip(t *testing.T) {
+	st, err := OpenFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 4, 18, 23, 59, 58, 0, time.UTC)
+	ticks := []Tick{
+		{Time: base.UnixMilli(), Price: 83.10},
+		{Time: base.Add(4 * time.Second).UnixMilli(), Price: 83.20}, // next UTC day → second segment
+	}
+	if err := st.AppendTicks("pyth/WTI/tick", ticks); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := st.LoadTicks("pyth/WTI/tick", base.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Price != 83.10 || got[1].Price != 83.20 {
+		t.Fatalf("unexpected ticks: %+v", got)
+	}
+
+	later, _ := st.LoadTicks("pyth/WTI/tick", base.Add(time.Second))
+	if len(later) != 1 {
+		t.Fatalf("expected since filter to drop the first tick, got %+v", later)
+	}
+}
+
+// Overlapping upserts (every Yahoo refresh re-sends the last few days) must
+// not duplicate bars, and a revised in-progress bar must win on reload.
+func TestFileStoreUpsertBarsLastWriteWins(t *testing.T) {
+	dir := t.TempDir()
+	st, _ := OpenFileStore(dir)
+	series := Series("yahoo", "WTI", "5m")
+	t0 := time.Date(2026, 4, 17, 14, 0, 0, 0, time.UTC).Unix()
+
+	first := []models.OHLCV{
+		{Time: t0, Open: 80, High: 81, Low: 79, Close: 80.5},
+		{Time: t0 + 300, Open: 80.5, High: 80.9, Low: 80.1, Close: 80.2},
+	}
+	second := []models.OHLCV{
+		first[0],
+		{Time: t0 + 300, Open: 80.5, High: 81.2, Low: 80.1, Close: 81.1}, // revised
+		{Time: t0 + 600, Open: 81.1, High: 81.3, Low: 81.0, Close: 81.2},
+	}
+	if err := st.UpsertBars(series, first); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpsertBars(series, second); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen to prove it's the on-disk state being read.
+	st2, _ := OpenFileStore(dir)
+	bars, err := st2.LoadBars(series, time.Unix(t0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 3 {
+		t.Fatalf("expected 3 distinct bars, got %d: %+v", len(bars), bars)
+	}
+	if bars[1].Close != 81.1 {
+		t.Fatalf("expected revised bar to win, got %+v", bars[1])
+	}
+}
+
+// A backfill older than the newest stored bar is what UpsertBars skips;
+// InsertBars must write it, and only once.
+func TestFileStoreInsertBarsBackfillsHistory(t *testing.T) {
+	dir := t.TempDir()
+	st, _ := OpenFileStore(dir)
+	series := Series("yahoo", "WTI", "1d")
+	day := func(y int, m time.Month, d int) int64 { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() }
+	st.UpsertBars(series, []models.OHLCV{{Time: day(2026, 4, 1), Close: 80}})
+
+	backfill := []models.OHLCV{{Time: day(1990, 1, 2), Close: 22}, {Time: day(2008, 7, 3), Close: 145}, {Time: day(2026, 4, 1), Close: 80}}
+	if err := st.UpsertBars(series, backfill); err != nil {
+		t.Fatal(err)
+	}
+	if bars, _ := st.LoadBars(series, time.Time{}); len(bars) != 1 {
+		t.Fatalf("expected the upsert to skip old bars, got %+v", bars)
+	}
+	for i := 0; i < 2; i++ {
+		if err := st.InsertBars(series, backfill); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st2, _ := OpenFileStore(dir)
+	bars, _ := st2.LoadBars(series, time.Time{})
+	if len(bars) != 3 || bars[0].Close != 22 || bars[1].Close != 145 {
+		t.Fatalf("expected the backfill on reload, got %+v", bars)
+	}
+	raw, _ := os.ReadFile(filepath.Join(dir, "yahoo", "WTI", "1d", "2026-04.ndjson"))
+	if n := strings.Count(string(raw), "\n"); n != 1 {
+		t.Fatalf("expected unchanged bars not to be rewritten, got %d lines", n)
+	}
+}
+
+func TestFileStoreSkipsTruncatedLines(t *testing.T) {
+	dir := t.TempDir()
+	st, _ := OpenFileStore(dir)
+	series := Series("yahoo", "WTI", "1d")
+	ts := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC).Unix()
+	if err := st.UpsertBars(series, []models.OHLCV{{Time: ts, Close: 80}}); err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a crash mid-write.
+	f, _ := os.OpenFile(filepath.Join(dir, "yahoo", "WTI", "1d", "2026-04.ndjson"), os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`{"time":17`)
+	f.Close()
+
+	bars, err := st.LoadBars(series, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 1 || bars[0].Close != 80 {
+		t.Fatalf("expected the intact bar only, got %+v", bars)
+	}
+}
+
+func TestFileStoreAppendAfterTruncatedLine(t *testing.T) {
+	dir := t.TempDir()
+	st, _ := OpenFileStore(dir)
+	series := Series("yahoo", "WTI", "1d")
+	day := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	if err := st.UpsertBars(series, []models.OHLCV{{Time: day.Unix(), Close: 80}}); err != nil {
+		t.Fatal(err)
+	}
+	f, _ := os.OpenFile(filepath.Join(dir, "yahoo", "WTI", "1d", "2026-04.ndjson"), os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`{"time":17`)
+	f.Close()
+
+	// The next write must not be glued onto the partial line.
+	next := day.AddDate(0, 0, 1).Unix()
+	if err := st.UpsertBars(series, []models.OHLCV{{Time: next, Close: 81}}); err != nil {
+		t.Fatal(err)
+	}
+	reopened, _ := OpenFileStore(dir)
+	bars, err := reopened.LoadBars(series, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bars) != 2 || bars[1].Time != next || bars[1].Close != 81 {
+		t.Fatalf("expected both intact bars after the append, got %+v", bars)
+	}
+}
+
+func TestFileStorePruneDropsOldSegments(t *testing.T) {
+	st, _ := OpenFileStore(t.TempDir())
+	series := "pyth/WTI/tick"
+	old := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	recent := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	st.AppendTicks(series, []Tick{{Time: old.UnixMilli(), Price: 1}, {Time: recent.UnixMilli(), Price: 2}})
+
+	if err := st.Prune(series, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	ticks, _ := st.LoadTicks(series, time.Time{})
+	if len(ticks) != 1 || ticks[0].Price != 2 {
+		t.Fatalf("expected only the recent tick to survive, got %+v", ticks)
+	}
+}
+
+func TestFileStoreCompactKeepsLastVersion(t *testing.T) {
+	root := t.TempDir()
+	st, _ := OpenFileStore(root)
+	series := "yahoo/WTI/5m"
+	t0 := time.Date(2026, 3, 2, 14, 0, 0, 0, time.UTC).Unix()
+	done := models.OHLCV{Time: t0, Open: 80, High: 81, Low: 79, Close: 80.5}
+	// The bar still forming is re-polled three times.
+	for _, c := range []float64{80.6, 80.7, 80.8} {
+		if err := st.UpsertBars(series, []models.OHLCV{done, {Time: t0 + 300, Open: 80.5, High: c, Low: 80.5, Close: c}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(root, "yahoo", "WTI", "5m", "2026-03.ndjson")
+	countLines := func() int {
+		raw, _ := os.ReadFile(path)
+		return strings.Count(string(raw), "\n")
+	}
+	if n := countLines(); n != 4 {
+		t.Fatalf("expected 4 lines before compaction, got %d", n)
+	}
+
+	if err := st.Compact(series); err != nil {
+		t.Fatal(err)
+	}
+	if n := countLines(); n != 2 {
+		t.Fatalf("expected 2 lines after compaction, got %d", n)
+	}
+	bars, _ := st.LoadBars(series, time.Time{})
+	if len(bars) != 2 || bars[0] != done || bars[1].Close != 80.8 {
+		t.Fatalf("unexpected bars after compaction: %+v", bars)
+	}
+	if leftovers, _ := filepath.Glob(path + ".tmp"); len(leftovers) != 0 {
+		t.Fatalf("temporary file left behind: %v", leftovers)
+	}
+}
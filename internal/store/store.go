@@ -0,0 +1,63 @@
+// Package store persists market data (Pyth ticks, intraday candles and
+// daily OHLCV history) across restarts so a deploy doesn't wipe the live
+// session and cold starts can serve charts before the upstreams answer.
+//
+// Data is addressed by series key — "<provider>/<symbol>/<interval>", e.g.
+// "pyth/WTI/tick", "pyth/WTI/1m", "yahoo/WTI/5m", "yahoo/WTI/1d" — so any
+// number of providers can share one store without coordinating schemas.
+package store
+
+import (
+	"live-oil-prices-go/internal/models"
+	"strings"
+	"time"
+)
+
+// Tick is a single raw price print, e.g. one Pyth Hermes publish.
+type Tick struct {
+	Time       int64   `json:"t"` // unix milliseconds
+	Price      float64 `json:"p"`
+	Confidence float64 `json:"c,omitempty"`
+}
+
+// Store is the pluggable persistence layer used by the feed services.
+// Implementations must be safe for concurrent use.
+//
+// Bars are keyed by their Time: UpsertBars may be called repeatedly with
+// overlapping windows (every Yahoo refresh returns the last few days) and
+// LoadBars returns one bar per timestamp, last write wins, oldest-first.
+type Store interface {
+	AppendTicks(series string, ticks []Tick) error
+	LoadTicks(series string, since time.Time) ([]Tick, error)
+	UpsertBars(series string, bars []models.OHLCV) error
+	// InsertBars merges bars of any age, for backfills older than the
+	// newest stored bar that UpsertBars would skip.
+	InsertBars(series string, bars []models.OHLCV) error
+	LoadBars(series string, since time.Time) ([]models.OHLCV, error)
+	// Prune drops whole segments that end before the cutoff. Retention is
+	// segment-granular, so a little data older than the cutoff may survive.
+	Prune(series string, before time.Time) error
+	// Compact drops the superseded versions of a series' bars that
+	// repeated UpsertBars calls leave behind.
+	Compact(series string) error
+	Close() error
+}
+
+// Series builds a series key from its parts. Symbols and intervals are
+// sanitised so a key can never escape the store's root directory.
+func Series(provider, symbol, interval string) string {
+	return strings.Join([]string{clean(provider), clean(symbol), clean(interval)}, "/")
+}
+
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r == '=' || r == '.':
+			return '_'
+		default:
+			return -1
+		}
+	}, s)
+}
@@ -0,0 +1,247 @@
+package upstream
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// transport is what a Client built with a nil *http.Client sends through.
+var transport http.RoundTripper = http.DefaultTransport
+
+// SetTransport makes every Client built afterwards with a nil
+// *http.Client send through rt: a Recorder to capture a session, a
+// Replayer to serve one back. Call it at startup, before the feeds are
+// constructed.
+func SetTransport(rt http.RoundTripper) {
+	transport = rt
+}
+
+// ErrNotRecorded is returned by a Replayer for a request that has no
+// fixture.
+var ErrNotRecorded = errors.New("no recorded response")
+
+// Exchange is one recorded request and its outcome, stored one per file
+// as <seq>.json. Offset is when the request was sent, relative to the
+// start of the recording; Latency is how long the answer took. Bodies are
+// stored as text, which every feed's JSON and RSS is.
+type Exchange struct {
+	Method    string      `json:"method"`
+	URL       string      `json:"url"`
+	OffsetMS  int64       `json:"offsetMs"`
+	LatencyMS int64       `json:"latencyMs"`
+	Status    int         `json:"status,omitempty"`
+	Header    http.Header `json:"header,omitempty"`
+	Body      string      `json:"body,omitempty"`
+	// Error is the transport error, when the request got no response.
+	Error string `json:"error,omitempty"`
+}
+
+// unkeyedParams are query parameters left out when matching a request to
+// a fixture. api_key is a secret (and is redacted in the file); start and
+// end are the date window of the EIA query, derived from today, which
+// would otherwise stop a recording matching on any later day.
+var unkeyedParams = []string{"api_key", "start", "end"}
+
+// secretParams are redacted from recorded URLs.
+var secretParams = []string{"api_key"}
+
+// fixtureKey identifies the requests a fixture answers.
+func fixtureKey(method string, u *url.URL) string {
+	q := u.Query()
+	for _, p := range unkeyedParams {
+		q.Del(p)
+	}
+	k := *u
+	k.RawQuery = q.Encode()
+	return method + " " + k.String()
+}
+
+// Recorder is an http.RoundTripper that passes requests to next and
+// writes every exchange to a fixture file in its directory.
+type Recorder struct {
+	dir   string
+	next  http.RoundTripper
+	start time.Time
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewRecorder records into dir, creating it if needed. It refuses a
+// directory that already holds fixtures: two sessions interleaved in one
+// directory wouldn't replay as either. next defaults to
+// http.DefaultTransport.
+func NewRecorder(dir string, next http.RoundTripper) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("record: %w", err)
+	}
+	if existing, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(existing) > 0 {
+		return nil, fmt.Errorf("record: %s already holds %d fixtures", dir, len(existing))
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, next: next, start: time.Now()}, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	sent := time.Now()
+	resp, err := r.next.RoundTrip(req)
+	if err != nil && req.Context().Err() != nil {
+		// Shutting down, not an upstream failure: nothing to replay.
+		return resp, err
+	}
+
+	ex := Exchange{
+		Method:   req.Method,
+		URL:      redact(req.URL),
+		OffsetMS: sent.Sub(r.start).Milliseconds(),
+	}
+	if err != nil {
+		ex.Error = err.Error()
+	} else {
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return nil, readErr
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		ex.Status = resp.StatusCode
+		ex.Header = resp.Header
+		ex.Body = string(body)
+	}
+	ex.LatencyMS = time.Since(sent).Milliseconds()
+	r.write(ex)
+	return resp, err
+}
+
+// write stores ex as the next fixture. A failed write is logged rather
+// than failing the live request it describes.
+func (r *Recorder) write(ex Exchange) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false) // keep URLs and RSS bodies readable
+	enc.SetIndent("", "  ")
+	err := enc.Encode(ex)
+	if err == nil {
+		r.mu.Lock()
+		r.seq++
+		name := filepath.Join(r.dir, fmt.Sprintf("%06d.json", r.seq))
+		r.mu.Unlock()
+		err = os.WriteFile(name, b.Bytes(), 0o644)
+	}
+	if err != nil {
+		log.Printf("record %s %s: %v", ex.Method, ex.URL, err)
+	}
+}
+
+func redact(u *url.URL) string {
+	q := u.Query()
+	for _, p := range secretParams {
+		if q.Has(p) {
+			q.Set(p, "REDACTED")
+		}
+	}
+	c := *u
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// Replayer is an http.RoundTripper that answers from a Recorder's
+// fixtures and never touches the network. The n-th request for a URL gets
+// the n-th response recorded for it, no earlier than it was recorded
+// (relative to NewReplayer) and after the recorded latency, so a session
+// replays with its original timing. Once a URL's fixtures run out the
+// last one keeps being served. Replay with the poll intervals the session
+// was recorded with, or the requests won't line up with their answers.
+type Replayer struct {
+	start time.Time
+
+	mu       sync.Mutex
+	fixtures map[string][]Exchange
+	served   map[string]int
+}
+
+// NewReplayer loads every fixture in dir.
+func NewReplayer(dir string) (*Replayer, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("replay: no fixtures in %s", dir)
+	}
+	sort.Strings(names)
+
+	fixtures := make(map[string][]Exchange)
+	for _, name := range names {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("replay: %w", err)
+		}
+		var ex Exchange
+		if err := json.Unmarshal(b, &ex); err != nil {
+			return nil, fmt.Errorf("replay %s: %w", name, err)
+		}
+		u, err := url.Parse(ex.URL)
+		if err != nil {
+			return nil, fmt.Errorf("replay %s: %w", name, err)
+		}
+		k := fixtureKey(ex.Method, u)
+		fixtures[k] = append(fixtures[k], ex)
+	}
+	return &Replayer{start: time.Now(), fixtures: fixtures, served: make(map[string]int)}, nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	k := fixtureKey(req.Method, req.URL)
+	r.mu.Lock()
+	recorded := r.fixtures[k]
+	if len(recorded) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrNotRecorded, k)
+	}
+	n := min(r.served[k], len(recorded)-1)
+	r.served[k]++
+	r.mu.Unlock()
+	ex := recorded[n]
+
+	wait := time.Until(r.start.Add(time.Duration(ex.OffsetMS) * time.Millisecond))
+	if !sleep(req.Context(), max(wait, 0)+time.Duration(ex.LatencyMS)*time.Millisecond) {
+		return nil, req.Context().Err()
+	}
+	if ex.Error != "" {
+		return nil, errors.New(ex.Error)
+	}
+	header := ex.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(ex.Status) + " " + http.StatusText(ex.Status),
+		StatusCode:    ex.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(ex.Body)),
+		ContentLength: int64(len(ex.Body)),
+		Request:       req,
+	}, nil
+}
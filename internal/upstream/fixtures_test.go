@@ -0,0 +1,75 @@
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// A recorded session replays the same answers in the same order, after
+// the recorded delay, without the server and without the API key.
+func TestRecordThenReplay(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"n":%d}`, n)
+	}))
+	dir := t.TempDir()
+
+	rec, err := NewRecorder(dir, srv.Client().Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New("test", &http.Client{Transport: rec}, Policy{})
+	url := srv.URL + "/v2/steo/data/?api_key=secret&start=2024-01&series=WTI"
+	for i := 0; i < 2; i++ {
+		get(t, c, url)
+		time.Sleep(30 * time.Millisecond)
+	}
+	srv.Close()
+
+	fixture, _ := os.ReadFile(filepath.Join(dir, "000001.json"))
+	if strings.Contains(string(fixture), "secret") || !strings.Contains(string(fixture), "REDACTED") {
+		t.Fatalf("api key not redacted: %s", fixture)
+	}
+	if _, err := NewRecorder(dir, nil); err == nil {
+		t.Fatal("recording over an existing session should fail")
+	}
+
+	rp, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = New("test", &http.Client{Transport: rp}, Policy{})
+	// A different key and date window still match.
+	url = srv.URL + "/v2/steo/data/?api_key=other&start=2025-06&series=WTI"
+	start := time.Now()
+	var bodies []string
+	for i := 0; i < 3; i++ {
+		resp, err := get(t, c, url)
+		if err != nil || resp.StatusCode != 200 || resp.Header.Get("Content-Type") != "application/json" {
+			t.Fatalf("replay %d: %v %v", i, resp, err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		bodies = append(bodies, string(b))
+	}
+	if got := strings.Join(bodies, " "); got != `{"n":1} {"n":2} {"n":2}` {
+		t.Fatalf("replayed %s", got)
+	}
+	if took := time.Since(start); took < 25*time.Millisecond {
+		t.Fatalf("second answer came after %v, before its recorded offset", took)
+	}
+
+	if _, err := get(t, c, srv.URL+"/elsewhere"); !errors.Is(err, ErrNotRecorded) {
+		t.Fatalf("unrecorded URL: %v", err)
+	}
+}
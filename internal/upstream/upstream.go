@@ -0,0 +1,437 @@
+// Package upstream is the HTTP client every market-data feed fetches
+// through. On top of net/http it adds, per destination host:
+//
+//   - retries with jittered exponential backoff on network errors, 429
+//     and 5xx responses;
+//   - a circuit breaker that fails fast after repeated failures and lets a
+//     single probe through once its cooldown has passed;
+//   - a token-bucket rate limit;
+//   - Retry-After: a 429 or 503 that asks for a pause holds every request
+//     to the host until it has passed;
+//   - optionally, conditional GETs: the ETag and Last-Modified of each URL
+//     are sent back as If-None-Match and If-Modified-Since, and a 304 is
+//     answered from the body stored with them.
+//
+// Callers keep using *http.Request and *http.Response, so status handling
+// stays where it was.
+//
+// Beneath the policy, a Recorder can capture every exchange to fixture
+// files and a Replayer can serve them back with their original timing;
+// see SetTransport.
+package upstream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"live-oil-prices-go/internal/metrics"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Policy tunes a Client. The zero value makes one attempt with no rate
+// limit and no breaker, which is what tests usually want.
+type Policy struct {
+	// Timeout bounds each attempt, not the whole call.
+	Timeout time.Duration
+	// MaxAttempts is the number of tries per call, the first included.
+	MaxAttempts int
+	// BaseDelay doubles with every retry up to MaxDelay; the wait is
+	// jittered between half and all of it. MaxDelay also caps how long a
+	// Retry-After is waited out in-call: longer ones fail the call and
+	// hold the host instead.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Rate is requests per second per host, with bursts of up to Burst.
+	// Zero disables the limit.
+	Rate  float64
+	Burst int
+	// FailureThreshold consecutive failed calls open a host's breaker for
+	// Cooldown. Zero disables the breaker.
+	FailureThreshold int
+	Cooldown         time.Duration
+	// Conditional turns on ETag / Last-Modified revalidation for GETs.
+	Conditional bool
+}
+
+var (
+	// ErrCircuitOpen is returned without a request while a host's breaker
+	// is open.
+	ErrCircuitOpen = errors.New("circuit open")
+	// ErrThrottled is returned without a request while a host's
+	// Retry-After is longer than the policy will wait.
+	ErrThrottled = errors.New("throttled by Retry-After")
+)
+
+// Conditional-cache bounds: bodies larger than maxCachedBody are passed
+// through uncached, and the cache holds at most maxCachedURLs entries.
+const (
+	maxCachedBody = 8 << 20
+	maxCachedURLs = 64
+)
+
+var (
+	retries = metrics.NewCounterVec("oilprices_upstream_retries_total",
+		"Upstream requests retried after a network error, 429 or 5xx.", "upstream", "host")
+	rejected = metrics.NewCounterVec("oilprices_upstream_rejected_total",
+		"Upstream calls failed without a request, by reason (circuit_open|retry_after).", "upstream", "host", "reason")
+	notModified = metrics.NewCounterVec("oilprices_upstream_not_modified_total",
+		"Conditional upstream requests answered 304 and served from the stored body.", "upstream", "host")
+	circuitOpen = metrics.NewGaugeVec("oilprices_upstream_circuit_open",
+		"1 while an upstream host's circuit breaker is open.", "upstream", "host")
+)
+
+// Client sends requests under a Policy. Safe for concurrent use.
+type Client struct {
+	name   string
+	http   *http.Client
+	policy Policy
+
+	mu    sync.Mutex
+	hosts map[string]*host
+	cache map[string]*stored // by URL, when policy.Conditional
+}
+
+// host is the per-destination state: token bucket, breaker and any
+// Retry-After hold.
+type host struct {
+	tokens float64
+	filled time.Time
+
+	failures  int
+	openUntil time.Time
+	probing   bool
+
+	holdUntil time.Time
+}
+
+type stored struct {
+	etag, lastModified string
+	header             http.Header
+	body               []byte
+}
+
+// New returns a client named name (the metrics label). hc defaults to an
+// http.Client with the policy's Timeout over the SetTransport transport.
+func New(name string, hc *http.Client, p Policy) *Client {
+	if hc == nil {
+		hc = &http.Client{Timeout: p.Timeout, Transport: transport}
+	}
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	return &Client{
+		name:   name,
+		http:   hc,
+		policy: p,
+		hosts:  make(map[string]*host),
+		cache:  make(map[string]*stored),
+	}
+}
+
+// Do sends req, retrying as the policy allows. Like http.Client.Do, a
+// response with any status is returned without error; the last one is
+// returned when retries run out. Requests with a body that can't be
+// replayed (no GetBody) get a single attempt.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	hostname := req.URL.Host
+	if err := c.admit(hostname); err != nil {
+		return nil, err
+	}
+
+	attempts := c.policy.MaxAttempts
+	if req.Body != nil && req.GetBody == nil {
+		attempts = 1
+	}
+	cached := c.validators(req)
+
+	var resp *http.Response
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = c.wait(ctx, hostname); err != nil {
+			break
+		}
+		var out *http.Request
+		if out, err = c.prepare(req, cached); err != nil {
+			break
+		}
+		resp, err = c.http.Do(out)
+		if ctx.Err() != nil || !retryable(resp, err) {
+			break
+		}
+		hint := c.noteRetryAfter(hostname, resp)
+		if attempt == attempts || hint > c.policy.MaxDelay {
+			break
+		}
+		delay := max(c.backoff(attempt), hint)
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			resp = nil
+		}
+		retries.Inc(c.name, hostname)
+		if !sleep(ctx, delay) {
+			err = ctx.Err()
+			break
+		}
+	}
+
+	c.settle(ctx, hostname, resp, err)
+	if err != nil || !c.policy.Conditional || req.Method != http.MethodGet {
+		return resp, err
+	}
+	return c.revalidated(req, resp, cached)
+}
+
+// retryable reports whether an attempt's outcome is worth another try.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff is the jittered wait before retry n (1-based): half to all of
+// BaseDelay·2ⁿ⁻¹, capped at MaxDelay.
+func (c *Client) backoff(n int) time.Duration {
+	d := c.policy.BaseDelay << (n - 1)
+	if d <= 0 || d > c.policy.MaxDelay {
+		d = c.policy.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (c *Client) hostLocked(name string) *host {
+	h, ok := c.hosts[name]
+	if !ok {
+		h = &host{tokens: float64(max(c.policy.Burst, 1))}
+		c.hosts[name] = h
+	}
+	return h
+}
+
+// admit applies the breaker. While open, calls fail fast; once the
+// cooldown has passed one call goes through as a probe and the rest keep
+// failing until it settles.
+func (c *Client) admit(name string) error {
+	if c.policy.FailureThreshold <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h := c.hostLocked(name)
+	if h.openUntil.IsZero() {
+		return nil
+	}
+	if time.Now().Before(h.openUntil) || h.probing {
+		rejected.Inc(c.name, name, "circuit_open")
+		return fmt.Errorf("%s: %w until %s", name, ErrCircuitOpen, h.openUntil.Format(time.RFC3339))
+	}
+	h.probing = true
+	return nil
+}
+
+// settle feeds a call's outcome to the breaker. A 4xx other than 429
+// means the host is up. Cancellation says nothing either way.
+func (c *Client) settle(ctx context.Context, name string, resp *http.Response, err error) {
+	if c.policy.FailureThreshold <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h := c.hostLocked(name)
+	switch {
+	case ctx.Err() != nil || errors.Is(err, ErrThrottled):
+		h.probing = false
+	case err != nil || retryable(resp, nil):
+		h.failures++
+		if h.probing || h.failures >= c.policy.FailureThreshold {
+			h.openUntil = time.Now().Add(c.policy.Cooldown)
+			circuitOpen.Set(1, c.name, name)
+		}
+		h.probing = false
+	default:
+		h.failures, h.probing, h.openUntil = 0, false, time.Time{}
+		circuitOpen.Set(0, c.name, name)
+	}
+}
+
+// wait blocks until the host's Retry-After hold has passed and a rate
+// token is free. A hold longer than MaxDelay fails with ErrThrottled
+// rather than stalling the caller.
+func (c *Client) wait(ctx context.Context, name string) error {
+	for {
+		c.mu.Lock()
+		h := c.hostLocked(name)
+		now := time.Now()
+		var delay time.Duration
+		switch {
+		case now.Before(h.holdUntil):
+			delay = h.holdUntil.Sub(now)
+			if delay > c.policy.MaxDelay {
+				until := h.holdUntil
+				c.mu.Unlock()
+				rejected.Inc(c.name, name, "retry_after")
+				return fmt.Errorf("%s: %w until %s", name, ErrThrottled, until.Format(time.RFC3339))
+			}
+		case c.policy.Rate > 0:
+			if !h.filled.IsZero() {
+				h.tokens = min(float64(max(c.policy.Burst, 1)), h.tokens+now.Sub(h.filled).Seconds()*c.policy.Rate)
+			}
+			h.filled = now
+			if h.tokens >= 1 {
+				h.tokens--
+				c.mu.Unlock()
+				return nil
+			}
+			delay = time.Duration((1 - h.tokens) / c.policy.Rate * float64(time.Second))
+		default:
+			c.mu.Unlock()
+			return nil
+		}
+		c.mu.Unlock()
+		if !sleep(ctx, delay) {
+			return ctx.Err()
+		}
+	}
+}
+
+// noteRetryAfter records a 429/503's Retry-After as a hold on the host and
+// returns it.
+func (c *Client) noteRetryAfter(name string, resp *http.Response) time.Duration {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return 0
+	}
+	d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !ok {
+		return 0
+	}
+	c.mu.Lock()
+	if h := c.hostLocked(name); time.Now().Add(d).After(h.holdUntil) {
+		h.holdUntil = time.Now().Add(d)
+	}
+	c.mu.Unlock()
+	return d
+}
+
+// parseRetryAfter reads either form of the header: delay-seconds or an
+// HTTP-date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// validators returns the stored response for a conditional GET, if any.
+func (c *Client) validators(req *http.Request) *stored {
+	if !c.policy.Conditional || req.Method != http.MethodGet {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache[req.URL.String()]
+}
+
+// prepare builds one attempt's request: a fresh body when retrying, and
+// the validators of a stored response.
+func (c *Client) prepare(req *http.Request, cached *stored) (*http.Request, error) {
+	out := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = body
+	}
+	if cached != nil {
+		if cached.etag != "" {
+			out.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			out.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+	return out, nil
+}
+
+// revalidated answers a 304 from the stored body as a 200, and stores a
+// 200 that carries validators.
+func (c *Client) revalidated(req *http.Request, resp *http.Response, cached *stored) (*http.Response, error) {
+	key := req.URL.String()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		resp.Body.Close()
+		notModified.Inc(c.name, req.URL.Host)
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        cached.header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(cached.body)),
+			ContentLength: int64(len(cached.body)),
+			Request:       req,
+		}, nil
+	case resp.StatusCode == http.StatusOK:
+		etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		if etag == "" && lastModified == "" {
+			return resp, nil
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedBody+1))
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		if len(body) > maxCachedBody {
+			resp.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+			return resp, nil
+		}
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		c.mu.Lock()
+		if _, ok := c.cache[key]; !ok && len(c.cache) >= maxCachedURLs {
+			for k := range c.cache {
+				delete(c.cache, k)
+				break
+			}
+		}
+		c.cache[key] = &stored{etag: etag, lastModified: lastModified, header: resp.Header.Clone(), body: body}
+		c.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// sleep waits for d, returning false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,155 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusServer answers with codes in turn, then 200 "ok".
+func statusServer(t *testing.T, hits *atomic.Int32, codes ...int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(hits.Add(1))
+		if n <= len(codes) {
+			if codes[n-1] == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "0")
+			}
+			w.WriteHeader(codes[n-1])
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, c *Client, url string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if resp != nil {
+		t.Cleanup(func() { resp.Body.Close() })
+	}
+	return resp, err
+}
+
+func TestRetriesTransientFailures(t *testing.T) {
+	var hits atomic.Int32
+	srv := statusServer(t, &hits, 503, 429)
+	c := New("test", srv.Client(), Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond})
+	resp, err := get(t, c, srv.URL)
+	if err != nil || resp.StatusCode != 200 || hits.Load() != 3 {
+		t.Fatalf("status %v err %v after %d hits", resp, err, hits.Load())
+	}
+
+	// A 404 is final.
+	hits.Store(0)
+	srv404 := statusServer(t, &hits, 404)
+	if resp, err := get(t, c, srv404.URL); err != nil || resp.StatusCode != 404 || hits.Load() != 1 {
+		t.Fatalf("404 retried: %d hits", hits.Load())
+	}
+}
+
+func TestRetryAfterHoldsTheHost(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	c := New("test", srv.Client(), Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+
+	// Two minutes is more than the policy waits: the 429 comes straight
+	// back, and the next call doesn't reach the server at all.
+	if resp, err := get(t, c, srv.URL); err != nil || resp.StatusCode != 429 || hits.Load() != 1 {
+		t.Fatalf("first call: %v %v, %d hits", resp, err, hits.Load())
+	}
+	if _, err := get(t, c, srv.URL); !errors.Is(err, ErrThrottled) || hits.Load() != 1 {
+		t.Fatalf("held host: err %v, %d hits", err, hits.Load())
+	}
+
+	if d, ok := parseRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT", time.Date(2015, 10, 21, 7, 27, 0, 0, time.UTC)); !ok || d != time.Minute {
+		t.Fatalf("HTTP-date form: %v %v", d, ok)
+	}
+}
+
+func TestCircuitBreakerOpensAndProbes(t *testing.T) {
+	var hits atomic.Int32
+	srv := statusServer(t, &hits, 500, 500)
+	c := New("test", srv.Client(), Policy{FailureThreshold: 2, Cooldown: 50 * time.Millisecond})
+
+	get(t, c, srv.URL)
+	get(t, c, srv.URL)
+	if _, err := get(t, c, srv.URL); !errors.Is(err, ErrCircuitOpen) || hits.Load() != 2 {
+		t.Fatalf("open breaker: err %v, %d hits", err, hits.Load())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if resp, err := get(t, c, srv.URL); err != nil || resp.StatusCode != 200 {
+		t.Fatalf("probe: %v %v", resp, err)
+	}
+	if resp, err := get(t, c, srv.URL); err != nil || resp.StatusCode != 200 || hits.Load() != 4 {
+		t.Fatalf("closed again: %v %v, %d hits", resp, err, hits.Load())
+	}
+}
+
+func TestRateLimitSpacesRequests(t *testing.T) {
+	var hits atomic.Int32
+	srv := statusServer(t, &hits)
+	c := New("test", srv.Client(), Policy{Rate: 20, Burst: 1})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		get(t, c, srv.URL)
+	}
+	// One token up front, then one every 50ms.
+	if took := time.Since(start); took < 90*time.Millisecond {
+		t.Fatalf("three requests at 20/s took %v", took)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := c.Do(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("waiting on a cancelled context: %v", err)
+	}
+}
+
+func TestConditionalRequestsReplayOn304(t *testing.T) {
+	var hits, revalidated atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") != "" {
+			revalidated.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 10:00:00 GMT")
+		io.WriteString(w, "<rss/>")
+	}))
+	defer srv.Close()
+	c := New("test", srv.Client(), Policy{Conditional: true})
+
+	for i := 0; i < 2; i++ {
+		resp, err := get(t, c, srv.URL)
+		if err != nil || resp.StatusCode != 200 {
+			t.Fatalf("call %d: %v %v", i, resp, err)
+		}
+		if body, _ := io.ReadAll(resp.Body); string(body) != "<rss/>" {
+			t.Fatalf("call %d body %q", i, body)
+		}
+	}
+	if hits.Load() != 2 || revalidated.Load() != 1 {
+		t.Fatalf("%d hits, %d revalidated", hits.Load(), revalidated.Load())
+	}
+}
@@ -0,0 +1,410 @@
+// Package websocket implements the parts of RFC 6455 the API needs: the
+// server handshake, unfragmented writes, fragmented reads, ping/pong and
+// the closing handshake. No extensions or subprotocols. Dial is a minimal
+// client for tests and tools.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Opcode is a frame type.
+type Opcode byte
+
+const (
+	OpContinuation Opcode = 0x0
+	OpText         Opcode = 0x1
+	OpBinary       Opcode = 0x2
+	OpClose        Opcode = 0x8
+	OpPing         Opcode = 0x9
+	OpPong         Opcode = 0xA
+)
+
+func (op Opcode) control() bool { return op&0x8 != 0 }
+
+// Close codes used by the API (RFC 6455 §7.4.1).
+const (
+	CloseNormal        = 1000
+	CloseGoingAway     = 1001
+	CloseProtocolError = 1002
+	CloseUnsupported   = 1003
+	CloseNoStatus      = 1005
+	ClosePolicy        = 1008
+	CloseTooBig        = 1009
+	CloseTryAgainLater = 1013
+)
+
+// acceptGUID is appended to the client key to derive Sec-WebSocket-Accept.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// DefaultReadLimit bounds an assembled message when SetReadLimit isn't
+// called.
+const DefaultReadLimit = 64 << 10
+
+// CloseError is returned by ReadMessage once the peer has sent a close
+// frame; the close has already been answered.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed (%d) %s", e.Code, e.Reason)
+}
+
+var errProtocol = errors.New("websocket: protocol error")
+
+// Conn is one WebSocket connection. Writes are serialised internally, so
+// one goroutine may read while others write; ReadMessage itself must only
+// be called from one goroutine.
+type Conn struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	client bool // masks outgoing frames, expects unmasked incoming ones
+
+	readLimit   int64
+	idleTimeout time.Duration
+
+	wmu        sync.Mutex
+	closeSent  bool
+	writeLimit time.Duration
+}
+
+// Upgrade performs the server handshake on r and takes over the
+// connection. On failure it has already written an HTTP error response.
+// Browsers don't apply CORS to WebSockets, so a handshake whose Origin
+// isn't the host it was sent to is refused with 403; clients that send no
+// Origin (anything but a browser) are let through.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	fail := func(status int, msg string) (*Conn, error) {
+		http.Error(w, msg, status)
+		return nil, errors.New("websocket: " + msg)
+	}
+	if r.Method != http.MethodGet {
+		return fail(http.StatusMethodNotAllowed, "handshake must be a GET")
+	}
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		return fail(http.StatusUpgradeRequired, "expected a websocket upgrade")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return fail(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	if !sameOrigin(r) {
+		return fail(http.StatusForbidden, "cross-origin websocket")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if raw, err := base64.StdEncoding.DecodeString(key); err != nil || len(raw) != 16 {
+		return fail(http.StatusBadRequest, "bad Sec-WebSocket-Key")
+	}
+
+	netConn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return fail(http.StatusInternalServerError, "connection can't be upgraded")
+	}
+	// The server's read/write timeouts were set for a request, not a
+	// long-lived socket.
+	_ = netConn.SetDeadline(time.Time{})
+
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := netConn.Write([]byte(resp)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return &Conn{conn: netConn, br: brw.Reader, readLimit: DefaultReadLimit}, nil
+}
+
+// Dial opens a client connection to a ws:// URL.
+func Dial(rawURL string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" {
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "80")
+	}
+	netConn, err := net.DialTimeout("tcp", host, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := "GET " + u.RequestURI() + " HTTP/1.1\r\n" +
+		"Host: " + u.Host + "\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := netConn.Write([]byte(req)); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, &http.Request{Method: http.MethodGet})
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: handshake failed: %s", resp.Status)
+	}
+	return &Conn{conn: netConn, br: br, client: true, readLimit: DefaultReadLimit}, nil
+}
+
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// sameOrigin reports whether r has no Origin or one naming r's own host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetReadLimit bounds the size of an assembled message. A larger one is
+// refused with close code 1009.
+func (c *Conn) SetReadLimit(n int64) { c.readLimit = n }
+
+// SetIdleTimeout makes ReadMessage fail when nothing — not even a pong —
+// arrives for d. Zero disables it.
+func (c *Conn) SetIdleTimeout(d time.Duration) {
+	c.idleTimeout = d
+	c.extendRead()
+}
+
+// SetWriteTimeout bounds every write; a peer that stops reading fails the
+// write instead of blocking the writer. Zero disables it.
+func (c *Conn) SetWriteTimeout(d time.Duration) {
+	c.wmu.Lock()
+	c.writeLimit = d
+	c.wmu.Unlock()
+}
+
+func (c *Conn) extendRead() {
+	if c.idleTimeout > 0 {
+		_ = c.conn.SetReadDeadline(time.Now().Add(c.idleTimeout))
+	} else {
+		_ = c.conn.SetReadDeadline(time.Time{})
+	}
+}
+
+// RemoteAddr is the peer's network address.
+func (c *Conn) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+// Close closes the underlying connection without a closing handshake.
+func (c *Conn) Close() error { return c.conn.Close() }
+
+// ReadMessage returns the next text or binary message, answering pings
+// and reassembling fragments on the way. After the peer's close frame it
+// returns a *CloseError; protocol violations are answered with a close
+// frame and returned as errors.
+func (c *Conn) ReadMessage() (Opcode, []byte, error) {
+	var (
+		msgOp Opcode
+		msg   []byte
+		open  bool // inside a fragmented message
+	)
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		c.extendRead()
+		switch {
+		case op == OpPing:
+			if err := c.writeFrame(OpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case op == OpPong:
+			continue
+		case op == OpClose:
+			code, reason := CloseNoStatus, ""
+			if len(payload) >= 2 {
+				code, reason = int(binary.BigEndian.Uint16(payload)), string(payload[2:])
+			}
+			c.WriteClose(code, "")
+			return 0, nil, &CloseError{Code: code, Reason: reason}
+		case op == OpContinuation:
+			if !open {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		case op == OpText || op == OpBinary:
+			if open {
+				return 0, nil, c.fail(CloseProtocolError, "expected a continuation frame")
+			}
+			msgOp, open = op, true
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+		if int64(len(msg)+len(payload)) > c.readLimit {
+			return 0, nil, c.fail(CloseTooBig, "message too large")
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msgOp, msg, nil
+		}
+	}
+}
+
+// readFrame reads one frame and unmasks its payload.
+func (c *Conn) readFrame() (fin bool, op Opcode, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin, op = head[0]&0x80 != 0, Opcode(head[0]&0x0F)
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	masked := head[1]&0x80 != 0
+	if masked == c.client {
+		return false, 0, nil, c.fail(CloseProtocolError, "wrong frame masking")
+	}
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if op.control() && (length > 125 || !fin) {
+		return false, 0, nil, c.fail(CloseProtocolError, "bad control frame")
+	}
+	if length > uint64(c.readLimit) {
+		return false, 0, nil, c.fail(CloseTooBig, "message too large")
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+// fail sends a close frame for a protocol violation and returns the error.
+func (c *Conn) fail(code int, reason string) error {
+	c.WriteClose(code, reason)
+	return fmt.Errorf("%w: %s", errProtocol, reason)
+}
+
+// WriteMessage sends data as a single text or binary frame.
+func (c *Conn) WriteMessage(op Opcode, data []byte) error {
+	return c.writeFrame(op, data)
+}
+
+// Ping sends a ping; the peer's pong keeps the idle timeout from firing.
+func (c *Conn) Ping() error { return c.writeFrame(OpPing, nil) }
+
+// WriteClose starts (or answers) the closing handshake. Only the first
+// call sends anything; no data can be written after it.
+func (c *Conn) WriteClose(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload = append(payload, reason...)
+	return c.writeFrame(OpClose, payload)
+}
+
+func (c *Conn) writeFrame(op Opcode, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closeSent {
+		return net.ErrClosed
+	}
+	if op == OpClose {
+		c.closeSent = true
+	}
+
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|byte(op))
+	maskBit := byte(0)
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	if c.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		for i, b := range payload {
+			frame = append(frame, b^mask[i%4])
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	if c.writeLimit > 0 {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(c.writeLimit))
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
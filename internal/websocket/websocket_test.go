@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func echoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		c.SetReadLimit(1 << 10)
+		for {
+			op, data, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := c.WriteMessage(op, data); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEchoPingAndClose(t *testing.T) {
+	srv := echoServer(t)
+	c, err := Dial("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetIdleTimeout(5 * time.Second)
+
+	// 200 bytes takes the 16-bit length form.
+	msg := strings.Repeat("x", 200)
+	if err := c.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.WriteMessage(OpText, []byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+	op, data, err := c.ReadMessage() // skips the pong
+	if err != nil || op != OpText || string(data) != msg {
+		t.Fatalf("echo: op=%d len=%d err=%v", op, len(data), err)
+	}
+
+	if err := c.WriteClose(CloseNormal, "bye"); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = c.ReadMessage()
+	var ce *CloseError
+	if !errors.As(err, &ce) || ce.Code != CloseNormal {
+		t.Fatalf("expected the close to be answered with 1000, got %v", err)
+	}
+}
+
+func TestOversizedMessageIsRefused(t *testing.T) {
+	srv := echoServer(t)
+	c, err := Dial("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetIdleTimeout(5 * time.Second)
+
+	if err := c.WriteMessage(OpText, make([]byte, 2<<10)); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = c.ReadMessage()
+	var ce *CloseError
+	if !errors.As(err, &ce) || ce.Code != CloseTooBig {
+		t.Fatalf("expected close 1009, got %v", err)
+	}
+}
+
+func TestUpgradeRejectsPlainRequests(t *testing.T) {
+	srv := echoServer(t)
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUpgradeRequired {
+		t.Fatalf("expected 426, got %d", res.StatusCode)
+	}
+}
+
+func TestUpgradeRejectsOtherOrigins(t *testing.T) {
+	srv := echoServer(t)
+	handshake := func(origin string) int {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		req.Header.Set("Origin", origin)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	if code := handshake("https://evil.example"); code != http.StatusForbidden {
+		t.Fatalf("cross-origin handshake: expected 403, got %d", code)
+	}
+	if code := handshake(srv.URL); code != http.StatusSwitchingProtocols {
+		t.Fatalf("same-origin handshake: expected 101, got %d", code)
+	}
+}
+
+func TestAcceptKey(t *testing.T) {
+	// The worked example from RFC 6455 §1.3.
+	if got := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("got %s", got)
+	}
+}
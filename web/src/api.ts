@@ -1,4 +1,4 @@
-import type { Price, ChartData, NewsArticle, Prediction, MarketAnalysis, HeroChart, ConsensusForecast } from './types';
+import type { Price, ChartData, IndicatorSet, RiskReport, NewsArticle, Prediction, MarketAnalysis, HeroChart, ConsensusForecast } from './types';
 
 const BASE = '';
 
@@ -39,6 +39,17 @@ export function getChartData(symbol: string, days: number = 90): Promise<ChartDa
   return fetchJSON<ChartData>(`/api/charts/${symbol}?days=${days}`);
 }
 
+/** getIndicators fetches indicator series aligned with the bars
+ *  getChartData returns for the same days, e.g. ['rsi:14', 'bb:20:2']. */
+export function getIndicators(symbol: string, specs: string[], days: number = 90): Promise<IndicatorSet> {
+  return fetchJSON<IndicatorSet>(`/api/indicators/${symbol}?days=${days}&ind=${encodeURIComponent(specs.join(','))}`);
+}
+
+/** getRisk fetches volatility, drawdown, VaR/CVaR and correlations for symbol. */
+export function getRisk(symbol: string): Promise<RiskReport> {
+  return fetchJSON<RiskReport>(`/api/risk/${symbol}`);
+}
+
 /** getHeroChart fetches the homepage hero chart payload. The server picks
  *  the right mode automatically: streaming 1-minute Pyth candles when the
  *  market is live, or a 1-day intraday Yahoo series for the prior session
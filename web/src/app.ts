@@ -1001,6 +1001,7 @@ function forecastCardHtml(p: Prediction): string {
               <div class="forecast-confidence-fill ${dirClass}" style="width: ${confidencePct}%"></div>
             </div>
           </div>
+          ${renderIntervals(p)}
         </div>
       </details>
 
@@ -1060,6 +1061,24 @@ function buildSignalChipsFromPrediction(p: Prediction): SignalChipView[] {
   return chips;
 }
 
+function renderIntervals(p: Prediction): string {
+  const intervals = p.intervals || [];
+  if (!intervals.length) return '';
+  const rows = intervals.map(iv => {
+    const coverage = iv.coverageSamples > 0
+      ? `held ${(iv.coverage * 100).toFixed(0)}% in backtest`
+      : 'not yet checked';
+    return `
+      <div class="forecast-interval">
+        <dt>${Math.round(iv.level * 100)}% range</dt>
+        <dd>$${iv.low.toFixed(2)} – $${iv.high.toFixed(2)}
+          <span class="forecast-interval-coverage">${coverage}</span>
+        </dd>
+      </div>`;
+  }).join('');
+  return `<dl class="forecast-intervals" aria-label="Prediction intervals">${rows}</dl>`;
+}
+
 function renderBacktestBlock(p: Prediction): string {
   const steps = p.backtestSteps || 0;
   const mape = p.mape || 0;
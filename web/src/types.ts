@@ -1,3 +1,14 @@
+/** Provenance says where a value came from and how fresh it is. */
+export interface Provenance {
+  sources: string[]; // highest-priority contributor first
+  fetchedAt?: string; // RFC3339, when the upstream was polled
+  asOf?: string; // RFC3339, market time of the newest data point
+  ageSeconds: number; // asOf → response time
+  synthetic: boolean; // generated stand-in, not market data
+  changeSuppressed?: boolean; // change zeroed by the extreme-move guard
+  baselineFallback?: boolean; // live change rebased on the prior quote
+}
+
 export interface Price {
   symbol: string;
   name: string;
@@ -10,6 +21,7 @@ export interface Price {
   updatedAt: string;
   contract?: string;
   source?: string;
+  provenance?: Provenance;
 }
 
 export interface OHLCV {
@@ -26,6 +38,91 @@ export interface ChartData {
   name: string;
   interval: string;
   data: OHLCV[];
+  provenance: Provenance;
+}
+
+/** IndicatorSet is /api/indicators/{symbol}: series aligned index by
+ *  index with the chart bars at `times`; null during warm-up. */
+export interface IndicatorSet {
+  symbol: string;
+  interval: string;
+  times: number[];
+  indicators: Indicator[];
+  provenance?: Provenance;
+}
+
+export interface Indicator {
+  id: string;       // canonical spec, e.g. "bb:20:2"
+  overlay: boolean; // price units: draw on the candle pane
+  lines: { name: string; values: (number | null)[] }[];
+}
+
+/** RiskReport is /api/risk/{symbol}. Volatilities are annualised;
+ *  drawdowns, VaR and CVaR are positive fractions (0.05 = 5%). */
+export interface RiskReport {
+  symbol: string;
+  name: string;
+  asOf?: string;
+  bars: number;
+  volatility: { days: number; closeToClose: number; parkinson: number; garmanKlass: number }[];
+  drawdowns: {
+    days: number; // 0 = all history
+    max: number;
+    peak?: string;
+    trough?: string;
+    recovered?: string;
+    current: number;
+  }[];
+  tailRisk: { days: number; level: number; var: number; cvar: number }[];
+  correlations: CorrelationMatrix[];
+  note?: string;
+  generatedAt: string;
+  provenance?: Provenance;
+}
+
+/** CorrelationMatrix rows and columns follow `symbols`; null where a pair
+ *  has too few sessions in common. */
+export interface CorrelationMatrix {
+  days: number;
+  symbols: string[];
+  matrix: (number | null)[][];
+}
+
+/** Tick is one print from the streaming feed, on the /api/ws ticks channel. */
+export interface Tick {
+  symbol: string;
+  price: number;
+  time: number; // publish time, unix ms
+  source: string;
+}
+
+export type SocketChannel = 'prices' | 'ticks' | 'candles' | 'hero' | 'news';
+
+/** SocketRequest is a client message on /api/ws; ref is echoed back. */
+export interface SocketRequest {
+  op: 'subscribe' | 'unsubscribe' | 'snapshot' | 'resume' | 'ping';
+  ref?: string;
+  channels?: SocketChannel[];
+  symbols?: string[]; // omitted = every symbol
+  since?: number;     // resume: last event id received
+}
+
+/** SocketMessage is every server message on /api/ws. seq counts up from 1
+ *  per connection without gaps; id is the stream event id to resume from. */
+export interface SocketMessage {
+  seq: number;
+  type: 'welcome' | 'ack' | 'event' | 'snapshot' | 'error' | 'pong';
+  ref?: string;
+  op?: string;
+  channel?: SocketChannel;
+  id?: number;
+  symbol?: string;
+  data?: unknown;
+  subscriptions?: Record<string, string[]>; // ["*"] = every symbol
+  channels?: SocketChannel[];
+  resumed?: boolean;
+  lastId?: number;
+  error?: string;
 }
 
 /** PythCandle is a streaming 1-minute OHLC bar built from Pyth Network ticks.
@@ -58,6 +155,7 @@ export interface HeroChart {
   updatedAt?: string; // RFC3339 of latest bar
   source: "pyth" | "yahoo" | "";
   bars: PythCandle[];
+  provenance?: Provenance;
 }
 
 export interface NewsArticle {
@@ -102,6 +200,196 @@ export interface Prediction {
   naiveMape?: number;
   skill?: number;
   backtestSteps?: number;
+
+  // Calibrated uncertainty; predictedLow/High are the 80% interval.
+  intervals?: PredictionInterval[];
+  fan?: FanPoint[]; // one entry per trading day out to the horizon
+
+  provenance?: Provenance;
+}
+
+/** PredictionInterval is read from the model's out-of-sample errors;
+ *  coverage is how often it held in the backtest. */
+export interface PredictionInterval {
+  level: number; // 0.8 = 80%
+  low: number;
+  high: number;
+  coverage: number;
+  coverageSamples: number; // 0 = too little history, Gaussian band
+}
+
+export interface FanPoint {
+  step: number; // trading days ahead
+  median: number;
+  intervals: PredictionInterval[];
+}
+
+/** ForecastSet is /api/predictions/{symbol}: the inverse-error-weighted
+ *  ensemble at several trading-day horizons. */
+export interface ForecastSet {
+  symbol: string;
+  name: string;
+  current: number;
+  horizons: HorizonForecast[];
+  note?: string;
+  disclaimer: string;
+  generatedAt: string;
+  provenance?: Provenance;
+}
+
+export interface HorizonForecast {
+  horizon: number; // trading days
+  predicted: number;
+  low: number;  // 80% GARCH band
+  high: number;
+  direction: 'bullish' | 'bearish' | 'neutral';
+  mape: number;
+  naiveMape: number;
+  skill: number;
+  backtestSteps: number;
+  volatility: number; // next-day σ as a fraction
+  models: ModelForecast[];
+}
+
+export interface ModelForecast {
+  model: string; // holt-damped | arima | theta | seasonal-naive
+  predicted: number;
+  mape: number;
+  weight: number; // 0 = excluded for not beating naive
+}
+
+/** TrackRecord is /api/predictions/track-record: published forecasts
+ *  scored against the realised close once their horizon elapsed. */
+export interface TrackRecord {
+  generatedAt: string;
+  since?: string;
+  rows: TrackRecordRow[];   // per symbol, model and horizon
+  models: TrackRecordRow[]; // per model and horizon, all symbols pooled
+}
+
+export interface TrackRecordRow {
+  symbol?: string;
+  model: string;
+  horizon: number; // trading days
+  published: number;
+  scored: number;
+  pending: number;
+  hitRate: number;
+  mape: number;
+  naiveMape: number;
+  skill: number;
+  coverage?: number; // share of closes inside the 80% band
+  banded?: number;
+  lastScoredAt?: string;
+}
+
+/** Spread is a named combination of benchmarks with every leg converted
+ *  into `unit` first (RBOB/HO are quoted per gallon, crude per barrel). */
+export interface Spread {
+  id: string;
+  name: string;
+  description?: string;
+  kind: "difference" | "ratio";
+  unit: string;
+  formula: string;
+  value: number;
+  change: number;
+  legs: SpreadLeg[];
+  updatedAt: string;
+  provenance?: Provenance;
+}
+
+export interface SpreadLeg {
+  symbol: string;
+  weight: number;
+  price: number;
+  unit: string;
+  converted: number;
+}
+
+export interface SpreadChart {
+  id: string;
+  name: string;
+  unit: string;
+  data: { time: number; value: number }[];
+  provenance?: Provenance;
+}
+
+/** ForwardCurve is a futures strip, front month first. Metric spreads are
+ *  deferred minus front, so positive means contango. */
+export interface ForwardCurve {
+  symbol: string;
+  name: string;
+  unit: string;
+  points: CurvePoint[];
+  metrics: CurveMetrics;
+  updatedAt: string;
+  provenance?: Provenance;
+}
+
+export interface CurvePoint {
+  contract: string; // "Dec 2026"
+  ticker: string;
+  month: string; // "2026-12"
+  price: number;
+  change: number;
+  updatedAt: string;
+  consensus?: number; // EIA STEO for the delivery month
+}
+
+export interface CurveMetrics {
+  structure: "contango" | "backwardation" | "flat";
+  m1m2: number;
+  m1m6?: number;
+  m1m12?: number;
+  slopePct: number;
+  rollYieldPct: number;
+}
+
+export interface CurveHistory {
+  symbol: string;
+  snapshots: { time: number; prices: number[]; metrics: CurveMetrics }[];
+}
+
+export interface Alert {
+  id: string;
+  name?: string;
+  type: 'threshold' | 'move' | 'spread' | 'rsi';
+  symbol?: string;
+  spread?: string;
+  direction?: string;
+  level?: number;
+  pct?: number;
+  window?: string;
+  regime?: string;
+  webhookUrl: string;
+  signed: boolean;
+  createdAt: string;
+  lastFiredAt?: string;
+  fireCount: number;
+}
+
+export interface AlertEvent {
+  alertId: string;
+  name?: string;
+  type: string;
+  symbol: string;
+  value: number;
+  level?: number;
+  message: string;
+  triggeredAt: string;
+}
+
+export interface AlertDelivery {
+  id: string;
+  alertId: string;
+  event: AlertEvent;
+  status: 'pending' | 'delivered' | 'failed';
+  attempts: number;
+  statusCode?: number;
+  error?: string;
+  createdAt: string;
+  updatedAt: string;
 }
 
 export interface ConsensusMonthly {
@@ -135,3 +423,42 @@ export interface MarketAnalysis {
   technical: TechnicalSignals;
   updatedAt: string;
 }
+
+export interface FeedHealth {
+  name: string;
+  status: 'ok' | 'degraded' | 'down' | 'pending';
+  critical: boolean;
+  lastSuccess?: string;
+  lastError?: string;
+  lastErrorAt?: string;
+  consecutiveFailures: number;
+  cacheAgeSeconds: number | null;
+  maxAgeSeconds: number;
+  maxFailures: number;
+}
+
+export interface HealthReport {
+  status: 'ok' | 'degraded' | 'unavailable';
+  ready: boolean;
+  feeds: FeedHealth[];
+  checkedAt: string;
+}
+
+export interface Instrument {
+  symbol: string;
+  name: string;
+  shortName: string;
+  unit: string;
+  exchange: string;
+  tradingHours: string;
+  card?: boolean;
+  basePrice: number;
+  yahoo?: string;
+  pythFeedId?: string;
+  eia?: { series: string; name?: string };
+  forecastHorizon?: number;
+  description?: string;
+  keywords?: string;
+  about?: string;
+  priceFactors?: string[];
+}